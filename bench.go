@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/bench"
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// benchSearch loads an index in process and measures search latency against
+// it, exiting 0 when every search succeeded, 1 when any failed and 2 on bad
+// usage
+func benchSearch(args []string) int {
+	flags := flag.NewFlagSet("bench", flag.ExitOnError)
+	dataDir := flags.String("data", "./data", "Data directory with the model and any index snapshots")
+	offline := flags.Bool("offline", false, "Read artifacts saved by the fetch command, never downloading")
+	index := flags.String("index", "verse", "Index to search")
+	queriesPath := flags.String("queries", "", "File of queries, one per line (default: a built-in sample)")
+	searches := flags.Int("n", 200, "Searches to measure")
+	warmup := flags.Int("warmup", 10, "Searches to run first without measuring")
+	concurrency := flags.Int("concurrency", 1, "Searches in flight at once")
+	k := flags.Int("k", 10, "Results per search")
+	rerank := flags.Bool("rerank", false, "Retrieve from the quantized index and re-rank")
+	exhaustive := flags.Bool("exhaustive", false, "Skip centroid routing")
+	routeBooks := flags.Int("route-books", 0, "Scan only the N books nearest each query in full (0 scans everything)")
+	queryCache := flags.Int("query-cache", 0, "Query embeddings to cache; 0 embeds every search")
+	onnxThreads := flags.Int("onnx-threads", 4, "Intra-op thread count for ONNX inference")
+	embeddingProvider := flags.String("embedding-provider", "", "Path to a JSON description of an HTTP embedding API to use instead of ONNX (optional)")
+	wait := flags.Duration("wait", 5*time.Minute, "Time to wait for the embedding model before measuring with the fallback")
+	sources := addIndexFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi bench [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 || *searches <= 0 {
+		flags.Usage()
+		return 2
+	}
+
+	queries := bench.DefaultQueries
+	if *queriesPath != "" {
+		var err error
+		if queries, err = readQueries(*queriesPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	cfg := &config.Config{
+		DataDir:          *dataDir,
+		Offline:          *offline,
+		Snapshots:        true,
+		Shards:           runtime.NumCPU(),
+		Int8Query:        true,
+		RerankCandidates: 200,
+		RouteBooks:       *routeBooks,
+		RouteSample:      0.1,
+		QueryCacheSize:   *queryCache,
+		ONNXThreads:      *onnxThreads,
+	}
+	err := sources.apply(cfg)
+	if err == nil && *embeddingProvider != "" {
+		cfg.EmbeddingProvider, err = config.LoadEmbeddingProvider(*embeddingProvider)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	embeddingService, err := embeddings.NewEmbeddingService(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer embeddingService.Close()
+	searchService, err := search.NewSearchService(embeddingService, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer searchService.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := searchService.PreloadGranularity(ctx, *index); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, *wait)
+	err = embeddingService.WaitReady(waitCtx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "embedding model not ready (%v); measuring with the fallback embeddings\n", err)
+	}
+
+	fmt.Printf("Benchmarking %d searches of %s (k=%d, concurrency %d)\n\n", *searches, *index, *k, *concurrency)
+	samples, elapsed := bench.Run(ctx, searchService, bench.Options{
+		Queries:     queries,
+		Searches:    *searches,
+		Warmup:      *warmup,
+		Concurrency: *concurrency,
+		Search: search.SearchOptions{
+			Granularity: *index,
+			K:           *k,
+			Rerank:      *rerank,
+			Exhaustive:  *exhaustive,
+		},
+	})
+	summary := bench.Summarize(samples, elapsed)
+	summary.Write(os.Stdout)
+
+	if summary.Errors > 0 || summary.Searches < *searches {
+		return 1
+	}
+	return 0
+}
+
+// readQueries reads one query per non-blank line
+func readQueries(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var queries []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if query := strings.TrimSpace(scanner.Text()); query != "" {
+			queries = append(queries, query)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(queries) == 0 {
+		return nil, fmt.Errorf("%s has no queries", path)
+	}
+	return queries, nil
+}
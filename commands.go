@@ -0,0 +1,117 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// command is a subcommand of the goscriptureapi binary
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int // Returns the exit code
+}
+
+// commands lists the subcommands in the order usage shows them
+var commands = []command{
+	{"serve", "Run the API server (the default)", serve},
+	{"fetch", "Download the model and data for offline use", fetchData},
+	{"index", "Build index snapshots, or ingest a corpus (index build, index ingest)", indexCommand},
+	{"bench", "Measure search latency against the local index", benchSearch},
+	{"ingest", "Embed a corpus into a named index (same as index ingest)", ingestCorpus},
+	{"replay", "Replay a request log against a running instance", replayLog},
+	{"verify-cluster", "Compare the index checksums of several nodes", verifyCluster},
+}
+
+// run dispatches to a subcommand. With no subcommand, or only flags, it
+// serves, so existing invocations keep working.
+func run(args []string) int {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return serve(args)
+	}
+	if args[0] == "help" {
+		usage(os.Stdout)
+		return 0
+	}
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(args[1:])
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", args[0])
+	usage(os.Stderr)
+	return 2
+}
+
+// usage lists the subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: goscriptureapi [command] [flags]")
+	fmt.Fprintln(w, "\nCommands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-15s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "\nRun goscriptureapi COMMAND -h for a command's flags.")
+}
+
+// indexCommand dispatches the index subcommands
+func indexCommand(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "build":
+			return buildIndices(args[1:])
+		case "ingest":
+			return ingestCorpus(args[1:])
+		}
+	}
+	fmt.Fprintln(os.Stderr, "Usage: goscriptureapi index build|ingest [flags]")
+	return 2
+}
+
+// indexFlags name the indices beyond verse and chapter, as the server's
+// flags of the same names do
+type indexFlags struct {
+	indices            *string
+	originalEmbeddings *string
+	originalFallback   *string
+	originalText       *string
+}
+
+// addIndexFlags defines the index flags on a subcommand
+func addIndexFlags(flags *flag.FlagSet) indexFlags {
+	return indexFlags{
+		indices:            flags.String("indices", "", "Path to a JSON array of named indices (optional)"),
+		originalEmbeddings: flags.String("original-embeddings", "", "URL of the original-language (Hebrew/Greek) verse embeddings (optional)"),
+		originalFallback:   flags.String("original-fallback", "", "URL of uncompressed original-language embeddings, tried if -original-embeddings fails (optional)"),
+		originalText:       flags.String("original-text", "", "URL of the original-language verse text for -original-embeddings"),
+	}
+}
+
+// apply adds the indices the flags name to cfg
+func (f indexFlags) apply(cfg *config.Config) error {
+	if *f.indices != "" {
+		indices, err := config.LoadIndices(*f.indices)
+		if err != nil {
+			return err
+		}
+		cfg.Indices = indices
+	}
+	if *f.originalEmbeddings != "" {
+		if *f.originalText == "" {
+			return errors.New("-original-embeddings needs -original-text")
+		}
+		cfg.Original = config.IndexSource{
+			Name:          search.GranularityOriginal,
+			EmbeddingsURL: *f.originalEmbeddings,
+			FallbackURL:   *f.originalFallback,
+			TextURL:       *f.originalText,
+		}
+	}
+	return nil
+}
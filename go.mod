@@ -9,6 +9,7 @@ require (
 	github.com/labstack/echo/v4 v4.11.4
 	github.com/rs/zerolog v1.31.0
 	github.com/yalue/onnxruntime_go v1.0.0
+	golang.org/x/time v0.5.0
 )
 
 require (
@@ -22,6 +23,5 @@ require (
 	golang.org/x/net v0.19.0 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 	golang.org/x/text v0.14.0 // indirect
-	golang.org/x/time v0.5.0 // indirect
 	google.golang.org/protobuf v1.34.2 // indirect
 )
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"syscall"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// buildIndices builds indices from their artifacts and persists their
+// snapshots in the data directory, so the server starts without downloading
+// or parsing them, exiting 0 on success, 1 when an index fails to build and
+// 2 on bad usage
+func buildIndices(args []string) int {
+	flags := flag.NewFlagSet("index build", flag.ExitOnError)
+	dataDir := flags.String("data", "./data", "Data directory of the server that will serve the indices")
+	offline := flags.Bool("offline", false, "Build from the artifacts saved by the fetch command, for a server run with -offline")
+	force := flags.Bool("force", false, "Rebuild snapshots that are already current")
+	sources := addIndexFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi index build [flags] [INDEX...]  (default: every configured index)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	cfg := &config.Config{
+		DataDir:   *dataDir,
+		Offline:   *offline,
+		Snapshots: true,
+		Shards:    runtime.NumCPU(),
+		Int8Query: true,
+	}
+	if err := sources.apply(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	names := flags.Args()
+	if len(names) == 0 {
+		for _, source := range search.ConfiguredSources(cfg) {
+			names = append(names, source.Granularity)
+		}
+	}
+
+	// Building never embeds a query, so the model isn't loaded
+	embeddingService, err := embeddings.NewFallbackEmbeddingService(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	searchService, err := search.NewSearchService(embeddingService, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer searchService.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	status := 0
+	for _, name := range names {
+		if *force {
+			if err := searchService.RemoveSnapshot(name); err != nil {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+				status = 1
+				continue
+			}
+		}
+		if err := searchService.PreloadGranularity(ctx, name); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			status = 1
+			continue
+		}
+		fmt.Printf("%s: %s\n", name, searchService.SnapshotPath(name))
+	}
+	return status
+}
@@ -0,0 +1,271 @@
+// Package analytics records searches and result clicks to a JSON Lines log
+// and keeps per-query aggregates, so operators can see what is searched for,
+// what finds nothing and what gets clicked. Callers redact query text with
+// the caller's privacy policy before recording it.
+package analytics
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// maxTracked caps the distinct queries aggregated in memory. Queries beyond
+// it are still logged, just not counted in TopQueries until a restart
+// replays the log.
+const maxTracked = 100000
+
+// Record types
+const (
+	TypeQuery = "query"
+	TypeClick = "click"
+)
+
+// Query is a search as it is logged
+type Query struct {
+	Type        string            `json:"type"`
+	Time        time.Time         `json:"time"`
+	Query       string            `json:"query,omitempty"` // Omitted when the caller's policy forbids keeping it
+	Granularity string            `json:"granularity"`
+	Filters     map[string]string `json:"filters,omitempty"`
+	Results     int               `json:"results"`
+	LatencyMs   float64           `json:"latencyMs"`
+}
+
+// Click is a result a client reported as opened
+type Click struct {
+	Type        string    `json:"type"`
+	Time        time.Time `json:"time"`
+	Query       string    `json:"query,omitempty"`
+	Granularity string    `json:"granularity,omitempty"`
+	Reference   string    `json:"reference"`
+	Position    int       `json:"position,omitempty"` // 1-based rank of the result, when known
+}
+
+// QueryStats aggregates every logged search for one query text
+type QueryStats struct {
+	Query        string    `json:"query"`
+	Count        int       `json:"count"`
+	ZeroResults  int       `json:"zeroResults"` // Searches that returned nothing
+	AvgResults   float64   `json:"avgResults"`
+	AvgLatencyMs float64   `json:"avgLatencyMs"`
+	Clicks       int       `json:"clicks"`
+	ClickThrough float64   `json:"clickThrough"` // Clicks per search
+	LastSeen     time.Time `json:"lastSeen"`
+
+	results   int
+	latencyMs float64
+}
+
+// Summary totals every search logged
+type Summary struct {
+	Searches    int       `json:"searches"`
+	ZeroResults int       `json:"zeroResults"`
+	Clicks      int       `json:"clicks"`
+	Queries     int       `json:"queries"` // Distinct query texts aggregated
+	Since       time.Time `json:"since"`   // Earliest search logged
+}
+
+// Log appends analytics records to a file and aggregates them by query
+type Log struct {
+	mu      sync.Mutex
+	file    *os.File
+	stats   map[string]*QueryStats
+	summary Summary
+}
+
+// Open opens the log at path for appending, aggregating the records already
+// in it. A malformed line, such as a record torn by a crash, is skipped.
+func Open(path string) (*Log, error) {
+	l := &Log{stats: make(map[string]*QueryStats)}
+	if err := l.replay(path); err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create analytics directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open analytics log: %w", err)
+	}
+	l.file = file
+	return l, nil
+}
+
+// replay aggregates the records of an existing log
+func (l *Log) replay(path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read analytics log: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// A click decodes into the query fields it is aggregated by
+		var record Query
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		switch record.Type {
+		case TypeQuery:
+			l.addQuery(record)
+		case TypeClick:
+			l.addClick(record.Query, record.Time)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read analytics log: %w", err)
+	}
+	return nil
+}
+
+// RecordQuery logs a search
+func (l *Log) RecordQuery(query Query) error {
+	query.Type = TypeQuery
+	if query.Time.IsZero() {
+		query.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addQuery(query)
+	return l.append(query)
+}
+
+// RecordClick logs a click on a result
+func (l *Log) RecordClick(click Click) error {
+	click.Type = TypeClick
+	if click.Time.IsZero() {
+		click.Time = time.Now().UTC()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.addClick(click.Query, click.Time)
+	return l.append(click)
+}
+
+// TopQueries returns up to limit queries, most searched first. zeroResults
+// keeps only queries that have returned nothing, the likeliest gaps in a
+// corpus.
+func (l *Log) TopQueries(limit int, zeroResults bool) ([]QueryStats, Summary) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	top := make([]QueryStats, 0, len(l.stats))
+	for _, stats := range l.stats {
+		if zeroResults && stats.ZeroResults == 0 {
+			continue
+		}
+		top = append(top, *stats)
+	}
+	sort.Slice(top, func(i, j int) bool {
+		a, b := top[i].Count, top[j].Count
+		if zeroResults {
+			a, b = top[i].ZeroResults, top[j].ZeroResults
+		}
+		if a != b {
+			return a > b
+		}
+		return top[i].Query < top[j].Query
+	})
+	if limit > 0 && len(top) > limit {
+		top = top[:limit]
+	}
+	for i := range top {
+		stats := &top[i]
+		if stats.Count > 0 {
+			stats.AvgResults = float64(stats.results) / float64(stats.Count)
+			stats.AvgLatencyMs = stats.latencyMs / float64(stats.Count)
+			stats.ClickThrough = float64(stats.Clicks) / float64(stats.Count)
+		}
+	}
+
+	summary := l.summary
+	summary.Queries = len(l.stats)
+	return top, summary
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// append writes a record. Callers must hold l.mu.
+func (l *Log) append(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to analytics log: %w", err)
+	}
+	return nil
+}
+
+// addQuery aggregates a search. Callers must hold l.mu or own the log.
+func (l *Log) addQuery(query Query) {
+	if l.summary.Since.IsZero() || query.Time.Before(l.summary.Since) {
+		l.summary.Since = query.Time
+	}
+	l.summary.Searches++
+	if query.Results == 0 {
+		l.summary.ZeroResults++
+	}
+
+	stats := l.statsFor(query.Query)
+	if stats == nil {
+		return
+	}
+	stats.Count++
+	stats.results += query.Results
+	stats.latencyMs += query.LatencyMs
+	if query.Results == 0 {
+		stats.ZeroResults++
+	}
+	if query.Time.After(stats.LastSeen) {
+		stats.LastSeen = query.Time
+	}
+}
+
+// addClick aggregates a click. Callers must hold l.mu or own the log.
+func (l *Log) addClick(query string, at time.Time) {
+	l.summary.Clicks++
+	if stats := l.statsFor(query); stats != nil {
+		stats.Clicks++
+		if at.After(stats.LastSeen) {
+			stats.LastSeen = at
+		}
+	}
+}
+
+// statsFor returns a query's aggregates, or nil for a redacted query or
+// once maxTracked queries are tracked
+func (l *Log) statsFor(query string) *QueryStats {
+	if query == "" {
+		return nil
+	}
+	stats, ok := l.stats[query]
+	if !ok {
+		if len(l.stats) >= maxTracked {
+			return nil
+		}
+		stats = &QueryStats{Query: query}
+		l.stats[query] = stats
+	}
+	return stats
+}
@@ -0,0 +1,130 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// CorporaResponse lists every configured corpus
+type CorporaResponse struct {
+	Corpora []search.CorpusInfo `json:"corpora"`
+	Count   int                 `json:"count"`
+	Status  string              `json:"status"`
+}
+
+// Corpora handles the admin catalog of configured corpora and their load state
+func (h *Handler) Corpora(c echo.Context) error {
+	corpora := h.search.Corpora()
+	return c.JSON(http.StatusOK, CorporaResponse{
+		Corpora: corpora,
+		Count:   len(corpora),
+		Status:  "success",
+	})
+}
+
+// IndexChecksumsResponse lists the content checksums of the loaded indices
+type IndexChecksumsResponse struct {
+	Indices []search.IndexChecksum `json:"indices"`
+	Count   int                    `json:"count"`
+	Status  string                 `json:"status"`
+}
+
+// IndexChecksums reports per-index content hashes, so operators can verify
+// that replicas serve identical data
+func (h *Handler) IndexChecksums(c echo.Context) error {
+	indices := h.search.IndexChecksums()
+	return c.JSON(http.StatusOK, IndexChecksumsResponse{
+		Indices: indices,
+		Count:   len(indices),
+		Status:  "success",
+	})
+}
+
+// ReloadRequest names the index to reload
+type ReloadRequest struct {
+	Index       string `json:"index,omitempty"`
+	Granularity string `json:"granularity,omitempty"`
+}
+
+// ReloadResponse reports a reload that has started
+type ReloadResponse struct {
+	Reload search.ReloadStatus `json:"reload"`
+	Status string              `json:"status"`
+}
+
+// Reload re-downloads an index and swaps it in once built, while the current
+// index keeps serving. Progress and the old and new versions are reported
+// under the index in /status. A reload downloads and rebuilds the index, so
+// the route is mounted behind AdminAuth.
+func (h *Handler) Reload(c echo.Context) error {
+	var req ReloadRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	reload, err := h.search.Reload(coalesce(req.Index, req.Granularity, "verse"))
+	if err != nil {
+		return searchError("Failed to reload index", err)
+	}
+	return c.JSON(http.StatusAccepted, ReloadResponse{Reload: reload, Status: "success"})
+}
+
+// LoadIndexRequest optionally describes a new index to download and serve
+// under the route's name, in the format of the -indices file
+type LoadIndexRequest struct {
+	EmbeddingsURL string `json:"embeddingsUrl,omitempty"`
+	FallbackURL   string `json:"fallbackUrl,omitempty"`
+	TextURL       string `json:"textUrl,omitempty"`
+}
+
+// LoadIndexResponse reports an index's catalog entry as its load starts, or
+// as it is when already loaded
+type LoadIndexResponse struct {
+	Corpus search.CorpusInfo `json:"corpus"`
+	Status string            `json:"status"`
+}
+
+// LoadIndex loads an index on demand without a restart: one unloaded
+// earlier, one that failed at startup, or a new one described by the body.
+// The load runs in the background; the index's state in /admin/corpora and
+// /status reports its progress.
+func (h *Handler) LoadIndex(c echo.Context) error {
+	var req LoadIndexRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	var source *search.CorpusSource
+	if req != (LoadIndexRequest{}) {
+		source = &search.CorpusSource{EmbeddingsURL: req.EmbeddingsURL, FallbackURL: req.FallbackURL, TextURL: req.TextURL}
+	}
+	info, started, err := h.search.LoadIndex(c.Param("name"), source)
+	if err != nil {
+		return searchError("Failed to load index", err)
+	}
+	status := http.StatusOK
+	if started {
+		status = http.StatusAccepted
+	}
+	return c.JSON(status, LoadIndexResponse{Corpus: info, Status: "success"})
+}
+
+// UnloadIndexResponse reports the memory an unload freed
+type UnloadIndexResponse struct {
+	Index      string `json:"index"`
+	FreedBytes int64  `json:"freedBytes"`
+	Status     string `json:"status"`
+}
+
+// UnloadIndex stops serving an index and frees its memory, for example to
+// drop the chapter index on a small instance
+func (h *Handler) UnloadIndex(c echo.Context) error {
+	name := c.Param("name")
+	freed, err := h.search.UnloadIndex(name)
+	if err != nil {
+		return searchError("Failed to unload index", err)
+	}
+	return c.JSON(http.StatusOK, UnloadIndexResponse{Index: name, FreedBytes: freed, Status: "success"})
+}
@@ -0,0 +1,28 @@
+package api
+
+import "github.com/dpshade/goscriptureapi/internal/search"
+
+// aggregationPool widens a search to the candidates its aggregations count,
+// returning the k its results are cut back to
+func aggregationPool(options *search.SearchOptions, aggs []string) int {
+	k := options.K
+	if len(aggs) > 0 {
+		options.K = max(k, search.AggregationCandidates)
+	}
+	return k
+}
+
+// aggregate counts a widened search's candidates by the requested facets and
+// cuts them back to the top k results, reapplying the transform so
+// probabilities sum to 1 over what is returned
+func aggregate(candidates []search.SearchResult, options search.SearchOptions, aggs []string, k int) ([]search.SearchResult, map[string][]search.Bucket) {
+	if len(aggs) == 0 {
+		return candidates, nil
+	}
+	aggregations := search.Aggregate(candidates, aggs)
+	if len(candidates) > k {
+		candidates = candidates[:k]
+		search.ApplyTransform(candidates, options)
+	}
+	return candidates, aggregations
+}
@@ -0,0 +1,194 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/analytics"
+	"github.com/dpshade/goscriptureapi/internal/feedback"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// maxTopQueries caps the limit parameter of /analytics/top-queries
+const maxTopQueries = 1000
+
+// FeedbackRequest reports a search result the user opened
+type FeedbackRequest struct {
+	Query       string `json:"query"`                 // The query as searched
+	Raw         bool   `json:"raw,omitempty"`         // Whether it was searched with raw=true
+	Reference   string `json:"reference"`             // The result opened, e.g. "John 3:16"
+	Position    int    `json:"position,omitempty"`    // Its 1-based rank in the results
+	Granularity string `json:"granularity,omitempty"` // The index searched, default "verse"
+	Relevant    *bool  `json:"relevant,omitempty"`    // Judges the result relevant or irrelevant; omitted reports a click
+}
+
+// FeedbackResponse acknowledges recorded feedback
+type FeedbackResponse struct {
+	Recorded bool   `json:"recorded"`
+	Votes    *int   `json:"votes,omitempty"` // Net relevance votes for the result, for a judgment
+	Status   string `json:"status"`
+}
+
+// TopQueriesResponse lists the most searched queries with their outcomes
+type TopQueriesResponse struct {
+	Queries []analytics.QueryStats `json:"queries"`
+	Count   int                    `json:"count"`
+	Summary analytics.Summary      `json:"summary"`
+	Status  string                 `json:"status"`
+}
+
+// SetAnalytics enables search analytics, /feedback and /analytics with a log
+func (h *Handler) SetAnalytics(queryLog *analytics.Log) {
+	h.analytics = queryLog
+}
+
+// Feedback records a click-through on a search result, or a judgment of
+// whether it is relevant
+func (h *Handler) Feedback(c echo.Context) error {
+	var req FeedbackRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+	if req.Relevant != nil && h.feedback == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Relevance feedback is not configured")
+	}
+	if req.Relevant == nil && h.analytics == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Analytics are not configured")
+	}
+	if strings.TrimSpace(req.Query) == "" || req.Reference == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "query and reference are required")
+	}
+	if req.Position < 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "position must be 1 or more")
+	}
+	ref, err := reference.Parse(req.Reference)
+	if err != nil {
+		return referenceError(err)
+	}
+
+	// Searches are logged and judged by their text with filters parsed out
+	query, _, _ := parseQuery(req.Query, req.Raw)
+	query = coalesce(query, req.Query)
+	if req.Relevant != nil {
+		return h.judge(c, query, ref, *req.Relevant, coalesce(req.Granularity, "verse"))
+	}
+
+	err = h.analytics.RecordClick(analytics.Click{
+		Query:       analyticsQuery(c, query),
+		Granularity: coalesce(req.Granularity, "verse"),
+		Reference:   ref.String(),
+		Position:    req.Position,
+	})
+	if err != nil {
+		return internalError("Failed to record feedback", err)
+	}
+	return c.JSON(http.StatusOK, FeedbackResponse{Recorded: true, Status: "success"})
+}
+
+// judge stores a relevance judgment, unless the caller's privacy policy
+// forbids keeping anything about its queries
+func (h *Handler) judge(c echo.Context, query string, ref reference.Reference, relevant bool, granularity string) error {
+	if granularity != "verse" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Relevance feedback applies to verse searches")
+	}
+	if PolicyFor(c).NoQueryLogging {
+		return c.JSON(http.StatusOK, FeedbackResponse{Recorded: false, Status: "success"})
+	}
+
+	votes, ok, err := h.feedback.Judge(query, ref.String(), relevant)
+	if err != nil {
+		return internalError("Failed to record feedback", err)
+	}
+	if !ok {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "This query has the most judged results allowed")
+	}
+	return c.JSON(http.StatusOK, FeedbackResponse{Recorded: true, Votes: &votes, Status: "success"})
+}
+
+// SetFeedback enables relevance judgments through /feedback with a store
+func (h *Handler) SetFeedback(store *feedback.Store) {
+	h.feedback = store
+}
+
+// TopQueries lists the most searched queries with their result counts,
+// latency and click-through, or with zeroResults=true those that have found
+// nothing
+func (h *Handler) TopQueries(c echo.Context) error {
+	if h.analytics == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Analytics are not configured")
+	}
+
+	limit := 20
+	if value := c.QueryParam("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxTopQueries {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "limit must be between 1 and "+strconv.Itoa(maxTopQueries))
+		}
+		limit = n
+	}
+	zeroResults, _ := strconv.ParseBool(c.QueryParam("zeroResults"))
+
+	queries, summary := h.analytics.TopQueries(limit, zeroResults)
+	return c.JSON(http.StatusOK, TopQueriesResponse{
+		Queries: queries,
+		Count:   len(queries),
+		Summary: summary,
+		Status:  "success",
+	})
+}
+
+// recordSearch logs a search to analytics, when enabled. A failure to log
+// never fails the search.
+func (h *Handler) recordSearch(c echo.Context, query string, options search.SearchOptions, results int, latency time.Duration) {
+	if h.analytics == nil {
+		return
+	}
+	err := h.analytics.RecordQuery(analytics.Query{
+		Query:       analyticsQuery(c, query),
+		Granularity: options.Granularity,
+		Filters:     analyticsFilters(options),
+		Results:     results,
+		LatencyMs:   float64(latency.Microseconds()) / 1000,
+	})
+	if err != nil {
+		log.Ctx(c.Request().Context()).Warn().Err(err).Msg("Failed to record search analytics")
+	}
+}
+
+// analyticsQuery returns the form of a query the caller's privacy policy
+// allows analytics to keep: normalized so repeats aggregate, hashed for
+// hash-only callers, and nothing at all for callers who opted out
+func analyticsQuery(c echo.Context, query string) string {
+	policy := PolicyFor(c)
+	if policy.NoQueryLogging {
+		return ""
+	}
+	return policy.Redact(strings.Join(strings.Fields(strings.ToLower(query)), " "))
+}
+
+// analyticsFilters returns the filters a search was narrowed by
+func analyticsFilters(options search.SearchOptions) map[string]string {
+	filters := make(map[string]string)
+	for name, value := range map[string]string{
+		"book":      options.Book,
+		"books":     strings.Join(options.Books, ","),
+		"chapter":   options.Chapter,
+		"verse":     options.Verse,
+		"testament": options.Testament,
+		"genre":     options.Genre,
+		"tag":       options.Tag,
+	} {
+		if value != "" {
+			filters[name] = value
+		}
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+	return filters
+}
@@ -0,0 +1,50 @@
+package api
+
+import (
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/labstack/echo/v4"
+)
+
+// requireModel makes the request's query embeddings fail rather than fall
+// back to the simple or placeholder embeddings
+func requireModel(c echo.Context) {
+	c.SetRequest(c.Request().WithContext(embeddings.WithRequireModel(c.Request().Context())))
+}
+
+// trackBackend records which backends embed the request's queries, returning
+// a function that names the least capable of them, or "" if none ran
+func trackBackend(c echo.Context) func() string {
+	var mu sync.Mutex
+	var backend string
+	ctx := embeddings.WithQueryReports(c.Request().Context(), func(report embeddings.QueryReport) {
+		mu.Lock()
+		defer mu.Unlock()
+		if backend == "" || fallbackRank(report.Backend) > fallbackRank(backend) {
+			backend = report.Backend
+		}
+	})
+	c.SetRequest(c.Request().WithContext(ctx))
+	return func() string {
+		mu.Lock()
+		defer mu.Unlock()
+		return backend
+	}
+}
+
+// fallbackRank orders backends from the model to the last fallback
+func fallbackRank(backend string) int {
+	switch backend {
+	case embeddings.BackendSimple:
+		return 1
+	case embeddings.BackendPlaceholder:
+		return 2
+	}
+	return 0
+}
+
+// isFallback reports whether a backend is a fallback rather than the model
+func isFallback(backend string) bool {
+	return fallbackRank(backend) > 0
+}
@@ -0,0 +1,190 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// maxBatchQueries caps how many queries a single /search/batch request may contain
+const maxBatchQueries = 256
+
+// BatchSearchRequest represents several search queries sharing the same options.
+// Inline filters ("love book:John") still apply per query.
+type BatchSearchRequest struct {
+	Queries      []string             `json:"queries"`
+	Raw          bool                 `json:"raw,omitempty"`          // Search the queries as written, without reading filters
+	RequireModel bool                 `json:"requireModel,omitempty"` // Fail rather than embed the queries with a fallback
+	Options      search.SearchOptions `json:"options,omitempty"`
+	Granularity  string               `json:"granularity,omitempty"`
+	Index        string               `json:"index,omitempty"`
+	K            int                  `json:"k,omitempty"`
+	Book         string               `json:"book,omitempty"`
+	Chapter      string               `json:"chapter,omitempty"`
+	Verse        string               `json:"verse,omitempty"`
+	Rerank       bool                 `json:"rerank,omitempty"`
+	Format       format.Options       `json:"format,omitempty"`
+	Fields       []string             `json:"fields,omitempty"`
+	Ranking      string               `json:"ranking,omitempty"`
+	Transform    string               `json:"transform,omitempty"`
+	Temperature  float64              `json:"temperature,omitempty"`
+	Diversity    float64              `json:"diversity,omitempty"`
+	MinScore     float64              `json:"minScore,omitempty"`
+	Group        string               `json:"group,omitempty"`
+	Expand       bool                 `json:"expand,omitempty"`
+	Exclude      []string             `json:"exclude,omitempty"`
+	Negative     string               `json:"negative,omitempty"`
+	Aggs         []string             `json:"aggs,omitempty"`
+	Include      []string             `json:"include,omitempty"`
+	ResultFields []string             `json:"resultFields,omitempty"`
+}
+
+// BatchSearchResponse represents per-query results in request order
+type BatchSearchResponse struct {
+	Results []SearchResponse `json:"results"`
+	Count   int              `json:"count"`
+	Status  string           `json:"status"`
+}
+
+// SearchBatch handles batched search requests
+func (h *Handler) SearchBatch(c echo.Context) error {
+	var req BatchSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	if len(req.Queries) == 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "At least one query is required")
+	}
+	if err := req.Format.Validate(); err != nil {
+		return invalidRequest(err)
+	}
+	if _, err := search.ParseFields(req.Fields); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateRanking(coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateTransform(coalesce(req.Transform, req.Options.Transform), coalesceFloat(req.Temperature, req.Options.Temperature)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateDiversity(coalesceFloat(req.Diversity, req.Options.Diversity)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateMinScore(coalesceFloat(req.MinScore, req.Options.MinScore)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateGroup(coalesce(req.Group, req.Options.Group)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateExpand(req.Expand || req.Options.Expand, coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateNegative(coalesce(req.Negative, req.Options.Negative), coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateAggregations(req.Aggs); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateInclude(coalesceSlice(req.Include, req.Options.Include)); err != nil {
+		return invalidRequest(err)
+	}
+	selection, err := parseResultFields(req.ResultFields)
+	if err != nil {
+		return invalidRequest(err)
+	}
+	if len(req.Queries) > maxBatchQueries {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Too many queries").
+			withDetails(map[string]int{"max": maxBatchQueries})
+	}
+	// Each query is embedded, so each costs what a search does; the limiter took the first token
+	if err := charge(c, len(req.Queries)-1); err != nil {
+		return err
+	}
+
+	queries := make([]string, len(req.Queries))
+	options := make([]search.SearchOptions, len(req.Queries))
+	ks := make([]int, len(req.Queries))
+	for i, raw := range req.Queries {
+		query, filters, _ := parseQuery(raw, req.Raw)
+		if query == "" {
+			if strings.TrimSpace(raw) != "" {
+				return apiError(http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("queries[%d] has filters but no search text; set raw=true to search it as written", i))
+			}
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, fmt.Sprintf("queries[%d] is empty", i))
+		}
+		queries[i] = query
+		options[i] = mergeOptions(SearchRequest{
+			Query:       raw,
+			Options:     req.Options,
+			Granularity: req.Granularity,
+			Index:       req.Index,
+			K:           req.K,
+			Book:        req.Book,
+			Chapter:     req.Chapter,
+			Verse:       req.Verse,
+			Rerank:      req.Rerank,
+			Fields:      req.Fields,
+			Ranking:     req.Ranking,
+			Transform:   req.Transform,
+			Temperature: req.Temperature,
+			Diversity:   req.Diversity,
+			MinScore:    req.MinScore,
+			Group:       req.Group,
+			Expand:      req.Expand,
+			Exclude:     req.Exclude,
+			Negative:    req.Negative,
+			Include:     req.Include,
+		}, filters)
+		if err := normalizeFilters(&options[i]); err != nil {
+			return bookError(err)
+		}
+		if err := applyTier(c, &options[i]); err != nil {
+			return err
+		}
+		if h.forwards(options[i]) {
+			return h.forwardBound(c, req)
+		}
+		if h.lexicon == nil && options[i].Includes(search.IncludeStrongs) {
+			return apiError(http.StatusNotFound, CodeFeatureDisabled, "Strong's numbers are not configured")
+		}
+		ks[i] = aggregationPool(&options[i], req.Aggs)
+	}
+
+	if req.RequireModel {
+		requireModel(c)
+	}
+	results, err := h.search.SearchBatch(c.Request().Context(), queries, options)
+	if err != nil {
+		return searchError("Search failed", err)
+	}
+
+	responses := make([]SearchResponse, len(results))
+	for i, result := range results {
+		result, aggregations := aggregate(result, options[i], req.Aggs, ks[i])
+		search.ApplyTransform(result, options[i])
+		verses := toVerseResults(result, req.Format)
+		h.attachStrongs(verses, options[i])
+		h.attachResultData(verses, result, options[i])
+		selectFields(verses, selection)
+		responses[i] = SearchResponse{
+			Query:           req.Queries[i],
+			Results:         verses,
+			Count:           len(verses),
+			Status:          "success",
+			Ranking:         options[i].Ranking,
+			Reproducibility: h.reproducibility(options[i].Granularity),
+			Aggregations:    aggregations,
+		}
+	}
+
+	return c.JSON(http.StatusOK, BatchSearchResponse{
+		Results: responses,
+		Count:   len(responses),
+		Status:  "success",
+	})
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/labstack/echo/v4"
+)
+
+// BooksResponse lists canonical books
+type BooksResponse struct {
+	Books  []canon.Book `json:"books"`
+	Count  int          `json:"count"`
+	Status string       `json:"status"`
+}
+
+// ChapterInfo describes one chapter of a book
+type ChapterInfo struct {
+	Chapter int `json:"chapter"`
+	Verses  int `json:"verses,omitempty"` // Omitted until the verse index is loaded
+}
+
+// ChaptersResponse lists the chapters of a book
+type ChaptersResponse struct {
+	Book     canon.Book    `json:"book"`
+	Chapters []ChapterInfo `json:"chapters"`
+	Count    int           `json:"count"`
+	Status   string        `json:"status"`
+}
+
+// CanonBook is a book with the verse count of each of its chapters
+type CanonBook struct {
+	canon.Book
+	Verses []int `json:"verses,omitempty"` // Verses per chapter; omitted until the verse index is loaded
+}
+
+// CanonResponse describes the full canonical structure
+type CanonResponse struct {
+	Books    []CanonBook `json:"books"`
+	Chapters int         `json:"chapters"`
+	Verses   int         `json:"verses"` // 0 until the verse index is loaded
+	Status   string      `json:"status"`
+}
+
+// Books lists the canonical books, optionally filtered by testament or genre
+func (h *Handler) Books(c echo.Context) error {
+	testament := strings.ToLower(c.QueryParam("testament"))
+	genre := strings.ToLower(c.QueryParam("genre"))
+
+	books := make([]canon.Book, 0, len(canon.Books))
+	for _, book := range canon.Books {
+		if testament != "" && book.Testament != testament {
+			continue
+		}
+		if genre != "" && book.Genre != genre {
+			continue
+		}
+		books = append(books, book)
+	}
+
+	return c.JSON(http.StatusOK, BooksResponse{
+		Books:  books,
+		Count:  len(books),
+		Status: "success",
+	})
+}
+
+// BookChapters lists a book's chapters with verse counts from the loaded corpus
+func (h *Handler) BookChapters(c echo.Context) error {
+	book, ok := canon.Lookup(c.Param("book"))
+	if !ok {
+		body := bookError(&unknownBookError{name: c.Param("book")})
+		return c.JSON(http.StatusNotFound, body)
+	}
+
+	chapters := make([]ChapterInfo, book.Chapters)
+	for i := range chapters {
+		chapters[i] = ChapterInfo{
+			Chapter: i + 1,
+			Verses:  h.search.VerseCount(book.Name, i+1),
+		}
+	}
+
+	return c.JSON(http.StatusOK, ChaptersResponse{
+		Book:     *book,
+		Chapters: chapters,
+		Count:    len(chapters),
+		Status:   "success",
+	})
+}
+
+// Canon returns every book with its chapter and verse counts from the loaded corpus
+func (h *Handler) Canon(c echo.Context) error {
+	resp := CanonResponse{Books: make([]CanonBook, len(canon.Books)), Status: "success"}
+	for i, book := range canon.Books {
+		verses := make([]int, book.Chapters)
+		total := 0
+		for chapter := range verses {
+			verses[chapter] = h.search.VerseCount(book.Name, chapter+1)
+			total += verses[chapter]
+		}
+		resp.Books[i] = CanonBook{Book: book}
+		if total > 0 {
+			resp.Books[i].Verses = verses
+		}
+		resp.Chapters += book.Chapters
+		resp.Verses += total
+	}
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,162 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/i18n"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// listParams hold comma-separated values whose order doesn't matter
+var listParams = map[string]bool{"books": true, "fields": true, "sources": true, "include": true, "resultFields": true}
+
+// defaultParams are dropped from canonical URLs since omitting them is equivalent
+var defaultParams = map[string]string{
+	"k":           "10",
+	"granularity": "verse",
+	"index":       "verse",
+	"ranking":     "default",
+	"namespace":   "default",
+}
+
+// canonicalSearchQuery returns the canonical query string for a GET search:
+// only known parameters, normalized values, defaults dropped, keys sorted. Two
+// requests that search the same way share one canonical URL, and so one edge
+// cache entry.
+func canonicalSearchQuery(values url.Values, lang string) string {
+	canonical := url.Values{}
+	for _, param := range searchQueryParams {
+		value := values.Get(param.Name)
+		if param.Name == "q" && value == "" {
+			value = values.Get("query")
+		}
+		if value = canonicalValue(param.Name, param.Schema.Type, value); value != "" {
+			canonical.Set(param.Name, value)
+		}
+	}
+	// Diagnostics and messages are localized, so the language joins the key
+	if lang != i18n.DefaultLanguage {
+		canonical.Set("lang", lang)
+	}
+	return canonical.Encode()
+}
+
+// canonicalValue normalizes one parameter, returning "" when it can be omitted
+func canonicalValue(name, typ, value string) string {
+	value = strings.Join(strings.Fields(value), " ")
+	switch {
+	case value == "":
+		return ""
+	case typ == "boolean":
+		if b, err := strconv.ParseBool(value); err != nil || !b {
+			return ""
+		}
+		return "true"
+	case typ == "integer":
+		if n, err := strconv.Atoi(value); err == nil {
+			value = strconv.Itoa(n)
+		}
+	case listParams[name]:
+		var items []string
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		sort.Strings(items)
+		value = strings.Join(items, ",")
+	}
+	if defaultParams[name] == value {
+		return ""
+	}
+	return value
+}
+
+// canonicalize points GET /search at its canonical URL with a Link header, or
+// redirects there when canonical redirects are enabled. It reports whether
+// the response has been written.
+func (h *Handler) canonicalize(c echo.Context) (bool, error) {
+	req := c.Request()
+	canonical := canonicalSearchQuery(req.URL.Query(), Language(c))
+	location := req.URL.Path + "?" + canonical
+
+	if h.config.CanonicalRedirect && req.URL.RawQuery != canonical {
+		return true, c.Redirect(http.StatusMovedPermanently, location)
+	}
+
+	c.Response().Header().Set("Link", "<"+location+`>; rel="canonical"`)
+	if req.URL.Query().Get("lang") == "" {
+		c.Response().Header().Add(echo.HeaderVary, "Accept-Language")
+	}
+	return false, nil
+}
+
+// setCacheHeaders marks a search response cacheable at the edge, tagged with
+// surrogate keys for its index version so operators can purge it when the
+// index changes. Responses that depend on user data aren't shared.
+func (h *Handler) setCacheHeaders(c echo.Context, options search.SearchOptions) {
+	header := c.Response().Header()
+	if options.Tag != "" || options.Notes || options.SearchesSource(search.SourceNotes) {
+		header.Set(echo.HeaderCacheControl, "private, no-cache")
+		return
+	}
+
+	version := h.search.IndexVersion(options.Granularity)
+	if h.config.SearchCacheTTL <= 0 || version == "" {
+		return
+	}
+
+	header.Set(echo.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(h.config.SearchCacheTTL.Seconds())))
+	keys := []string{"search", "granularity-" + options.Granularity, "index-" + version}
+	header.Set("Surrogate-Key", strings.Join(keys, " ")) // Fastly
+	header.Set("Cache-Tag", strings.Join(keys, ","))     // Cloudflare
+}
+
+// cachedSearchResponse is a /search response body kept for repeated queries
+type cachedSearchResponse struct {
+	body  []byte
+	count int
+}
+
+// cachedSearch returns the response body cached for a search's ETag, which
+// covers its normalized query, options, output settings and index version.
+// A hit is still recorded in analytics and popular queries.
+func (h *Handler) cachedSearch(c echo.Context, tag, query string, options search.SearchOptions) ([]byte, bool) {
+	if tag == "" || h.config.ResponseCacheBytes <= 0 {
+		return nil, false
+	}
+	start := time.Now()
+	value, ok := h.responses.Get(tag)
+	if !ok {
+		return nil, false
+	}
+	cached := value.(*cachedSearchResponse)
+	if cached.count > 0 {
+		h.recordQuery(c, query)
+	}
+	h.recordSearch(c, query, options, cached.count, time.Since(start))
+	return cached.body, true
+}
+
+// cacheSearch encodes a response and caches it under its ETag, returning the
+// body to send
+func (h *Handler) cacheSearch(tag string, response SearchResponse) ([]byte, bool) {
+	if h.config.ResponseCacheBytes <= 0 {
+		return nil, false
+	}
+	body, err := json.Marshal(response)
+	if err != nil {
+		return nil, false
+	}
+	body = append(body, '\n') // As c.JSON writes it
+	h.responses.Set(tag, &cachedSearchResponse{body: body, count: response.Count}, int64(len(body)))
+	return body, true
+}
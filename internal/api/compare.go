@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// CompareRequest names two queries to compare under the same options.
+// Inline filters ("love book:John") still apply per query.
+type CompareRequest struct {
+	A           string               `json:"a"`
+	B           string               `json:"b"`
+	Raw         bool                 `json:"raw,omitempty"` // Search the queries as written, without reading filters
+	Options     search.SearchOptions `json:"options,omitempty"`
+	Granularity string               `json:"granularity,omitempty"`
+	Index       string               `json:"index,omitempty"`
+	K           int                  `json:"k,omitempty"`
+	Book        string               `json:"book,omitempty"`
+	Chapter     string               `json:"chapter,omitempty"`
+	Verse       string               `json:"verse,omitempty"`
+	Testament   string               `json:"testament,omitempty"`
+	Genre       string               `json:"genre,omitempty"`
+	Ranking     string               `json:"ranking,omitempty"`
+	Format      format.Options       `json:"format,omitempty"`
+}
+
+// CompareResponse reports the queries' similarity, each query's results, and
+// which results they share
+type CompareResponse struct {
+	A          SearchResponse        `json:"a"`
+	B          SearchResponse        `json:"b"`
+	Similarity float32               `json:"similarity"` // Cosine similarity of the query embeddings
+	Shared     []search.SharedResult `json:"shared"`
+	OnlyA      []string              `json:"onlyA"`
+	OnlyB      []string              `json:"onlyB"`
+	Jaccard    float64               `json:"jaccard"`
+	Status     string                `json:"status"`
+}
+
+// CompareQueries embeds two queries and reports how their top-k results differ
+func (h *Handler) CompareQueries(c echo.Context) error {
+	var req CompareRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	if err := req.Format.Validate(); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateRanking(coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return invalidRequest(err)
+	}
+
+	raw := [2]string{req.A, req.B}
+	var queries [2]string
+	var options [2]search.SearchOptions
+	for i := range raw {
+		query, filters, _ := parseQuery(raw[i], req.Raw)
+		if query == "" {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "Both queries a and b are required")
+		}
+		queries[i] = query
+		options[i] = mergeOptions(SearchRequest{
+			Query:       raw[i],
+			Options:     req.Options,
+			Granularity: req.Granularity,
+			Index:       req.Index,
+			K:           req.K,
+			Book:        req.Book,
+			Chapter:     req.Chapter,
+			Verse:       req.Verse,
+			Testament:   req.Testament,
+			Genre:       req.Genre,
+			Ranking:     req.Ranking,
+		}, filters)
+		if err := normalizeFilters(&options[i]); err != nil {
+			return bookError(err)
+		}
+		if err := applyTier(c, &options[i]); err != nil {
+			return err
+		}
+	}
+
+	comparison, err := h.search.Compare(c.Request().Context(), queries[0], queries[1], options[0], options[1])
+	if err != nil {
+		return searchError("Comparison failed", err)
+	}
+
+	response := func(i int, results []search.SearchResult) SearchResponse {
+		verses := toVerseResults(results, req.Format)
+		return SearchResponse{
+			Query:   raw[i],
+			Results: verses,
+			Count:   len(verses),
+			Status:  "success",
+			Ranking: options[i].Ranking,
+		}
+	}
+	return c.JSON(http.StatusOK, CompareResponse{
+		A:          response(0, comparison.A),
+		B:          response(1, comparison.B),
+		Similarity: comparison.Similarity,
+		Shared:     comparison.Shared,
+		OnlyA:      comparison.OnlyA,
+		OnlyB:      comparison.OnlyB,
+		Jaccard:    comparison.Jaccard,
+		Status:     "success",
+	})
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// CrossReferenceResult represents a single related passage
+type CrossReferenceResult struct {
+	Reference  string   `json:"reference"`
+	Text       string   `json:"text,omitempty"`
+	Votes      int      `json:"votes"`
+	Similarity *float32 `json:"similarity,omitempty"`
+}
+
+// CrossReferencesResponse represents a cross-reference lookup response
+type CrossReferencesResponse struct {
+	Reference string                 `json:"reference"`
+	Results   []CrossReferenceResult `json:"results"`
+	Count     int                    `json:"count"`
+	Status    string                 `json:"status"`
+}
+
+// CrossReferences handles cross-reference lookups for a verse or range
+func (h *Handler) CrossReferences(c echo.Context) error {
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return referenceError(err)
+	}
+
+	k := 20
+	if kVal, err := strconv.Atoi(c.QueryParam("k")); err == nil && kVal > 0 {
+		k = kVal
+	}
+	rerank, _ := strconv.ParseBool(c.QueryParam("rerank"))
+
+	refs, err := h.crossrefs.Lookup(ref)
+	if err != nil {
+		return apiError(http.StatusServiceUnavailable, CodeCrossRefsNotLoaded, "Cross-references are not loaded yet").
+			withDetails(err.Error())
+	}
+
+	results := make([]CrossReferenceResult, 0, len(refs))
+	for _, xref := range refs {
+		result := CrossReferenceResult{
+			Reference: xref.To.String(),
+			Votes:     xref.Votes,
+		}
+		if verses, err := h.search.Passage(xref.To); err == nil {
+			texts := make([]string, 0, len(verses))
+			for _, verse := range verses {
+				texts = append(texts, verse.Text)
+			}
+			result.Text = strings.Join(texts, " ")
+		}
+		results = append(results, result)
+	}
+
+	// Optionally re-rank by embedding similarity to the source passage
+	if rerank {
+		if source, ok := h.passageEmbedding(ref); ok {
+			for i, xref := range refs {
+				if target, ok := h.passageEmbedding(xref.To); ok {
+					similarity := search.CosineSimilarity(source, target)
+					results[i].Similarity = &similarity
+				}
+			}
+			sort.SliceStable(results, func(i, j int) bool {
+				return similarityOf(results[i]) > similarityOf(results[j])
+			})
+		}
+	}
+
+	if len(results) > k {
+		results = results[:k]
+	}
+
+	return c.JSON(http.StatusOK, CrossReferencesResponse{
+		Reference: ref.String(),
+		Results:   results,
+		Count:     len(results),
+		Status:    "success",
+	})
+}
+
+// passageEmbedding averages the precomputed embeddings of the verses in a reference
+func (h *Handler) passageEmbedding(ref reference.Reference) ([]float32, bool) {
+	verses, err := h.search.Passage(ref)
+	if err != nil {
+		return nil, false
+	}
+
+	var mean []float32
+	count := 0
+	for _, verse := range verses {
+		embedding, ok := h.search.VerseEmbedding(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)
+		if !ok {
+			continue
+		}
+		if mean == nil {
+			mean = make([]float32, len(embedding))
+		}
+		for i := range mean {
+			if i < len(embedding) {
+				mean[i] += embedding[i]
+			}
+		}
+		count++
+	}
+
+	if count == 0 {
+		return nil, false
+	}
+	for i := range mean {
+		mean[i] /= float32(count)
+	}
+	return mean, true
+}
+
+// similarityOf returns a result's similarity, ranking unscored results last
+func similarityOf(result CrossReferenceResult) float32 {
+	if result.Similarity == nil {
+		return -2
+	}
+	return *result.Similarity
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// dailyThemeCandidates is how many of the verses nearest a theme the
+// themed verse of the day rotates through
+const dailyThemeCandidates = 10
+
+// VerseOfTheDayResponse is the verse (or passage) chosen for a date
+type VerseOfTheDayResponse struct {
+	Date      string             `json:"date"`
+	Reference string             `json:"reference"`
+	Text      string             `json:"text"`
+	Verses    []BibleVerseResult `json:"verses"`
+	Theme     string             `json:"theme,omitempty"`
+	Curated   bool               `json:"curated"` // Drawn from the -daily-verses list rather than every verse
+	Status    string             `json:"status"`
+}
+
+// SetDailyVerses draws the verse of the day from a curated list
+func (h *Handler) SetDailyVerses(refs []reference.Reference) {
+	h.dailyVerses = refs
+}
+
+// VerseOfTheDay returns the verse for a date (default today, UTC). Every
+// caller gets the same verse for the same date and theme.
+func (h *Handler) VerseOfTheDay(c echo.Context) error {
+	date := time.Now().UTC()
+	if value := c.QueryParam("date"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "date must be formatted YYYY-MM-DD")
+		}
+		date = parsed
+	}
+	day := date.Format(time.DateOnly)
+	theme := strings.Join(strings.Fields(c.QueryParam("theme")), " ")
+
+	var ref reference.Reference
+	var err error
+	if theme != "" {
+		ref, err = h.themedDailyVerse(c.Request().Context(), day, theme)
+	} else {
+		ref, err = h.dailyVerse(date)
+	}
+	if err != nil {
+		return err
+	}
+
+	verses, err := h.search.Passage(ref)
+	if err != nil || len(verses) == 0 {
+		return apiError(http.StatusServiceUnavailable, CodeGranularityNotLoaded, "Verse text is not loaded yet")
+	}
+
+	response := VerseOfTheDayResponse{
+		Date:      day,
+		Reference: ref.String(),
+		Verses:    make([]BibleVerseResult, 0, len(verses)),
+		Theme:     theme,
+		Curated:   len(h.dailyVerses) > 0,
+		Status:    "success",
+	}
+	formatted := make([]format.Verse, 0, len(verses))
+	for _, verse := range verses {
+		formatted = append(formatted, format.Verse{Chapter: verse.Meta.Chapter, VerseNum: verse.Meta.VerseNum, Text: verse.Text})
+		response.Verses = append(response.Verses, BibleVerseResult{
+			Book:     verse.Meta.Book,
+			Chapter:  verse.Meta.Chapter,
+			VerseNum: verse.Meta.VerseNum,
+			Text:     verse.Text,
+		})
+	}
+	response.Text = format.Passage(formatted, format.Options{})
+
+	// Today's verse changes at midnight UTC; a dated one never changes
+	maxAge := 24 * time.Hour
+	if c.QueryParam("date") == "" {
+		maxAge = date.Truncate(24 * time.Hour).Add(24 * time.Hour).Sub(date)
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	return c.JSON(http.StatusOK, response)
+}
+
+// dailyVerse picks a date's verse: the curated list in rotation, so each
+// entry comes up once per cycle, or else a verse selected by the date's hash
+func (h *Handler) dailyVerse(date time.Time) (reference.Reference, error) {
+	if len(h.dailyVerses) > 0 {
+		day := date.Unix() / int64((24 * time.Hour).Seconds())
+		n := int64(len(h.dailyVerses))
+		return h.dailyVerses[(day%n+n)%n], nil
+	}
+
+	verses := h.search.Verses()
+	if len(verses) == 0 {
+		return reference.Reference{}, apiError(http.StatusServiceUnavailable, CodeGranularityNotLoaded, "Verse text is not loaded yet")
+	}
+	return verseReference(verses[dailyHash(date.Format(time.DateOnly), "")%uint64(len(verses))].Meta), nil
+}
+
+// themedDailyVerse picks a date's verse among the candidates nearest a
+// theme: the curated passages, or else every verse
+func (h *Handler) themedDailyVerse(ctx context.Context, day, theme string) (reference.Reference, error) {
+	var candidates []reference.Reference
+	if len(h.dailyVerses) > 0 {
+		embedding, err := h.search.EmbedQuery(ctx, theme)
+		if err != nil {
+			return reference.Reference{}, searchError("Failed to match the theme", err)
+		}
+		type scored struct {
+			ref        reference.Reference
+			similarity float32
+		}
+		var ranked []scored
+		for _, ref := range h.dailyVerses {
+			if passage, ok := h.passageEmbedding(ref); ok {
+				ranked = append(ranked, scored{ref, search.CosineSimilarity(embedding, passage)})
+			}
+		}
+		sort.SliceStable(ranked, func(i, j int) bool {
+			return ranked[i].similarity > ranked[j].similarity
+		})
+		for _, r := range ranked[:min(len(ranked), dailyThemeCandidates)] {
+			candidates = append(candidates, r.ref)
+		}
+	} else {
+		results, err := h.search.Search(ctx, theme, search.SearchOptions{
+			Granularity: "verse",
+			K:           dailyThemeCandidates,
+			Ranking:     search.RankingPure,
+		})
+		if err != nil {
+			return reference.Reference{}, searchError("Failed to match the theme", err)
+		}
+		for _, result := range results {
+			candidates = append(candidates, verseReference(result.Chunk.Meta))
+		}
+	}
+
+	if len(candidates) == 0 {
+		return reference.Reference{}, apiError(http.StatusServiceUnavailable, CodeGranularityNotLoaded, "Verse text is not loaded yet")
+	}
+	return candidates[dailyHash(day, theme)%uint64(len(candidates))], nil
+}
+
+// dailyHash seeds the selection for a date and theme
+func dailyHash(day, theme string) uint64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(day + "\x00" + strings.ToLower(theme)))
+	return hash.Sum64()
+}
+
+// verseReference builds the reference of a single verse
+func verseReference(meta search.Metadata) reference.Reference {
+	book := meta.Book
+	if b, ok := canon.Lookup(book); ok {
+		book = b.Name
+	}
+	return reference.Reference{
+		Book:         book,
+		StartChapter: meta.Chapter,
+		StartVerse:   meta.VerseNum,
+		EndChapter:   meta.Chapter,
+		EndVerse:     meta.VerseNum,
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/deprecation"
+	"github.com/labstack/echo/v4"
+)
+
+// DeprecatedSurfaces lists the routes and parameters scheduled for removal.
+// Register a surface here when its replacement ships, and document it in the
+// README's deprecation table.
+var DeprecatedSurfaces = []deprecation.Surface{
+	{
+		ID:          "search-query-param",
+		Method:      http.MethodGet,
+		Path:        "/search",
+		Param:       "query",
+		Replacement: "q",
+		Since:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC),
+	},
+	{
+		ID:          "search-stream-query-param",
+		Method:      http.MethodGet,
+		Path:        "/search/stream",
+		Param:       "query",
+		Replacement: "q",
+		Since:       time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC),
+		Sunset:      time.Date(2027, 4, 16, 0, 0, 0, 0, time.UTC),
+	},
+}
+
+// DeprecationHeaders marks responses to deprecated surfaces with a
+// Deprecation header (RFC 9745) and a Sunset header (RFC 8594), counting each
+// use in the registry. A request using several surfaces gets the earliest
+// dates.
+func DeprecationHeaders(registry *deprecation.Registry) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			used := registry.Observe(req.Method, c.Path(), req.URL.Query())
+			if len(used) == 0 {
+				return next(c)
+			}
+
+			since, sunset := used[0].Since, used[0].Sunset
+			for _, surface := range used[1:] {
+				if surface.Since.Before(since) {
+					since = surface.Since
+				}
+				if surface.Sunset.Before(sunset) {
+					sunset = surface.Sunset
+				}
+			}
+			header := c.Response().Header()
+			header.Set("Deprecation", "@"+strconv.FormatInt(since.Unix(), 10))
+			header.Set("Sunset", sunset.Format(http.TimeFormat))
+			return next(c)
+		}
+	}
+}
+
+// SetDeprecations enables usage reporting at /admin/usage
+func (h *Handler) SetDeprecations(registry *deprecation.Registry) {
+	h.deprecations = registry
+}
+
+// UsageResponse reports how often deprecated surfaces are still used
+type UsageResponse struct {
+	Deprecations []deprecation.Usage `json:"deprecations"`
+	Count        int                 `json:"count"`
+	Status       string              `json:"status"`
+}
+
+// Usage reports each deprecated surface with its use since startup, so
+// operators can tell when a surface is safe to remove
+func (h *Handler) Usage(c echo.Context) error {
+	usage := h.deprecations.Usage()
+	return c.JSON(http.StatusOK, UsageResponse{
+		Deprecations: usage,
+		Count:        len(usage),
+		Status:       "success",
+	})
+}
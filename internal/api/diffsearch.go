@@ -0,0 +1,108 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// DiffSearchRequest names a query and the two pipelines to rank it with.
+// Filters apply to both sides; inline filters ("love book:John") are honored.
+type DiffSearchRequest struct {
+	Query       string               `json:"query"`
+	Raw         bool                 `json:"raw,omitempty"` // Search the query as written, without reading filters
+	A           string               `json:"a"`
+	B           string               `json:"b"`
+	Options     search.SearchOptions `json:"options,omitempty"`
+	Granularity string               `json:"granularity,omitempty"`
+	Index       string               `json:"index,omitempty"`
+	K           int                  `json:"k,omitempty"`
+	Book        string               `json:"book,omitempty"`
+	Chapter     string               `json:"chapter,omitempty"`
+	Verse       string               `json:"verse,omitempty"`
+	Testament   string               `json:"testament,omitempty"`
+	Genre       string               `json:"genre,omitempty"`
+	Format      format.Options       `json:"format,omitempty"`
+}
+
+// PipelineResults is one side of a pipeline diff
+type PipelineResults struct {
+	Pipeline search.Pipeline    `json:"pipeline"`
+	Results  []BibleVerseResult `json:"results"`
+	Count    int                `json:"count"`
+	TookMs   float64            `json:"tookMs"`
+}
+
+// DiffSearchResponse shows both pipelines' results side by side with how
+// their rankings overlap
+type DiffSearchResponse struct {
+	Query string          `json:"query"`
+	A     PipelineResults `json:"a"`
+	B     PipelineResults `json:"b"`
+	search.PipelineDiff
+	Status string `json:"status"`
+}
+
+// DiffSearch ranks a query with two named pipelines, so relevance changes
+// can be reviewed before a feature flag is flipped
+func (h *Handler) DiffSearch(c echo.Context) error {
+	var req DiffSearchRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+	if err := req.Format.Validate(); err != nil {
+		return invalidRequest(err)
+	}
+	if req.A == "" || req.B == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Both pipelines a and b are required").
+			withDetails(map[string]interface{}{"pipelines": search.Pipelines})
+	}
+
+	query, filters, _ := parseQuery(req.Query, req.Raw)
+	if query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Query is required")
+	}
+	options := mergeOptions(SearchRequest{
+		Query:       req.Query,
+		Options:     req.Options,
+		Granularity: req.Granularity,
+		Index:       req.Index,
+		K:           req.K,
+		Book:        req.Book,
+		Chapter:     req.Chapter,
+		Verse:       req.Verse,
+		Testament:   req.Testament,
+		Genre:       req.Genre,
+	}, filters)
+	if err := normalizeFilters(&options); err != nil {
+		return bookError(err)
+	}
+
+	diff, err := h.search.DiffPipelines(c.Request().Context(), query, options, req.A, req.B)
+	if errors.Is(err, search.ErrUnknownPipeline) {
+		return invalidRequest(err).withDetails(map[string]interface{}{"pipelines": search.Pipelines})
+	}
+	if err != nil {
+		return searchError("Pipeline diff failed", err)
+	}
+
+	side := func(run search.PipelineRun) PipelineResults {
+		verses := toVerseResults(run.Results, req.Format)
+		return PipelineResults{
+			Pipeline: run.Pipeline,
+			Results:  verses,
+			Count:    len(verses),
+			TookMs:   float64(run.Took.Microseconds()) / 1000,
+		}
+	}
+	return c.JSON(http.StatusOK, DiffSearchResponse{
+		Query:        req.Query,
+		A:            side(diff.A),
+		B:            side(diff.B),
+		PipelineDiff: *diff,
+		Status:       "success",
+	})
+}
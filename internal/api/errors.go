@@ -0,0 +1,242 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrorCode identifies a class of error clients can branch on
+type ErrorCode string
+
+const (
+	CodeInvalidRequest       ErrorCode = "invalid_request"
+	CodeInvalidReference     ErrorCode = "invalid_reference"
+	CodeUnknownBook          ErrorCode = "unknown_book"
+	CodeLimitExceeded        ErrorCode = "limit_exceeded"
+	CodeNotFound             ErrorCode = "not_found"
+	CodeUnknownIndex         ErrorCode = "unknown_index"
+	CodeMethodNotAllowed     ErrorCode = "method_not_allowed"
+	CodeUnidentifiedClient   ErrorCode = "unidentified_client"
+	CodeInvalidKey           ErrorCode = "invalid_key"
+	CodeUnauthorized         ErrorCode = "unauthorized"
+	CodeOriginNotAllowed     ErrorCode = "origin_not_allowed"
+	CodeTierRestricted       ErrorCode = "tier_restricted"
+	CodeRateLimited          ErrorCode = "rate_limited"
+	CodeGranularityNotLoaded ErrorCode = "granularity_not_loaded"
+	CodeGranularityFailed    ErrorCode = "granularity_unavailable"
+	CodeReloadInProgress     ErrorCode = "reload_in_progress"
+	CodeModelNotReady        ErrorCode = "model_not_ready"
+	CodeCrossRefsNotLoaded   ErrorCode = "crossrefs_not_loaded"
+	CodeFeatureDisabled      ErrorCode = "feature_disabled"
+	CodeUpstreamFailed       ErrorCode = "upstream_failed"
+	CodeSearchTimeout        ErrorCode = "search_timeout"
+	CodeRequestCancelled     ErrorCode = "request_cancelled"
+	CodeInternal             ErrorCode = "internal_error"
+)
+
+// StatusClientClosedRequest is logged for requests the client abandoned
+// before a response was ready. Nobody is left to read it.
+const StatusClientClosedRequest = 499
+
+// ErrorResponse is the body of every error response
+type ErrorResponse struct {
+	Error  ErrorBody `json:"error"`
+	Status string    `json:"status"` // Always "error"
+}
+
+// ErrorBody describes what went wrong
+type ErrorBody struct {
+	Code       ErrorCode   `json:"code"`
+	Message    string      `json:"message"`
+	Details    interface{} `json:"details,omitempty"`
+	Suggestion string      `json:"suggestion,omitempty"`
+	RequestID  string      `json:"requestId,omitempty"`
+}
+
+// APIError is returned by handlers and rendered by ErrorHandler
+type APIError struct {
+	Status     int
+	Code       ErrorCode
+	Message    string
+	Details    interface{}
+	Suggestion string
+	Err        error // Underlying cause, logged for server errors
+}
+
+func (e *APIError) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// apiError builds an error response
+func apiError(status int, code ErrorCode, message string) *APIError {
+	return &APIError{Status: status, Code: code, Message: message}
+}
+
+// invalidRequest reports a validation failure using the error's own message
+func invalidRequest(err error) *APIError {
+	return apiError(http.StatusBadRequest, CodeInvalidRequest, err.Error())
+}
+
+// withDetails attaches structured details
+func (e *APIError) withDetails(details interface{}) *APIError {
+	e.Details = details
+	return e
+}
+
+// internalError reports a server failure. The cause is logged and returned
+// as details.
+func internalError(message string, err error) *APIError {
+	return &APIError{
+		Status:  http.StatusInternalServerError,
+		Code:    CodeInternal,
+		Message: message,
+		Details: err.Error(),
+		Err:     err,
+	}
+}
+
+// searchError classifies a search service failure
+func searchError(message string, err error) *APIError {
+	e := internalError(message, err)
+	// Cancellation is checked first, since it can surface wrapped in any
+	// other error, such as an embedding failure
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		e.Status, e.Code, e.Message, e.Details = http.StatusGatewayTimeout, CodeSearchTimeout, "Search exceeded the maximum search duration", nil
+	case errors.Is(err, context.Canceled):
+		e.Status, e.Code, e.Message, e.Details = StatusClientClosedRequest, CodeRequestCancelled, "Request cancelled", nil
+	case errors.Is(err, search.ErrUnknownIndex):
+		e.Status, e.Code, e.Message = http.StatusNotFound, CodeUnknownIndex, "Unknown index"
+	case errors.Is(err, search.ErrReloading):
+		e.Status, e.Code, e.Message = http.StatusConflict, CodeReloadInProgress, "Index is already loading"
+	case errors.Is(err, search.ErrNotLoaded):
+		e.Status, e.Code = http.StatusServiceUnavailable, CodeGranularityNotLoaded
+	case errors.Is(err, search.ErrUnavailable):
+		e.Status, e.Code = http.StatusServiceUnavailable, CodeGranularityFailed
+	case errors.Is(err, embeddings.ErrModelRequired):
+		e.Status, e.Code, e.Message = http.StatusServiceUnavailable, CodeModelNotReady, "The embedding model is not serving, and the request requires it"
+	case errors.Is(err, search.ErrEmbedding):
+		e.Status, e.Code = http.StatusServiceUnavailable, CodeModelNotReady
+	case errors.Is(err, search.ErrNoEnsemble), errors.Is(err, search.ErrEnsembleGranularity), errors.Is(err, search.ErrGroupGranularity), errors.Is(err, search.ErrPinned), errors.Is(err, search.ErrParallelRange):
+		e.Status, e.Code, e.Message, e.Details = http.StatusBadRequest, CodeInvalidRequest, err.Error(), nil
+	case errors.Is(err, search.ErrNoEmbedding):
+		e.Status, e.Code = http.StatusNotFound, CodeNotFound
+	case errors.Is(err, search.ErrInvalidCorpus):
+		e.Status, e.Code, e.Message, e.Details = http.StatusBadRequest, CodeInvalidRequest, err.Error(), nil
+	case errors.Is(err, search.ErrCorpusNotFound):
+		e.Status, e.Code, e.Message, e.Details = http.StatusNotFound, CodeNotFound, err.Error(), nil
+	case errors.Is(err, search.ErrIndexRejected):
+		e.Status, e.Code, e.Message, e.Details = http.StatusBadRequest, CodeInvalidRequest, err.Error(), nil
+	case errors.Is(err, search.ErrIndexNode):
+		e.Status, e.Code = http.StatusBadGateway, CodeUpstreamFailed
+	}
+	return e
+}
+
+// referenceError reports a reference that failed to parse
+func referenceError(err error) *APIError {
+	e := apiError(http.StatusBadRequest, CodeInvalidReference, "Invalid reference")
+	var parseErr *reference.ParseError
+	if errors.As(err, &parseErr) {
+		e.Details = parseErr
+		e.Suggestion = parseErr.Suggestion
+		if parseErr.Kind == reference.ErrUnknownBook {
+			e.Code = CodeUnknownBook
+		}
+	} else {
+		e.Details = err.Error()
+	}
+	return e
+}
+
+// noteError maps note store errors to responses
+func noteError(err error) *APIError {
+	switch {
+	case errors.Is(err, notes.ErrNotFound):
+		return apiError(http.StatusNotFound, CodeNotFound, err.Error())
+	case errors.Is(err, notes.ErrInvalid):
+		return invalidRequest(err)
+	}
+	return internalError("Failed to save note", err)
+}
+
+// ErrorHandler renders every error a handler returns as an ErrorResponse,
+// including Echo's own errors for unknown routes and bad methods
+func ErrorHandler(err error, c echo.Context) {
+	if c.Response().Committed {
+		return
+	}
+
+	apiErr := toAPIError(err)
+	if apiErr.Status >= http.StatusInternalServerError {
+		log.Ctx(c.Request().Context()).Error().Err(err).Str("path", c.Path()).Msg(apiErr.Message)
+	}
+
+	if c.Request().Method == http.MethodHead {
+		err = c.NoContent(apiErr.Status)
+	} else {
+		err = c.JSON(apiErr.Status, errorResponse(c, apiErr))
+	}
+	if err != nil {
+		log.Ctx(c.Request().Context()).Error().Err(err).Msg("Failed to write error response")
+	}
+}
+
+// errorResponse builds the envelope for an error
+func errorResponse(c echo.Context, e *APIError) ErrorResponse {
+	return ErrorResponse{
+		Error: ErrorBody{
+			Code:       e.Code,
+			Message:    e.Message,
+			Details:    e.Details,
+			Suggestion: e.Suggestion,
+			RequestID:  c.Response().Header().Get(echo.HeaderXRequestID),
+		},
+		Status: "error",
+	}
+}
+
+// toAPIError converts any error into an APIError
+func toAPIError(err error) *APIError {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr
+	}
+
+	var httpErr *echo.HTTPError
+	if errors.As(err, &httpErr) {
+		message := http.StatusText(httpErr.Code)
+		if text, ok := httpErr.Message.(string); ok {
+			message = text
+		}
+		code := CodeInvalidRequest
+		switch {
+		case httpErr.Code == http.StatusNotFound:
+			code = CodeNotFound
+		case httpErr.Code == http.StatusMethodNotAllowed:
+			code = CodeMethodNotAllowed
+		case httpErr.Code == http.StatusTooManyRequests:
+			code = CodeRateLimited
+		case httpErr.Code >= http.StatusInternalServerError:
+			code = CodeInternal
+		}
+		return &APIError{Status: httpErr.Code, Code: code, Message: message, Err: httpErr.Internal}
+	}
+
+	return internalError("Internal server error", err)
+}
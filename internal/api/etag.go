@@ -0,0 +1,81 @@
+package api
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// etag derives a validator from everything a response depends on: the
+// request's parameters and the versions of the data that answer it. It is
+// weak because the same response may be sent gzipped or not.
+func etag(parts ...interface{}) string {
+	hash := sha256.New()
+	json.NewEncoder(hash).Encode(parts)
+	return `W/"` + hex.EncodeToString(hash.Sum(nil)[:16]) + `"`
+}
+
+// matchesETag reports whether an If-None-Match header lists a tag. Weak
+// comparison is used, as RFC 9110 requires for If-None-Match.
+func matchesETag(header, tag string) bool {
+	tag = strings.TrimPrefix(tag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// notModified reports whether a GET or HEAD request already holds the
+// response tagged tag, so 304 Not Modified can be sent without a body
+func notModified(c echo.Context, tag string) bool {
+	req := c.Request()
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	header := req.Header.Get("If-None-Match")
+	return header != "" && matchesETag(header, tag)
+}
+
+// searchETag tags a search response by its query, resolved options, output
+// settings and the index and model versions answering it. It returns "" for
+// searches whose results depend on more, such as tags, notes and relevance
+// feedback, which can change without the index changing. The tag also keys
+// the response cache, so everything that shapes the body must be part of it.
+func (h *Handler) searchETag(c echo.Context, req SearchRequest, query string, options search.SearchOptions) string {
+	if options.Tag != "" || options.Notes || options.SearchesSource(search.SourceNotes) || req.Explain {
+		return ""
+	}
+	if h.search.FeedbackApplies(query, options) {
+		return ""
+	}
+	version := h.search.IndexVersion(options.Granularity)
+	if version == "" {
+		return ""
+	}
+	// Other indices still read the verse index, to highlight a chapter's
+	// nearest verse, so a verse reload must change their tags too
+	var verseVersion string
+	if options.Granularity != "verse" {
+		verseVersion = h.search.IndexVersion("verse")
+	}
+	return etag(req.Query, req.Raw, options, options.MaxK, req.Format, req.ResultFields, req.Aggs,
+		Language(c), version, verseVersion, h.search.ModelHash(), h.config.Deterministic, h.config.Seed)
+}
+
+// passagesETag tags a passages response by its references, output settings
+// and the verse text version. Passages with notes aren't tagged.
+func (h *Handler) passagesETag(c echo.Context, req PassagesRequest) string {
+	version := h.search.IndexVersion("verse")
+	if req.Notes || version == "" {
+		return ""
+	}
+	return etag(req.References, req.Format, req.Attribution, Language(c), version)
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// explainSearch makes a search record how it is answered, returning the
+// options that carry the explanation. It also reports the query embedding's
+// backend and timing through the request context.
+func (h *Handler) explainSearch(c echo.Context, options search.SearchOptions) (search.SearchOptions, *search.Explanation) {
+	explanation := &search.Explanation{
+		Index:   coalesce(options.Granularity, "verse"),
+		Filters: appliedFilters(options, h.config.LimitsFor(options.Granularity).MinScore),
+	}
+	ctx := embeddings.WithQueryReports(c.Request().Context(), explanation.RecordEmbedding)
+	c.SetRequest(c.Request().WithContext(ctx))
+	return options.Explaining(explanation), explanation
+}
+
+// appliedFilters lists a search's filters and its effective score floor
+func appliedFilters(options search.SearchOptions, floor float32) map[string]string {
+	filters := make(map[string]string)
+	for name, value := range map[string]string{
+		"book":      options.Book,
+		"books":     strings.Join(options.Books, ","),
+		"testament": options.Testament,
+		"genre":     options.Genre,
+		"chapter":   options.Chapter,
+		"verse":     options.Verse,
+		"tag":       options.Tag,
+		"phrases":   strings.Join(options.Phrases, ","),
+		"exclude":   strings.Join(options.Exclude, ","),
+	} {
+		if value != "" {
+			filters[name] = value
+		}
+	}
+	if minScore := max(float64(floor), options.MinScore); minScore != 0 {
+		filters["minScore"] = strconv.FormatFloat(minScore, 'g', -1, 32)
+	}
+	return filters
+}
+
+// explainResults adds each result's score breakdown to its search metadata:
+// its rank, and its raw cosine similarity or, when answered lexically, its
+// lexical score
+func explainResults(verses []BibleVerseResult, results []search.SearchResult, degraded string) {
+	for i := range verses {
+		breakdown := map[string]interface{}{"rank": i + 1, "score": results[i].Score}
+		if degraded == search.ShedLexical {
+			breakdown["lexical"] = results[i].Similarity
+		} else {
+			breakdown["cosine"] = results[i].Similarity
+		}
+		verses[i].SearchMeta["explain"] = breakdown
+	}
+}
@@ -0,0 +1,135 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// resultFieldNames are the result fields resultFields can keep, in the
+// order they are written
+var resultFieldNames = []string{"book", "chapter", "verseNum", "text", "searchMeta"}
+
+// resultSelection is the result fields a client asked for
+type resultSelection struct {
+	fields map[string]bool
+	meta   map[string]bool // searchMeta keys to keep; nil keeps them all
+}
+
+// parseResultFields reads a resultFields list such as
+// "book,chapter,verseNum,searchMeta.similarity". It returns nil, keeping
+// every field, for an empty list.
+func parseResultFields(names []string) (*resultSelection, error) {
+	if len(names) == 0 {
+		return nil, nil
+	}
+	selection := &resultSelection{fields: make(map[string]bool)}
+	wholeMeta := false
+	for _, name := range names {
+		name = strings.TrimPrefix(strings.TrimSpace(name), "_")
+		if key, ok := strings.CutPrefix(name, "searchMeta."); ok && key != "" {
+			if selection.meta == nil {
+				selection.meta = make(map[string]bool)
+			}
+			selection.meta[key] = true
+			selection.fields["searchMeta"] = true
+			continue
+		}
+		known := false
+		for _, field := range resultFieldNames {
+			known = known || field == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown result field: %s (use %s, or searchMeta.KEY)", name, strings.Join(resultFieldNames, ", "))
+		}
+		selection.fields[name] = true
+		wholeMeta = wholeMeta || name == "searchMeta"
+	}
+	if wholeMeta {
+		selection.meta = nil
+	}
+	return selection, nil
+}
+
+// selectFields trims each result to the fields selected
+func selectFields(verses []BibleVerseResult, selection *resultSelection) {
+	for i := range verses {
+		verses[i].selection = selection
+	}
+}
+
+// MarshalJSON writes only the selected fields of a trimmed result
+func (r BibleVerseResult) MarshalJSON() ([]byte, error) {
+	type plain BibleVerseResult
+	if r.selection == nil {
+		return json.Marshal(plain(r))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for _, name := range resultFieldNames {
+		if !r.selection.fields[name] {
+			continue
+		}
+		var value interface{}
+		switch name {
+		case "book":
+			value = r.Book
+		case "chapter":
+			value = r.Chapter
+		case "verseNum":
+			value = r.VerseNum
+		case "text":
+			value = r.Text
+		case "searchMeta":
+			meta := r.SearchMeta
+			if r.selection.meta != nil {
+				meta = make(map[string]interface{}, len(r.selection.meta))
+				for key := range r.selection.meta {
+					if v, ok := r.SearchMeta[key]; ok {
+						meta[key] = v
+					}
+				}
+			}
+			if len(meta) == 0 {
+				continue
+			}
+			name, value = "_searchMeta", meta
+		}
+
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:", name)
+		buf.Write(encoded)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// attachResultData adds the full passage metadata and the index vector of
+// each result to its search metadata when requested
+func (h *Handler) attachResultData(verses []BibleVerseResult, results []search.SearchResult, options search.SearchOptions) {
+	metadata := options.Includes(search.IncludeMetadata)
+	embedding := options.Includes(search.IncludeEmbedding)
+	if !metadata && !embedding {
+		return
+	}
+	for i, result := range results {
+		if metadata {
+			verses[i].SearchMeta["metadata"] = result.Chunk.Meta
+		}
+		if embedding && result.Source != search.SourceNotes {
+			if vector, ok := h.search.Embedding(options.Granularity, result.ID); ok {
+				verses[i].SearchMeta["embedding"] = vector
+			}
+		}
+	}
+}
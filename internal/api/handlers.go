@@ -1,24 +1,73 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"net/http/httputil"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/dpshade/goscriptureapi/internal/analytics"
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/crossrefs"
+	"github.com/dpshade/goscriptureapi/internal/deprecation"
+	"github.com/dpshade/goscriptureapi/internal/feedback"
+	"github.com/dpshade/goscriptureapi/internal/cursor"
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/lexicon"
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/questions"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/saved"
 	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/dpshade/goscriptureapi/internal/startup"
+	"github.com/dpshade/goscriptureapi/internal/suggest"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/dpshade/goscriptureapi/internal/widget"
 	"github.com/labstack/echo/v4"
-	"github.com/rs/zerolog/log"
 )
 
 // Handler handles API requests
 type Handler struct {
-	search *search.SearchService
+	config    *config.Config
+	search    *search.SearchService
+	crossrefs *crossrefs.Service
+	cursors   *cursor.Store
+	tags      *tags.Store
+	notes     *notes.Store
+	privacy   *privacy.Store
+	suggest   *suggest.Suggester
+	topics    *suggest.Topics
+
+	widgetKeys   widget.Keys
+	questions    *questions.Generator
+	deprecations *deprecation.Registry
+	startup      *startup.Machine
+	saved        *saved.Store
+	lexicon      *lexicon.Service
+	dailyVerses  []reference.Reference
+	analytics    *analytics.Log
+	feedback     *feedback.Store
+	responses    *search.Cache // Search responses by ETag, for repeated queries
+	indexNode    *httputil.ReverseProxy // Answers what a stateless node can't
 }
 
 // NewHandler creates a new API handler
-func NewHandler(searchService *search.SearchService) *Handler {
+func NewHandler(cfg *config.Config, searchService *search.SearchService, crossrefService *crossrefs.Service, tagStore *tags.Store, noteStore *notes.Store, privacyStore *privacy.Store) *Handler {
 	return &Handler{
-		search: searchService,
+		config:    cfg,
+		search:    searchService,
+		crossrefs: crossrefService,
+		cursors:   cursor.NewStore(cfg.CursorTTL, cfg.MaxCursors),
+		tags:      tagStore,
+		notes:     noteStore,
+		privacy:   privacyStore,
+		suggest:   suggest.New(searchService.VerseCount),
+		topics:    suggest.NewTopics(suggest.DefaultTopics, searchService.EmbedQueries),
+		responses: search.NewCache(cfg.ResponseCacheBytes, cfg.ResponseCacheTTL),
 	}
 }
 
@@ -29,29 +78,110 @@ func (h *Handler) Health(c echo.Context) error {
 	})
 }
 
+// SetStartup reports the startup state machine in /status
+func (h *Handler) SetStartup(machine *startup.Machine) {
+	h.startup = machine
+}
+
 // Status handles status requests
 func (h *Handler) Status(c echo.Context) error {
 	status := h.search.GetStatus()
+	if h.startup != nil {
+		status["startup"] = h.startup.Status()
+	}
+	status["responseCache"] = h.responses.Stats()
+	status["crossReferences"] = map[string]interface{}{
+		"loaded": h.crossrefs.Loaded(),
+		"count":  h.crossrefs.Count(),
+	}
+	if h.lexicon != nil {
+		entries, verses := h.lexicon.Counts()
+		status["lexicon"] = map[string]interface{}{
+			"entries":      entries,
+			"taggedVerses": verses,
+		}
+	}
 	return c.JSON(http.StatusOK, status)
 }
 
 // SearchRequest represents a search request
 type SearchRequest struct {
 	Query       string                `json:"query"`
+	Raw         bool                  `json:"raw,omitempty"` // Search the query as written, without reading filters
+	Explain     bool                  `json:"explain,omitempty"` // Report how the search was answered
+	RequireModel bool                 `json:"requireModel,omitempty"` // Fail rather than embed the query with a fallback
 	Options     search.SearchOptions  `json:"options,omitempty"`
 	Granularity string               `json:"granularity,omitempty"`
+	Index       string               `json:"index,omitempty"` // Named index to search, taking precedence over granularity
 	K           int                  `json:"k,omitempty"`
 	Book        string               `json:"book,omitempty"`
 	Chapter     string               `json:"chapter,omitempty"`
 	Verse       string               `json:"verse,omitempty"`
+	Rerank      bool                 `json:"rerank,omitempty"`
+	Exhaustive  bool                 `json:"exhaustive,omitempty"` // Scan every vector, skipping centroid routing
+	Format      format.Options       `json:"format,omitempty"`
+	Fields      []string             `json:"fields,omitempty"`
+	Ranking     string               `json:"ranking,omitempty"`
+	PageSize    int                  `json:"pageSize,omitempty"` // Enables cursor paging
+	Cursor      string               `json:"cursor,omitempty"`   // Continues a previous page
+
+	Highlight     bool   `json:"highlight,omitempty"`
+	HighlightPre  string `json:"highlightPre,omitempty"`
+	HighlightPost string `json:"highlightPost,omitempty"`
+
+	Tag       string `json:"tag,omitempty"`       // Only verses carrying this tag
+	Namespace string `json:"namespace,omitempty"` // Tag and note namespace
+	Notes     bool   `json:"notes,omitempty"`     // Attach notes to each result
+
+	Include      []string `json:"include,omitempty"`      // Extra data for each result: "strongs", "metadata", "embedding"
+	ResultFields []string `json:"resultFields,omitempty"` // Result fields to return, e.g. "book,chapter,verseNum,searchMeta.similarity"
+
+	Testament string   `json:"testament,omitempty"`
+	Genre     string   `json:"genre,omitempty"`
+	Books     []string `json:"books,omitempty"`
+
+	Sources []string `json:"sources,omitempty"` // "scripture" and/or "notes"
+
+	Transform   string  `json:"transform,omitempty"`   // "softmax"
+	Temperature float64 `json:"temperature,omitempty"` // Softmax temperature
+
+	Diversity float64 `json:"diversity,omitempty"` // MMR weight: 0 for relevance only, up to 1
+	MinScore  float64 `json:"minScore,omitempty"`  // Only results at least this similar to the query
+	Group     string  `json:"group,omitempty"`     // "chapter" returns each chapter's best verse
+	Expand    bool    `json:"expand,omitempty"`    // Also search thesaurus reformulations of the query
+
+	Exclude  []string `json:"exclude,omitempty"`  // Drop results containing any of these words or phrases
+	Negative string   `json:"negative,omitempty"` // An example of what not to find, subtracted from the query vector
+
+	Aggs []string `json:"aggs,omitempty"` // Facets to count over the top candidates: "book", "testament", "genre"
 }
 
 // SearchResponse represents a search response
 type SearchResponse struct {
-	Query   string                 `json:"query"`
-	Results []BibleVerseResult     `json:"results"`
-	Count   int                   `json:"count"`
-	Status  string                `json:"status"`
+	Query           string             `json:"query"`
+	Results         []BibleVerseResult `json:"results"`
+	Count           int                `json:"count"`
+	Status          string             `json:"status"`
+	Ranking         string             `json:"ranking,omitempty"`
+	Reproducibility *Reproducibility   `json:"reproducibility,omitempty"`
+	Offset          int                `json:"offset,omitempty"`
+	Total           int                `json:"total,omitempty"`
+	Cursor          string             `json:"cursor,omitempty"`
+	Sources         map[string]int     `json:"sources,omitempty"` // Result counts per source when notes are searched
+	Diagnostics     []string           `json:"diagnostics,omitempty"` // Why nothing matched, when Count is zero
+	Parsed          *ParsedQuery       `json:"parsed,omitempty"`      // How the query was read, when it had filters, phrases or references
+	EmptyFilters    []string           `json:"emptyFilters,omitempty"` // Filters matching no candidates on their own, when Count is zero
+	Degraded        string             `json:"degraded,omitempty"`    // "cache" or "lexical" when shed under load, "lexical" while embeddings load
+	Explain         *search.Explanation `json:"explain,omitempty"`    // How the search was answered, with explain=true
+	EmbeddingBackend string            `json:"embeddingBackend,omitempty"` // What embedded the query: "onnx", "remote", or the "simple" or "placeholder" fallback
+	Aggregations    map[string][]search.Bucket `json:"aggregations,omitempty"` // Facet counts over the top candidates, with aggs
+}
+
+// Reproducibility stamps a response with everything needed to reproduce it
+type Reproducibility struct {
+	ModelHash    string `json:"modelHash,omitempty"`
+	IndexVersion string `json:"indexVersion"`
+	Seed         int64  `json:"seed"`
 }
 
 // BibleVerseResult represents a Bible verse search result
@@ -61,6 +191,8 @@ type BibleVerseResult struct {
 	VerseNum   int                   `json:"verseNum"`
 	Text       string                `json:"text"`
 	SearchMeta map[string]interface{} `json:"_searchMeta,omitempty"`
+
+	selection *resultSelection // Fields to write, nil for all
 }
 
 // Search handles search requests (both GET and POST)
@@ -69,148 +201,603 @@ func (h *Handler) Search(c echo.Context) error {
 	
 	// Handle GET request with query parameters
 	if c.Request().Method == "GET" {
-		req.Query = c.QueryParam("q")
-		if req.Query == "" {
-			req.Query = c.QueryParam("query")
-		}
-		
-		// Parse optional parameters
-		if k := c.QueryParam("k"); k != "" {
-			if kVal, err := strconv.Atoi(k); err == nil {
-				req.K = kVal
-			}
+		if done, err := h.canonicalize(c); done {
+			return err
 		}
-		
-		req.Book = c.QueryParam("book")
-		req.Chapter = c.QueryParam("chapter")
-		req.Verse = c.QueryParam("verse")
-		req.Granularity = c.QueryParam("granularity")
-		
+		req = searchRequestFromQuery(c)
 	} else {
 		// Handle POST request with JSON body
 		if err := c.Bind(&req); err != nil {
-			return c.JSON(http.StatusBadRequest, map[string]string{
-				"error": "Invalid request body",
-			})
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+		}
+	}
+
+	// Cursors, and the scan timings explain reports, are the index node's
+	if h.indexNode != nil && (req.Cursor != "" || req.PageSize > 0 || req.Explain) {
+		return h.forwardBound(c, req)
+	}
+	if req.Cursor != "" {
+		return h.nextPage(c, req.Cursor)
+	}
+
+	query, options, err := h.searchOptions(c, req)
+	if err != nil {
+		return err
+	}
+	if h.forwards(options) {
+		return h.forwardBound(c, req)
+	}
+	if req.PageSize > 0 {
+		return h.firstPage(c, req, query, options)
+	}
+
+	// A client holding this response needn't be sent it again, unless it
+	// came from a fallback the model now serving would improve on
+	tag := h.searchETag(c, req, query, options)
+	if tag != "" && h.search.ModelReady() && notModified(c, tag) {
+		h.setCacheHeaders(c, options)
+		c.Response().Header().Set("ETag", tag)
+		return c.NoContent(http.StatusNotModified)
+	}
+	if cached, ok := h.cachedSearch(c, tag, query, options); ok {
+		if c.Request().Method == http.MethodGet {
+			h.setCacheHeaders(c, options)
+		}
+		c.Response().Header().Set("ETag", tag)
+		return c.JSONBlob(http.StatusOK, cached)
+	}
+
+	response, err := h.searchResponse(c, req, query, options)
+	if err != nil {
+		return err
+	}
+	if response.Degraded != "" || response.Explain != nil || isFallback(response.EmbeddingBackend) {
+		// A shed or fallback answer shouldn't outlive the condition that
+		// caused it, and explained timings are this request's alone
+		c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	} else {
+		if c.Request().Method == http.MethodGet {
+			h.setCacheHeaders(c, options)
 		}
+		if tag != "" {
+			c.Response().Header().Set("ETag", tag)
+			if body, ok := h.cacheSearch(tag, response); ok {
+				return c.JSONBlob(http.StatusOK, body)
+			}
+		}
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// searchOptions validates a search request and resolves its query and options
+func (h *Handler) searchOptions(c echo.Context, req SearchRequest) (string, search.SearchOptions, error) {
+	if err := req.Format.Validate(); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if _, err := search.ParseFields(req.Fields); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateRanking(coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateSources(coalesceSlice(req.Sources, req.Options.Sources)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateInclude(coalesceSlice(req.Include, req.Options.Include)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if _, err := parseResultFields(req.ResultFields); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateTransform(coalesce(req.Transform, req.Options.Transform), coalesceFloat(req.Temperature, req.Options.Temperature)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateDiversity(coalesceFloat(req.Diversity, req.Options.Diversity)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateMinScore(coalesceFloat(req.MinScore, req.Options.MinScore)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateGroup(coalesce(req.Group, req.Options.Group)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateExpand(req.Expand || req.Options.Expand, coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateNegative(coalesce(req.Negative, req.Options.Negative), coalesce(req.Ranking, req.Options.Ranking)); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
+	}
+	if err := search.ValidateAggregations(req.Aggs); err != nil {
+		return "", search.SearchOptions{}, invalidRequest(err)
 	}
 
 	// Parse query to extract filters
-	query, filters := parseQuery(req.Query)
+	query, filters, _ := parseQuery(req.Query, req.Raw)
+	if query == "" && strings.TrimSpace(req.Query) != "" {
+		return "", search.SearchOptions{}, apiError(http.StatusBadRequest, CodeInvalidRequest, "The query has filters but no search text; set raw=true to search it as written")
+	}
 
 	// Merge request options with parsed filters
-	options := search.SearchOptions{
+	options := mergeOptions(req, filters)
+	if err := normalizeFilters(&options); err != nil {
+		return "", search.SearchOptions{}, bookError(err)
+	}
+	if h.lexicon == nil && options.Includes(search.IncludeStrongs) {
+		return "", search.SearchOptions{}, apiError(http.StatusNotFound, CodeFeatureDisabled, "Strong's numbers are not configured")
+	}
+	if err := applyTier(c, &options); err != nil {
+		return "", search.SearchOptions{}, err
+	}
+	if req.RequireModel {
+		requireModel(c)
+	}
+	return query, options, nil
+}
+
+// rankResults runs a search and applies its transform
+func (h *Handler) rankResults(c echo.Context, query string, options search.SearchOptions) ([]search.SearchResult, string, error) {
+	start := time.Now()
+	results, degraded, err := h.searchSources(c.Request().Context(), query, options)
+	if err != nil {
+		return nil, "", searchError("Search failed", err)
+	}
+	search.ApplyTransform(results, options)
+	if len(results) > 0 {
+		h.recordQuery(c, query)
+	}
+	h.recordSearch(c, query, options, len(results), time.Since(start))
+	return results, degraded, nil
+}
+
+// searchResponse runs a search and builds its response
+func (h *Handler) searchResponse(c echo.Context, req SearchRequest, query string, options search.SearchOptions) (SearchResponse, error) {
+	var explanation *search.Explanation
+	if req.Explain {
+		options, explanation = h.explainSearch(c, options)
+	}
+	backend := trackBackend(c)
+	k := aggregationPool(&options, req.Aggs)
+	results, degraded, err := h.rankResults(c, query, options)
+	if err != nil {
+		return SearchResponse{}, err
+	}
+	var aggregations map[string][]search.Bucket
+	results, aggregations = aggregate(results, options, req.Aggs, k)
+
+	// Convert results to Bible verse format
+	verses := toVerseResults(results, req.Format)
+	if explanation != nil {
+		explainResults(verses, results, degraded)
+	}
+	h.attachNotes(verses, options)
+	h.attachStrongs(verses, options)
+	h.attachResultData(verses, results, options)
+	selection, _ := parseResultFields(req.ResultFields)
+	selectFields(verses, selection)
+
+	response := SearchResponse{
+		Query:           req.Query,
+		Results:         verses,
+		Count:           len(verses),
+		Status:          "success",
+		Ranking:         options.Ranking,
+		Reproducibility: h.reproducibility(options.Granularity),
+		Sources:         sourceCounts(results),
+		Degraded:        degraded,
+		Explain:         explanation,
+		EmbeddingBackend: backend(),
+		Aggregations:    aggregations,
+	}
+	if len(verses) == 0 {
+		response.Diagnostics = h.diagnostics(c, options)
+		response.EmptyFilters = h.search.EmptyFilters(options)
+	}
+	_, _, response.Parsed = parseQuery(req.Query, req.Raw)
+	return response, nil
+}
+
+// firstPage ranks up to the cursor limit (or k, if given) and returns the first page
+func (h *Handler) firstPage(c echo.Context, req SearchRequest, query string, options search.SearchOptions) error {
+	options.K = h.config.CursorMaxResults
+	options.Paged = true
+	if k := maxInt(req.K, req.Options.K); k > 0 {
+		options.K = min(k, h.config.CursorMaxResults)
+	}
+
+	results, _, err := h.rankResults(c, query, options)
+	if err != nil {
+		return err
+	}
+	page, err := h.cursors.Start(&cursor.Cursor{
+		Query:    req.Query,
+		Options:  options,
+		Format:   req.Format,
+		Fields:   req.ResultFields,
+		Results:  results,
+		PageSize: req.PageSize,
+	})
+	if err != nil {
+		return internalError("Failed to create cursor", err)
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSON(http.StatusOK, h.pageResponse(req.Query, options, req.Format, req.ResultFields, page))
+}
+
+// searchRequestFromQuery reads a search request from GET query parameters
+func searchRequestFromQuery(c echo.Context) SearchRequest {
+	var req SearchRequest
+	req.Query = coalesce(c.QueryParam("q"), c.QueryParam("query"))
+	req.Query = strings.Join(strings.Fields(req.Query), " ") // As in the canonical URL
+	req.Raw, _ = strconv.ParseBool(c.QueryParam("raw"))
+	req.Explain, _ = strconv.ParseBool(c.QueryParam("explain"))
+	req.RequireModel, _ = strconv.ParseBool(c.QueryParam("requireModel"))
+
+	// Parse optional parameters
+	if k := c.QueryParam("k"); k != "" {
+		if kVal, err := strconv.Atoi(k); err == nil {
+			req.K = kVal
+		}
+	}
+
+	req.Book = c.QueryParam("book")
+	req.Chapter = c.QueryParam("chapter")
+	req.Verse = c.QueryParam("verse")
+	req.Granularity = c.QueryParam("granularity")
+	req.Index = c.QueryParam("index")
+	req.Rerank, _ = strconv.ParseBool(c.QueryParam("rerank"))
+	req.Exhaustive, _ = strconv.ParseBool(c.QueryParam("exhaustive"))
+	req.Format.DivineName = c.QueryParam("divineName")
+	req.Ranking = c.QueryParam("ranking")
+	if fields := c.QueryParam("fields"); fields != "" {
+		req.Fields = strings.Split(fields, ",")
+	}
+	req.PageSize, _ = strconv.Atoi(c.QueryParam("pageSize"))
+	req.Cursor = c.QueryParam("cursor")
+	req.Highlight, _ = strconv.ParseBool(c.QueryParam("highlight"))
+	req.HighlightPre = c.QueryParam("highlightPre")
+	req.HighlightPost = c.QueryParam("highlightPost")
+	req.Tag = c.QueryParam("tag")
+	req.Namespace = c.QueryParam("namespace")
+	req.Notes, _ = strconv.ParseBool(c.QueryParam("notes"))
+	req.Testament = c.QueryParam("testament")
+	req.Genre = c.QueryParam("genre")
+	if books := c.QueryParam("books"); books != "" {
+		req.Books = strings.Split(books, ",")
+	}
+	if sources := c.QueryParam("sources"); sources != "" {
+		req.Sources = strings.Split(sources, ",")
+	}
+	if include := c.QueryParam("include"); include != "" {
+		req.Include = strings.Split(include, ",")
+	}
+	if fields := c.QueryParam("resultFields"); fields != "" {
+		req.ResultFields = strings.Split(fields, ",")
+	}
+	req.Transform = c.QueryParam("transform")
+	req.Temperature, _ = strconv.ParseFloat(c.QueryParam("temperature"), 64)
+	req.Diversity, _ = strconv.ParseFloat(c.QueryParam("diversity"), 64)
+	req.MinScore, _ = strconv.ParseFloat(c.QueryParam("minScore"), 64)
+	req.Group = c.QueryParam("group")
+	req.Expand, _ = strconv.ParseBool(c.QueryParam("expand"))
+	if exclude := c.QueryParam("exclude"); exclude != "" {
+		req.Exclude = strings.Split(exclude, ",")
+	}
+	req.Negative = c.QueryParam("negative")
+	if aggs := c.QueryParam("aggs"); aggs != "" {
+		req.Aggs = strings.Split(aggs, ",")
+	}
+	return req
+}
+
+// nextPage continues a cursor issued by an earlier search
+func (h *Handler) nextPage(c echo.Context, token string) error {
+	cur, page, err := h.cursors.Next(token)
+	if err != nil {
+		return apiError(http.StatusNotFound, CodeNotFound, err.Error())
+	}
+	c.Response().Header().Set(echo.HeaderCacheControl, "no-store")
+	return c.JSON(http.StatusOK, h.pageResponse(cur.Query, cur.Options, cur.Format, cur.Fields, page))
+}
+
+// pageResponse builds the search response for one page of a cursor
+func (h *Handler) pageResponse(query string, options search.SearchOptions, opts format.Options, fields []string, page cursor.Page) SearchResponse {
+	verses := toVerseResults(page.Results, opts)
+	h.attachNotes(verses, options)
+	h.attachStrongs(verses, options)
+	h.attachResultData(verses, page.Results, options)
+	selection, _ := parseResultFields(fields)
+	selectFields(verses, selection)
+	return SearchResponse{
+		Query:           query,
+		Results:         verses,
+		Count:           len(verses),
+		Status:          "success",
+		Ranking:         options.Ranking,
+		Reproducibility: h.reproducibility(options.Granularity),
+		Sources:         sourceCounts(page.Results),
+		Offset:          page.Offset,
+		Total:           page.Total,
+		Cursor:          page.Next,
+	}
+}
+
+// reproducibility returns the response stamp in determinism mode, nil otherwise
+func (h *Handler) reproducibility(granularity string) *Reproducibility {
+	if !h.config.Deterministic {
+		return nil
+	}
+	return &Reproducibility{
+		ModelHash:    h.search.ModelHash(),
+		IndexVersion: h.search.IndexVersion(granularity),
+		Seed:         h.config.Seed,
+	}
+}
+
+// mergeOptions combines explicit request fields, inline query filters, and nested options
+func mergeOptions(req SearchRequest, filters search.SearchOptions) search.SearchOptions {
+	return search.SearchOptions{
 		Book:        coalesce(req.Book, filters.Book, req.Options.Book),
 		Chapter:     coalesce(req.Chapter, filters.Chapter, req.Options.Chapter),
 		Verse:       coalesce(req.Verse, filters.Verse, req.Options.Verse),
-		Granularity: coalesce(req.Granularity, req.Options.Granularity, "verse"),
+		Granularity: coalesce(req.Index, req.Granularity, req.Options.Granularity, "verse"),
 		K:           maxInt(req.K, req.Options.K, 10),
+		Rerank:      req.Rerank || req.Options.Rerank,
+		Exhaustive:  req.Exhaustive || req.Options.Exhaustive,
+		Fields:      coalesceSlice(req.Fields, req.Options.Fields),
+		Ranking:     coalesce(req.Ranking, req.Options.Ranking, search.RankingDefault),
+
+		Highlight:     req.Highlight || req.Options.Highlight,
+		HighlightPre:  coalesce(req.HighlightPre, req.Options.HighlightPre),
+		HighlightPost: coalesce(req.HighlightPost, req.Options.HighlightPost),
+
+		Tag:       strings.ToLower(coalesce(req.Tag, filters.Tag, req.Options.Tag)),
+		Namespace: strings.ToLower(coalesce(req.Namespace, req.Options.Namespace, tags.DefaultNamespace)),
+		Notes:     req.Notes || req.Options.Notes,
+
+		Include: coalesceSlice(req.Include, req.Options.Include),
+
+		Testament: coalesce(req.Testament, filters.Testament, req.Options.Testament),
+		Genre:     coalesce(req.Genre, filters.Genre, req.Options.Genre),
+		Books:     coalesceSlice(req.Books, filters.Books, req.Options.Books),
+
+		Sources: coalesceSlice(req.Sources, req.Options.Sources),
+
+		Transform:   coalesce(req.Transform, req.Options.Transform),
+		Temperature: coalesceFloat(req.Temperature, req.Options.Temperature),
+
+		Diversity: coalesceFloat(req.Diversity, req.Options.Diversity),
+		MinScore:  coalesceFloat(req.MinScore, req.Options.MinScore),
+		Group:     coalesce(req.Group, req.Options.Group),
+		Expand:    req.Expand || req.Options.Expand,
+
+		Phrases:  filters.Phrases,
+		Exclude:  append(coalesceSlice(req.Exclude, req.Options.Exclude), filters.Exclude...),
+		Negative: strings.TrimSpace(coalesce(req.Negative, req.Options.Negative)),
 	}
+}
 
-	// Perform search
-	results, err := h.search.Search(query, options)
-	if err != nil {
-		log.Error().Err(err).Msg("Search failed")
-		return c.JSON(http.StatusInternalServerError, map[string]string{
-			"error": "Search failed",
-			"details": err.Error(),
-		})
+// canonicalBook resolves a book filter such as "1 Cor", "I Corinthians" or
+// "1co" to its canonical ID; an empty filter stays empty
+func canonicalBook(name string) (string, error) {
+	if name == "" {
+		return "", nil
+	}
+	book, ok := canon.Lookup(name)
+	if !ok {
+		return "", &unknownBookError{name: name}
 	}
+	return book.ID, nil
+}
 
-	// Convert results to Bible verse format
+// normalizeFilters resolves book names to canonical IDs and validates the
+// testament, genre, chapter and verse filters
+func normalizeFilters(options *search.SearchOptions) error {
+	var err error
+	if options.Book, err = canonicalBook(options.Book); err != nil {
+		return err
+	}
+	for i, name := range options.Books {
+		if options.Books[i], err = canonicalBook(strings.TrimSpace(name)); err != nil {
+			return err
+		}
+	}
+
+	if options.Testament != "" {
+		testament, ok := canon.NormalizeTestament(options.Testament)
+		if !ok {
+			return fmt.Errorf("unknown testament: %s (use ot or nt)", options.Testament)
+		}
+		options.Testament = testament
+	}
+	if options.Genre != "" {
+		genre, ok := canon.NormalizeGenre(options.Genre)
+		if !ok {
+			return fmt.Errorf("unknown genre: %s", options.Genre)
+		}
+		options.Genre = genre
+	}
+	if options.Chapter != "" {
+		chapter, err := search.ParseChapter(options.Chapter)
+		if err != nil {
+			return err
+		}
+		options.Chapter = strconv.Itoa(chapter)
+	}
+	if options.Verse != "" {
+		first, last, err := search.ParseVerseRange(options.Verse)
+		if err != nil {
+			return err
+		}
+		options.Verse = strconv.Itoa(first)
+		if last != first {
+			options.Verse += "-" + strconv.Itoa(last)
+		}
+	}
+	return nil
+}
+
+// unknownBookError reports a book filter that doesn't name a canonical book
+type unknownBookError struct {
+	name string
+}
+
+func (e *unknownBookError) Error() string {
+	return "unknown book: " + e.name
+}
+
+// bookError reports a bad filter, suggesting the nearest book if any
+func bookError(err error) *APIError {
+	e := invalidRequest(err)
+	if bookErr, ok := err.(*unknownBookError); ok {
+		e.Code = CodeUnknownBook
+		if nearest := canon.Nearest(bookErr.name); nearest != nil {
+			e.Suggestion = nearest.Name
+		}
+	}
+	return e
+}
+
+// attachNotes adds the namespace's notes to each result's search metadata
+// when requested. Chapter results carry every note within the chapter.
+func (h *Handler) attachNotes(verses []BibleVerseResult, options search.SearchOptions) {
+	if !options.Notes {
+		return
+	}
+	for i, verse := range verses {
+		if found := h.notes.ForVerse(options.Namespace, verse.Book, verse.Chapter, verse.VerseNum); len(found) > 0 {
+			verses[i].SearchMeta["notes"] = found
+		}
+	}
+}
+
+// attachStrongs adds each verse's Strong's-tagged words to its search
+// metadata when requested
+func (h *Handler) attachStrongs(verses []BibleVerseResult, options search.SearchOptions) {
+	if h.lexicon == nil || !options.Includes(search.IncludeStrongs) {
+		return
+	}
+	for i, verse := range verses {
+		if verse.VerseNum == 0 {
+			continue
+		}
+		if words := h.lexicon.ForVerse(verse.Book, verse.Chapter, verse.VerseNum); len(words) > 0 {
+			verses[i].SearchMeta["strongs"] = words
+		}
+	}
+}
+
+// toVerseResults converts search results to the Bible verse response format
+func toVerseResults(results []search.SearchResult, opts format.Options) []BibleVerseResult {
 	verses := make([]BibleVerseResult, 0, len(results))
 	for _, result := range results {
 		verse := BibleVerseResult{
 			Book:     result.Chunk.Meta.Book,
 			Chapter:  result.Chunk.Meta.Chapter,
 			VerseNum: result.Chunk.Meta.VerseNum,
-			Text:     result.Chunk.Text,
+			Text:     format.Text(result.Chunk.Text, opts),
 			SearchMeta: map[string]interface{}{
 				"similarity": result.Similarity,
 				"score":      result.Score,
 				"reference":  result.Chunk.Meta.Reference,
 			},
 		}
+		if len(result.MatchedFields) > 0 {
+			verse.SearchMeta["matchedFields"] = result.MatchedFields
+		}
+		if len(result.Contributions) > 0 {
+			verse.SearchMeta["contributions"] = result.Contributions
+		}
+		if len(result.Expansions) > 0 {
+			verse.SearchMeta["expansions"] = result.Expansions
+		}
+		if result.Probability != nil {
+			verse.SearchMeta["probability"] = *result.Probability
+		}
+		if result.Rollup != nil {
+			verse.SearchMeta["chapter"] = result.Rollup
+		}
+		if result.Source != "" {
+			verse.SearchMeta["source"] = result.Source
+		}
+		if meta := result.Chunk.Meta; meta.Original != "" {
+			verse.SearchMeta["original"] = meta.Original
+			verse.SearchMeta["language"] = meta.Language
+			if meta.Transliteration != "" {
+				verse.SearchMeta["transliteration"] = meta.Transliteration
+			}
+		}
+		if result.Source == search.SourceNotes {
+			verse.SearchMeta["noteId"] = result.ID
+		}
+		if result.Corpus != "" {
+			verse.SearchMeta["corpus"] = result.Corpus
+			verse.SearchMeta["documentId"] = result.ID
+		}
+		if result.Highlight != nil {
+			verse.SearchMeta["highlight"] = format.Text(result.Highlight.Text, opts)
+			if result.Highlight.NearestVerse != "" {
+				verse.SearchMeta["nearestVerse"] = result.Highlight.NearestVerse
+			}
+		}
+		if result.Chunk.Meta.Heading != "" {
+			verse.SearchMeta["heading"] = result.Chunk.Meta.Heading
+		}
 		verses = append(verses, verse)
 	}
-
-	response := SearchResponse{
-		Query:   req.Query,
-		Results: verses,
-		Count:   len(verses),
-		Status:  "success",
-	}
-
-	return c.JSON(http.StatusOK, response)
+	return verses
 }
 
 // EmbedRequest represents an embedding request
 type EmbedRequest struct {
-	Text string `json:"text"`
-	Type string `json:"type,omitempty"` // "query" or "document"
+	Text         string `json:"text"`
+	Type         string `json:"type,omitempty"`         // "query" (default) or "document"
+	RequireModel bool   `json:"requireModel,omitempty"` // Fail rather than embed a query with a fallback
 }
 
 // EmbedResponse represents an embedding response
 type EmbedResponse struct {
-	Embedding  []float32 `json:"embedding"`
-	Dimensions int       `json:"dimensions"`
+	Embedding        []float32 `json:"embedding"`
+	Dimensions       int       `json:"dimensions"`
+	EmbeddingBackend string    `json:"embeddingBackend"` // What embedded the text: "onnx", "remote", or for queries the "simple" or "placeholder" fallback
 }
 
-// Embed handles embedding generation requests
+// Embed embeds a text as a search query, with the query prompt and the
+// query cache, or as a document to be searched, with the document prompt.
+// Documents need the model; queries fall back like searches unless the
+// request requires the model.
 func (h *Handler) Embed(c echo.Context) error {
 	var req EmbedRequest
 	if err := c.Bind(&req); err != nil {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Invalid request body",
-		})
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
 	}
 
 	if req.Text == "" {
-		return c.JSON(http.StatusBadRequest, map[string]string{
-			"error": "Text is required",
-		})
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Text is required")
 	}
 
-	// This endpoint would typically generate embeddings using the model
-	// For now, it returns a message indicating the feature is planned
-	return c.JSON(http.StatusNotImplemented, map[string]string{
-		"message": "Direct embedding generation will be available when ONNX runtime is integrated",
-		"status": "planned",
-	})
-}
-
-// parseQuery parses a search query to extract filters
-func parseQuery(query string) (string, search.SearchOptions) {
-	filters := search.SearchOptions{}
-	parts := strings.Fields(query)
-	semanticParts := []string{}
-
-	for _, part := range parts {
-		if strings.Contains(part, ":") {
-			kv := strings.SplitN(part, ":", 2)
-			if len(kv) == 2 {
-				key := strings.ToLower(kv[0])
-				value := kv[1]
-
-				switch key {
-				case "book":
-					filters.Book = value
-				case "chapter":
-					filters.Chapter = value
-				case "verse":
-					filters.Verse = value
-				default:
-					semanticParts = append(semanticParts, part)
-				}
-			} else {
-				semanticParts = append(semanticParts, part)
-			}
-		} else {
-			semanticParts = append(semanticParts, part)
+	var response EmbedResponse
+	switch req.Type {
+	case "", "query":
+		if req.RequireModel {
+			requireModel(c)
+		}
+		backend := trackBackend(c)
+		embedding, err := h.search.EmbedQuery(c.Request().Context(), req.Text)
+		if err != nil {
+			return searchError("Failed to embed query", err)
 		}
+		response.Embedding, response.EmbeddingBackend = embedding, backend()
+	case "document":
+		embeddings, err := h.search.EmbedDocuments(c.Request().Context(), []string{req.Text})
+		if err != nil {
+			return searchError("Failed to embed document", err)
+		}
+		response.Embedding, response.EmbeddingBackend = embeddings[0], h.search.EmbeddingBackend()
+	default:
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Type must be \"query\" or \"document\"")
 	}
-
-	return strings.Join(semanticParts, " "), filters
+	response.Dimensions = len(response.Embedding)
+	return c.JSON(http.StatusOK, response)
 }
 
 // Helper functions
@@ -223,6 +810,24 @@ func coalesce(values ...string) string {
 	return ""
 }
 
+func coalesceSlice(values ...[]string) []string {
+	for _, v := range values {
+		if len(v) > 0 {
+			return v
+		}
+	}
+	return nil
+}
+
+func coalesceFloat(values ...float64) float64 {
+	for _, v := range values {
+		if v != 0 {
+			return v
+		}
+	}
+	return 0
+}
+
 func maxInt(values ...int) int {
 	max := 0
 	for _, v := range values {
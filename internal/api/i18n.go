@@ -0,0 +1,83 @@
+package api
+
+import (
+	"fmt"
+
+	"github.com/dpshade/goscriptureapi/internal/i18n"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// LocalizedSerializer translates error response bodies into the language the
+// request asked for with ?lang= or Accept-Language. Handlers keep writing
+// English messages; the catalogs in internal/i18n hold the translations.
+type LocalizedSerializer struct {
+	echo.DefaultJSONSerializer
+}
+
+// Serialize translates error bodies before encoding them
+func (s LocalizedSerializer) Serialize(c echo.Context, i interface{}, indent string) error {
+	if lang := Language(c); lang != i18n.DefaultLanguage {
+		if localized, ok := localizeBody(lang, i); ok {
+			c.Response().Header().Set("Content-Language", lang)
+			i = localized
+		}
+	}
+	return s.DefaultJSONSerializer.Serialize(c, i, indent)
+}
+
+// Language returns the response language negotiated for a request
+func Language(c echo.Context) string {
+	return i18n.Negotiate(c.QueryParam("lang"), c.Request().Header.Get("Accept-Language"))
+}
+
+// localizeBody returns a translated copy of an error response, or false if
+// the value isn't one
+func localizeBody(lang string, i interface{}) (interface{}, bool) {
+	body, ok := i.(ErrorResponse)
+	if !ok {
+		return nil, false
+	}
+	body.Error.Message = i18n.Translate(lang, body.Error.Message)
+	switch details := body.Error.Details.(type) {
+	case string:
+		body.Error.Details = i18n.Translate(lang, details)
+	case *reference.ParseError:
+		body.Error.Details = localizeParseError(lang, details)
+	}
+	return body, true
+}
+
+// localizeParseError returns a copy of a parse error with its message translated
+func localizeParseError(lang string, err *reference.ParseError) *reference.ParseError {
+	translated := *err
+	translated.Message = i18n.Translate(lang, err.Message)
+	return &translated
+}
+
+// diagnostics explains an empty result set in the request's language
+func (h *Handler) diagnostics(c echo.Context, options search.SearchOptions) []string {
+	granularity := coalesce(options.Granularity, "verse")
+
+	var messages []string
+	if floor := h.config.LimitsFor(granularity).MinScore; floor > 0 {
+		messages = append(messages, fmt.Sprintf("No results scored above the %s score floor of %g", granularity, floor))
+	}
+	if options.MinScore != 0 {
+		messages = append(messages, fmt.Sprintf("No results scored above the requested minScore of %g", options.MinScore))
+	}
+	if options.Book != "" || len(options.Books) > 0 || options.Chapter != "" || options.Verse != "" ||
+		options.Testament != "" || options.Genre != "" || options.Tag != "" || len(options.Phrases) > 0 || len(options.Exclude) > 0 {
+		messages = append(messages, "Filters may be excluding matches; try removing some of them")
+	}
+	if len(messages) == 0 {
+		messages = append(messages, "No results matched the query")
+	}
+
+	lang := Language(c)
+	for i, message := range messages {
+		messages[i] = i18n.Translate(lang, message)
+	}
+	return messages
+}
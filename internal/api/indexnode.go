@@ -0,0 +1,145 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// RankIndex ranks an API node's embedded query against this node's indices.
+// It serves stateless API nodes, which embed queries but hold no indices.
+func (h *Handler) RankIndex(c echo.Context) error {
+	var req search.RankRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+	if len(req.Embedding) == 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "embedding is required")
+	}
+
+	options := req.Options
+	options.MaxK, options.Paged = req.MaxK, req.Paged
+	results, err := h.search.SearchEmbedding(c.Request().Context(), req.Query, req.Embedding, options)
+	if err != nil {
+		return searchError("Search failed", err)
+	}
+	return c.JSON(http.StatusOK, search.RankResponse{
+		Results: results,
+		Version: h.search.IndexVersion(coalesce(options.Granularity, "verse")),
+	})
+}
+
+// forwarded is a request on its way to the index node: the client it came
+// from, and where the proxy records its failure
+type forwarded struct {
+	clientIP string
+	failure  error
+}
+
+// forwardedKey holds a request's *forwarded in its context
+type forwardedKey struct{}
+
+// SetIndexNode makes this a stateless API node, forwarding requests it
+// can't answer without indices to the index node at target. The client's
+// X-Forwarded-For is replaced by the address this node identified it by, so
+// an index node trusting only its API nodes rate-limits the real client.
+func (h *Handler) SetIndexNode(target *url.URL) {
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.SetURL(target)
+			pr.Out.Host = pr.In.Host
+			// This node compresses the response itself
+			pr.Out.Header.Del(echo.HeaderAcceptEncoding)
+			pr.Out.Header.Del(echo.HeaderXRealIP)
+			if fwd, ok := pr.In.Context().Value(forwardedKey{}).(*forwarded); ok {
+				pr.Out.Header.Set(echo.HeaderXForwardedFor, fwd.clientIP)
+			}
+		},
+		ErrorHandler: func(_ http.ResponseWriter, req *http.Request, err error) {
+			if fwd, ok := req.Context().Value(forwardedKey{}).(*forwarded); ok {
+				fwd.failure = err
+			}
+		},
+	}
+	h.indexNode = proxy
+}
+
+// statelessRoutes are the routes a stateless node serves itself: searches,
+// which it embeds and ranks on the index node, and those needing no index
+var statelessRoutes = map[string]bool{
+	"/health":          true,
+	"/status":          true,
+	"/search":          true,
+	"/search/stream":   true,
+	"/search/batch":    true,
+	"/queries/compare": true,
+	"/embed":           true,
+	"/widget.js":       true,
+	"/widget/search":   true,
+	"/openapi.json":    true,
+}
+
+// Stateless forwards every other route to the index node, with its own
+// middleware such as admin authentication and rate limiting left to the
+// index node, which sees the client's address in X-Forwarded-For. It passes
+// everything through on a node holding its indices.
+func (h *Handler) Stateless() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if h.indexNode == nil || statelessRoutes[c.Path()] {
+				return next(c)
+			}
+			return h.forward(c)
+		}
+	}
+}
+
+// forward hands a request to the index node as is, apart from naming its
+// client in X-Forwarded-For
+func (h *Handler) forward(c echo.Context) error {
+	fwd := &forwarded{clientIP: c.RealIP()}
+	req := c.Request()
+	req = req.WithContext(context.WithValue(req.Context(), forwardedKey{}, fwd))
+	h.indexNode.ServeHTTP(c.Response(), req)
+	if failure := fwd.failure; failure != nil {
+		if req.Context().Err() != nil {
+			return searchError("Request failed", req.Context().Err())
+		}
+		return &APIError{
+			Status:  http.StatusBadGateway,
+			Code:    CodeUpstreamFailed,
+			Message: "The index node could not be reached",
+			Err:     failure,
+		}
+	}
+	return nil
+}
+
+// forwardBound hands a request whose JSON body has been bound into body to
+// the index node, encoding body again. GET requests are forwarded as they came.
+func (h *Handler) forwardBound(c echo.Context, body interface{}) error {
+	if c.Request().Method != http.MethodGet {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return internalError("Failed to forward request", err)
+		}
+		c.Request().Body = io.NopCloser(bytes.NewReader(encoded))
+		c.Request().ContentLength = int64(len(encoded))
+		c.Request().Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	}
+	return h.forward(c)
+}
+
+// forwards reports whether a stateless node must forward a search with these
+// options, since notes and stored vectors are only on the index node
+func (h *Handler) forwards(options search.SearchOptions) bool {
+	return h.indexNode != nil &&
+		(options.Notes || options.SearchesSource(search.SourceNotes) || options.Includes(search.IncludeEmbedding))
+}
@@ -0,0 +1,67 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// maxCorpusBytes bounds an uploaded corpus body
+const maxCorpusBytes = 64 << 20
+
+// CorpusResponse describes an ingested corpus
+type CorpusResponse struct {
+	Corpus search.CorpusInfo `json:"corpus"`
+	Status string            `json:"status"`
+}
+
+// IngestCorpus embeds an uploaded JSON array or JSON Lines corpus of
+// documents into a named index, searchable as index=name. License and
+// attribution query parameters are stored with it. Ingestion embeds every
+// document, so the route is mounted behind AdminAuth and the rate limiter.
+func (h *Handler) IngestCorpus(c echo.Context) error {
+	name := c.QueryParam("name")
+	if err := search.ValidateCorpusName(name); err != nil {
+		return invalidRequest(err)
+	}
+
+	body := http.MaxBytesReader(c.Response(), c.Request().Body, maxCorpusBytes)
+	docs, err := search.ParseDocuments(body)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return apiError(http.StatusRequestEntityTooLarge, CodeLimitExceeded, "Corpus is too large").
+				withDetails(map[string]int64{"maxBytes": maxCorpusBytes})
+		}
+		return invalidRequest(err)
+	}
+
+	license := config.CorpusLicense{
+		Title:       c.QueryParam("title"),
+		License:     c.QueryParam("license"),
+		LicenseURL:  c.QueryParam("licenseUrl"),
+		Attribution: c.QueryParam("attribution"),
+		SourceURL:   c.QueryParam("sourceUrl"),
+	}
+	corpus, err := h.search.IngestCorpus(c.Request().Context(), name, docs, license)
+	if err != nil {
+		return searchError("Failed to ingest corpus", err)
+	}
+	return c.JSON(http.StatusCreated, CorpusResponse{
+		Corpus: *corpus,
+		Status: "success",
+	})
+}
+
+// DeleteCorpus stops serving an ingested corpus and deletes it
+func (h *Handler) DeleteCorpus(c echo.Context) error {
+	if err := h.search.DeleteCorpus(c.Param("name")); err != nil {
+		return searchError("Failed to delete corpus", err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/lexicon"
+	"github.com/labstack/echo/v4"
+)
+
+// LexiconResponse is a Strong's lexicon entry
+type LexiconResponse struct {
+	Entry       *lexicon.Entry `json:"entry"`
+	Occurrences int            `json:"occurrences"` // Tagged verses using the number
+	Status      string         `json:"status"`
+}
+
+// SetLexicon enables /lexicon and include=strongs with a loaded lexicon
+func (h *Handler) SetLexicon(service *lexicon.Service) {
+	h.lexicon = service
+}
+
+// Lexicon returns the lexicon entry for a Strong's number
+func (h *Handler) Lexicon(c echo.Context) error {
+	if h.lexicon == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "The lexicon is not configured")
+	}
+
+	entry, occurrences, err := h.lexicon.Lookup(c.Param("strongs"))
+	if errors.Is(err, lexicon.ErrInvalidNumber) {
+		return invalidRequest(err)
+	}
+	if err != nil {
+		return apiError(http.StatusNotFound, CodeNotFound, "No lexicon entry for that Strong's number")
+	}
+
+	return c.JSON(http.StatusOK, LexiconResponse{
+		Entry:       entry,
+		Occurrences: occurrences,
+		Status:      "success",
+	})
+}
@@ -0,0 +1,49 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// CorpusMeta is the public description of one corpus: what it is and how
+// its text must be attributed
+type CorpusMeta struct {
+	Granularity string                `json:"granularity"`
+	Ingested    bool                  `json:"ingested,omitempty"`
+	Loaded      bool                  `json:"loaded"`
+	License     *config.CorpusLicense `json:"license,omitempty"`
+}
+
+// MetaResponse describes the API and the corpora it serves
+type MetaResponse struct {
+	Name    string       `json:"name"`
+	Version string       `json:"version"`
+	Model   string       `json:"model"`
+	Corpora []CorpusMeta `json:"corpora"`
+	Status  string       `json:"status"`
+}
+
+// Meta lists every corpus with its license and attribution, so clients can
+// show the notices the texts they display require
+func (h *Handler) Meta(c echo.Context) error {
+	corpora := h.search.Corpora()
+	meta := make([]CorpusMeta, len(corpora))
+	for i, corpus := range corpora {
+		meta[i] = CorpusMeta{
+			Granularity: corpus.Granularity,
+			Ingested:    corpus.Ingested,
+			Loaded:      corpus.State == search.CorpusLoaded,
+			License:     corpus.License,
+		}
+	}
+	return c.JSON(http.StatusOK, MetaResponse{
+		Name:    "GoScriptureAPI",
+		Version: apiVersion,
+		Model:   config.ModelConfig.ModelID,
+		Corpora: meta,
+		Status:  "success",
+	})
+}
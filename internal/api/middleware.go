@@ -0,0 +1,315 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"math"
+	"net"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/qos"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+	"github.com/labstack/echo/v4/middleware"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/time/rate"
+)
+
+// policyKey is the context key holding the request's privacy policy
+const policyKey = "privacyPolicy"
+
+// TierHeader names the caller's QoS tier in responses when tiers are configured
+const TierHeader = "X-QoS-Tier"
+
+// redactedParams are the query parameters that carry search text
+var redactedParams = []string{"q", "query", "exclude", "negative"}
+
+// requestIDPattern bounds the X-Request-ID values accepted from clients and
+// proxies, so they can't inject arbitrary text into logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID propagates the caller's X-Request-ID, or generates one, and
+// echoes it in the response. The request context carries a logger tagged with
+// the ID, so search and embedding code can log with log.Ctx(ctx).
+func RequestID() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			id := req.Header.Get(echo.HeaderXRequestID)
+			if !requestIDPattern.MatchString(id) {
+				id = newRequestID()
+			}
+			c.Response().Header().Set(echo.HeaderXRequestID, id)
+
+			logger := log.With().Str("request_id", id).Logger()
+			c.SetRequest(req.WithContext(logger.WithContext(req.Context())))
+			return next(c)
+		}
+	}
+}
+
+// newRequestID returns a random 128-bit hex ID
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// RequestLogger logs each request through zerolog, resolving the caller's
+// privacy policy first and redacting query text it doesn't allow to be kept.
+// Later middleware and handlers read the policy with PolicyFor.
+func RequestLogger(store *privacy.Store) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			policy := store.For(c.Request().Header.Get(privacy.KeyHeader))
+			c.Set(policyKey, policy)
+
+			start := time.Now()
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			req := c.Request()
+			log.Ctx(req.Context()).Info().
+				Str("method", req.Method).
+				Str("uri", redactURI(req, policy)).
+				Int("status", c.Response().Status).
+				Dur("latency", time.Since(start)).
+				Str("remote_ip", c.RealIP()).
+				Msg("request")
+			return nil
+		}
+	}
+}
+
+// PolicyFor returns the privacy policy RequestLogger resolved for a request
+func PolicyFor(c echo.Context) privacy.Policy {
+	policy, _ := c.Get(policyKey).(privacy.Policy)
+	return policy
+}
+
+// redactURI returns the request path and query with search text redacted
+func redactURI(req *http.Request, policy privacy.Policy) string {
+	values := req.URL.Query()
+	changed := false
+	for _, param := range redactedParams {
+		query := values.Get(param)
+		if redacted := policy.Redact(query); redacted != query {
+			values.Set(param, redacted)
+			changed = true
+		}
+	}
+	if !changed {
+		return req.URL.RequestURI()
+	}
+	return req.URL.Path + "?" + values.Encode()
+}
+
+// QoS resolves the caller's service tier from its API key. The tier rides
+// on the request context, where the search and embedding services read it;
+// TierFor reads it in handlers. Without configured tiers every caller gets
+// qos.Unrestricted.
+func QoS(tiers *qos.Tiers) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			tier := tiers.For(c.Request().Header.Get(privacy.KeyHeader))
+			if tiers != nil {
+				c.Response().Header().Set(TierHeader, tier.Name)
+			}
+			req := c.Request()
+			c.SetRequest(req.WithContext(qos.WithTier(req.Context(), tier)))
+			return next(c)
+		}
+	}
+}
+
+// TierFor returns the QoS tier resolved for a request
+func TierFor(c echo.Context) qos.Tier {
+	return qos.FromContext(c.Request().Context())
+}
+
+// applyTier restricts search options to what the caller's QoS tier allows:
+// re-ranking must be included, and the tier's max-k replaces the default cap
+func applyTier(c echo.Context, options *search.SearchOptions) error {
+	tier := TierFor(c)
+	if options.Rerank && !tier.Rerank {
+		return apiError(http.StatusForbidden, CodeTierRestricted, "Re-ranking is not included in your service tier").
+			withDetails(map[string]string{"tier": tier.Name})
+	}
+	options.MaxK = tier.MaxK
+	return nil
+}
+
+// SearchDeadline bounds how long a request's searches may run. Embedding and
+// index scans still running at the deadline are abandoned, and the request
+// fails with 504 search_timeout. Zero leaves searches unbounded; either way
+// they stop when the client disconnects.
+func SearchDeadline(limit time.Duration) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		if limit <= 0 {
+			return next
+		}
+		return func(c echo.Context) error {
+			req := c.Request()
+			ctx, cancel := context.WithTimeout(req.Context(), limit)
+			defer cancel()
+			c.SetRequest(req.WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// AdminAuth requires the admin bearer token in the Authorization header.
+// Without a configured token the routes it guards are disabled.
+func AdminAuth(token string) echo.MiddlewareFunc {
+	return bearerAuth(token, "admin")
+}
+
+// IndexAuth requires the index-node token, which stateless API nodes send
+// to rank searches. Without a configured token ranking for them is disabled.
+func IndexAuth(token string) echo.MiddlewareFunc {
+	return bearerAuth(token, "index-node")
+}
+
+// bearerAuth requires token in the Authorization header, naming it as kind
+func bearerAuth(token, kind string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if token == "" {
+				return apiError(http.StatusNotFound, CodeFeatureDisabled, strings.ToUpper(kind[:1])+kind[1:]+" authentication is not configured")
+			}
+			given, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+				c.Response().Header().Set(echo.HeaderWWWAuthenticate, "Bearer")
+				return apiError(http.StatusUnauthorized, CodeUnauthorized, "A valid "+kind+" token is required")
+			}
+			return next(c)
+		}
+	}
+}
+
+// IPExtractor identifies clients for rate limiting and request logs. Without
+// trusted proxies the client is the connection's peer, since any client can
+// send X-Forwarded-For. Behind trusted proxies it is the last address in
+// X-Forwarded-For that none of them added.
+func IPExtractor(trusted []*net.IPNet) echo.IPExtractor {
+	if len(trusted) == 0 {
+		return echo.ExtractIPDirect()
+	}
+	options := []echo.TrustOption{echo.TrustLoopback(false), echo.TrustLinkLocal(false), echo.TrustPrivateNet(false)}
+	for _, network := range trusted {
+		options = append(options, echo.TrustIPRange(network))
+	}
+	return echo.ExtractIPFromXFFHeader(options...)
+}
+
+// RateLimiter returns token bucket middleware for the expensive endpoints.
+// Callers with a tiered API key share a bucket per key at their tier's rate;
+// everyone else gets a bucket per client IP. It passes everything through
+// when no tier is rate limited.
+func RateLimiter(cfg *config.Config, tiers *qos.Tiers) echo.MiddlewareFunc {
+	stores := make(map[string]middleware.RateLimiterStore)
+	retryAfter := make(map[string]string)
+	for _, tier := range tiers.All() {
+		limit, burst := tier.RateLimit, tier.RateBurst
+		if limit == 0 {
+			limit, burst = cfg.RateLimit, cfg.RateBurst
+		}
+		if limit <= 0 {
+			continue
+		}
+		if burst <= 0 {
+			burst = int(math.Ceil(limit))
+		}
+		stores[tier.Name] = middleware.NewRateLimiterMemoryStoreWithConfig(middleware.RateLimiterMemoryStoreConfig{
+			Rate:      rate.Limit(limit),
+			Burst:     burst,
+			ExpiresIn: 5 * time.Minute,
+		})
+		// A denied client can retry once the bucket has refilled a single token
+		retryAfter[tier.Name] = strconv.Itoa(int(math.Ceil(1 / limit)))
+	}
+	if len(stores) == 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+
+	store := tieredStore(stores)
+	identify := func(c echo.Context) (string, error) {
+		key := c.Request().Header.Get(privacy.KeyHeader)
+		if tiers.Keyed(key) {
+			return TierFor(c).Name + "\x00key:" + key, nil
+		}
+		return TierFor(c).Name + "\x00ip:" + c.RealIP(), nil
+	}
+	deny := func(c echo.Context) error {
+		c.Response().Header().Set("Retry-After", retryAfter[TierFor(c).Name])
+		return apiError(http.StatusTooManyRequests, CodeRateLimited, "Rate limit exceeded")
+	}
+
+	limiter := middleware.RateLimiterWithConfig(middleware.RateLimiterConfig{
+		Skipper: func(c echo.Context) bool {
+			return stores[TierFor(c).Name] == nil
+		},
+		Store:               store,
+		IdentifierExtractor: identify,
+		ErrorHandler: func(c echo.Context, err error) error {
+			return apiError(http.StatusForbidden, CodeUnidentifiedClient, "Unable to identify client")
+		},
+		DenyHandler: func(c echo.Context, identifier string, err error) error {
+			return deny(c)
+		},
+	})
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return limiter(func(c echo.Context) error {
+			// Requests costing more than the token the limiter took charge the rest themselves
+			c.Set(chargeKey, func(n int) error {
+				if stores[TierFor(c).Name] == nil {
+					return nil
+				}
+				identifier, err := identify(c)
+				if err != nil {
+					return err
+				}
+				for i := 0; i < n; i++ {
+					if allowed, err := store.Allow(identifier); err != nil || !allowed {
+						return deny(c)
+					}
+				}
+				return nil
+			})
+			return next(c)
+		})
+	}
+}
+
+// chargeKey is the context key holding the rate limiter's charge function
+const chargeKey = "rateCharge"
+
+// charge takes n more tokens from the caller's rate limit bucket, for a
+// request that costs more than one, such as a batch. It fails with
+// rate_limited if the bucket runs out, and always succeeds on routes without
+// a rate limit.
+func charge(c echo.Context, n int) error {
+	if charge, ok := c.Get(chargeKey).(func(int) error); ok && n > 0 {
+		return charge(n)
+	}
+	return nil
+}
+
+// tieredStore routes each identifier to its tier's token buckets. Identifiers
+// are the tier name, a NUL byte, then the key or IP.
+type tieredStore map[string]middleware.RateLimiterStore
+
+func (s tieredStore) Allow(identifier string) (bool, error) {
+	tier, _, _ := strings.Cut(identifier, "\x00")
+	return s[tier].Allow(identifier)
+}
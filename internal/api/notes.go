@@ -0,0 +1,218 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"sort"
+
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/labstack/echo/v4"
+)
+
+// NoteRequest creates or updates a note
+type NoteRequest struct {
+	Namespace string `json:"namespace,omitempty"`
+	Reference string `json:"reference,omitempty"` // Required when creating
+	Body      string `json:"body"`                // Markdown
+}
+
+// NotesResponse lists notes
+type NotesResponse struct {
+	Namespace string       `json:"namespace"`
+	Notes     []notes.Note `json:"notes"`
+	Count     int          `json:"count"`
+	Status    string       `json:"status"`
+}
+
+// NoteResponse returns a single note
+type NoteResponse struct {
+	Note   notes.Note `json:"note"`
+	Status string     `json:"status"`
+}
+
+// CreateNote attaches a note to a verse or range
+func (h *Handler) CreateNote(c echo.Context) error {
+	var req NoteRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	namespace, err := tags.Normalize(coalesce(req.Namespace, tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	ref, err := reference.Parse(req.Reference)
+	if err != nil {
+		return referenceError(err)
+	}
+
+	note, err := h.notes.Create(namespace, ref, req.Body)
+	if err != nil {
+		return noteError(err)
+	}
+	return c.JSON(http.StatusCreated, NoteResponse{Note: note, Status: "success"})
+}
+
+// UpdateNote replaces a note's body
+func (h *Handler) UpdateNote(c echo.Context) error {
+	var req NoteRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	namespace, err := tags.Normalize(coalesce(req.Namespace, c.QueryParam("namespace"), tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	note, err := h.notes.Update(namespace, c.Param("id"), req.Body)
+	if err != nil {
+		return noteError(err)
+	}
+	return c.JSON(http.StatusOK, NoteResponse{Note: note, Status: "success"})
+}
+
+// DeleteNote removes a note
+func (h *Handler) DeleteNote(c echo.Context) error {
+	namespace, err := tags.Normalize(coalesce(c.QueryParam("namespace"), tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	if err := h.notes.Delete(namespace, c.Param("id")); err != nil {
+		return noteError(err)
+	}
+	return c.JSON(http.StatusOK, map[string]string{
+		"status": "success",
+	})
+}
+
+// Notes lists a namespace's notes, or only those overlapping ?ref=
+func (h *Handler) Notes(c echo.Context) error {
+	namespace, err := tags.Normalize(coalesce(c.QueryParam("namespace"), tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	var found []notes.Note
+	if input := c.QueryParam("ref"); input != "" {
+		ref, err := reference.Parse(input)
+		if err != nil {
+			return referenceError(err)
+		}
+		found = h.notes.Overlapping(namespace, ref)
+	} else {
+		found = h.notes.List(namespace)
+	}
+
+	return c.JSON(http.StatusOK, NotesResponse{
+		Namespace: namespace,
+		Notes:     found,
+		Count:     len(found),
+		Status:    "success",
+	})
+}
+
+// ExportNotes downloads a namespace's notes as JSON (default) or Markdown
+func (h *Handler) ExportNotes(c echo.Context) error {
+	namespace, err := tags.Normalize(coalesce(c.QueryParam("namespace"), tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	all := h.notes.List(namespace)
+	switch c.QueryParam("format") {
+	case "", "json":
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="notes-`+namespace+`.json"`)
+		return c.JSON(http.StatusOK, all)
+	case "markdown", "md":
+		c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="notes-`+namespace+`.md"`)
+		return c.Blob(http.StatusOK, "text/markdown; charset=utf-8", []byte(notes.Markdown(namespace, all)))
+	default:
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "format must be json or markdown")
+	}
+}
+
+// searchSources runs a search across the requested sources. Scripture and the
+// namespace's notes are ranked together by similarity to a single query
+// embedding; scripture filters don't apply to notes. Scripture-only searches
+// may be shed under load, reported as search.ShedCache or search.ShedLexical.
+func (h *Handler) searchSources(ctx context.Context, query string, options search.SearchOptions) ([]search.SearchResult, string, error) {
+	if !options.SearchesSource(search.SourceNotes) {
+		return h.search.SearchOrShed(ctx, query, options)
+	}
+
+	embedding, err := h.search.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var results []search.SearchResult
+	if options.SearchesSource(search.SourceScripture) {
+		if results, err = h.search.SearchEmbedding(ctx, query, embedding, options); err != nil {
+			return nil, "", err
+		}
+		for i := range results {
+			results[i].Source = search.SourceScripture
+		}
+	}
+
+	k := options.K
+	if k <= 0 {
+		k = 10
+	}
+	for _, match := range h.notes.Search(options.Namespace, embedding, k) {
+		if float64(match.Similarity) >= options.MinScore {
+			results = append(results, noteResult(match))
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].Score > results[j].Score
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, "", nil
+}
+
+// noteResult presents a note match in the shape of a search result
+func noteResult(match notes.Match) search.SearchResult {
+	meta := search.Metadata{Reference: match.Note.Reference}
+	if ref, err := reference.Parse(match.Note.Reference); err == nil {
+		meta.Book = ref.Book
+		meta.Chapter = ref.StartChapter
+		meta.VerseNum = ref.StartVerse
+	}
+
+	return search.SearchResult{
+		ID:         match.Note.ID,
+		Similarity: match.Similarity,
+		Score:      match.Similarity,
+		Chunk: search.ChunkData{
+			ID:   match.Note.ID,
+			Text: match.Note.Body,
+			Meta: meta,
+		},
+		Source: search.SourceNotes,
+	}
+}
+
+// sourceCounts breaks results down by source, or returns nil for scripture-only searches
+func sourceCounts(results []search.SearchResult) map[string]int {
+	var counts map[string]int
+	for _, result := range results {
+		if result.Source == "" {
+			continue
+		}
+		if counts == nil {
+			counts = make(map[string]int)
+		}
+		counts[result.Source]++
+	}
+	return counts
+}
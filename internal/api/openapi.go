@@ -0,0 +1,363 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/openapi"
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// apiVersion is the version advertised in the OpenAPI document
+const apiVersion = "2.0.0"
+
+var (
+	specOnce sync.Once
+	spec     *openapi.Document
+)
+
+// searchQueryParams are the GET /search parameters read by searchRequestFromQuery
+var searchQueryParams = []openapi.Parameter{
+	openapi.QueryParam("q", "string", "Search query; inline filters such as book:John are honored, quoted phrases must appear word for word, -words must not appear, and references such as John 3:16 are searched as text"),
+	openapi.QueryParam("raw", "boolean", "Search the query as written, without reading inline filters"),
+	openapi.QueryParam("explain", "boolean", "Report how the search was answered: index, filters, embedding backend, scan and timings, with a score breakdown per result"),
+	openapi.QueryParam("requireModel", "boolean", "Fail with model_not_ready rather than embed the query with the simple or placeholder fallback"),
+	openapi.QueryParam("k", "integer", "Number of results (default 10)"),
+	openapi.QueryParam("book", "string", "Restrict results to a book"),
+	openapi.QueryParam("chapter", "string", "Restrict results to a chapter"),
+	openapi.QueryParam("books", "string", "Comma-separated books to search"),
+	openapi.QueryParam("testament", "string", "\"ot\" or \"nt\""),
+	openapi.QueryParam("genre", "string", "Genre such as gospels or wisdom, or a group: prophets, epistles"),
+	openapi.QueryParam("verse", "string", "Restrict results to a verse or range, e.g. 16 or 1-10"),
+	openapi.QueryParam("granularity", "string", "\"verse\", \"chapter\", or \"original\" for Hebrew and Greek verses (needs -original-embeddings)"),
+	openapi.QueryParam("index", "string", "Named index to search: verse, chapter, a configured index or an ingested corpus; takes precedence over granularity"),
+	openapi.QueryParam("rerank", "boolean", "Quantized retrieval followed by exact re-ranking"),
+	openapi.QueryParam("divineName", "string", "LORD/GOD rendering: asis, smallcaps, html or title"),
+	openapi.QueryParam("ranking", "string", "\"default\", \"pure\", or \"ensemble\" (needs -ensemble-model)"),
+	openapi.QueryParam("fields", "string", "Comma-separated searchable fields with optional boosts, e.g. text,heading^2"),
+	openapi.QueryParam("highlight", "boolean", "Return text with query terms wrapped in markers"),
+	openapi.QueryParam("highlightPre", "string", "Opening highlight marker (default <mark>)"),
+	openapi.QueryParam("highlightPost", "string", "Closing highlight marker (default </mark>)"),
+	openapi.QueryParam("tag", "string", "Only verses carrying this tag; also accepted inline as tag:name"),
+	openapi.QueryParam("namespace", "string", "Tag namespace (default \"default\")"),
+	openapi.QueryParam("notes", "boolean", "Attach the namespace's notes to each result"),
+	openapi.QueryParam("sources", "string", "Comma-separated sources to search: scripture (default) and/or notes"),
+	openapi.QueryParam("transform", "string", "\"softmax\" adds a probability to each result, summing to 1 over the results"),
+	openapi.QueryParam("temperature", "number", "Softmax temperature (default 0.05); lower values favor the top results"),
+	openapi.QueryParam("group", "string", "\"chapter\" scores verses but returns one result per chapter: its best verse, with the chapter's score, hits and evidence"),
+	openapi.QueryParam("diversity", "number", "Maximal Marginal Relevance weight from 0 (relevance only, default) to 1; higher values trade relevance for distinct passages"),
+	openapi.QueryParam("minScore", "number", "Only return results at least this similar to the query, from -1 to 1; stricter than -score-floor to take effect"),
+	openapi.QueryParam("pageSize", "integer", "Enables cursor paging with this page size"),
+	openapi.QueryParam("cursor", "string", "Continues a previous page"),
+	openapi.QueryParam("exhaustive", "boolean", "Scan the whole index even when -route-books routes unfiltered searches"),
+	openapi.QueryParam("include", "string", "Comma-separated extras for each result: strongs (needs -lexicon and -strongs-text), metadata, embedding"),
+	openapi.QueryParam("resultFields", "string", "Comma-separated result fields to return: book, chapter, verseNum, text, searchMeta, or searchMeta.KEY (default: all)"),
+	openapi.QueryParam("expand", "boolean", "Also search reformulations of the query with modern and King James synonyms (love/charity, donkey/ass) and fuse the rankings"),
+	openapi.QueryParam("exclude", "string", "Comma-separated words or phrases; results containing any of them are dropped, as with -word in the query"),
+	openapi.QueryParam("negative", "string", "An example sentence of what not to find; its embedding is subtracted from the query's"),
+	openapi.QueryParam("aggs", "string", "Comma-separated facets to count over the top 100 candidates: book, testament, genre"),
+}
+
+// buildSpec describes every route registered in main. Add new endpoints here
+// alongside their route so generated clients stay complete.
+func buildSpec() *openapi.Document {
+	b := openapi.NewBuilder("GoScriptureAPI", apiVersion, "Semantic Bible search backed by EmbeddingGemma")
+
+	noteIDParam := openapi.Parameter{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}
+	refParam := openapi.Parameter{Name: "ref", In: "query", Required: true, Description: "Scripture reference, e.g. John 3:16", Schema: &openapi.Schema{Type: "string"}}
+
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/health", Summary: "Liveness check", Response: map[string]string{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/status", Summary: "Index and model status"})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/meta", Summary: "Served corpora with their license and attribution", Response: MetaResponse{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/search", Summary: "Semantic search", Params: searchQueryParams, Response: SearchResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/search", Summary: "Semantic search", Request: SearchRequest{}, Response: SearchResponse{}})
+	b.Add(openapi.Route{
+		Method:      http.MethodGet,
+		Path:        "/search/stream",
+		Summary:     "Semantic search streamed as Server-Sent Events (partial, result and error events)",
+		Params:      searchQueryParams[:20],
+		ContentType: "text/event-stream",
+		Response:    StreamProgress{},
+	})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/results/save", Summary: "Run a search and save its result set under a short ID for sharing", Request: SaveResultsRequest{}, Response: SavedResultsResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/feedback", Summary: "Record a click on a search result for analytics, or judge whether it is relevant", Request: FeedbackRequest{}, Response: FeedbackResponse{}})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/analytics/top-queries",
+		Summary: "Most searched queries with result counts, latency and click-through; requires the admin bearer token",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("limit", "integer", "Number of queries to list, 1-1000 (default 20)"),
+			openapi.QueryParam("zeroResults", "boolean", "List only queries that have returned nothing, most frequent first"),
+		},
+		Response: TopQueriesResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/results/{id}", Summary: "A saved result set, exactly as it was ranked", Params: []openapi.Parameter{{Name: "id", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}}, Response: SavedResultsResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/search/batch", Summary: "Batched semantic search", Request: BatchSearchRequest{}, Response: BatchSearchResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/queries/compare", Summary: "Compare two queries' similarity and top-k results", Request: CompareRequest{}, Response: CompareResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/transcripts/align", Summary: "Detect scripture quotations and allusions in a transcript, with character and time offsets", Request: AlignRequest{}, Response: AlignResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/embed", Summary: "Embed a text as a search query or a document", Request: EmbedRequest{}, Response: EmbedResponse{}})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/passages",
+		Summary: "Resolve references to passage text, revalidated with If-None-Match",
+		Params: []openapi.Parameter{
+			{Name: "ref", In: "query", Required: true, Description: "A reference such as \"John 3:16-18\"; repeat for several", Schema: &openapi.Schema{Type: "array", Items: &openapi.Schema{Type: "string"}}},
+			openapi.QueryParam("verseNumbers", "string", "\"none\", \"inline\" or \"bracketed\""),
+			openapi.QueryParam("layout", "string", "\"paragraph\" or \"verse\""),
+			openapi.QueryParam("divineName", "string", "LORD/GOD rendering: asis, smallcaps, html or title"),
+			openapi.QueryParam("notes", "boolean", "Attach notes overlapping each passage"),
+			openapi.QueryParam("namespace", "string", "Note namespace (default \"default\")"),
+			openapi.QueryParam("attribution", "boolean", "Include the verse text's license and attribution"),
+		},
+		Response: PassagesResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/passages", Summary: "Resolve references to passage text", Request: PassagesRequest{}, Response: PassagesResponse{}})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/crossrefs",
+		Summary: "Cross-references for a verse or range",
+		Params: []openapi.Parameter{
+			refParam,
+			openapi.QueryParam("k", "integer", "Number of cross-references (default 20)"),
+			openapi.QueryParam("rerank", "boolean", "Order by semantic similarity instead of votes"),
+		},
+		Response: CrossReferencesResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/verse-of-the-day",
+		Summary: "The verse for a date, the same for every caller, optionally matched to a theme",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("date", "string", "Date as YYYY-MM-DD (default today, UTC)"),
+			openapi.QueryParam("theme", "string", "Pick among the verses nearest this theme, e.g. hope"),
+		},
+		Response: VerseOfTheDayResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:   http.MethodGet,
+		Path:     "/questions",
+		Summary:  "Discussion questions for a chapter or pericope, generated once by the configured LLM backend and cached",
+		Params:   []openapi.Parameter{refParam},
+		Response: QuestionsResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:   http.MethodGet,
+		Path:     "/lexicon/{strongs}",
+		Summary:  "Strong's lexicon entry for a Hebrew (H) or Greek (G) number, e.g. G26",
+		Params:   []openapi.Parameter{{Name: "strongs", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}},
+		Response: LexiconResponse{},
+	})
+	quizParams := []openapi.Parameter{
+		refParam,
+		openapi.QueryParam("difficulty", "string", "easy, medium (default) or hard"),
+		openapi.QueryParam("seed", "integer", "Seed for word and distractor selection (default derived from the request)"),
+	}
+	b.Add(openapi.Route{
+		Method:   http.MethodGet,
+		Path:     "/quiz/fill-in",
+		Summary:  "Fill-in-the-blank exercise for a passage of up to 10 verses",
+		Params:   quizParams,
+		Response: QuizFillInResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/quiz/match",
+		Summary: "Reference-matching exercise: pick the reference of each verse's text",
+		Params: append(quizParams,
+			openapi.QueryParam("count", "integer", "Number of items (default 5, max 20)"),
+			openapi.QueryParam("choices", "integer", "References offered per item (default 4, 2 to 6)"),
+		),
+		Response: QuizMatchResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/similar",
+		Summary: "Verses nearest to a reference",
+		Params: []openapi.Parameter{
+			refParam,
+			openapi.QueryParam("k", "integer", "Number of results (default 10)"),
+			openapi.QueryParam("book", "string", "Restrict results to a book"),
+			openapi.QueryParam("chapter", "string", "Restrict results to a chapter"),
+		},
+		Response: SearchResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/parallels",
+		Summary: "Near-duplicate passages in other books: synoptic parallels and Old Testament quotations",
+		Params: []openapi.Parameter{
+			refParam,
+			openapi.QueryParam("threshold", "number", "Minimum cosine similarity of a parallel (default 0.85)"),
+			openapi.QueryParam("k", "integer", "Maximum parallels per verse (default 5, max 20)"),
+		},
+		Response: ParallelsResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/suggest",
+		Summary: "Completions of partially typed input: books, chapters, verses and popular queries",
+		Params: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Partial input, e.g. \"Joh\" or \"John 3:1\"", Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("limit", "integer", "Maximum suggestions (default 10, max 50)"),
+		},
+		Response: SuggestResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/autocomplete",
+		Summary: "Typed completions for search boxes: references, topics matched by prefix and meaning, and popular queries",
+		Params: []openapi.Parameter{
+			{Name: "q", In: "query", Required: true, Description: "Partial input, e.g. \"Rom 8\" or \"worried about\"", Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("limit", "integer", "Maximum suggestions (default 10, max 50)"),
+			openapi.QueryParam("semantic", "boolean", "Match topics by embedding similarity too (default true)"),
+		},
+		Response: AutocompleteResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/books",
+		Summary: "Canonical books with IDs, abbreviations, testament, genre and chapter counts",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("testament", "string", "\"ot\" or \"nt\""),
+			openapi.QueryParam("genre", "string", "e.g. law, gospels, pauline-epistles"),
+		},
+		Response: BooksResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/widget.js", Summary: "Embeddable search box script, configured by its data-key attribute"})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/widget/search",
+		Summary: "Constrained verse search for the embeddable widget; the Origin must be allowed for the key",
+		Params: []openapi.Parameter{
+			{Name: "key", In: "query", Required: true, Description: "Publishable widget key", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "q", In: "query", Required: true, Description: "Search query, up to 200 characters", Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("k", "integer", "Number of results (default 5, max 10)"),
+		},
+		Response: WidgetSearchResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/canon", Summary: "Every book with testament, genre, chapters and verses per chapter", Response: CanonResponse{}})
+	b.Add(openapi.Route{
+		Method:   http.MethodGet,
+		Path:     "/books/{book}/chapters",
+		Summary:  "Chapters of a book with verse counts",
+		Params:   []openapi.Parameter{{Name: "book", In: "path", Required: true, Description: "Book ID, name or abbreviation", Schema: &openapi.Schema{Type: "string"}}},
+		Response: ChaptersResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/chapters/{book}/{chapter}/summary",
+		Summary: "A chapter's most representative verses by embedding centrality, revalidated with If-None-Match",
+		Params: []openapi.Parameter{
+			{Name: "book", In: "path", Required: true, Description: "Book ID, name or abbreviation", Schema: &openapi.Schema{Type: "string"}},
+			{Name: "chapter", In: "path", Required: true, Schema: &openapi.Schema{Type: "integer"}},
+			openapi.QueryParam("n", "integer", "Number of verses, 1-10 (default 3)"),
+		},
+		Response: ChapterSummaryResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/tags",
+		Summary: "Tags on a verse, or verses carrying a tag",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("ref", "string", "A single verse"),
+			openapi.QueryParam("tag", "string", "A tag name"),
+			openapi.QueryParam("namespace", "string", "Tag namespace (default \"default\")"),
+		},
+		Response: TagResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/tags", Summary: "Attach tags to a verse or range", Request: TagRequest{}, Response: TagResponse{}})
+	b.Add(openapi.Route{Method: http.MethodDelete, Path: "/tags", Summary: "Remove tags from a verse or range", Request: TagRequest{}, Response: TagResponse{}})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/thesaurus",
+		Summary: "Modern and King James term mapping used by query expansion and lexical scoring",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("term", "string", "Look up one term's synonyms"),
+			openapi.QueryParam("q", "string", "Preview the reformulations expand=true searches for a query"),
+		},
+		Response: ThesaurusResponse{},
+	})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/notes",
+		Summary: "Notes in a namespace, optionally only those overlapping a reference",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("ref", "string", "Verse or range"),
+			openapi.QueryParam("namespace", "string", "Note namespace (default \"default\")"),
+		},
+		Response: NotesResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/notes", Summary: "Attach a Markdown note to a verse or range", Request: NoteRequest{}, Response: NoteResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPut, Path: "/notes/{id}", Summary: "Replace a note's body", Params: []openapi.Parameter{noteIDParam}, Request: NoteRequest{}, Response: NoteResponse{}})
+	b.Add(openapi.Route{Method: http.MethodDelete, Path: "/notes/{id}", Summary: "Delete a note", Params: []openapi.Parameter{noteIDParam, openapi.QueryParam("namespace", "string", "Note namespace (default \"default\")")}})
+	b.Add(openapi.Route{
+		Method:  http.MethodGet,
+		Path:    "/notes/export",
+		Summary: "Export a namespace's notes as JSON or Markdown",
+		Params: []openapi.Parameter{
+			openapi.QueryParam("namespace", "string", "Note namespace (default \"default\")"),
+			openapi.QueryParam("format", "string", "\"json\" (default) or \"markdown\""),
+		},
+		Response: []notes.Note{},
+	})
+	b.Add(openapi.Route{
+		Method:   http.MethodGet,
+		Path:     "/userdata/export",
+		Summary:  "Export tags and notes as a portable archive",
+		Params:   []openapi.Parameter{openapi.QueryParam("namespace", "string", "Comma-separated namespaces to export (default all)")},
+		Response: UserDataArchive{},
+	})
+	b.Add(openapi.Route{
+		Method:   http.MethodPost,
+		Path:     "/userdata/import",
+		Summary:  "Restore an exported archive",
+		Params:   []openapi.Parameter{openapi.QueryParam("namespace", "string", "Comma-separated namespaces to import (default all)")},
+		Request:  UserDataArchive{},
+		Response: ImportResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/privacy", Summary: "Privacy policy for the caller's X-API-Key", Response: PrivacyResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPut, Path: "/privacy", Summary: "Set the privacy policy for the caller's X-API-Key", Request: privacy.Policy{}, Response: PrivacyResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/admin/purge", Summary: "Erase a namespace's tags and notes; requires the admin bearer token", Request: PurgeRequest{}, Response: PurgeResponse{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/admin/corpora", Summary: "Catalog of configured corpora", Response: CorporaResponse{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/admin/usage", Summary: "Use of deprecated routes and parameters since startup", Response: UsageResponse{}})
+	b.Add(openapi.Route{
+		Method:   http.MethodPost,
+		Path:     "/admin/corpus",
+		Summary:  "Embed a JSON array or JSON Lines corpus of documents into a named index, searchable as index=name; requires the admin bearer token",
+		Params: []openapi.Parameter{
+			{Name: "name", In: "query", Required: true, Description: "Corpus name: lowercase letters, digits, '-' or '_'", Schema: &openapi.Schema{Type: "string"}},
+			openapi.QueryParam("title", "string", "Corpus title shown in /meta"),
+			openapi.QueryParam("license", "string", "License of the corpus text, e.g. CC-BY-4.0"),
+			openapi.QueryParam("licenseUrl", "string", "URL of the license text"),
+			openapi.QueryParam("attribution", "string", "Attribution notice to display with the corpus text"),
+			openapi.QueryParam("sourceUrl", "string", "Where the corpus text was obtained"),
+		},
+		Request:  []search.Document{},
+		Response: CorpusResponse{},
+	})
+	b.Add(openapi.Route{Method: http.MethodDelete, Path: "/admin/corpus/{name}", Summary: "Delete an ingested corpus; requires the admin bearer token", Params: []openapi.Parameter{{Name: "name", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/admin/diff-search", Summary: "Rank a query with two named pipelines side by side, with overlap metrics", Request: DiffSearchRequest{}, Response: DiffSearchResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/admin/reload", Summary: "Re-download an index and swap it in once built, without dropping queries; requires the admin bearer token", Request: ReloadRequest{}, Response: ReloadResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/admin/granularity/{name}/load", Summary: "Load an index on demand, or register and load a new one; requires the admin bearer token", Params: []openapi.Parameter{{Name: "name", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}}, Request: LoadIndexRequest{}, Response: LoadIndexResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/admin/granularity/{name}/unload", Summary: "Stop serving an index and free its memory; requires the admin bearer token", Params: []openapi.Parameter{{Name: "name", In: "path", Required: true, Schema: &openapi.Schema{Type: "string"}}}, Response: UnloadIndexResponse{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/admin/index-checksums", Summary: "Content checksums of the loaded indices, for verifying replicas", Response: IndexChecksumsResponse{}})
+	b.Add(openapi.Route{Method: http.MethodPost, Path: "/index/rank", Summary: "Rank a stateless API node's embedded query against this node's indices; requires the index-node bearer token", Request: search.RankRequest{}, Response: search.RankResponse{}})
+	b.Add(openapi.Route{Method: http.MethodGet, Path: "/openapi.json", Summary: "This document"})
+
+	for _, surface := range DeprecatedSurfaces {
+		b.Deprecate(surface.Method, surface.Path, surface.Param,
+			"Deprecated: use "+surface.Replacement+". Sunset "+surface.Sunset.Format(time.DateOnly))
+	}
+
+	return b.Document()
+}
+
+// OpenAPI serves the OpenAPI 3 document describing the API
+func (h *Handler) OpenAPI(c echo.Context) error {
+	specOnce.Do(func() { spec = buildSpec() })
+	return c.JSON(http.StatusOK, spec)
+}
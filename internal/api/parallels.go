@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// ParallelMatch is a near-duplicate of a verse in another book
+type ParallelMatch struct {
+	Reference  string  `json:"reference"`
+	Text       string  `json:"text"`
+	Similarity float32 `json:"similarity"`
+	Kind       string  `json:"kind"` // "synoptic", "quotation" or "duplicate"
+}
+
+// VerseParallels is a verse of the reference and its parallels
+type VerseParallels struct {
+	Reference string          `json:"reference"`
+	Text      string          `json:"text"`
+	Parallels []ParallelMatch `json:"parallels"`
+}
+
+// ParallelsResponse lists the parallels found for a reference's verses
+type ParallelsResponse struct {
+	Reference string           `json:"reference"`
+	Threshold float64          `json:"threshold"`
+	Verses    []VerseParallels `json:"verses"`
+	Count     int              `json:"count"` // Parallels across all verses
+	Status    string           `json:"status"`
+}
+
+// Parallels finds near-duplicate passages in other books, such as synoptic
+// parallels and Old Testament quotations in the New
+func (h *Handler) Parallels(c echo.Context) error {
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return referenceError(err)
+	}
+
+	threshold := search.DefaultParallelThreshold
+	if value := c.QueryParam("threshold"); value != "" {
+		if threshold, err = strconv.ParseFloat(value, 64); err != nil || threshold <= 0 || threshold > 1 || math.IsNaN(threshold) {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "threshold must be greater than 0 and at most 1")
+		}
+	}
+	k := 5
+	if value := c.QueryParam("k"); value != "" {
+		if k, err = strconv.Atoi(value); err != nil || k < 1 || k > 20 {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "k must be between 1 and 20")
+		}
+	}
+
+	parallels, err := h.search.Parallels(c.Request().Context(), ref, search.SearchOptions{K: k, MinScore: threshold})
+	if err != nil {
+		return searchError("Parallel passage lookup failed", err)
+	}
+
+	verses := make([]VerseParallels, len(parallels))
+	count := 0
+	for i, parallel := range parallels {
+		source := parallel.Verse.Meta
+		matches := make([]ParallelMatch, len(parallel.Matches))
+		for j, match := range parallel.Matches {
+			meta := match.Chunk.Meta
+			matches[j] = ParallelMatch{
+				Reference:  fmt.Sprintf("%s %d:%d", meta.Book, meta.Chapter, meta.VerseNum),
+				Text:       match.Chunk.Text,
+				Similarity: match.Similarity,
+				Kind:       search.ParallelKind(source.Book, meta.Book),
+			}
+		}
+		verses[i] = VerseParallels{
+			Reference: fmt.Sprintf("%s %d:%d", source.Book, source.Chapter, source.VerseNum),
+			Text:      parallel.Verse.Text,
+			Parallels: matches,
+		}
+		count += len(matches)
+	}
+
+	return c.JSON(http.StatusOK, ParallelsResponse{
+		Reference: ref.String(),
+		Threshold: threshold,
+		Verses:    verses,
+		Count:     count,
+		Status:    "success",
+	})
+}
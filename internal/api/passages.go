@@ -0,0 +1,176 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/labstack/echo/v4"
+)
+
+// maxPassageReferences caps how many references a single /passages request may resolve
+const maxPassageReferences = 500
+
+// maxPassageVerses caps the verses a single /passages request may return, so
+// whole-book references can't return the whole Bible. Psalms, the longest
+// book, fits.
+const maxPassageVerses = 5000
+
+// PassagesRequest represents a batch reference lookup request
+type PassagesRequest struct {
+	References  []string       `json:"references"`
+	Format      format.Options `json:"format,omitempty"`
+	Notes       bool           `json:"notes,omitempty"`       // Attach notes overlapping each passage
+	Namespace   string         `json:"namespace,omitempty"`   // Note namespace
+	Attribution bool           `json:"attribution,omitempty"` // Include the verse text's license and attribution
+}
+
+// PassageResult represents the resolution of a single requested reference
+type PassageResult struct {
+	Input     string                `json:"input"`
+	Reference string                `json:"reference,omitempty"`
+	Text      string                `json:"text,omitempty"`
+	Verses    []BibleVerseResult    `json:"verses,omitempty"`
+	Error     *reference.ParseError `json:"error,omitempty"`
+	Notes     []notes.Note          `json:"notes,omitempty"`
+}
+
+// PassagesResponse represents a batch reference lookup response
+type PassagesResponse struct {
+	Passages    []PassageResult       `json:"passages"`
+	Count       int                   `json:"count"`
+	Attribution *config.CorpusLicense `json:"attribution,omitempty"`
+	Status      string                `json:"status"`
+}
+
+// Passages handles batch reference lookups, preserving request order. GET
+// takes the references as repeated ref parameters, so responses can be
+// revalidated with If-None-Match.
+func (h *Handler) Passages(c echo.Context) error {
+	var req PassagesRequest
+	if c.Request().Method == http.MethodGet {
+		req = passagesRequestFromQuery(c)
+	} else if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	if len(req.References) == 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "At least one reference is required")
+	}
+	if err := req.Format.Validate(); err != nil {
+		return invalidRequest(err)
+	}
+	if len(req.References) > maxPassageReferences {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Too many references").
+			withDetails(map[string]int{"max": maxPassageReferences})
+	}
+
+	namespace, err := tags.Normalize(coalesce(req.Namespace, tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	tag := h.passagesETag(c, req)
+	if tag != "" && notModified(c, tag) {
+		c.Response().Header().Set("ETag", tag)
+		return c.NoContent(http.StatusNotModified)
+	}
+
+	lang := Language(c)
+	passages := make([]PassageResult, 0, len(req.References))
+	total := 0
+	for _, input := range req.References {
+		result := PassageResult{Input: input}
+
+		refs, err := reference.ParseList(input)
+		if err != nil {
+			var parseErr *reference.ParseError
+			if !errors.As(err, &parseErr) {
+				parseErr = &reference.ParseError{Kind: reference.ErrMalformed, Input: input, Message: err.Error()}
+			}
+			result.Error = localizeParseError(lang, parseErr)
+			passages = append(passages, result)
+			continue
+		}
+		result.Reference = refs.String()
+
+		// A list's passages are joined in the order written
+		var verses []*search.TextData
+		for _, ref := range refs {
+			passage, err := h.search.Passage(ref)
+			if err != nil {
+				return apiError(http.StatusServiceUnavailable, CodeGranularityNotLoaded, "Verse text is not loaded yet").
+					withDetails(err.Error())
+			}
+			verses = append(verses, passage...)
+			if total+len(verses) > maxPassageVerses {
+				return apiError(http.StatusBadRequest, CodeLimitExceeded, "Passages are too long").
+					withDetails(map[string]int{"maxVerses": maxPassageVerses})
+			}
+		}
+		total += len(verses)
+		if len(verses) == 0 {
+			result.Error = localizeParseError(lang, &reference.ParseError{
+				Kind:    reference.ErrNotFound,
+				Input:   input,
+				Message: "no verses found for reference",
+			})
+			passages = append(passages, result)
+			continue
+		}
+
+		formatted := make([]format.Verse, 0, len(verses))
+		result.Verses = make([]BibleVerseResult, 0, len(verses))
+		for _, verse := range verses {
+			formatted = append(formatted, format.Verse{
+				Chapter:  verse.Meta.Chapter,
+				VerseNum: verse.Meta.VerseNum,
+				Text:     verse.Text,
+			})
+			result.Verses = append(result.Verses, BibleVerseResult{
+				Book:     verse.Meta.Book,
+				Chapter:  verse.Meta.Chapter,
+				VerseNum: verse.Meta.VerseNum,
+				Text:     format.Text(verse.Text, req.Format),
+			})
+		}
+		result.Text = format.Passage(formatted, req.Format)
+		if req.Notes {
+			result.Notes = h.notes.Overlapping(namespace, refs...)
+		}
+
+		passages = append(passages, result)
+	}
+
+	response := PassagesResponse{
+		Passages: passages,
+		Count:    len(passages),
+		Status:   "success",
+	}
+	if req.Attribution {
+		response.Attribution = h.search.License("verse")
+	}
+	if tag != "" {
+		c.Response().Header().Set("ETag", tag)
+	}
+	return c.JSON(http.StatusOK, response)
+}
+
+// passagesRequestFromQuery reads a passages request from GET query parameters
+func passagesRequestFromQuery(c echo.Context) PassagesRequest {
+	var req PassagesRequest
+	req.References = c.QueryParams()["ref"]
+	req.Format.VerseNumbers = c.QueryParam("verseNumbers")
+	req.Format.Layout = c.QueryParam("layout")
+	req.Format.DivineName = c.QueryParam("divineName")
+	req.Notes, _ = strconv.ParseBool(c.QueryParam("notes"))
+	req.Namespace = c.QueryParam("namespace")
+	req.Attribution, _ = strconv.ParseBool(c.QueryParam("attribution"))
+	return req
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// PrivacyResponse returns the policy applied to the caller's key
+type PrivacyResponse struct {
+	Policy privacy.Policy `json:"policy"`
+	Status string         `json:"status"`
+}
+
+// PurgeRequest names the namespace whose stored data should be erased
+type PurgeRequest struct {
+	Namespace string `json:"namespace"`
+}
+
+// PurgeResponse counts what a purge erased
+type PurgeResponse struct {
+	Namespace string `json:"namespace"`
+	Tags      int    `json:"tags"`
+	Notes     int    `json:"notes"`
+	Status    string `json:"status"`
+}
+
+// Privacy returns the privacy policy for the caller's API key
+func (h *Handler) Privacy(c echo.Context) error {
+	return c.JSON(http.StatusOK, PrivacyResponse{
+		Policy: h.privacy.For(c.Request().Header.Get(privacy.KeyHeader)),
+		Status: "success",
+	})
+}
+
+// SetPrivacy stores the privacy policy for the caller's API key
+func (h *Handler) SetPrivacy(c echo.Context) error {
+	key := c.Request().Header.Get(privacy.KeyHeader)
+	if key == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "An "+privacy.KeyHeader+" header is required")
+	}
+
+	var policy privacy.Policy
+	if err := c.Bind(&policy); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	if err := h.privacy.Set(key, policy); err != nil {
+		if errors.Is(err, privacy.ErrTooManyKeys) {
+			return apiError(http.StatusBadRequest, CodeLimitExceeded, "Too many keys have privacy settings").
+				withDetails(map[string]int{"maxKeys": privacy.MaxKeys})
+		}
+		return internalError("Failed to save privacy settings", err)
+	}
+
+	return c.JSON(http.StatusOK, PrivacyResponse{
+		Policy: policy,
+		Status: "success",
+	})
+}
+
+// Purge erases every tag and note stored in a namespace. It is mounted
+// behind AdminAuth.
+func (h *Handler) Purge(c echo.Context) error {
+	var req PurgeRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	namespace, err := tags.Normalize(req.Namespace)
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	resp := PurgeResponse{Namespace: namespace, Status: "success"}
+	if resp.Tags, err = h.tags.DeleteNamespace(namespace); err == nil {
+		resp.Notes, err = h.notes.DeleteNamespace(namespace)
+	}
+	if err != nil {
+		return internalError("Failed to purge namespace", err)
+	}
+
+	log.Ctx(c.Request().Context()).Info().Str("namespace", namespace).Int("tags", resp.Tags).Int("notes", resp.Notes).Msg("Purged namespace")
+	return c.JSON(http.StatusOK, resp)
+}
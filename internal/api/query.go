@@ -0,0 +1,173 @@
+package api
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// maxReferenceTokens bounds how many words a reference in a query may span,
+// as in "1 John 3:16, 18"
+const maxReferenceTokens = 5
+
+// ParsedQuery reports how a search query was read: its search text, the
+// filters written into it, the quoted phrases results must contain, and the
+// scripture references it mentions
+type ParsedQuery struct {
+	Text       string            `json:"text"`
+	Filters    map[string]string `json:"filters,omitempty"`
+	Phrases    []string          `json:"phrases,omitempty"`    // Kept in the search text without their quotes
+	Excluded   []string          `json:"excluded,omitempty"`   // Words and phrases written -word or -"a phrase", removed from the search text
+	References []string          `json:"references,omitempty"` // Kept in the search text, never read as filters
+}
+
+// parseQuery splits a search query into search text and key:value filters
+// such as book:John or book:"1 John". A quoted phrase such as
+// "be still and know" stays in the text and also restricts results to
+// texts containing it, and -word or -"a phrase" drops texts containing
+// that. Scripture references such as John 3:16 and tokens with an unknown
+// key ("Note:") stay in the text. A raw query is searched as written. The
+// ParsedQuery is nil unless the query had filters, phrases, exclusions or
+// references.
+func parseQuery(query string, raw bool) (string, search.SearchOptions, *ParsedQuery) {
+	filters := search.SearchOptions{}
+	if raw {
+		return strings.TrimSpace(query), filters, nil
+	}
+
+	tokens := queryTokens(query)
+	parsed := &ParsedQuery{Filters: make(map[string]string)}
+	text := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		if n, ref := referenceAt(tokens[i:]); n > 0 {
+			text = append(text, tokens[i:i+n]...)
+			parsed.References = append(parsed.References, ref)
+			i += n - 1
+			continue
+		}
+		if excluded, ok := excludedTerm(tokens[i]); ok {
+			filters.Exclude = append(filters.Exclude, excluded)
+			parsed.Excluded = append(parsed.Excluded, excluded)
+			continue
+		}
+		if phrase, ok := quotedPhrase(tokens[i]); ok {
+			text = append(text, phrase)
+			filters.Phrases = append(filters.Phrases, phrase)
+			parsed.Phrases = append(parsed.Phrases, phrase)
+			continue
+		}
+
+		key, value, ok := strings.Cut(tokens[i], ":")
+		key = strings.ToLower(key)
+		value = strings.Trim(value, `"`)
+		if !ok || value == "" || !setFilter(&filters, key, value) {
+			text = append(text, tokens[i])
+			continue
+		}
+		parsed.Filters[key] = value
+	}
+
+	parsed.Text = strings.Join(text, " ")
+	if len(parsed.Filters) == 0 && len(parsed.Phrases) == 0 && len(parsed.Excluded) == 0 && len(parsed.References) == 0 {
+		return parsed.Text, filters, nil
+	}
+	return parsed.Text, filters, parsed
+}
+
+// quotedPhrase returns the phrase in a token wrapped in double quotes
+func quotedPhrase(token string) (string, bool) {
+	if len(token) < 2 || !strings.HasPrefix(token, `"`) || !strings.HasSuffix(token, `"`) {
+		return "", false
+	}
+	phrase := strings.TrimSpace(token[1 : len(token)-1])
+	return phrase, phrase != ""
+}
+
+// excludedTerm returns the word or quoted phrase in a token written -word
+// or -"a phrase". A dash before anything without letters, as in "-" or
+// "-1", is left as text.
+func excludedTerm(token string) (string, bool) {
+	term, ok := strings.CutPrefix(token, "-")
+	if !ok {
+		return "", false
+	}
+	if phrase, quoted := quotedPhrase(term); quoted {
+		term = phrase
+	}
+	return term, strings.ContainsFunc(term, unicode.IsLetter)
+}
+
+// setFilter applies a query filter, reporting whether key names one
+func setFilter(filters *search.SearchOptions, key, value string) bool {
+	switch key {
+	case "book":
+		filters.Book = value
+	case "chapter":
+		filters.Chapter = value
+	case "verse":
+		filters.Verse = value
+	case "tag":
+		filters.Tag = value
+	case "testament":
+		filters.Testament = value
+	case "genre":
+		filters.Genre = value
+	case "books":
+		filters.Books = strings.Split(value, ",")
+	default:
+		return false
+	}
+	return true
+}
+
+// referenceAt finds the longest scripture reference at the start of tokens
+// that gives a chapter, returning its length in tokens and canonical form
+func referenceAt(tokens []string) (int, string) {
+	if !strings.ContainsFunc(tokens[0], unicode.IsLetter) && !isBookNumber(tokens[0]) {
+		return 0, ""
+	}
+	for n := min(len(tokens), maxReferenceTokens); n > 0; n-- {
+		// A reference ends with its chapter or verse, so "Job" and "Acts"
+		// stay words
+		if !strings.ContainsFunc(tokens[n-1], unicode.IsDigit) {
+			continue
+		}
+		if refs, err := reference.ParseList(strings.Join(tokens[:n], " ")); err == nil {
+			return n, refs.String()
+		}
+	}
+	return 0, ""
+}
+
+// isBookNumber reports whether a token numbers a book, as "1" in "1 John"
+func isBookNumber(token string) bool {
+	return token == "1" || token == "2" || token == "3"
+}
+
+// queryTokens splits a query on whitespace, keeping quoted values such as
+// book:"1 John" in one token
+func queryTokens(query string) []string {
+	var tokens []string
+	var current strings.Builder
+	quoted := false
+	for _, r := range query {
+		switch {
+		case r == '"':
+			quoted = !quoted
+			current.WriteRune(r)
+		case unicode.IsSpace(r) && !quoted:
+			if current.Len() > 0 {
+				tokens = append(tokens, current.String())
+				current.Reset()
+			}
+		default:
+			current.WriteRune(r)
+		}
+	}
+	if current.Len() > 0 {
+		tokens = append(tokens, current.String())
+	}
+	return tokens
+}
@@ -0,0 +1,79 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/questions"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/labstack/echo/v4"
+)
+
+// maxQuestionVerses caps the passage sent to the question backend
+const maxQuestionVerses = 200
+
+// QuestionsResponse lists discussion questions for a passage
+type QuestionsResponse struct {
+	Reference   string    `json:"reference"`
+	Questions   []string  `json:"questions"`
+	Model       string    `json:"model"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	Cached      bool      `json:"cached"`
+	Status      string    `json:"status"`
+}
+
+// SetQuestions enables /questions with a question generator
+func (h *Handler) SetQuestions(generator *questions.Generator) {
+	h.questions = generator
+}
+
+// Questions returns discussion questions for a chapter or pericope,
+// generating them once per passage
+func (h *Handler) Questions(c echo.Context) error {
+	if h.questions == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Question generation is not configured")
+	}
+
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return referenceError(err)
+	}
+
+	verses, err := h.search.Passage(ref)
+	if err != nil {
+		return searchError("Failed to resolve passage", err)
+	}
+	if len(verses) == 0 {
+		return apiError(http.StatusNotFound, CodeNotFound, "No verses found for reference")
+	}
+	if len(verses) > maxQuestionVerses {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Passage is too long").
+			withDetails(map[string]int{"max": maxQuestionVerses})
+	}
+
+	lines := make([]string, len(verses))
+	for i, verse := range verses {
+		lines[i] = fmt.Sprintf("%d:%d %s", verse.Meta.Chapter, verse.Meta.VerseNum, verse.Text)
+	}
+
+	set, cached, err := h.questions.Questions(c.Request().Context(), ref.String(), strings.Join(lines, "\n"))
+	if err != nil {
+		if errors.Is(err, questions.ErrBackend) {
+			return apiError(http.StatusBadGateway, CodeUpstreamFailed, "Question generation failed").
+				withDetails(err.Error())
+		}
+		return internalError("Question generation failed", err)
+	}
+
+	return c.JSON(http.StatusOK, QuestionsResponse{
+		Reference:   set.Reference,
+		Questions:   set.Questions,
+		Model:       set.Model,
+		GeneratedAt: set.GeneratedAt,
+		Cached:      cached,
+		Status:      "success",
+	})
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"hash/fnv"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/quiz"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxFillInVerses caps the passage blanked by /quiz/fill-in
+	maxFillInVerses = 10
+	// maxMatchItems caps the items in a /quiz/match response
+	maxMatchItems = 20
+	// maxMatchChoices caps the references offered per matching item
+	maxMatchChoices = 6
+)
+
+// QuizFillInResponse is a fill-in-the-blank exercise for a passage
+type QuizFillInResponse struct {
+	Reference  string       `json:"reference"`
+	Difficulty string       `json:"difficulty"`
+	Seed       int64        `json:"seed"`   // Pass back to get the same blanks again
+	Prompt     string       `json:"prompt"` // The text with each blank replaced by "_____"
+	Blanks     []quiz.Blank `json:"blanks"`
+	Status     string       `json:"status"`
+}
+
+// QuizMatchItem asks which reference a verse's text belongs to
+type QuizMatchItem struct {
+	Text    string   `json:"text"`
+	Choices []string `json:"choices"`
+	Answer  int      `json:"answer"` // Index of the correct choice
+}
+
+// QuizMatchResponse is a reference-matching exercise for a passage
+type QuizMatchResponse struct {
+	Reference  string          `json:"reference"`
+	Difficulty string          `json:"difficulty"`
+	Seed       int64           `json:"seed"`
+	Items      []QuizMatchItem `json:"items"`
+	Count      int             `json:"count"`
+	Status     string          `json:"status"`
+}
+
+// QuizFillIn blanks words of a passage for memorization practice
+func (h *Handler) QuizFillIn(c echo.Context) error {
+	ref, difficulty, rng, seed, err := h.quizParams(c, "fill-in")
+	if err != nil {
+		return err
+	}
+
+	verses, err := h.quizVerses(ref)
+	if err != nil {
+		return err
+	}
+	if len(verses) > maxFillInVerses {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Passage is too long").
+			withDetails(map[string]int{"max": maxFillInVerses})
+	}
+
+	texts := make([]string, len(verses))
+	for i, verse := range verses {
+		texts[i] = verse.Text
+	}
+	prompt, blanks := quiz.FillIn(strings.Join(texts, " "), difficulty, rng)
+
+	return c.JSON(http.StatusOK, QuizFillInResponse{
+		Reference:  ref.String(),
+		Difficulty: difficulty,
+		Seed:       seed,
+		Prompt:     prompt,
+		Blanks:     blanks,
+		Status:     "success",
+	})
+}
+
+// QuizMatch asks which reference each of a passage's verses belongs to
+func (h *Handler) QuizMatch(c echo.Context) error {
+	ref, difficulty, rng, seed, err := h.quizParams(c, "match")
+	if err != nil {
+		return err
+	}
+
+	count := 5
+	if v, err := strconv.Atoi(c.QueryParam("count")); err == nil && v > 0 {
+		count = min(v, maxMatchItems)
+	}
+	choices := 4
+	if v, err := strconv.Atoi(c.QueryParam("choices")); err == nil && v >= 2 {
+		choices = min(v, maxMatchChoices)
+	}
+
+	verses, err := h.quizVerses(ref)
+	if err != nil {
+		return err
+	}
+
+	items := make([]QuizMatchItem, 0, min(count, len(verses)))
+	for _, i := range rng.Perm(len(verses))[:min(count, len(verses))] {
+		meta := verses[i].Meta
+		answer := reference.Reference{Book: meta.Book, StartChapter: meta.Chapter, StartVerse: meta.VerseNum, EndChapter: meta.Chapter, EndVerse: meta.VerseNum}
+		options := []string{answer.String()}
+		for _, distractor := range quiz.Distractors(answer, difficulty, choices-1, h.search.VerseCount, rng) {
+			options = append(options, distractor.String())
+		}
+		rng.Shuffle(len(options), func(a, b int) { options[a], options[b] = options[b], options[a] })
+
+		item := QuizMatchItem{Text: verses[i].Text, Choices: options}
+		for j, option := range options {
+			if option == answer.String() {
+				item.Answer = j
+			}
+		}
+		items = append(items, item)
+	}
+
+	return c.JSON(http.StatusOK, QuizMatchResponse{
+		Reference:  ref.String(),
+		Difficulty: difficulty,
+		Seed:       seed,
+		Items:      items,
+		Count:      len(items),
+		Status:     "success",
+	})
+}
+
+// quizParams reads the reference, difficulty and seed shared by the quiz
+// endpoints. Without a seed, one is derived from the request so the same
+// URL always yields the same exercise.
+func (h *Handler) quizParams(c echo.Context, kind string) (reference.Reference, string, *rand.Rand, int64, error) {
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return ref, "", nil, 0, referenceError(err)
+	}
+
+	difficulty := coalesce(c.QueryParam("difficulty"), quiz.Medium)
+	if err := quiz.ValidateDifficulty(difficulty); err != nil {
+		return ref, "", nil, 0, invalidRequest(err)
+	}
+
+	var seed int64
+	if s := c.QueryParam("seed"); s != "" {
+		seed, err = strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return ref, "", nil, 0, apiError(http.StatusBadRequest, CodeInvalidRequest, "Seed must be an integer")
+		}
+	} else {
+		hash := fnv.New64a()
+		hash.Write([]byte(strings.Join([]string{kind, ref.String(), difficulty, strconv.FormatInt(h.config.Seed, 10)}, "\x00")))
+		seed = int64(hash.Sum64() >> 1)
+	}
+	return ref, difficulty, rand.New(rand.NewSource(seed)), seed, nil
+}
+
+// quizVerses resolves the verses of a quiz passage
+func (h *Handler) quizVerses(ref reference.Reference) ([]*search.TextData, error) {
+	verses, err := h.search.Passage(ref)
+	if err != nil {
+		return nil, searchError("Failed to resolve passage", err)
+	}
+	if len(verses) == 0 {
+		return nil, apiError(http.StatusNotFound, CodeNotFound, "No verses found for reference")
+	}
+	return verses, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/saved"
+	"github.com/labstack/echo/v4"
+)
+
+// SaveResultsRequest is a search request to run and save, with how long to keep it
+type SaveResultsRequest struct {
+	SearchRequest
+	TTL string `json:"ttl,omitempty"` // e.g. "72h"; defaults to and is capped by -saved-results-ttl
+}
+
+// SavedResultsResponse is a saved result set
+type SavedResultsResponse struct {
+	ID        string         `json:"id"`
+	URL       string         `json:"url"` // Path to retrieve the result set
+	CreatedAt time.Time      `json:"createdAt"`
+	ExpiresAt time.Time      `json:"expiresAt"`
+	Search    SearchResponse `json:"search"`
+	Status    string         `json:"status"`
+}
+
+// SetSavedResults enables /results with a store for saved result sets
+func (h *Handler) SetSavedResults(store *saved.Store) {
+	h.saved = store
+}
+
+// SaveResults runs a search and saves its result set under a short ID, so
+// the exact ranking can be shared as a link
+func (h *Handler) SaveResults(c echo.Context) error {
+	if h.saved == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Saved results are not configured")
+	}
+
+	var req SaveResultsRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+	if req.PageSize > 0 || req.Cursor != "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Paged searches can't be saved; use k instead")
+	}
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		if ttl, err = time.ParseDuration(req.TTL); err != nil || ttl <= 0 {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "ttl must be a positive duration such as 72h")
+		}
+	}
+
+	query, options, err := h.searchOptions(c, req.SearchRequest)
+	if err != nil {
+		return err
+	}
+	response, err := h.searchResponse(c, req.SearchRequest, query, options)
+	if err != nil {
+		return err
+	}
+
+	entry, err := h.saved.Save(response, ttl)
+	if err != nil {
+		return internalError("Failed to save results", err)
+	}
+	return c.JSON(http.StatusCreated, SavedResultsResponse{
+		ID:        entry.ID,
+		URL:       "/results/" + entry.ID,
+		CreatedAt: entry.CreatedAt,
+		ExpiresAt: entry.ExpiresAt,
+		Search:    response,
+		Status:    "success",
+	})
+}
+
+// SavedResults returns a saved result set exactly as it was ranked
+func (h *Handler) SavedResults(c echo.Context) error {
+	if h.saved == nil {
+		return apiError(http.StatusNotFound, CodeFeatureDisabled, "Saved results are not configured")
+	}
+
+	entry, err := h.saved.Get(c.Param("id"))
+	if errors.Is(err, saved.ErrNotFound) {
+		return apiError(http.StatusNotFound, CodeNotFound, "Saved results not found or expired")
+	}
+	if err != nil {
+		return internalError("Failed to read saved results", err)
+	}
+
+	resp := SavedResultsResponse{
+		ID:        entry.ID,
+		URL:       "/results/" + entry.ID,
+		CreatedAt: entry.CreatedAt,
+		ExpiresAt: entry.ExpiresAt,
+		Status:    "success",
+	}
+	if err := json.Unmarshal(entry.Response, &resp.Search); err != nil {
+		return internalError("Failed to read saved results", err)
+	}
+
+	// The result set never changes, so it may be cached until it expires
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age="+strconv.Itoa(int(time.Until(entry.ExpiresAt).Seconds())))
+	return c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// Similar handles "verses like this one" requests for a reference
+func (h *Handler) Similar(c echo.Context) error {
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return referenceError(err)
+	}
+
+	k := 10
+	if kVal, err := strconv.Atoi(c.QueryParam("k")); err == nil && kVal > 0 {
+		k = kVal
+	}
+
+	book, err := canonicalBook(c.QueryParam("book"))
+	if err != nil {
+		return bookError(err)
+	}
+
+	chapter, err := search.ParseChapter(c.QueryParam("chapter"))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	options := search.SearchOptions{
+		Book: book,
+		K:    k,
+	}
+	if chapter > 0 {
+		options.Chapter = strconv.Itoa(chapter)
+	}
+
+	results, err := h.search.Similar(c.Request().Context(), ref, options)
+	if err != nil {
+		return searchError("Similar verse lookup failed", err)
+	}
+
+	verses := toVerseResults(results, format.Options{})
+	return c.JSON(http.StatusOK, SearchResponse{
+		Query:           ref.String(),
+		Results:         verses,
+		Count:           len(verses),
+		Status:          "success",
+		Reproducibility: h.reproducibility("verse"),
+	})
+}
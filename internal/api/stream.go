@@ -0,0 +1,143 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/i18n"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+// StreamProgress is the payload of a partial search event
+type StreamProgress struct {
+	Results []BibleVerseResult `json:"results"`
+	Scanned int                `json:"scanned"`
+	Total   int                `json:"total"`
+}
+
+// SearchStream streams search results as Server-Sent Events. "partial" events
+// carry the running top K as the index is scanned; a final "result" event
+// carries the complete SearchResponse, or an "error" event if the search fails.
+func (h *Handler) SearchStream(c echo.Context) error {
+	req := searchRequestFromQuery(c)
+	if req.Query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Query is required")
+	}
+	if err := req.Format.Validate(); err != nil {
+		return invalidRequest(err)
+	}
+	if _, err := search.ParseFields(req.Fields); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateRanking(req.Ranking); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateTransform(req.Transform, req.Temperature); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateDiversity(req.Diversity); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateMinScore(req.MinScore); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateGroup(req.Group); err != nil {
+		return invalidRequest(err)
+	}
+	if err := search.ValidateInclude(req.Include); err != nil {
+		return invalidRequest(err)
+	}
+	selection, err := parseResultFields(req.ResultFields)
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	query, filters, _ := parseQuery(req.Query, req.Raw)
+	if query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "The query has filters but no search text; set raw=true to search it as written")
+	}
+	options := mergeOptions(req, filters)
+	if err := normalizeFilters(&options); err != nil {
+		return bookError(err)
+	}
+	if err := applyTier(c, &options); err != nil {
+		return err
+	}
+	if h.forwards(options) {
+		return h.forward(c)
+	}
+	if req.RequireModel {
+		requireModel(c)
+	}
+	backend := trackBackend(c)
+
+	res := c.Response()
+	res.Header().Set(echo.HeaderContentType, "text/event-stream")
+	res.Header().Set(echo.HeaderCacheControl, "no-cache")
+	res.Header().Set(echo.HeaderConnection, "keep-alive")
+	res.Header().Set("X-Accel-Buffering", "no")
+	res.WriteHeader(http.StatusOK)
+
+	ctx := c.Request().Context()
+	err = h.search.SearchStream(c.Request().Context(), query, options, func(update search.StreamUpdate) bool {
+		if ctx.Err() != nil {
+			// Client went away; stop scanning
+			return false
+		}
+
+		search.ApplyTransform(update.Results, options)
+		verses := toVerseResults(update.Results, req.Format)
+		h.attachNotes(verses, options)
+		h.attachStrongs(verses, options)
+		h.attachResultData(verses, update.Results, options)
+		selectFields(verses, selection)
+		if !update.Final {
+			return writeEvent(c, "partial", StreamProgress{Results: verses, Scanned: update.Scanned, Total: update.Total}) == nil
+		}
+
+		return writeEvent(c, "result", SearchResponse{
+			Query:            req.Query,
+			Results:          verses,
+			Count:            len(verses),
+			Status:           "success",
+			Ranking:          options.Ranking,
+			Reproducibility:  h.reproducibility(options.Granularity),
+			EmbeddingBackend: backend(),
+		}) == nil
+	})
+	if errors.Is(err, context.Canceled) {
+		// Nobody is left to tell
+		return nil
+	}
+	if err != nil {
+		log.Ctx(c.Request().Context()).Error().Err(err).Msg("Streaming search failed")
+		return writeEvent(c, "error", errorResponse(c, searchError("Search failed", err)))
+	}
+
+	return nil
+}
+
+// writeEvent writes a single Server-Sent Event with a JSON payload and flushes it
+func writeEvent(c echo.Context, event string, payload interface{}) error {
+	if lang := Language(c); lang != i18n.DefaultLanguage {
+		if localized, ok := localizeBody(lang, payload); ok {
+			payload = localized
+		}
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	res := c.Response()
+	if _, err := fmt.Fprintf(res, "event: %s\ndata: %s\n\n", event, data); err != nil {
+		return err
+	}
+	res.Flush()
+	return nil
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/suggest"
+	"github.com/labstack/echo/v4"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// maxSuggestions caps the limit parameter of /suggest and /autocomplete
+	maxSuggestions = 50
+	// minSemanticInput is the shortest input embedded for topic suggestions
+	minSemanticInput = 3
+)
+
+// SuggestResponse lists completions of partially typed input
+type SuggestResponse struct {
+	Query       string               `json:"query"`
+	Suggestions []suggest.Suggestion `json:"suggestions"`
+	Count       int                  `json:"count"`
+	Status      string               `json:"status"`
+}
+
+// Suggest completes search box input with references and popular queries
+func (h *Handler) Suggest(c echo.Context) error {
+	query := coalesce(c.QueryParam("q"), c.QueryParam("query"))
+	if query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Query parameter q is required")
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, maxSuggestions)
+	}
+
+	suggestions := h.suggest.Suggest(query, limit)
+	return c.JSON(http.StatusOK, SuggestResponse{
+		Query:       query,
+		Suggestions: suggestions,
+		Count:       len(suggestions),
+		Status:      "success",
+	})
+}
+
+// AutocompleteResponse lists typed completions of partially typed input
+type AutocompleteResponse struct {
+	Query       string               `json:"query"`
+	Suggestions []suggest.Suggestion `json:"suggestions"`
+	Count       int                  `json:"count"`
+	Semantic    bool                 `json:"semantic"` // Whether topics were also matched by meaning
+	Status      string               `json:"status"`
+}
+
+// Autocomplete completes search box input with references, topics and
+// popular queries, in that order. Topics match by prefix and, unless the
+// input reads as a reference, by embedding similarity.
+func (h *Handler) Autocomplete(c echo.Context) error {
+	query := coalesce(c.QueryParam("q"), c.QueryParam("query"))
+	if query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Query parameter q is required")
+	}
+
+	limit := 10
+	if l, err := strconv.Atoi(c.QueryParam("limit")); err == nil && l > 0 {
+		limit = min(l, maxSuggestions)
+	}
+	semantic := true
+	if v, err := strconv.ParseBool(c.QueryParam("semantic")); err == nil {
+		semantic = v
+	}
+
+	var references, queries []suggest.Suggestion
+	for _, suggestion := range h.suggest.Suggest(query, limit) {
+		if suggestion.Kind == suggest.KindReference {
+			references = append(references, suggestion)
+		} else {
+			queries = append(queries, suggestion)
+		}
+	}
+
+	topics := h.topics.Prefix(query, limit)
+	embedded := false
+	_, refErr := reference.ParseList(query)
+	isReference := len(references) > 0 || refErr == nil
+	// Every keystroke may arrive here, so the model is skipped when it is
+	// backed up, and fallback embeddings would suggest noise
+	if semantic && !isReference && utf8.RuneCountInString(query) >= minSemanticInput && h.search.ModelReady() && !h.search.Overloaded() {
+		ctx := c.Request().Context()
+		exclude := make(map[string]bool, len(topics))
+		for _, topic := range topics {
+			exclude[topic.Text] = true
+		}
+		embedding, err := h.search.EmbedQuery(ctx, query)
+		if err == nil {
+			var similar []suggest.Suggestion
+			if similar, err = h.topics.Similar(ctx, embedding, limit, exclude); err == nil {
+				topics = append(topics, similar...)
+				embedded = true
+			}
+		}
+		if err != nil {
+			log.Ctx(ctx).Debug().Err(err).Msg("Semantic topic suggestions unavailable")
+		}
+	}
+
+	suggestions := append(append(append([]suggest.Suggestion{}, references...), topics...), queries...)
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return c.JSON(http.StatusOK, AutocompleteResponse{
+		Query:       query,
+		Suggestions: suggestions,
+		Count:       len(suggestions),
+		Semantic:    embedded,
+		Status:      "success",
+	})
+}
+
+// recordQuery counts a search towards popular query suggestions, unless the
+// caller's privacy policy forbids keeping its text
+func (h *Handler) recordQuery(c echo.Context, query string) {
+	policy := PolicyFor(c)
+	if policy.NoQueryLogging || policy.HashOnly {
+		return
+	}
+	h.suggest.Record(query)
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+// SummaryVerse is a verse representing its chapter in a summary
+type SummaryVerse struct {
+	Reference  string  `json:"reference"`
+	VerseNum   int     `json:"verseNum"`
+	Text       string  `json:"text"`
+	Centrality float32 `json:"centrality"` // Mean cosine similarity to the chapter's other verses
+}
+
+// ChapterSummaryResponse is a chapter's representative verses
+type ChapterSummaryResponse struct {
+	Book      canon.Book     `json:"book"`
+	Chapter   int            `json:"chapter"`
+	Reference string         `json:"reference"`
+	Verses    []SummaryVerse `json:"verses"`
+	Count     int            `json:"count"`
+	Status    string         `json:"status"`
+}
+
+// ChapterSummary picks a chapter's most representative verses by embedding
+// centrality, for study overviews
+func (h *Handler) ChapterSummary(c echo.Context) error {
+	book, ok := canon.Lookup(c.Param("book"))
+	if !ok {
+		// As for /books/:book/chapters, an unknown book in the path is not found
+		e := bookError(&unknownBookError{name: c.Param("book")})
+		e.Status = http.StatusNotFound
+		return e
+	}
+	chapter, err := strconv.Atoi(c.Param("chapter"))
+	if err != nil || chapter < 1 || chapter > book.Chapters {
+		return apiError(http.StatusNotFound, CodeNotFound, "No such chapter in that book")
+	}
+	n := 3
+	if value := c.QueryParam("n"); value != "" {
+		if n, err = strconv.Atoi(value); err != nil || n < 1 || n > search.MaxSummaryVerses {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "n must be between 1 and "+strconv.Itoa(search.MaxSummaryVerses))
+		}
+	}
+
+	// A summary only changes with the verse index
+	var tag string
+	if version := h.search.IndexVersion("verse"); version != "" {
+		tag = etag(book.ID, chapter, n, version)
+		if notModified(c, tag) {
+			c.Response().Header().Set("ETag", tag)
+			return c.NoContent(http.StatusNotModified)
+		}
+	}
+
+	summary, err := h.search.ChapterSummary(book.Name, chapter, n)
+	if err != nil {
+		return searchError("Chapter summary failed", err)
+	}
+
+	verses := make([]SummaryVerse, len(summary))
+	for i, verse := range summary {
+		verses[i] = SummaryVerse{
+			Reference:  fmt.Sprintf("%s %d:%d", book.Name, chapter, verse.Verse.Meta.VerseNum),
+			VerseNum:   verse.Verse.Meta.VerseNum,
+			Text:       verse.Verse.Text,
+			Centrality: verse.Centrality,
+		}
+	}
+	if tag != "" {
+		c.Response().Header().Set("ETag", tag)
+	}
+	return c.JSON(http.StatusOK, ChapterSummaryResponse{
+		Book:      *book,
+		Chapter:   chapter,
+		Reference: fmt.Sprintf("%s %d", book.Name, chapter),
+		Verses:    verses,
+		Count:     len(verses),
+		Status:    "success",
+	})
+}
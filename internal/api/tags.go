@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/labstack/echo/v4"
+)
+
+// TagRequest attaches or removes tags on every verse of a reference
+type TagRequest struct {
+	Namespace string   `json:"namespace,omitempty"`
+	Reference string   `json:"reference"`
+	Tags      []string `json:"tags"`
+}
+
+// TagResponse reports the result of a tag change or lookup
+type TagResponse struct {
+	Namespace string   `json:"namespace"`
+	Reference string   `json:"reference,omitempty"`
+	Verses    []string `json:"verses,omitempty"`
+	Tags      []string `json:"tags,omitempty"`
+	Count     int      `json:"count"`
+	Status    string   `json:"status"`
+}
+
+// AddTags attaches tags to a verse or range
+func (h *Handler) AddTags(c echo.Context) error {
+	return h.changeTags(c, h.tags.Add)
+}
+
+// RemoveTags detaches tags from a verse or range
+func (h *Handler) RemoveTags(c echo.Context) error {
+	return h.changeTags(c, h.tags.Remove)
+}
+
+// changeTags validates a TagRequest, expands its reference to verses, and applies change
+func (h *Handler) changeTags(c echo.Context, change func(string, []reference.Reference, []string) error) error {
+	var req TagRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	namespace, err := tags.Normalize(coalesce(req.Namespace, tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	if len(req.Tags) == 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "At least one tag is required")
+	}
+	names := make([]string, 0, len(req.Tags))
+	for _, tag := range req.Tags {
+		name, err := tags.Normalize(tag)
+		if err != nil {
+			return invalidRequest(err)
+		}
+		names = append(names, name)
+	}
+
+	ref, err := reference.Parse(req.Reference)
+	if err != nil {
+		return referenceError(err)
+	}
+
+	verses, err := h.expandVerses(ref)
+	if err != nil {
+		return searchError("Could not resolve verses", err)
+	}
+
+	if err := change(namespace, verses, names); err != nil {
+		return internalError("Failed to update tags", err)
+	}
+
+	return c.JSON(http.StatusOK, TagResponse{
+		Namespace: namespace,
+		Reference: ref.String(),
+		Tags:      names,
+		Count:     len(verses),
+		Status:    "success",
+	})
+}
+
+// Tags lists the tags on a verse (?ref=) or the verses carrying a tag (?tag=)
+func (h *Handler) Tags(c echo.Context) error {
+	namespace, err := tags.Normalize(coalesce(c.QueryParam("namespace"), tags.DefaultNamespace))
+	if err != nil {
+		return invalidRequest(err)
+	}
+
+	if tag := c.QueryParam("tag"); tag != "" {
+		name, err := tags.Normalize(tag)
+		if err != nil {
+			return invalidRequest(err)
+		}
+
+		verses := h.tags.Verses(namespace, name)
+		refs := make([]string, len(verses))
+		for i, verse := range verses {
+			refs[i] = verse.String()
+		}
+		return c.JSON(http.StatusOK, TagResponse{
+			Namespace: namespace,
+			Verses:    refs,
+			Tags:      []string{name},
+			Count:     len(refs),
+			Status:    "success",
+		})
+	}
+
+	if c.QueryParam("ref") == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Either ref or tag is required")
+	}
+	ref, err := reference.Parse(c.QueryParam("ref"))
+	if err != nil {
+		return referenceError(err)
+	}
+	if ref.IsRange() || ref.StartVerse == 0 {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "ref must be a single verse")
+	}
+
+	tagList := h.tags.Tags(namespace, ref)
+	return c.JSON(http.StatusOK, TagResponse{
+		Namespace: namespace,
+		Reference: ref.String(),
+		Tags:      tagList,
+		Count:     len(tagList),
+		Status:    "success",
+	})
+}
+
+// expandVerses lists the single-verse references covered by ref. Ranges and
+// whole chapters need the verse index to know where chapters end.
+func (h *Handler) expandVerses(ref reference.Reference) ([]reference.Reference, error) {
+	if !ref.IsRange() && ref.StartVerse > 0 {
+		return []reference.Reference{ref}, nil
+	}
+
+	passage, err := h.search.Passage(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	verses := make([]reference.Reference, 0, len(passage))
+	for _, verse := range passage {
+		verses = append(verses, reference.Reference{
+			Book:         ref.Book,
+			StartChapter: verse.Meta.Chapter,
+			StartVerse:   verse.Meta.VerseNum,
+			EndChapter:   verse.Meta.Chapter,
+			EndVerse:     verse.Meta.VerseNum,
+		})
+	}
+	return verses, nil
+}
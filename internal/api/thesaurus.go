@@ -0,0 +1,52 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/thesaurus"
+	"github.com/labstack/echo/v4"
+)
+
+// maxThesaurusPreview caps the reformulations /thesaurus previews for a query
+const maxThesaurusPreview = 10
+
+// ThesaurusResponse lists thesaurus entries, or previews how a query is
+// reformulated
+type ThesaurusResponse struct {
+	Entries        []thesaurus.Entry `json:"entries,omitempty"`
+	Count          int               `json:"count"`
+	Reformulations []string          `json:"reformulations,omitempty"` // The rewrites expand=true searches for q
+	Status         string            `json:"status"`
+}
+
+// Thesaurus lists the modern and King James term mapping used by query
+// expansion and lexical scoring. term looks up one term's synonyms, and q
+// previews a query's reformulations.
+func (h *Handler) Thesaurus(c echo.Context) error {
+	th := h.search.Thesaurus()
+
+	if query := strings.TrimSpace(c.QueryParam("q")); query != "" {
+		reformulations := th.Reformulations(query, maxThesaurusPreview)
+		return c.JSON(http.StatusOK, ThesaurusResponse{
+			Reformulations: reformulations,
+			Count:          len(reformulations),
+			Status:         "success",
+		})
+	}
+
+	if term := strings.TrimSpace(c.QueryParam("term")); term != "" {
+		synonyms := th.Synonyms(term)
+		if len(synonyms) == 0 {
+			return apiError(http.StatusNotFound, CodeNotFound, "No thesaurus entry for that term")
+		}
+		return c.JSON(http.StatusOK, ThesaurusResponse{
+			Entries: []thesaurus.Entry{{Term: strings.ToLower(term), Synonyms: synonyms}},
+			Count:   1,
+			Status:  "success",
+		})
+	}
+
+	entries := th.Entries()
+	return c.JSON(http.StatusOK, ThesaurusResponse{Entries: entries, Count: len(entries), Status: "success"})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxTranscriptChars caps the length of a transcript sent to /transcripts/align
+	maxTranscriptChars = 50000
+	// maxAlignWindow caps the window size, beyond which the model truncates input
+	maxAlignWindow = 100
+)
+
+// TranscriptSegment is a timed stretch of a transcript, in seconds
+type TranscriptSegment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// AlignRequest is a transcript to scan for scripture, as plain text or as
+// timed segments
+type AlignRequest struct {
+	Text          string              `json:"text,omitempty"`
+	Segments      []TranscriptSegment `json:"segments,omitempty"` // Joined with spaces; enables times
+	Window        int                 `json:"window,omitempty"`   // Words per window, default 20
+	Stride        int                 `json:"stride,omitempty"`   // Words between windows, default window/2
+	MinSimilarity float32             `json:"minSimilarity,omitempty"`
+}
+
+// AlignedReference is a verse quoted or alluded to in the transcript
+type AlignedReference struct {
+	Reference  string   `json:"reference"`
+	Kind       string   `json:"kind"` // "quotation" or "allusion"
+	Similarity float32  `json:"similarity"`
+	Overlap    float64  `json:"overlap"`
+	Start      int      `json:"start"` // Character offsets into the transcript
+	End        int      `json:"end"`
+	StartTime  *float64 `json:"startTime,omitempty"` // Seconds, when segments are given
+	EndTime    *float64 `json:"endTime,omitempty"`
+	Excerpt    string   `json:"excerpt"`
+	VerseText  string   `json:"verseText"`
+}
+
+// AlignResponse lists the references detected in a transcript, in order
+type AlignResponse struct {
+	References []AlignedReference `json:"references"`
+	Count      int                `json:"count"`
+	Status     string             `json:"status"`
+}
+
+// AlignTranscript detects scripture quotations and allusions in a transcript
+func (h *Handler) AlignTranscript(c echo.Context) error {
+	var req AlignRequest
+	if err := c.Bind(&req); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid request body")
+	}
+
+	// Segments are joined with single spaces, remembering where each starts
+	text := req.Text
+	var segmentStarts []int
+	if len(req.Segments) > 0 {
+		parts := make([]string, len(req.Segments))
+		offset := 0
+		for i, segment := range req.Segments {
+			parts[i] = segment.Text
+			segmentStarts = append(segmentStarts, offset)
+			offset += utf8.RuneCountInString(segment.Text) + 1
+		}
+		text = strings.Join(parts, " ")
+	}
+
+	if strings.TrimSpace(text) == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Text is required")
+	}
+	if req.Window > maxAlignWindow {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Window is too large").
+			withDetails(map[string]int{"max": maxAlignWindow})
+	}
+	if utf8.RuneCountInString(text) > maxTranscriptChars {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Transcript is too long").
+			withDetails(map[string]int{"max": maxTranscriptChars})
+	}
+
+	alignments, err := h.search.Align(c.Request().Context(), text, search.AlignOptions{
+		Window:        req.Window,
+		Stride:        req.Stride,
+		MinSimilarity: req.MinSimilarity,
+	})
+	if err != nil {
+		return searchError("Alignment failed", err)
+	}
+
+	runes := []rune(text)
+	references := make([]AlignedReference, len(alignments))
+	for i, a := range alignments {
+		references[i] = AlignedReference{
+			Reference:  a.Verse.Chunk.Meta.Reference,
+			Kind:       a.Kind,
+			Similarity: a.Similarity,
+			Overlap:    a.Overlap,
+			Start:      a.Start,
+			End:        a.End,
+			Excerpt:    string(runes[a.Start:a.End]),
+			VerseText:  a.Verse.Chunk.Text,
+		}
+		if segmentStarts != nil {
+			// The segments containing the first and last characters give the times
+			first := sort.SearchInts(segmentStarts, a.Start+1) - 1
+			last := sort.SearchInts(segmentStarts, a.End) - 1
+			references[i].StartTime = &req.Segments[first].Start
+			references[i].EndTime = &req.Segments[last].End
+		}
+	}
+
+	return c.JSON(http.StatusOK, AlignResponse{
+		References: references,
+		Count:      len(references),
+		Status:     "success",
+	})
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/labstack/echo/v4"
+)
+
+// archiveVersion is bumped whenever the archive layout changes incompatibly
+const archiveVersion = 1
+
+// UserDataArchive is a portable snapshot of per-namespace user data
+type UserDataArchive struct {
+	Version    int                `json:"version"`
+	ExportedAt time.Time          `json:"exportedAt"`
+	Namespaces []NamespaceArchive `json:"namespaces"`
+}
+
+// NamespaceArchive holds one namespace's tags and notes
+type NamespaceArchive struct {
+	Namespace string              `json:"namespace"`
+	Tags      map[string][]string `json:"tags"` // "John 3:16" -> tags
+	Notes     []notes.Note        `json:"notes"`
+}
+
+// ImportResult reports what an import restored for one namespace
+type ImportResult struct {
+	Namespace    string `json:"namespace"`
+	Tags         int    `json:"tags"`
+	Notes        int    `json:"notes"`
+	SkippedTags  int    `json:"skippedTags,omitempty"`
+	SkippedNotes int    `json:"skippedNotes,omitempty"`
+}
+
+// ImportResponse summarizes an import
+type ImportResponse struct {
+	Namespaces []ImportResult `json:"namespaces"`
+	Status     string         `json:"status"`
+}
+
+// ExportUserData downloads tags and notes for the requested namespaces
+// (comma-separated ?namespace=, default all) as a single archive
+func (h *Handler) ExportUserData(c echo.Context) error {
+	namespaces, err := parseNamespaces(c.QueryParam("namespace"))
+	if err != nil {
+		return invalidRequest(err)
+	}
+	if len(namespaces) == 0 {
+		namespaces = h.userNamespaces()
+	}
+
+	archive := UserDataArchive{
+		Version:    archiveVersion,
+		ExportedAt: time.Now().UTC(),
+		Namespaces: make([]NamespaceArchive, 0, len(namespaces)),
+	}
+	for _, namespace := range namespaces {
+		archive.Namespaces = append(archive.Namespaces, NamespaceArchive{
+			Namespace: namespace,
+			Tags:      h.tags.Export(namespace),
+			Notes:     h.notes.List(namespace),
+		})
+	}
+
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="userdata.json"`)
+	return c.JSON(http.StatusOK, archive)
+}
+
+// ImportUserData restores an archive produced by ExportUserData. Tags are
+// merged and notes with matching IDs are replaced; ?namespace= restricts the
+// import to the listed namespaces.
+func (h *Handler) ImportUserData(c echo.Context) error {
+	var archive UserDataArchive
+	if err := c.Bind(&archive); err != nil {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid archive")
+	}
+	if archive.Version != archiveVersion {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Unsupported archive version").
+			withDetails(map[string]int{"version": archive.Version, "supported": archiveVersion})
+	}
+
+	only, err := parseNamespaces(c.QueryParam("namespace"))
+	if err != nil {
+		return invalidRequest(err)
+	}
+	wanted := make(map[string]bool, len(only))
+	for _, namespace := range only {
+		wanted[namespace] = true
+	}
+
+	// Validate every namespace before writing anything
+	for i, entry := range archive.Namespaces {
+		namespace, err := tags.Normalize(entry.Namespace)
+		if err != nil {
+			return apiError(http.StatusBadRequest, CodeInvalidRequest, "Invalid namespace in archive").
+				withDetails(err.Error())
+		}
+		archive.Namespaces[i].Namespace = namespace
+	}
+
+	results := make([]ImportResult, 0, len(archive.Namespaces))
+	for _, entry := range archive.Namespaces {
+		if len(wanted) > 0 && !wanted[entry.Namespace] {
+			continue
+		}
+
+		result := ImportResult{Namespace: entry.Namespace}
+		if result.Tags, result.SkippedTags, err = h.tags.Import(entry.Namespace, entry.Tags); err != nil {
+			return internalError("Failed to import user data", err)
+		}
+		if result.Notes, result.SkippedNotes, err = h.notes.Import(entry.Namespace, entry.Notes); err != nil {
+			return internalError("Failed to import user data", err)
+		}
+		results = append(results, result)
+	}
+
+	return c.JSON(http.StatusOK, ImportResponse{
+		Namespaces: results,
+		Status:     "success",
+	})
+}
+
+// userNamespaces lists every namespace holding tags or notes
+func (h *Handler) userNamespaces() []string {
+	seen := make(map[string]bool)
+	var namespaces []string
+	for _, namespace := range append(h.tags.Namespaces(), h.notes.Namespaces()...) {
+		if !seen[namespace] {
+			seen[namespace] = true
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// parseNamespaces splits and normalizes a comma-separated namespace list
+func parseNamespaces(value string) ([]string, error) {
+	var namespaces []string
+	for _, part := range strings.Split(value, ",") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+		namespace, err := tags.Normalize(part)
+		if err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	return namespaces, nil
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/dpshade/goscriptureapi/internal/widget"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	// maxWidgetResults caps k on /widget/search
+	maxWidgetResults = 10
+	// maxWidgetQuery caps the query length, in characters, on /widget/search
+	maxWidgetQuery = 200
+)
+
+// WidgetResult is one verse in a widget search response
+type WidgetResult struct {
+	Reference string `json:"reference"`
+	Text      string `json:"text"`
+}
+
+// WidgetSearchResponse is the small response the embeddable widget renders
+type WidgetSearchResponse struct {
+	Results []WidgetResult `json:"results"`
+	Status  string         `json:"status"`
+}
+
+// SetWidgetKeys configures the publishable keys accepted by /widget/search
+func (h *Handler) SetWidgetKeys(keys widget.Keys) {
+	h.widgetKeys = keys
+}
+
+// WidgetScript serves the embeddable search box script
+func (h *Handler) WidgetScript(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderCacheControl, "public, max-age=3600")
+	return c.Blob(http.StatusOK, "application/javascript; charset=utf-8", widget.Script)
+}
+
+// WidgetSearch is a constrained verse search for embedded widgets. The key
+// must be configured and the calling page's origin must be one it allows.
+func (h *Handler) WidgetSearch(c echo.Context) error {
+	key := c.QueryParam("key")
+	if !h.widgetKeys.Known(key) {
+		return apiError(http.StatusUnauthorized, CodeInvalidKey, "Unknown widget key")
+	}
+	origin := c.Request().Header.Get(echo.HeaderOrigin)
+	if !h.widgetKeys.Allows(key, origin) {
+		return apiError(http.StatusForbidden, CodeOriginNotAllowed, "This widget key can't be used from this origin")
+	}
+
+	// Only the key's own origins may read the response. The CORS middleware
+	// already varies responses on Origin.
+	c.Response().Header().Set(echo.HeaderAccessControlAllowOrigin, origin)
+
+	query := c.QueryParam("q")
+	if query == "" {
+		return apiError(http.StatusBadRequest, CodeInvalidRequest, "Query is required")
+	}
+	if utf8.RuneCountInString(query) > maxWidgetQuery {
+		return apiError(http.StatusBadRequest, CodeLimitExceeded, "Query is too long").
+			withDetails(map[string]int{"max": maxWidgetQuery})
+	}
+
+	k := 5
+	if kVal, err := strconv.Atoi(c.QueryParam("k")); err == nil && kVal > 0 {
+		k = min(kVal, maxWidgetResults)
+	}
+
+	results, _, err := h.search.SearchOrShed(c.Request().Context(), query, search.SearchOptions{K: k})
+	if err != nil {
+		return searchError("Search failed", err)
+	}
+
+	resp := WidgetSearchResponse{Results: make([]WidgetResult, len(results)), Status: "success"}
+	for i, result := range results {
+		resp.Results[i] = WidgetResult{
+			Reference: result.Chunk.Meta.Reference,
+			Text:      format.Text(result.Chunk.Text, format.Options{}),
+		}
+	}
+	return c.JSON(http.StatusOK, resp)
+}
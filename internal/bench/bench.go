@@ -0,0 +1,186 @@
+// Package bench measures search latency against indices loaded in process,
+// splitting each search into embedding, scanning and re-ranking time so a
+// regression can be traced to its stage.
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// DefaultQueries are searched when no query file is given
+var DefaultQueries = []string{
+	"love your enemies",
+	"faith without works is dead",
+	"the Lord is my shepherd",
+	"do not be anxious about anything",
+	"in the beginning God created the heavens and the earth",
+	"forgiveness of sins",
+	"the kingdom of heaven is like a mustard seed",
+	"wisdom is better than rubies",
+	"comfort for those who mourn",
+	"the fruit of the Spirit",
+}
+
+// Options configure a benchmark
+type Options struct {
+	Queries     []string // Searched round robin
+	Searches    int      // Total searches, after any warmup
+	Warmup      int      // Searches run first and left out of the results
+	Concurrency int
+	Search      search.SearchOptions
+}
+
+// Sample is the timing of one search
+type Sample struct {
+	Total, Embed, Scan, Sort time.Duration
+	Backend                  string // Embedding backend that embedded the query
+	Err                      error
+}
+
+// Run searches the queries, returning each measured search's timing in issue
+// order and the wall time they took
+func Run(ctx context.Context, service *search.SearchService, opts Options) ([]Sample, time.Duration) {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	for i := 0; i < opts.Warmup && ctx.Err() == nil; i++ {
+		measure(ctx, service, opts.Queries[i%len(opts.Queries)], opts.Search)
+	}
+
+	start := time.Now()
+	samples := make([]Sample, opts.Searches)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				samples[i] = measure(ctx, service, opts.Queries[i%len(opts.Queries)], opts.Search)
+			}
+		}()
+	}
+	issued := 0
+	for ; issued < opts.Searches && ctx.Err() == nil; issued++ {
+		next <- issued
+	}
+	close(next)
+	wg.Wait()
+	return samples[:issued], time.Since(start)
+}
+
+// measure runs one search, timing its stages
+func measure(ctx context.Context, service *search.SearchService, query string, options search.SearchOptions) Sample {
+	explanation := &search.Explanation{}
+	ctx = embeddings.WithQueryReports(ctx, explanation.RecordEmbedding)
+	start := time.Now()
+	_, err := service.Search(ctx, query, options.Explaining(explanation))
+	return Sample{
+		Total:   time.Since(start),
+		Embed:   duration(explanation.EmbedMs),
+		Scan:    duration(explanation.ScanMs),
+		Sort:    duration(explanation.SortMs),
+		Backend: explanation.Backend,
+		Err:     err,
+	}
+}
+
+func duration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// Percentiles of a latency distribution
+type Percentiles struct {
+	P50, P90, P99, Max time.Duration
+}
+
+// Summary aggregates a benchmark's samples
+type Summary struct {
+	Searches int
+	Errors   int
+	FirstErr error
+	Elapsed  time.Duration // Wall time, for throughput
+	Backends map[string]int
+
+	Total, Embed, Scan, Sort Percentiles
+}
+
+// Summarize aggregates samples taken over elapsed
+func Summarize(samples []Sample, elapsed time.Duration) Summary {
+	summary := Summary{Elapsed: elapsed, Backends: make(map[string]int)}
+	var total, embed, scan, sorting []time.Duration
+	for _, sample := range samples {
+		summary.Searches++
+		if sample.Err != nil {
+			summary.Errors++
+			if summary.FirstErr == nil {
+				summary.FirstErr = sample.Err
+			}
+			continue
+		}
+		summary.Backends[sample.Backend]++
+		total = append(total, sample.Total)
+		embed = append(embed, sample.Embed)
+		scan = append(scan, sample.Scan)
+		sorting = append(sorting, sample.Sort)
+	}
+	summary.Total = percentiles(total)
+	summary.Embed = percentiles(embed)
+	summary.Scan = percentiles(scan)
+	summary.Sort = percentiles(sorting)
+	return summary
+}
+
+// percentiles computes latency percentiles by nearest rank
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+	}
+	return Percentiles{P50: at(0.5), P90: at(0.9), P99: at(0.99), Max: sorted[len(sorted)-1]}
+}
+
+// Write prints the summary for a terminal
+func (s Summary) Write(w io.Writer) {
+	fmt.Fprintf(w, "Searches: %d (%d failed)\n", s.Searches, s.Errors)
+	if s.FirstErr != nil {
+		fmt.Fprintf(w, "  first error: %v\n", s.FirstErr)
+	}
+	if s.Elapsed > 0 {
+		fmt.Fprintf(w, "Throughput: %.1f searches/s\n", float64(s.Searches)/s.Elapsed.Seconds())
+	}
+	backends := make([]string, 0, len(s.Backends))
+	for backend := range s.Backends {
+		backends = append(backends, backend)
+	}
+	sort.Strings(backends)
+	for _, backend := range backends {
+		fmt.Fprintf(w, "  embedded by %s: %d\n", backend, s.Backends[backend])
+	}
+
+	fmt.Fprintln(w, "\nLatency      p50        p90        p99        max")
+	for _, row := range []struct {
+		name string
+		p    Percentiles
+	}{{"total", s.Total}, {"embed", s.Embed}, {"scan", s.Scan}, {"sort", s.Sort}} {
+		fmt.Fprintf(w, "  %-9s %-10s %-10s %-10s %s\n", row.name,
+			round(row.p.P50), round(row.p.P90), round(row.p.P99), round(row.p.Max))
+	}
+}
+
+// round shortens a latency for display
+func round(d time.Duration) time.Duration {
+	return d.Round(10 * time.Microsecond)
+}
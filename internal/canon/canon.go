@@ -0,0 +1,284 @@
+// Package canon is the registry of the 66 books of the Protestant canon:
+// canonical IDs and names, abbreviations, testament, genre and chapter counts.
+package canon
+
+import "strings"
+
+// Testaments
+const (
+	OldTestament = "ot"
+	NewTestament = "nt"
+)
+
+// Genres
+const (
+	GenreLaw             = "law"
+	GenreHistory         = "history"
+	GenreWisdom          = "wisdom"
+	GenreMajorProphets   = "major-prophets"
+	GenreMinorProphets   = "minor-prophets"
+	GenreGospels         = "gospels"
+	GenrePaulineEpistles = "pauline-epistles"
+	GenreGeneralEpistles = "general-epistles"
+	GenreApocalyptic     = "apocalyptic"
+)
+
+// genreGroups are broader genres accepted in filters, covering several genres
+var genreGroups = map[string][]string{
+	"prophets": {GenreMajorProphets, GenreMinorProphets},
+	"epistles": {GenrePaulineEpistles, GenreGeneralEpistles},
+}
+
+// testamentAliases maps accepted testament spellings to their canonical form
+var testamentAliases = map[string]string{
+	"ot": OldTestament, "old": OldTestament, "old testament": OldTestament,
+	"nt": NewTestament, "new": NewTestament, "new testament": NewTestament,
+}
+
+// Book describes a canonical book of the Bible. ID is the OSIS book
+// abbreviation, which is stable and safe to use in URLs and filters.
+type Book struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	Abbreviations []string `json:"abbreviations"`
+	Testament     string   `json:"testament"`
+	Genre         string   `json:"genre"`
+	Chapters      int      `json:"chapters"`
+}
+
+// Books lists the 66 books of the Protestant canon in order
+var Books = []Book{
+	// Old Testament
+	{ID: "Gen", Name: "Genesis", Abbreviations: []string{"gen", "ge", "gn"}, Testament: OldTestament, Genre: GenreLaw, Chapters: 50},
+	{ID: "Exod", Name: "Exodus", Abbreviations: []string{"exod", "exo", "ex"}, Testament: OldTestament, Genre: GenreLaw, Chapters: 40},
+	{ID: "Lev", Name: "Leviticus", Abbreviations: []string{"lev", "le", "lv"}, Testament: OldTestament, Genre: GenreLaw, Chapters: 27},
+	{ID: "Num", Name: "Numbers", Abbreviations: []string{"num", "nu", "nm", "nb"}, Testament: OldTestament, Genre: GenreLaw, Chapters: 36},
+	{ID: "Deut", Name: "Deuteronomy", Abbreviations: []string{"deut", "deu", "de", "dt"}, Testament: OldTestament, Genre: GenreLaw, Chapters: 34},
+	{ID: "Josh", Name: "Joshua", Abbreviations: []string{"josh", "jos", "jsh"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 24},
+	{ID: "Judg", Name: "Judges", Abbreviations: []string{"judg", "jdg", "jg", "jdgs"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 21},
+	{ID: "Ruth", Name: "Ruth", Abbreviations: []string{"rth", "ru"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 4},
+	{ID: "1Sam", Name: "1 Samuel", Abbreviations: []string{"1sam", "1sa", "1sm", "1s"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 31},
+	{ID: "2Sam", Name: "2 Samuel", Abbreviations: []string{"2sam", "2sa", "2sm", "2s"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 24},
+	{ID: "1Kgs", Name: "1 Kings", Abbreviations: []string{"1kgs", "1ki", "1kin", "1k"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 22},
+	{ID: "2Kgs", Name: "2 Kings", Abbreviations: []string{"2kgs", "2ki", "2kin", "2k"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 25},
+	{ID: "1Chr", Name: "1 Chronicles", Abbreviations: []string{"1chron", "1chr", "1ch"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 29},
+	{ID: "2Chr", Name: "2 Chronicles", Abbreviations: []string{"2chron", "2chr", "2ch"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 36},
+	{ID: "Ezra", Name: "Ezra", Abbreviations: []string{"ezr", "ez"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 10},
+	{ID: "Neh", Name: "Nehemiah", Abbreviations: []string{"neh", "ne"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 13},
+	{ID: "Esth", Name: "Esther", Abbreviations: []string{"esth", "est", "es"}, Testament: OldTestament, Genre: GenreHistory, Chapters: 10},
+	{ID: "Job", Name: "Job", Abbreviations: []string{"jb"}, Testament: OldTestament, Genre: GenreWisdom, Chapters: 42},
+	{ID: "Ps", Name: "Psalms", Abbreviations: []string{"psalm", "pslm", "psa", "psm", "pss", "ps"}, Testament: OldTestament, Genre: GenreWisdom, Chapters: 150},
+	{ID: "Prov", Name: "Proverbs", Abbreviations: []string{"prov", "pro", "prv", "pr"}, Testament: OldTestament, Genre: GenreWisdom, Chapters: 31},
+	{ID: "Eccl", Name: "Ecclesiastes", Abbreviations: []string{"eccles", "eccl", "ecc", "ec", "qoh"}, Testament: OldTestament, Genre: GenreWisdom, Chapters: 12},
+	{ID: "Song", Name: "Song of Solomon", Abbreviations: []string{"songofsongs", "song", "sos", "canticles", "cant"}, Testament: OldTestament, Genre: GenreWisdom, Chapters: 8},
+	{ID: "Isa", Name: "Isaiah", Abbreviations: []string{"isa", "is"}, Testament: OldTestament, Genre: GenreMajorProphets, Chapters: 66},
+	{ID: "Jer", Name: "Jeremiah", Abbreviations: []string{"jer", "je", "jr"}, Testament: OldTestament, Genre: GenreMajorProphets, Chapters: 52},
+	{ID: "Lam", Name: "Lamentations", Abbreviations: []string{"lam", "la"}, Testament: OldTestament, Genre: GenreMajorProphets, Chapters: 5},
+	{ID: "Ezek", Name: "Ezekiel", Abbreviations: []string{"ezek", "eze", "ezk"}, Testament: OldTestament, Genre: GenreMajorProphets, Chapters: 48},
+	{ID: "Dan", Name: "Daniel", Abbreviations: []string{"dan", "da", "dn"}, Testament: OldTestament, Genre: GenreMajorProphets, Chapters: 12},
+	{ID: "Hos", Name: "Hosea", Abbreviations: []string{"hos", "ho"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 14},
+	{ID: "Joel", Name: "Joel", Abbreviations: []string{"jl"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 3},
+	{ID: "Amos", Name: "Amos", Abbreviations: []string{"am"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 9},
+	{ID: "Obad", Name: "Obadiah", Abbreviations: []string{"obad", "ob"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 1},
+	{ID: "Jonah", Name: "Jonah", Abbreviations: []string{"jnh", "jon"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 4},
+	{ID: "Mic", Name: "Micah", Abbreviations: []string{"mic", "mc"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 7},
+	{ID: "Nah", Name: "Nahum", Abbreviations: []string{"nah", "na"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 3},
+	{ID: "Hab", Name: "Habakkuk", Abbreviations: []string{"hab", "hb"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 3},
+	{ID: "Zeph", Name: "Zephaniah", Abbreviations: []string{"zeph", "zep", "zp"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 3},
+	{ID: "Hag", Name: "Haggai", Abbreviations: []string{"hag", "hg"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 2},
+	{ID: "Zech", Name: "Zechariah", Abbreviations: []string{"zech", "zec", "zc"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 14},
+	{ID: "Mal", Name: "Malachi", Abbreviations: []string{"mal", "ml"}, Testament: OldTestament, Genre: GenreMinorProphets, Chapters: 4},
+
+	// New Testament
+	{ID: "Matt", Name: "Matthew", Abbreviations: []string{"matt", "mat", "mt"}, Testament: NewTestament, Genre: GenreGospels, Chapters: 28},
+	{ID: "Mark", Name: "Mark", Abbreviations: []string{"mrk", "mar", "mk", "mr"}, Testament: NewTestament, Genre: GenreGospels, Chapters: 16},
+	{ID: "Luke", Name: "Luke", Abbreviations: []string{"luk", "lk"}, Testament: NewTestament, Genre: GenreGospels, Chapters: 24},
+	{ID: "John", Name: "John", Abbreviations: []string{"joh", "jhn", "jn"}, Testament: NewTestament, Genre: GenreGospels, Chapters: 21},
+	{ID: "Acts", Name: "Acts", Abbreviations: []string{"act", "ac"}, Testament: NewTestament, Genre: GenreHistory, Chapters: 28},
+	{ID: "Rom", Name: "Romans", Abbreviations: []string{"rom", "ro", "rm"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 16},
+	{ID: "1Cor", Name: "1 Corinthians", Abbreviations: []string{"1cor", "1co"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 16},
+	{ID: "2Cor", Name: "2 Corinthians", Abbreviations: []string{"2cor", "2co"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 13},
+	{ID: "Gal", Name: "Galatians", Abbreviations: []string{"gal", "ga"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 6},
+	{ID: "Eph", Name: "Ephesians", Abbreviations: []string{"eph", "ephes"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 6},
+	{ID: "Phil", Name: "Philippians", Abbreviations: []string{"phil", "php"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 4},
+	{ID: "Col", Name: "Colossians", Abbreviations: []string{"col", "co"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 4},
+	{ID: "1Thess", Name: "1 Thessalonians", Abbreviations: []string{"1thess", "1thes", "1th"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 5},
+	{ID: "2Thess", Name: "2 Thessalonians", Abbreviations: []string{"2thess", "2thes", "2th"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 3},
+	{ID: "1Tim", Name: "1 Timothy", Abbreviations: []string{"1tim", "1ti"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 6},
+	{ID: "2Tim", Name: "2 Timothy", Abbreviations: []string{"2tim", "2ti"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 4},
+	{ID: "Titus", Name: "Titus", Abbreviations: []string{"tit", "ti"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 3},
+	{ID: "Phlm", Name: "Philemon", Abbreviations: []string{"philem", "phlm", "phm", "pm"}, Testament: NewTestament, Genre: GenrePaulineEpistles, Chapters: 1},
+	{ID: "Heb", Name: "Hebrews", Abbreviations: []string{"heb"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 13},
+	{ID: "Jas", Name: "James", Abbreviations: []string{"jas", "jm"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 5},
+	{ID: "1Pet", Name: "1 Peter", Abbreviations: []string{"1pet", "1pe", "1pt", "1p"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 5},
+	{ID: "2Pet", Name: "2 Peter", Abbreviations: []string{"2pet", "2pe", "2pt", "2p"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 3},
+	{ID: "1John", Name: "1 John", Abbreviations: []string{"1jhn", "1jn", "1jo", "1j"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 5},
+	{ID: "2John", Name: "2 John", Abbreviations: []string{"2jhn", "2jn", "2jo", "2j"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 1},
+	{ID: "3John", Name: "3 John", Abbreviations: []string{"3jhn", "3jn", "3jo", "3j"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 1},
+	{ID: "Jude", Name: "Jude", Abbreviations: []string{"jud", "jd"}, Testament: NewTestament, Genre: GenreGeneralEpistles, Chapters: 1},
+	{ID: "Rev", Name: "Revelation", Abbreviations: []string{"rev", "re", "apocalypse", "revelations"}, Testament: NewTestament, Genre: GenreApocalyptic, Chapters: 22},
+}
+
+// bookIndex maps normalized names and abbreviations to positions in Books
+var bookIndex = buildBookIndex()
+
+func buildBookIndex() map[string]int {
+	index := make(map[string]int)
+	for i, book := range Books {
+		index[strings.ToLower(book.ID)] = i
+		index[bookKey(book.Name)] = i
+		for _, abbr := range book.Abbreviations {
+			index[abbr] = i
+		}
+	}
+	return index
+}
+
+// bookKey normalizes a book name for lookup: lowercase with spaces and periods removed
+func bookKey(name string) string {
+	name = strings.ToLower(name)
+	name = strings.ReplaceAll(name, ".", "")
+	return strings.Join(strings.Fields(name), "")
+}
+
+// ordinalPrefixes maps spelled-out and roman ordinals to their digit form
+var ordinalPrefixes = map[string]string{
+	"1": "1", "i": "1", "first": "1", "1st": "1",
+	"2": "2", "ii": "2", "second": "2", "2nd": "2",
+	"3": "3", "iii": "3", "third": "3", "3rd": "3",
+}
+
+// Lookup resolves a book ID, name or abbreviation to its canonical book
+func Lookup(name string) (*Book, bool) {
+	fields := strings.Fields(strings.ToLower(strings.ReplaceAll(name, ".", " ")))
+	if len(fields) == 0 {
+		return nil, false
+	}
+
+	// Normalize "I Corinthians", "First John", "1st Peter" to a digit prefix
+	if len(fields) > 1 {
+		if digit, ok := ordinalPrefixes[fields[0]]; ok {
+			fields[0] = digit
+		}
+	}
+	key := strings.Join(fields, "")
+
+	if i, ok := bookIndex[key]; ok {
+		return &Books[i], true
+	}
+
+	// Accept an unambiguous prefix of a full book name ("Deuter", "Philipp")
+	if len(key) >= 3 {
+		match := -1
+		for i, book := range Books {
+			if strings.HasPrefix(bookKey(book.Name), key) {
+				if match >= 0 {
+					return nil, false
+				}
+				match = i
+			}
+		}
+		if match >= 0 {
+			return &Books[match], true
+		}
+	}
+
+	return nil, false
+}
+
+// Nearest returns the book whose name or abbreviation is closest to name.
+// Full names win ties against abbreviations so "Jhon" suggests John, not Jonah.
+func Nearest(name string) *Book {
+	key := bookKey(name)
+	best, bestScore, bestDistance := -1, -1, 0
+	for i, book := range Books {
+		candidates := append([]string{bookKey(book.Name)}, book.Abbreviations...)
+		for j, candidate := range candidates {
+			d := editDistance(key, candidate)
+			score := d * 2
+			if j > 0 {
+				score++
+			}
+			if bestScore < 0 || score < bestScore {
+				best, bestScore, bestDistance = i, score, d
+			}
+		}
+	}
+
+	// Don't suggest something unrelated to what was typed
+	if best < 0 || bestDistance > len(key)/2+1 {
+		return nil
+	}
+	return &Books[best]
+}
+
+// editDistance computes the optimal string alignment distance between two
+// strings, counting an adjacent transposition ("Jhon") as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// Position returns the canonical order of a book (0 for Genesis), or
+// len(Books) for names that don't resolve
+func Position(name string) int {
+	if book, ok := Lookup(name); ok {
+		return bookIndex[strings.ToLower(book.ID)]
+	}
+	return len(Books)
+}
+
+// NormalizeTestament resolves "OT", "old" or "Old Testament" to a testament constant
+func NormalizeTestament(name string) (string, bool) {
+	testament, ok := testamentAliases[strings.ToLower(strings.TrimSpace(name))]
+	return testament, ok
+}
+
+// NormalizeGenre lowercases a genre and checks that it is a genre or genre group
+func NormalizeGenre(name string) (string, bool) {
+	genre := strings.ToLower(strings.TrimSpace(name))
+	if _, ok := genreGroups[genre]; ok {
+		return genre, true
+	}
+	for _, book := range Books {
+		if book.Genre == genre {
+			return genre, true
+		}
+	}
+	return "", false
+}
+
+// InGenre reports whether the book belongs to a genre or genre group such as "prophets"
+func (b *Book) InGenre(genre string) bool {
+	if b.Genre == genre {
+		return true
+	}
+	for _, member := range genreGroups[genre] {
+		if b.Genre == member {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,206 @@
+// Package cluster checks that several API nodes serve identical index data,
+// by comparing the checksums each reports at /admin/index-checksums.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// ChecksumsPath is where a node reports its index checksums
+const ChecksumsPath = "/admin/index-checksums"
+
+// Node is one node's reported checksums, or why they couldn't be fetched
+type Node struct {
+	URL     string
+	Indices map[string]search.IndexChecksum // By granularity
+	Err     error
+}
+
+// Report compares the nodes of a cluster
+type Report struct {
+	Nodes      []Node
+	Mismatches []string // One line per differing index field
+}
+
+// Consistent reports whether every node answered with identical checksums
+func (r Report) Consistent() bool {
+	if len(r.Mismatches) > 0 {
+		return false
+	}
+	for _, node := range r.Nodes {
+		if node.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Verify fetches every node's checksums concurrently, authenticating with the
+// admin token, and compares each node with the first one that answered
+func Verify(ctx context.Context, client *http.Client, urls []string, token string) Report {
+	nodes := make([]Node, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		wg.Add(1)
+		go func(i int, url string) {
+			defer wg.Done()
+			nodes[i] = fetch(ctx, client, url, token)
+		}(i, url)
+	}
+	wg.Wait()
+
+	report := Report{Nodes: nodes}
+	first := -1
+	for i := range nodes {
+		if nodes[i].Err == nil {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return report
+	}
+
+	for i, node := range nodes {
+		if node.Err != nil || i == first {
+			continue
+		}
+		report.Mismatches = append(report.Mismatches, compare(nodes[first], node)...)
+	}
+	return report
+}
+
+// compare lists how node's indices differ from the reference node's
+func compare(reference, node Node) []string {
+	granularities := make(map[string]bool)
+	for granularity := range reference.Indices {
+		granularities[granularity] = true
+	}
+	for granularity := range node.Indices {
+		granularities[granularity] = true
+	}
+	sorted := make([]string, 0, len(granularities))
+	for granularity := range granularities {
+		sorted = append(sorted, granularity)
+	}
+	sort.Strings(sorted)
+
+	var mismatches []string
+	for _, granularity := range sorted {
+		want, okWant := reference.Indices[granularity]
+		got, okGot := node.Indices[granularity]
+		switch {
+		case !okGot:
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s index not loaded (loaded on %s)", node.URL, granularity, reference.URL))
+			continue
+		case !okWant:
+			mismatches = append(mismatches, fmt.Sprintf("%s: %s index not loaded (loaded on %s)", reference.URL, granularity, node.URL))
+			continue
+		}
+
+		fields := []struct{ name, want, got string }{
+			{"model", want.Model, got.Model},
+			{"vectors", fmt.Sprint(want.Vectors), fmt.Sprint(got.Vectors)},
+			{"dimensions", fmt.Sprint(want.Dimensions), fmt.Sprint(got.Dimensions)},
+			{"vectorChecksum", want.VectorChecksum, got.VectorChecksum},
+			{"textChecksum", want.TextChecksum, got.TextChecksum},
+		}
+		for _, field := range fields {
+			if field.want != field.got {
+				mismatches = append(mismatches, fmt.Sprintf("%s: %s %s is %s, %s has %s",
+					node.URL, granularity, field.name, field.got, reference.URL, field.want))
+			}
+		}
+	}
+	return mismatches
+}
+
+// fetch reads a node's checksums
+func fetch(ctx context.Context, client *http.Client, url, token string) Node {
+	node := Node{URL: strings.TrimSuffix(url, "/")}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, node.URL+ChecksumsPath, nil)
+	if err != nil {
+		node.Err = err
+		return node
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		node.Err = err
+		return node
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		node.Err = fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return node
+	}
+
+	var body struct {
+		Indices []search.IndexChecksum `json:"indices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		node.Err = fmt.Errorf("invalid checksum response: %w", err)
+		return node
+	}
+	node.Indices = make(map[string]search.IndexChecksum, len(body.Indices))
+	for _, index := range body.Indices {
+		node.Indices[index.Granularity] = index
+	}
+	return node
+}
+
+// Write prints the report for a terminal: each node's indices, then any
+// mismatches
+func (r Report) Write(w io.Writer) {
+	for _, node := range r.Nodes {
+		if node.Err != nil {
+			fmt.Fprintf(w, "%s: error: %v\n", node.URL, node.Err)
+			continue
+		}
+		if len(node.Indices) == 0 {
+			fmt.Fprintf(w, "%s: no indices loaded\n", node.URL)
+		}
+		granularities := make([]string, 0, len(node.Indices))
+		for granularity := range node.Indices {
+			granularities = append(granularities, granularity)
+		}
+		sort.Strings(granularities)
+		for _, granularity := range granularities {
+			index := node.Indices[granularity]
+			fmt.Fprintf(w, "%s: %s vectors=%d vectors:%s text:%s\n", node.URL, granularity,
+				index.Vectors, short(index.VectorChecksum), short(index.TextChecksum))
+		}
+	}
+
+	if len(r.Mismatches) > 0 {
+		fmt.Fprintln(w, "\nMismatches:")
+		for _, mismatch := range r.Mismatches {
+			fmt.Fprintln(w, "  "+mismatch)
+		}
+	}
+	if r.Consistent() {
+		fmt.Fprintln(w, "\nAll nodes serve identical indices")
+	}
+}
+
+// short abbreviates a checksum for display
+func short(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
@@ -1,11 +1,297 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // Config holds the application configuration
 type Config struct {
 	Port      string
 	ModelPath string
 	DataDir   string
 	Debug     bool
+
+	// RerankCandidates is the number of quantized candidates re-scored at full precision
+	RerankCandidates int
+
+	// Int8Query quantizes queries for the quantized index's int32 dot-product
+	// scan instead of scoring float queries against int8 vectors
+	Int8Query bool
+
+	// BinaryIndex replaces the int8 index with a sign-bit index searched by
+	// Hamming distance, so re-ranked searches need 1/32 of the float memory
+	BinaryIndex bool
+
+	// Shards is how many shards a vector index search fans out across
+	Shards int
+
+	// RouteBooks enables centroid routing: unfiltered searches scan the
+	// RouteBooks books whose centroids are nearest the query in full, and
+	// the nearest RouteSample fraction of the other books' chapters
+	RouteBooks  int
+	RouteSample float64
+
+	// ShutdownTimeout bounds how long in-flight requests may drain on shutdown
+	ShutdownTimeout time.Duration
+
+	// AdminToken is the bearer token every /admin endpoint and the analytics
+	// report require; they are disabled without one
+	AdminToken string
+
+	// IndexNode is the URL of the index node a stateless API node ranks
+	// searches on, authenticating with IndexToken. Empty holds indices in
+	// process. IndexTimeout bounds each request to the index node.
+	IndexNode    string
+	IndexTimeout time.Duration
+
+	// IndexToken is the bearer token stateless API nodes send an index node
+	// to rank searches; an index node without one ranks for none
+	IndexToken string
+
+	// MaxSearchDuration bounds how long a request may spend embedding and
+	// scanning before its search is abandoned; zero leaves it unbounded
+	MaxSearchDuration time.Duration
+
+	// WaitForModel holds off serving until the embedding model is ready, so
+	// no query is answered with a fallback embedding while it loads
+	WaitForModel bool
+
+	// Offline reads remote artifacts from the copies the fetch command saved
+	// under DataDir, and never downloads the model or cross-references
+	Offline bool
+
+	// CrossRefsPath optionally points at a local cross-reference dataset
+	CrossRefsPath string
+
+	// WidgetKeysPath optionally points at the embeddable widget's keys and their origins
+	WidgetKeysPath string
+
+	// ONNXThreads sets the intra-op thread count for model inference
+	ONNXThreads int
+
+	// Deterministic pins thread counts and seeds and stamps responses with
+	// model and index fingerprints so results can be reproduced exactly
+	Deterministic bool
+	Seed          int64
+
+	// Cursor paging: idle lifetime, live cursor cap, and results ranked per cursor
+	CursorTTL        time.Duration
+	MaxCursors       int
+	CursorMaxResults int
+
+	// RateLimit is the per-client-IP request rate (requests/second) on search
+	// and embedding endpoints; zero disables rate limiting
+	RateLimit float64
+	RateBurst int
+
+	// TrustedProxies are the proxies whose X-Forwarded-For is believed when
+	// identifying a client. Without any, the client is the connection's peer.
+	TrustedProxies []*net.IPNet
+
+	// Limits holds per-granularity score floors and result caps
+	Limits map[string]GranularityLimits
+
+	// SearchCacheTTL is the edge cache lifetime advertised on GET /search
+	// responses; zero omits cache headers. CanonicalRedirect sends
+	// non-canonical GET /search URLs to their canonical form.
+	SearchCacheTTL    time.Duration
+	CanonicalRedirect bool
+
+	// Compress gzips responses larger than a kilobyte for clients that
+	// accept it
+	Compress bool
+
+	// Snapshots persists parsed indices to DataDir so restarts skip
+	// downloading and parsing the JSON artifacts
+	Snapshots bool
+
+	// DownloadCacheBytes bounds the parsed artifact downloads kept for
+	// retrying a failed load, by JSON size; DownloadCacheTTL expires them
+	DownloadCacheBytes int64
+	DownloadCacheTTL   time.Duration
+
+	// ResponseCacheBytes bounds the /search responses kept for repeated
+	// queries, by JSON size; ResponseCacheTTL expires them. Zero bytes
+	// disables the cache.
+	ResponseCacheBytes int64
+	ResponseCacheTTL   time.Duration
+
+	// QueryCacheSize is the number of query embeddings kept in the LRU cache;
+	// zero disables caching
+	QueryCacheSize int
+
+	// ShedQueueDepth is the embedding queue depth at which searches are
+	// answered from the query cache or lexically instead of waiting for
+	// inference; zero disables load shedding
+	ShedQueueDepth int
+
+	// EmbeddingProvider embeds queries and documents through an external
+	// HTTP API instead of in-process ONNX; nil uses ONNX
+	EmbeddingProvider *EmbeddingProvider
+
+	// InferenceSlots bounds concurrent ONNX inferences; waiting queries are
+	// admitted by QoS tier priority. Zero leaves inference unbounded.
+	InferenceSlots int
+
+	// Indices are named indices loaded alongside verse and chapter
+	Indices []IndexSource
+
+	// Preload names the indices loaded at startup; nil loads every
+	// configured one. The verse index is always loaded. With LazyLoad, any
+	// other index loads on its first search.
+	Preload  []string
+	LazyLoad bool
+
+	// Original sources the original-language granularity: Hebrew and Greek
+	// verse texts with their own embeddings. It is off without EmbeddingsURL.
+	Original IndexSource
+
+	// CorpusLicenses overrides, per granularity, the license and attribution
+	// declared in artifact headers
+	CorpusLicenses map[string]CorpusLicense
+
+	// Default privacy policy for requests without an API key or per-key settings
+	NoQueryLogging bool
+	HashQueries    bool
+}
+
+// GranularityLimits keeps small indices from padding results with weak matches
+type GranularityLimits struct {
+	// MinScore drops results whose cosine similarity falls below it
+	MinScore float32
+	// MaxK caps the number of results a single search may return; zero means no cap
+	MaxK int
+}
+
+// DefaultLimits are the limits applied when no flags override them. Chapters
+// have roughly 1,200 vectors, so a low-similarity tail is mostly noise.
+var DefaultLimits = map[string]GranularityLimits{
+	"verse":   {MinScore: 0, MaxK: 100},
+	"chapter": {MinScore: 0.3, MaxK: 25},
+}
+
+// LimitsFor returns the limits configured for a granularity. Named
+// indices without limits of their own get the verse limits.
+func (c *Config) LimitsFor(granularity string) GranularityLimits {
+	if limits, ok := c.Limits[granularity]; ok {
+		return limits
+	}
+	if limits, ok := DefaultLimits[granularity]; ok {
+		return limits
+	}
+	return c.LimitsFor("verse")
+}
+
+// Preloads reports whether an index is loaded at startup
+func (c *Config) Preloads(name string) bool {
+	if name == "verse" || c.Preload == nil {
+		return true
+	}
+	for _, preload := range c.Preload {
+		if preload == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseLimits builds per-granularity limits from "verse=0.2,chapter=0.35"
+// style floor and max-k specs, starting from DefaultLimits
+func ParseLimits(floors, maxK string) (map[string]GranularityLimits, error) {
+	limits := make(map[string]GranularityLimits, len(DefaultLimits))
+	for granularity, l := range DefaultLimits {
+		limits[granularity] = l
+	}
+
+	err := parseGranularityList(floors, func(granularity, value string) error {
+		floor, err := strconv.ParseFloat(value, 32)
+		if err != nil || floor < -1 || floor > 1 {
+			return fmt.Errorf("invalid score floor for %s: %s", granularity, value)
+		}
+		l := limitsOrVerse(limits, granularity)
+		l.MinScore = float32(floor)
+		limits[granularity] = l
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = parseGranularityList(maxK, func(granularity, value string) error {
+		k, err := strconv.Atoi(value)
+		if err != nil || k < 0 {
+			return fmt.Errorf("invalid max-k for %s: %s", granularity, value)
+		}
+		l := limitsOrVerse(limits, granularity)
+		l.MaxK = k
+		limits[granularity] = l
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return limits, nil
+}
+
+// ParseTrustedProxies reads a comma-separated list of proxy IPs and CIDR ranges
+func ParseTrustedProxies(spec string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+// limitsOrVerse returns a granularity's limits so far, starting a named index
+// from the verse defaults
+func limitsOrVerse(limits map[string]GranularityLimits, granularity string) GranularityLimits {
+	if l, ok := limits[granularity]; ok {
+		return l
+	}
+	return DefaultLimits["verse"]
+}
+
+// parseGranularityList calls fn for each "granularity=value" pair in spec
+func parseGranularityList(spec string, fn func(granularity, value string) error) error {
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		granularity, value, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("expected granularity=value, got %q", pair)
+		}
+		if err := fn(strings.TrimSpace(granularity), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
 // ArweaveURLs contains the URLs for pre-computed embeddings and text data
@@ -25,6 +311,9 @@ var ArweaveURLs = struct {
 	ChapterText:        "https://arweave.net/daKtqqHpLRnAWCNEWY8Q92NwSyJxWbm7WFDE3ut_BuM",
 }
 
+// CrossReferencesURL is the OpenBible.info export of the Treasury of Scripture Knowledge
+var CrossReferencesURL = "https://a.openbible.info/data/cross-references.zip"
+
 // ModelConfig contains model-specific configuration
 var ModelConfig = struct {
 	ModelID      string
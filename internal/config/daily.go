@@ -0,0 +1,33 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+// LoadDailyVerses reads a curated JSON array of references, such as
+// "Psalm 23:1" or "Lamentations 3:22-23", for the verse of the day
+func LoadDailyVerses(path string) ([]reference.Reference, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read daily verses: %w", err)
+	}
+	var refs []string
+	if err := json.Unmarshal(data, &refs); err != nil {
+		return nil, fmt.Errorf("failed to parse daily verses: %w", err)
+	}
+	if len(refs) == 0 {
+		return nil, fmt.Errorf("daily verses list is empty")
+	}
+
+	parsed := make([]reference.Reference, len(refs))
+	for i, ref := range refs {
+		if parsed[i], err = reference.Parse(ref); err != nil {
+			return nil, fmt.Errorf("daily verse %d: %w", i, err)
+		}
+	}
+	return parsed, nil
+}
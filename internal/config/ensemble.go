@@ -0,0 +1,57 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// EnsembleModel is a second embedding model with its own verse index. Queries
+// with ranking=ensemble search both models and fuse their rankings.
+type EnsembleModel struct {
+	ID           string `json:"id"`
+	ModelURL     string `json:"modelUrl"`          // ONNX graph: input_ids, attention_mask -> sentence_embedding
+	DataURL      string `json:"dataUrl,omitempty"` // External weights, if any
+	TokenizerURL string `json:"tokenizerUrl"`      // SentencePiece model
+	QueryPrefix  string `json:"queryPrefix,omitempty"`
+	OutputDims   int    `json:"outputDims"`           // Width of sentence_embedding
+	Dimensions   int    `json:"dimensions,omitempty"` // Matryoshka truncation, default OutputDims
+	VersesURL    string `json:"versesUrl"`            // Verse embeddings built with this model
+
+	// Reciprocal rank fusion weights for the primary and this model, default 1 each
+	PrimaryWeight float64 `json:"primaryWeight,omitempty"`
+	Weight        float64 `json:"weight,omitempty"`
+}
+
+// LoadEnsembleModel reads and validates an ensemble model description
+func LoadEnsembleModel(path string) (*EnsembleModel, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ensemble model: %w", err)
+	}
+	var model EnsembleModel
+	if err := json.Unmarshal(data, &model); err != nil {
+		return nil, fmt.Errorf("failed to parse ensemble model: %w", err)
+	}
+
+	switch {
+	case model.ID == "" || model.ModelURL == "" || model.TokenizerURL == "" || model.VersesURL == "":
+		return nil, fmt.Errorf("ensemble model needs id, modelUrl, tokenizerUrl and versesUrl")
+	case model.OutputDims <= 0:
+		return nil, fmt.Errorf("ensemble model needs a positive outputDims")
+	case model.Dimensions < 0 || model.Dimensions > model.OutputDims:
+		return nil, fmt.Errorf("ensemble model dimensions must be between 1 and outputDims")
+	case model.PrimaryWeight < 0 || model.Weight < 0:
+		return nil, fmt.Errorf("ensemble weights can't be negative")
+	}
+	if model.Dimensions == 0 {
+		model.Dimensions = model.OutputDims
+	}
+	if model.PrimaryWeight == 0 {
+		model.PrimaryWeight = 1
+	}
+	if model.Weight == 0 {
+		model.Weight = 1
+	}
+	return &model, nil
+}
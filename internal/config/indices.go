@@ -0,0 +1,40 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// IndexSource is a named index downloaded like the built-in verse and chapter
+// indices, from an embeddings artifact and a text file in the same formats
+type IndexSource struct {
+	Name          string `json:"name"`
+	EmbeddingsURL string `json:"embeddingsUrl"`
+	FallbackURL   string `json:"fallbackUrl,omitempty"` // Uncompressed embeddings, tried if EmbeddingsURL fails
+	TextURL       string `json:"textUrl"`
+}
+
+// LoadIndices reads a JSON array of named index sources
+func LoadIndices(path string) ([]IndexSource, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indices: %w", err)
+	}
+	var indices []IndexSource
+	if err := json.Unmarshal(data, &indices); err != nil {
+		return nil, fmt.Errorf("failed to parse indices: %w", err)
+	}
+
+	seen := make(map[string]bool, len(indices))
+	for _, index := range indices {
+		switch {
+		case index.Name == "" || index.EmbeddingsURL == "" || index.TextURL == "":
+			return nil, fmt.Errorf("index needs name, embeddingsUrl and textUrl")
+		case seen[index.Name]:
+			return nil, fmt.Errorf("index %s is defined twice", index.Name)
+		}
+		seen[index.Name] = true
+	}
+	return indices, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// CorpusLicense is the license and attribution a corpus's text must be
+// shown with, such as a translation's copyright line
+type CorpusLicense struct {
+	Title       string `json:"title,omitempty"`       // e.g. "World English Bible"
+	License     string `json:"license,omitempty"`     // SPDX identifier or name, e.g. "CC-BY-4.0"
+	LicenseURL  string `json:"licenseUrl,omitempty"`  // Full license text
+	Attribution string `json:"attribution,omitempty"` // Notice to display alongside quoted text
+	SourceURL   string `json:"sourceUrl,omitempty"`   // Where the text was obtained
+}
+
+// IsZero reports whether no field is set
+func (l CorpusLicense) IsZero() bool {
+	return l == CorpusLicense{}
+}
+
+// Merge returns l with every field set in override replaced
+func (l CorpusLicense) Merge(override CorpusLicense) CorpusLicense {
+	if override.Title != "" {
+		l.Title = override.Title
+	}
+	if override.License != "" {
+		l.License = override.License
+	}
+	if override.LicenseURL != "" {
+		l.LicenseURL = override.LicenseURL
+	}
+	if override.Attribution != "" {
+		l.Attribution = override.Attribution
+	}
+	if override.SourceURL != "" {
+		l.SourceURL = override.SourceURL
+	}
+	return l
+}
+
+// LoadCorpusLicenses reads a JSON object of corpus granularity -> license
+func LoadCorpusLicenses(path string) (map[string]CorpusLicense, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read corpus licenses: %w", err)
+	}
+	var licenses map[string]CorpusLicense
+	if err := json.Unmarshal(data, &licenses); err != nil {
+		return nil, fmt.Errorf("failed to parse corpus licenses: %w", err)
+	}
+	for granularity, license := range licenses {
+		if license.IsZero() {
+			return nil, fmt.Errorf("corpus license for %s is empty", granularity)
+		}
+	}
+	return licenses, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// IsRemote reports whether an artifact location is fetched over HTTP rather
+// than read from a local path
+func IsRemote(location string) bool {
+	return strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// LocalPath returns the file an artifact location names: a plain path, or a
+// file:// URL
+func LocalPath(location string) string {
+	return strings.TrimPrefix(location, "file://")
+}
+
+// ArtifactPath is where a data directory keeps its copy of a remote artifact
+// for offline use: under "artifacts", named by a hash of the URL and its
+// last path segment
+func ArtifactPath(dataDir, url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:6])
+	if base := path.Base(strings.SplitN(url, "?", 2)[0]); base != "." && base != "/" {
+		name += "-" + base
+	}
+	return filepath.Join(dataDir, "artifacts", name)
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Embedding provider APIs
+const (
+	ProviderOpenAI = "openai" // POST {model, input} to /v1/embeddings
+	ProviderOllama = "ollama" // POST {model, input} to /api/embed
+)
+
+// EmbeddingProvider is an external HTTP embedding API used instead of the
+// in-process ONNX model. It must serve the model the indices were built with.
+type EmbeddingProvider struct {
+	API       string `json:"api"`                 // ProviderOpenAI or ProviderOllama
+	URL       string `json:"url"`                 // Full endpoint URL, e.g. http://localhost:11434/api/embed
+	Model     string `json:"model"`               // Sent as the request's model
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"` // Environment variable holding a bearer token
+
+	// Prompts prepended to queries and documents, default EmbeddingGemma's
+	QueryPrefix    *string `json:"queryPrefix,omitempty"`
+	DocumentPrefix *string `json:"documentPrefix,omitempty"`
+
+	BatchSize int      `json:"batchSize,omitempty"` // Texts per request, default 32
+	Timeout   Duration `json:"timeout,omitempty"`   // Per request, default 30s
+}
+
+// LoadEmbeddingProvider reads and validates a remote embedding provider
+func LoadEmbeddingProvider(path string) (*EmbeddingProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read embedding provider: %w", err)
+	}
+	var provider EmbeddingProvider
+	if err := json.Unmarshal(data, &provider); err != nil {
+		return nil, fmt.Errorf("failed to parse embedding provider: %w", err)
+	}
+
+	switch {
+	case provider.API != ProviderOpenAI && provider.API != ProviderOllama:
+		return nil, fmt.Errorf("embedding provider api must be %s or %s", ProviderOpenAI, ProviderOllama)
+	case provider.URL == "" || provider.Model == "":
+		return nil, fmt.Errorf("embedding provider needs url and model")
+	case provider.BatchSize < 0:
+		return nil, fmt.Errorf("embedding provider batchSize can't be negative")
+	case provider.APIKeyEnv != "" && os.Getenv(provider.APIKeyEnv) == "":
+		return nil, fmt.Errorf("embedding provider API key variable %s is not set", provider.APIKeyEnv)
+	}
+	if provider.QueryPrefix == nil {
+		prefix := ModelConfig.QueryPrefix
+		provider.QueryPrefix = &prefix
+	}
+	if provider.DocumentPrefix == nil {
+		prefix := ModelConfig.DocumentPrefix
+		provider.DocumentPrefix = &prefix
+	}
+	if provider.BatchSize == 0 {
+		provider.BatchSize = 32
+	}
+	if provider.Timeout <= 0 {
+		provider.Timeout = Duration(30 * time.Second)
+	}
+	return &provider, nil
+}
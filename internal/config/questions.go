@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// QuestionsBackend is an OpenAI-compatible chat completions endpoint that
+// writes discussion questions for /questions
+type QuestionsBackend struct {
+	URL       string `json:"url"`                 // e.g. https://api.openai.com/v1/chat/completions
+	Model     string `json:"model"`               // Sent as the request's model
+	APIKeyEnv string `json:"apiKeyEnv,omitempty"` // Environment variable holding a bearer token
+	Count     int    `json:"count,omitempty"`     // Questions per passage, default 5
+	Prompt    string `json:"prompt,omitempty"`    // System prompt, default a study-group prompt
+
+	// Timeout bounds a single completion request, default 60s
+	Timeout Duration `json:"timeout,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "90s" in JSON
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"60s\"")
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// LoadQuestionsBackend reads and validates a question generation backend
+func LoadQuestionsBackend(path string) (*QuestionsBackend, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read questions backend: %w", err)
+	}
+	var backend QuestionsBackend
+	if err := json.Unmarshal(data, &backend); err != nil {
+		return nil, fmt.Errorf("failed to parse questions backend: %w", err)
+	}
+
+	switch {
+	case backend.URL == "" || backend.Model == "":
+		return nil, fmt.Errorf("questions backend needs url and model")
+	case backend.Count < 0 || backend.Count > 20:
+		return nil, fmt.Errorf("questions backend count must be between 1 and 20")
+	case backend.APIKeyEnv != "" && os.Getenv(backend.APIKeyEnv) == "":
+		return nil, fmt.Errorf("questions backend API key variable %s is not set", backend.APIKeyEnv)
+	}
+	if backend.Count == 0 {
+		backend.Count = 5
+	}
+	if backend.Timeout <= 0 {
+		backend.Timeout = Duration(60 * time.Second)
+	}
+	return &backend, nil
+}
@@ -0,0 +1,259 @@
+// Package crossrefs loads a verse cross-reference dataset (the OpenBible.info
+// export of the Treasury of Scripture Knowledge) and answers lookups by reference.
+package crossrefs
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/rs/zerolog/log"
+)
+
+// CrossReference links a source verse to a related passage
+type CrossReference struct {
+	From  reference.Reference
+	To    reference.Reference
+	Votes int
+}
+
+// Service holds cross-references indexed by source chapter and verse
+type Service struct {
+	config  *config.Config
+	entries map[string]map[int][]CrossReference // "book:chapter" -> verse -> references
+	count   int
+	loaded  bool
+	mu      sync.RWMutex
+}
+
+// NewService creates a new cross-reference service
+func NewService(cfg *config.Config) *Service {
+	return &Service{
+		config:  cfg,
+		entries: make(map[string]map[int][]CrossReference),
+	}
+}
+
+// Load reads the dataset from the configured path, downloading it into the
+// data directory on first use
+func (s *Service) Load() error {
+	path := s.config.CrossRefsPath
+	if path == "" {
+		path = filepath.Join(s.config.DataDir, "crossrefs", "cross_references.txt")
+		if _, err := os.Stat(path); err != nil {
+			if s.config.Offline {
+				return fmt.Errorf("%s is missing, and offline mode doesn't download it", path)
+			}
+			if err := download(config.CrossReferencesURL, path); err != nil {
+				return fmt.Errorf("failed to download cross-references: %w", err)
+			}
+		}
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open cross-references: %w", err)
+	}
+	defer file.Close()
+
+	entries, count, err := parse(file)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.count = count
+	s.loaded = true
+	s.mu.Unlock()
+
+	log.Info().Int("crossReferences", count).Msg("Cross-references loaded successfully")
+	return nil
+}
+
+// Loaded reports whether the dataset is ready
+func (s *Service) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
+
+// Count returns the number of loaded cross-references
+func (s *Service) Count() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.count
+}
+
+// Lookup returns the cross-references for every verse covered by ref,
+// strongest (most voted) first
+func (s *Service) Lookup(ref reference.Reference) ([]CrossReference, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loaded {
+		return nil, fmt.Errorf("cross-references not loaded")
+	}
+
+	var results []CrossReference
+	for chapter := ref.StartChapter; chapter <= ref.EndChapter; chapter++ {
+		for verse, refs := range s.entries[chapterKey(ref.Book, chapter)] {
+			if ref.Contains(chapter, verse) {
+				results = append(results, refs...)
+			}
+		}
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Votes != results[j].Votes {
+			return results[i].Votes > results[j].Votes
+		}
+		return results[i].To.String() < results[j].To.String()
+	})
+
+	return results, nil
+}
+
+// parse reads the tab-separated "From Verse, To Verse, Votes" format, where
+// references look like "Gen.1.1" and ranges like "Prov.8.22-Prov.8.30"
+func parse(r io.Reader) (map[string]map[int][]CrossReference, int, error) {
+	entries := make(map[string]map[int][]CrossReference)
+	count := 0
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "From Verse") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 {
+			continue
+		}
+
+		from, err := parseRef(fields[0])
+		if err != nil {
+			log.Debug().Err(err).Str("ref", fields[0]).Msg("Skipping unparseable cross-reference source")
+			continue
+		}
+		to, err := parseRef(fields[1])
+		if err != nil {
+			log.Debug().Err(err).Str("ref", fields[1]).Msg("Skipping unparseable cross-reference target")
+			continue
+		}
+
+		votes := 0
+		if len(fields) > 2 {
+			votes, _ = strconv.Atoi(strings.TrimSpace(fields[2]))
+		}
+
+		key := chapterKey(from.Book, from.StartChapter)
+		if entries[key] == nil {
+			entries[key] = make(map[int][]CrossReference)
+		}
+		entries[key][from.StartVerse] = append(entries[key][from.StartVerse], CrossReference{
+			From:  from,
+			To:    to,
+			Votes: votes,
+		})
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, 0, fmt.Errorf("failed to read cross-references: %w", err)
+	}
+
+	return entries, count, nil
+}
+
+// parseRef parses a dotted reference, joining "A-B" ranges that repeat the book
+func parseRef(s string) (reference.Reference, error) {
+	start, end, isRange := strings.Cut(strings.TrimSpace(s), "-")
+
+	from, err := reference.Parse(start)
+	if err != nil {
+		return reference.Reference{}, err
+	}
+	if !isRange {
+		return from, nil
+	}
+
+	to, err := reference.Parse(end)
+	if err != nil {
+		return reference.Reference{}, err
+	}
+
+	from.EndChapter = to.EndChapter
+	from.EndVerse = to.EndVerse
+	return from, nil
+}
+
+// chapterKey builds the lookup key for a book and chapter
+func chapterKey(book string, chapter int) string {
+	return fmt.Sprintf("%s:%d", strings.ToLower(book), chapter)
+}
+
+// download fetches the dataset, unpacking it if it arrives as a zip archive
+func download(url, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	client := &http.Client{
+		Timeout: 60 * time.Second,
+	}
+
+	log.Info().Str("url", url).Str("path", path).Msg("Downloading cross-references...")
+	resp, err := client.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	// Check zip magic number
+	if len(body) >= 4 && bytes.Equal(body[:4], []byte("PK\x03\x04")) {
+		archive, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+		if err != nil {
+			return err
+		}
+		for _, f := range archive.File {
+			if !strings.HasSuffix(f.Name, ".txt") {
+				continue
+			}
+			rc, err := f.Open()
+			if err != nil {
+				return err
+			}
+			body, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	return os.WriteFile(path, body, 0644)
+}
@@ -0,0 +1,141 @@
+// Package cursor keeps ranked result sets server-side so clients can page
+// through large result sets with an opaque token instead of huge k values.
+package cursor
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/format"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// ErrNotFound is returned for unknown or expired cursor tokens
+var ErrNotFound = errors.New("cursor not found or expired")
+
+// Cursor is a ranked result set being paged through
+type Cursor struct {
+	Query    string
+	Options  search.SearchOptions
+	Format   format.Options
+	Fields   []string // Result fields to return, nil for all
+	Results  []search.SearchResult
+	PageSize int
+
+	offset  int
+	expires time.Time
+}
+
+// Page is one page of a cursor's results
+type Page struct {
+	Results []search.SearchResult
+	Offset  int
+	Total   int
+	Next    string // Token for the following page, empty when exhausted
+}
+
+// Store holds cursors until they expire
+type Store struct {
+	ttl        time.Duration
+	maxCursors int
+	cursors    map[string]*Cursor
+	mu         sync.Mutex
+}
+
+// NewStore creates a cursor store. Cursors expire ttl after their last use,
+// and at most maxCursors are kept at once.
+func NewStore(ttl time.Duration, maxCursors int) *Store {
+	return &Store{
+		ttl:        ttl,
+		maxCursors: maxCursors,
+		cursors:    make(map[string]*Cursor),
+	}
+}
+
+// Start stores a cursor and returns its first page
+func (s *Store) Start(c *Cursor) (Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.evictLocked()
+	return s.advanceLocked(c)
+}
+
+// Next returns the page following the one the token was issued for
+func (s *Store) Next(token string) (*Cursor, Page, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, ok := s.cursors[token]
+	if !ok || time.Now().After(c.expires) {
+		delete(s.cursors, token)
+		return nil, Page{}, ErrNotFound
+	}
+
+	// Tokens are single-use: each page issues a fresh one
+	delete(s.cursors, token)
+	page, err := s.advanceLocked(c)
+	return c, page, err
+}
+
+// Len returns the number of live cursors
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cursors)
+}
+
+// advanceLocked slices the next page and re-registers the cursor if results remain
+func (s *Store) advanceLocked(c *Cursor) (Page, error) {
+	end := min(c.offset+c.PageSize, len(c.Results))
+	page := Page{
+		Results: c.Results[c.offset:end],
+		Offset:  c.offset,
+		Total:   len(c.Results),
+	}
+	c.offset = end
+
+	if c.offset < len(c.Results) {
+		token, err := newToken()
+		if err != nil {
+			return Page{}, err
+		}
+		c.expires = time.Now().Add(s.ttl)
+		s.cursors[token] = c
+		page.Next = token
+	}
+
+	return page, nil
+}
+
+// evictLocked drops expired cursors, then the soonest-expiring ones while over capacity
+func (s *Store) evictLocked() {
+	now := time.Now()
+	for token, c := range s.cursors {
+		if now.After(c.expires) {
+			delete(s.cursors, token)
+		}
+	}
+
+	for len(s.cursors) >= s.maxCursors && len(s.cursors) > 0 {
+		var oldest string
+		for token, c := range s.cursors {
+			if oldest == "" || c.expires.Before(s.cursors[oldest].expires) {
+				oldest = token
+			}
+		}
+		delete(s.cursors, oldest)
+	}
+}
+
+// newToken returns a random opaque cursor token
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,88 @@
+// Package deprecation tracks API surfaces scheduled for removal: which routes
+// and parameters are deprecated, when they stop working, and how often
+// clients still use them.
+package deprecation
+
+import (
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Surface is a deprecated route, or a deprecated query parameter of a route
+type Surface struct {
+	ID          string    `json:"id"`
+	Method      string    `json:"method"`
+	Path        string    `json:"path"`            // Route path as registered, e.g. /books/:book/chapters
+	Param       string    `json:"param,omitempty"` // Only requests sending this query parameter
+	Replacement string    `json:"replacement"`     // What clients should use instead
+	Since       time.Time `json:"since"`
+	Sunset      time.Time `json:"sunset"` // When the surface may be removed
+}
+
+// Usage is a surface with how often it has been used since startup
+type Usage struct {
+	Surface
+	Count    int64      `json:"count"`
+	LastUsed *time.Time `json:"lastUsed,omitempty"`
+}
+
+// Registry matches requests against deprecated surfaces and counts their use
+type Registry struct {
+	surfaces []Surface
+
+	mu       sync.Mutex
+	counts   []int64
+	lastUsed []time.Time
+}
+
+// NewRegistry creates a registry of surfaces
+func NewRegistry(surfaces ...Surface) *Registry {
+	return &Registry{
+		surfaces: surfaces,
+		counts:   make([]int64, len(surfaces)),
+		lastUsed: make([]time.Time, len(surfaces)),
+	}
+}
+
+// Observe returns the deprecated surfaces a request uses, counting each use
+func (r *Registry) Observe(method, path string, query url.Values) []Surface {
+	if r == nil {
+		return nil
+	}
+	var used []Surface
+	for i, surface := range r.surfaces {
+		if surface.Method != method || surface.Path != path {
+			continue
+		}
+		if surface.Param != "" && !query.Has(surface.Param) {
+			continue
+		}
+		used = append(used, surface)
+
+		r.mu.Lock()
+		r.counts[i]++
+		r.lastUsed[i] = time.Now().UTC()
+		r.mu.Unlock()
+	}
+	return used
+}
+
+// Usage returns every surface with its usage counts, in registration order
+func (r *Registry) Usage() []Usage {
+	if r == nil {
+		return []Usage{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	usage := make([]Usage, len(r.surfaces))
+	for i, surface := range r.surfaces {
+		usage[i] = Usage{Surface: surface, Count: r.counts[i]}
+		if r.counts[i] > 0 {
+			last := r.lastUsed[i]
+			usage[i].LastUsed = &last
+		}
+	}
+	return usage
+}
@@ -2,8 +2,10 @@ package embeddings
 
 import (
 	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"math"
@@ -11,8 +13,11 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/qos"
 	"github.com/rs/zerolog/log"
 )
 
@@ -22,31 +27,46 @@ type EmbeddingService struct {
 	modelLoaded     bool
 	mu              sync.RWMutex
 	realOnnxService *RealONNXEmbeddingService
+	remote          *RemoteEmbeddingService // Replaces ONNX when an embedding provider is configured
 	simpleService   *SimpleEmbeddingService
 	usePrecomputed  bool
+	queries         *queryCache
+	inflight        atomic.Int64 // Model inferences running or waiting for the model
+	scheduler       *scheduler   // Admits waiting inferences by QoS priority; nil when unbounded
+	modelErr        error        // Why the ONNX model failed to initialize
+	served          queryCounts  // Queries embedded per backend
 }
 
 // NewEmbeddingService creates a new embedding service
 func NewEmbeddingService(cfg *config.Config) (*EmbeddingService, error) {
+	if cfg.EmbeddingProvider != nil {
+		return newRemoteEmbeddingService(cfg)
+	}
+
 	// Try real ONNX implementation first
 	realOnnxService, err := NewRealONNXEmbeddingService(cfg)
 	if err == nil {
+		// Return service that can use ONNX when ready
+		service := &EmbeddingService{
+			config:         cfg,
+			realOnnxService: realOnnxService,
+			usePrecomputed: false,
+			queries:        newQueryCache(cfg.QueryCacheSize),
+			scheduler:      newScheduler(cfg.InferenceSlots),
+		}
+
 		// Try to initialize in background (don't block startup)
 		go func() {
 			if initErr := realOnnxService.Initialize(); initErr == nil {
 				log.Info().Msg("Real ONNX EmbeddingGemma model initialized in background")
 			} else {
 				log.Warn().Err(initErr).Msg("Failed to initialize real ONNX model")
+				service.mu.Lock()
+				service.modelErr = initErr
+				service.mu.Unlock()
 			}
 		}()
 		
-		// Return service that can use ONNX when ready
-		service := &EmbeddingService{
-			config:         cfg,
-			realOnnxService: realOnnxService,
-			usePrecomputed: false,
-		}
-		
 		// Also initialize simple service as fallback
 		simpleService, simpleErr := NewSimpleEmbeddingService(cfg)
 		if simpleErr == nil {
@@ -74,6 +94,8 @@ func NewEmbeddingService(cfg *config.Config) (*EmbeddingService, error) {
 		config:        cfg,
 		simpleService: simpleService,
 		usePrecomputed: true,
+		queries:       newQueryCache(cfg.QueryCacheSize),
+		scheduler:     newScheduler(cfg.InferenceSlots),
 	}
 
 	// Create data directory if it doesn't exist
@@ -85,32 +107,232 @@ func NewEmbeddingService(cfg *config.Config) (*EmbeddingService, error) {
 	return service, nil
 }
 
-// EmbedQuery generates embeddings for a search query
-func (s *EmbeddingService) EmbedQuery(text string) ([]float32, error) {
-	// Try real ONNX service first if available and initialized
-	if s.realOnnxService != nil {
-		if embedding, err := s.realOnnxService.EmbedQuery(text); err == nil {
-			return embedding, nil
+// NewFallbackEmbeddingService creates a service without a model, for tools
+// that load indices but never embed queries with the model
+func NewFallbackEmbeddingService(cfg *config.Config) (*EmbeddingService, error) {
+	simpleService, err := NewSimpleEmbeddingService(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create simple embedding service: %w", err)
+	}
+	return &EmbeddingService{
+		config:         cfg,
+		simpleService:  simpleService,
+		usePrecomputed: true,
+		queries:        newQueryCache(cfg.QueryCacheSize),
+		scheduler:      newScheduler(cfg.InferenceSlots),
+	}, nil
+}
+
+// newRemoteEmbeddingService creates a service backed by an embedding
+// provider, never touching ONNX Runtime
+func newRemoteEmbeddingService(cfg *config.Config) (*EmbeddingService, error) {
+	if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	remote := NewRemoteEmbeddingService(cfg.EmbeddingProvider, config.ModelConfig.Dimensions)
+	service := &EmbeddingService{
+		config:    cfg,
+		remote:    remote,
+		queries:   newQueryCache(cfg.QueryCacheSize),
+		scheduler: newScheduler(cfg.InferenceSlots),
+	}
+	if simpleService, err := NewSimpleEmbeddingService(cfg); err == nil {
+		service.simpleService = simpleService
+	}
+
+	// Check the provider in the background, like ONNX initialization
+	go func() {
+		if err := remote.Probe(context.Background()); err != nil {
+			log.Warn().Err(err).Str("url", cfg.EmbeddingProvider.URL).Msg("Embedding provider unavailable; queries use the fallback until it answers")
+		}
+	}()
+
+	log.Info().Str("api", cfg.EmbeddingProvider.API).Str("model", cfg.EmbeddingProvider.Model).Msg("EmbeddingService initialized (remote provider + fallback)")
+	return service, nil
+}
+
+// hasModel reports whether queries can reach a model, ONNX or remote
+func (s *EmbeddingService) hasModel() bool {
+	return s.realOnnxService != nil || s.remote != nil
+}
+
+// modelQueries embeds queries with the remote provider or the ONNX model.
+// Queries whose caller has gone by the time a slot frees up aren't run.
+func (s *EmbeddingService) modelQueries(ctx context.Context, texts []string) ([][]float32, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if s.remote != nil {
+		return s.remote.EmbedQueries(ctx, texts)
+	}
+	return s.realOnnxService.EmbedQueries(ctx, texts)
+}
+
+// backendName names the model backend in logs
+func (s *EmbeddingService) backendName() string {
+	if s.remote != nil {
+		return BackendRemote
+	}
+	return BackendONNX
+}
+
+// EmbedQuery generates embeddings for a search query. Log lines carry the
+// request logger from ctx.
+func (s *EmbeddingService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	start := time.Now()
+
+	// Repeated queries skip inference
+	key := s.queryKey(text)
+	if embedding, ok := s.queries.get(key); ok {
+		log.Ctx(ctx).Debug().Msg("Query embedding served from cache")
+		s.report(ctx, s.backendName(), true, start)
+		return embedding, nil
+	}
+
+	// Try the model first if available and initialized
+	if s.hasModel() {
+		s.inflight.Add(1)
+		if err := s.scheduler.acquire(ctx, qos.FromContext(ctx).Priority); err != nil {
+			s.inflight.Add(-1)
+			return nil, err
+		}
+		embeddings, err := s.modelQueries(ctx, []string{text})
+		s.scheduler.release()
+		s.inflight.Add(-1)
+		if err == nil {
+			log.Ctx(ctx).Debug().Str("backend", s.backendName()).Dur("took", time.Since(start)).Msg("Query embedded with model")
+			s.queries.put(key, embeddings[0])
+			s.report(ctx, s.backendName(), false, start)
+			return embeddings[0], nil
+		} else if ctx.Err() != nil {
+			// A fallback embedding is no use to a caller that has gone
+			return nil, ctx.Err()
 		} else {
-			log.Debug().Err(err).Msg("Real ONNX service failed, falling back")
+			log.Ctx(ctx).Debug().Str("backend", s.backendName()).Err(err).Msg("Model embedding failed, falling back")
 		}
 	}
-	
-	
+
+	if modelRequired(ctx) {
+		return nil, ErrModelRequired
+	}
+
 	// Try simple service
 	if s.simpleService != nil {
 		if embedding, err := s.simpleService.EmbedQuery(text); err == nil {
+			log.Ctx(ctx).Debug().Msg("Query embedded with simple fallback")
+			s.report(ctx, BackendSimple, false, start)
 			return embedding, nil
 		}
 	}
 	
 	// Final fallback to placeholder embedding
+	log.Ctx(ctx).Debug().Msg("Query embedded with placeholder fallback")
+	s.report(ctx, BackendPlaceholder, false, start)
 	return s.generatePlaceholderEmbedding(config.ModelConfig.QueryPrefix + text), nil
 }
 
+// EmbedQueries generates embeddings for several search queries, using a
+// single batched ONNX inference when the model is available
+func (s *EmbeddingService) EmbedQueries(ctx context.Context, texts []string) ([][]float32, error) {
+	start := time.Now()
+
+	// Only queries missing from the cache go through inference
+	embeddings := make([][]float32, len(texts))
+	var missing []int
+	for i, text := range texts {
+		if embedding, ok := s.queries.get(s.queryKey(text)); ok {
+			embeddings[i] = embedding
+			s.report(ctx, s.backendName(), true, start)
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return embeddings, nil
+	}
+
+	if s.hasModel() {
+		batch := make([]string, len(missing))
+		for j, i := range missing {
+			batch[j] = texts[i]
+		}
+		s.inflight.Add(int64(len(batch)))
+		if err := s.scheduler.acquire(ctx, qos.FromContext(ctx).Priority); err != nil {
+			s.inflight.Add(-int64(len(batch)))
+			return nil, err
+		}
+		computed, err := s.modelQueries(ctx, batch)
+		s.scheduler.release()
+		s.inflight.Add(-int64(len(batch)))
+		if err == nil {
+			log.Ctx(ctx).Debug().Str("backend", s.backendName()).Int("queries", len(batch)).Int("cached", len(texts)-len(batch)).
+				Dur("took", time.Since(start)).Msg("Queries embedded with model")
+			for j, i := range missing {
+				embeddings[i] = computed[j]
+				s.queries.put(s.queryKey(texts[i]), computed[j])
+				s.report(ctx, s.backendName(), false, start)
+			}
+			return embeddings, nil
+		} else if ctx.Err() != nil {
+			return nil, ctx.Err()
+		} else {
+			log.Ctx(ctx).Debug().Str("backend", s.backendName()).Err(err).Msg("Batch embedding failed, falling back")
+		}
+	}
+
+	// Fall back to embedding each query individually
+	for _, i := range missing {
+		embedding, err := s.EmbedQuery(ctx, texts[i])
+		if err != nil {
+			return nil, err
+		}
+		embeddings[i] = embedding
+	}
+	return embeddings, nil
+}
+
+// QueueDepth returns the number of queries currently in or waiting for ONNX inference
+func (s *EmbeddingService) QueueDepth() int {
+	return int(s.inflight.Load())
+}
+
+// CachedQuery returns a query's embedding if it's cached, without running inference
+func (s *EmbeddingService) CachedQuery(ctx context.Context, text string) ([]float32, bool) {
+	start := time.Now()
+	embedding, ok := s.queries.get(s.queryKey(text))
+	if ok {
+		s.report(ctx, s.backendName(), true, start)
+	}
+	return embedding, ok
+}
+
+// QueryCacheStats reports hit and miss counts for the query embedding cache
+func (s *EmbeddingService) QueryCacheStats() CacheStats {
+	return s.queries.stats()
+}
+
+// queryKey builds the cache key for a query. Only model embeddings are
+// cached; fallback embeddings are cheap and would outlive the model loading.
+func (s *EmbeddingService) queryKey(text string) queryKey {
+	if s.remote != nil {
+		return queryKey{query: text, prefix: *s.config.EmbeddingProvider.QueryPrefix, model: s.remote.Model()}
+	}
+	return queryKey{
+		query:  text,
+		prefix: config.ModelConfig.QueryPrefix,
+		model:  config.ModelConfig.ModelID,
+	}
+}
+
 // EmbedDocument generates embeddings for a document
 func (s *EmbeddingService) EmbedDocument(text string) ([]float32, error) {
-	// Try real ONNX service first if available and initialized
+	// Try the model first if available and initialized
+	if s.remote != nil {
+		if embedding, err := s.remote.EmbedDocument(context.Background(), text); err == nil {
+			return embedding, nil
+		}
+	}
 	if s.realOnnxService != nil {
 		if embedding, err := s.realOnnxService.EmbedDocument(text); err == nil {
 			return embedding, nil
@@ -129,6 +351,70 @@ func (s *EmbeddingService) EmbedDocument(text string) ([]float32, error) {
 	return s.generatePlaceholderEmbedding(config.ModelConfig.DocumentPrefix + text), nil
 }
 
+// documentBatchSize is the number of documents EmbedDocuments embeds per
+// scheduler slot, so queries waiting behind an ingestion aren't starved
+const documentBatchSize = 32
+
+// ingestPriority ranks document batches below every QoS tier's queries
+const ingestPriority = math.MinInt
+
+// ErrModelNotReady reports that documents can't be embedded because no model
+// is serving; unlike queries, documents never fall back to placeholders
+var ErrModelNotReady = errors.New("embedding model is not ready")
+
+// EmbedDocuments embeds documents with the model in batches, for building an
+// index. Each batch waits for an inference slot behind any waiting queries.
+func (s *EmbeddingService) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	if !s.ModelReady() {
+		return nil, ErrModelNotReady
+	}
+
+	embeddings := make([][]float32, 0, len(texts))
+	for start := 0; start < len(texts); start += documentBatchSize {
+		batch := texts[start:min(start+documentBatchSize, len(texts))]
+		if err := s.scheduler.acquire(ctx, ingestPriority); err != nil {
+			return nil, err
+		}
+		var computed [][]float32
+		var err error
+		if s.remote != nil {
+			computed, err = s.remote.EmbedDocuments(ctx, batch)
+		} else {
+			computed, err = s.realOnnxService.EmbedDocuments(ctx, batch)
+		}
+		s.scheduler.release()
+		if err != nil {
+			return nil, err
+		}
+		embeddings = append(embeddings, computed...)
+	}
+	return embeddings, nil
+}
+
+// WaitReady blocks until the model is serving, fails to initialize, or ctx ends
+func (s *EmbeddingService) WaitReady(ctx context.Context) error {
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+	for !s.ModelReady() {
+		if err := s.ModelError(); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// ModelError returns why the ONNX model failed to initialize, if it did
+func (s *EmbeddingService) ModelError() error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.modelErr
+}
+
 // InitializeWithPrecomputedData initializes the simple service with loaded embeddings
 func (s *EmbeddingService) InitializeWithPrecomputedData(embeddings map[string][]float32, texts map[string]string) {
 	if s.simpleService != nil {
@@ -136,6 +422,61 @@ func (s *EmbeddingService) InitializeWithPrecomputedData(embeddings map[string][
 	}
 }
 
+// ModelHash returns the fingerprint of the loaded ONNX model, or "" if the
+// model isn't ready or determinism mode is off
+func (s *EmbeddingService) ModelHash() string {
+	if s.realOnnxService != nil {
+		return s.realOnnxService.ModelHash()
+	}
+	return ""
+}
+
+// ModelReady reports whether queries are embedded by the model rather than a fallback
+func (s *EmbeddingService) ModelReady() bool {
+	if s.remote != nil {
+		return s.remote.Ready()
+	}
+	return s.realOnnxService != nil && s.realOnnxService.Ready()
+}
+
+// Embedding backends
+const (
+	BackendONNX   = "onnx"   // In-process ONNX Runtime
+	BackendRemote = "remote" // External embedding provider
+
+	// Fallbacks for queries while no model can answer
+	BackendSimple      = "simple"      // Mean of the verse embeddings whose text matches the query
+	BackendPlaceholder = "placeholder" // Deterministic pseudo-random embedding
+)
+
+// BackendStatus describes where query embeddings come from
+type BackendStatus struct {
+	Backend string `json:"backend"`
+	API     string `json:"api,omitempty"` // Provider API, for the remote backend
+	Model   string `json:"model"`
+	Ready   bool   `json:"ready"` // False while queries use a fallback embedding
+	Active  string `json:"active,omitempty"` // Backend that embedded the latest query, possibly a fallback
+	Queries map[string]int64 `json:"queries"` // Queries embedded per backend since startup
+}
+
+// Backend reports the embedding backend and whether it is serving queries
+func (s *EmbeddingService) Backend() BackendStatus {
+	if s.remote != nil {
+		return BackendStatus{Backend: BackendRemote, API: s.config.EmbeddingProvider.API, Model: s.remote.Model(), Ready: s.ModelReady(),
+			Active: s.served.latest(), Queries: s.served.counts()}
+	}
+	return BackendStatus{Backend: BackendONNX, Model: config.ModelConfig.ModelID, Ready: s.ModelReady(),
+		Active: s.served.latest(), Queries: s.served.counts()}
+}
+
+// Close releases the ONNX session and runtime if they were initialized
+func (s *EmbeddingService) Close() error {
+	if s.realOnnxService != nil {
+		return s.realOnnxService.Close()
+	}
+	return nil
+}
+
 // generatePlaceholderEmbedding creates a deterministic placeholder embedding
 // In production, this would be replaced with actual model inference
 func (s *EmbeddingService) generatePlaceholderEmbedding(text string) []float32 {
@@ -0,0 +1,64 @@
+package embeddings
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// ModelEmbedder embeds queries with a single additional ONNX model. Unlike
+// EmbeddingService it has no fallbacks: until the model is ready, queries fail.
+type ModelEmbedder struct {
+	spec    ModelSpec
+	onnx    *RealONNXEmbeddingService
+	queries *queryCache
+}
+
+// NewModelEmbedder creates an embedder for a model and starts downloading and
+// loading it in the background
+func NewModelEmbedder(cfg *config.Config, spec ModelSpec) *ModelEmbedder {
+	m := &ModelEmbedder{
+		spec:    spec,
+		onnx:    newONNXService(cfg, spec),
+		queries: newQueryCache(cfg.QueryCacheSize),
+	}
+	go func() {
+		if err := m.onnx.Initialize(); err != nil {
+			log.Warn().Err(err).Str("model", spec.ID).Msg("Failed to initialize ONNX model")
+		}
+	}()
+	return m
+}
+
+// ID returns the model's identifier
+func (m *ModelEmbedder) ID() string {
+	return m.spec.ID
+}
+
+// EmbedQuery embeds a search query with the model
+func (m *ModelEmbedder) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	key := queryKey{query: text, prefix: m.spec.QueryPrefix, model: m.spec.ID}
+	if embedding, ok := m.queries.get(key); ok {
+		return embedding, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	embedding, err := m.onnx.EmbedQuery(text)
+	if err != nil {
+		return nil, fmt.Errorf("model %s: %w", m.spec.ID, err)
+	}
+	log.Ctx(ctx).Debug().Str("model", m.spec.ID).Dur("took", time.Since(start)).Msg("Query embedded with ONNX")
+	m.queries.put(key, embedding)
+	return embedding, nil
+}
+
+// Close releases the model's ONNX session
+func (m *ModelEmbedder) Close() error {
+	return m.onnx.Close()
+}
@@ -0,0 +1,107 @@
+package embeddings
+
+import (
+	"container/list"
+	"sync"
+)
+
+// queryKey identifies a query embedding: the same text embeds differently
+// under another task prefix or model
+type queryKey struct {
+	query  string
+	prefix string
+	model  string
+}
+
+type queryEntry struct {
+	key       queryKey
+	embedding []float32
+}
+
+// CacheStats reports query cache effectiveness
+type CacheStats struct {
+	Capacity  int     `json:"capacity"`
+	Size      int     `json:"size"`
+	Hits      uint64  `json:"hits"`
+	Misses    uint64  `json:"misses"`
+	Evictions uint64  `json:"evictions"`
+	HitRate   float64 `json:"hitRate"`
+}
+
+// queryCache is a fixed-capacity LRU of model query embeddings, so repeated
+// and popular queries skip inference. A zero capacity disables it.
+type queryCache struct {
+	capacity int
+	mu       sync.Mutex
+	order    *list.List // front is most recently used
+	entries  map[queryKey]*list.Element
+
+	hits, misses, evictions uint64
+}
+
+func newQueryCache(capacity int) *queryCache {
+	return &queryCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[queryKey]*list.Element),
+	}
+}
+
+// get returns a copy of a cached embedding so callers may modify it
+func (c *queryCache) get(key queryKey) ([]float32, bool) {
+	if c.capacity <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return append([]float32(nil), elem.Value.(*queryEntry).embedding...), true
+}
+
+// put stores a copy of an embedding, evicting the least recently used entry
+// when full
+func (c *queryCache) put(key queryKey, embedding []float32) {
+	if c.capacity <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stored := append([]float32(nil), embedding...)
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*queryEntry).embedding = stored
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&queryEntry{key: key, embedding: stored})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*queryEntry).key)
+		c.evictions++
+	}
+}
+
+func (c *queryCache) stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := CacheStats{
+		Capacity:  c.capacity,
+		Size:      c.order.Len(),
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
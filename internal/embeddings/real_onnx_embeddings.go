@@ -1,6 +1,9 @@
 package embeddings
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,24 +17,88 @@ import (
 	ort "github.com/yalue/onnxruntime_go"
 )
 
+// ModelSpec describes an ONNX sentence embedding model: a graph taking
+// input_ids and attention_mask and returning sentence_embedding, with a
+// SentencePiece tokenizer
+type ModelSpec struct {
+	ID             string
+	Dir            string // Directory under DataDir holding the downloaded files
+	ModelURL       string
+	DataURL        string // External weights, if the graph has them
+	TokenizerURL   string
+	QueryPrefix    string
+	DocumentPrefix string
+	OutputDims     int // Width of sentence_embedding
+	Dimensions     int // Matryoshka truncation served to callers
+}
+
+// DefaultModelSpec is EmbeddingGemma, the primary model
+func DefaultModelSpec() ModelSpec {
+	const base = "https://huggingface.co/onnx-community/embeddinggemma-300m-ONNX/resolve/main/"
+	return ModelSpec{
+		ID:             config.ModelConfig.ModelID,
+		Dir:            "models",
+		ModelURL:       base + "onnx/model.onnx",
+		DataURL:        base + "onnx/model.onnx_data",
+		TokenizerURL:   base + "tokenizer.model", // Use EmbeddingGemma's own tokenizer
+		QueryPrefix:    config.ModelConfig.QueryPrefix,
+		DocumentPrefix: config.ModelConfig.DocumentPrefix,
+		OutputDims:     modelDimensions,
+		Dimensions:     config.ModelConfig.Dimensions,
+	}
+}
+
+// ortEnv reference-counts the process-wide ONNX Runtime environment, which
+// several model sessions share
+var ortEnv struct {
+	mu    sync.Mutex
+	users int
+}
+
+func acquireEnvironment() error {
+	ortEnv.mu.Lock()
+	defer ortEnv.mu.Unlock()
+	if ortEnv.users == 0 {
+		if err := ort.InitializeEnvironment(); err != nil {
+			return err
+		}
+	}
+	ortEnv.users++
+	return nil
+}
+
+func releaseEnvironment() {
+	ortEnv.mu.Lock()
+	defer ortEnv.mu.Unlock()
+	if ortEnv.users--; ortEnv.users == 0 {
+		ort.DestroyEnvironment()
+	}
+}
+
 // RealONNXEmbeddingService implements EmbeddingGemma using proper ONNX Runtime and SentencePiece
 type RealONNXEmbeddingService struct {
 	config     *config.Config
-	session    *ort.DynamicSession[int64, float32]
+	spec       ModelSpec
+	session    *ort.DynamicAdvancedSession
 	tokenizer  *sentencepiece.Processor
 	modelPath  string
 	tokenizerPath string
 	initialized bool
+	modelHash  string
 	mu         sync.RWMutex
 }
 
 // NewRealONNXEmbeddingService creates a new ONNX-based embedding service
 func NewRealONNXEmbeddingService(cfg *config.Config) (*RealONNXEmbeddingService, error) {
-	service := &RealONNXEmbeddingService{
+	return newONNXService(cfg, DefaultModelSpec()), nil
+}
+
+// newONNXService creates an uninitialized service for a model
+func newONNXService(cfg *config.Config, spec ModelSpec) *RealONNXEmbeddingService {
+	return &RealONNXEmbeddingService{
 		config: cfg,
+		spec:   spec,
 	}
-
-	return service, nil
 }
 
 // Initialize downloads and loads the model and tokenizer
@@ -43,19 +110,8 @@ func (s *RealONNXEmbeddingService) Initialize() error {
 		return nil
 	}
 
-	// Create models directory
-	modelDir := filepath.Join(s.config.DataDir, "models")
-	if err := os.MkdirAll(modelDir, 0755); err != nil {
-		return fmt.Errorf("failed to create model directory: %w", err)
-	}
-
-	// Set up file paths
-	s.modelPath = filepath.Join(modelDir, "model.onnx")
-	s.tokenizerPath = filepath.Join(modelDir, "tokenizer.model")
-
-	// Download model files if needed
-	if err := s.downloadModelFiles(); err != nil {
-		return fmt.Errorf("failed to download model files: %w", err)
+	if err := s.prepareFiles(); err != nil {
+		return err
 	}
 
 	// Load the ONNX model
@@ -68,17 +124,58 @@ func (s *RealONNXEmbeddingService) Initialize() error {
 		return fmt.Errorf("failed to load tokenizer: %w", err)
 	}
 
+	// Fingerprint the exact weights so reproducible responses can be stamped
+	if s.config.Deterministic {
+		paths := []string{s.modelPath, s.tokenizerPath}
+		if s.spec.DataURL != "" {
+			paths = []string{s.modelPath, s.modelPath + "_data", s.tokenizerPath}
+		}
+		hash, err := hashFiles(paths...)
+		if err != nil {
+			return fmt.Errorf("failed to hash model files: %w", err)
+		}
+		s.modelHash = hash
+	}
+
 	s.initialized = true
-	log.Info().Msg("Real ONNX EmbeddingGemma model initialized successfully")
+	log.Info().Str("model", s.spec.ID).Msg("Real ONNX model initialized successfully")
+	return nil
+}
+
+// FetchModel downloads a model's files into cfg.DataDir, if they aren't
+// there already, without loading it
+func FetchModel(cfg *config.Config, spec ModelSpec) error {
+	return newONNXService(cfg, spec).prepareFiles()
+}
+
+// prepareFiles locates the model files in the data directory, downloading
+// any that are missing
+func (s *RealONNXEmbeddingService) prepareFiles() error {
+	// Create models directory
+	modelDir := filepath.Join(s.config.DataDir, s.spec.Dir)
+	if err := os.MkdirAll(modelDir, 0755); err != nil {
+		return fmt.Errorf("failed to create model directory: %w", err)
+	}
+
+	// Set up file paths
+	s.modelPath = filepath.Join(modelDir, "model.onnx")
+	s.tokenizerPath = filepath.Join(modelDir, "tokenizer.model")
+
+	// Download model files if needed
+	if err := s.downloadModelFiles(); err != nil {
+		return fmt.Errorf("failed to download model files: %w", err)
+	}
 	return nil
 }
 
 // downloadModelFiles downloads the ONNX model and tokenizer
 func (s *RealONNXEmbeddingService) downloadModelFiles() error {
 	files := map[string]string{
-		s.modelPath: "https://huggingface.co/onnx-community/embeddinggemma-300m-ONNX/resolve/main/onnx/model.onnx",
-		s.modelPath + "_data": "https://huggingface.co/onnx-community/embeddinggemma-300m-ONNX/resolve/main/onnx/model.onnx_data", // Model weights
-		s.tokenizerPath: "https://huggingface.co/onnx-community/embeddinggemma-300m-ONNX/resolve/main/tokenizer.model", // Use EmbeddingGemma's own tokenizer
+		s.modelPath:     s.spec.ModelURL,
+		s.tokenizerPath: s.spec.TokenizerURL,
+	}
+	if s.spec.DataURL != "" {
+		files[s.modelPath+"_data"] = s.spec.DataURL // Model weights
 	}
 
 	for filePath, url := range files {
@@ -87,6 +184,10 @@ func (s *RealONNXEmbeddingService) downloadModelFiles() error {
 			continue
 		}
 
+		if s.config.Offline {
+			return fmt.Errorf("%s is missing, and offline mode doesn't download it", filePath)
+		}
+
 		log.Info().Str("url", url).Str("path", filePath).Msg("Downloading file...")
 
 		if err := s.downloadFile(url, filePath); err != nil {
@@ -124,28 +225,40 @@ func (s *RealONNXEmbeddingService) downloadFile(url, filepath string) error {
 // loadONNXModel loads the ONNX model using ONNX Runtime
 func (s *RealONNXEmbeddingService) loadONNXModel() error {
 	// Initialize ONNX Runtime environment
-	if err := ort.InitializeEnvironment(); err != nil {
+	if err := acquireEnvironment(); err != nil {
 		return fmt.Errorf("failed to initialize ONNX runtime: %w", err)
 	}
 
 	// Create session options
 	sessionOptions, err := ort.NewSessionOptions()
 	if err != nil {
+		releaseEnvironment()
 		return fmt.Errorf("failed to create session options: %w", err)
 	}
 	defer sessionOptions.Destroy()
 
-	// Enable CPU optimizations
-	if err := sessionOptions.SetIntraOpNumThreads(4); err != nil {
-		log.Warn().Err(err).Msg("Failed to set intra-op threads")
+	// Enable CPU optimizations; determinism mode pins a single thread so
+	// floating-point reductions always run in the same order
+	threads := s.config.ONNXThreads
+	if s.config.Deterministic {
+		threads = 1
+		if err := sessionOptions.SetInterOpNumThreads(1); err != nil {
+			log.Warn().Err(err).Msg("Failed to set inter-op threads")
+		}
+	}
+	if threads > 0 {
+		if err := sessionOptions.SetIntraOpNumThreads(threads); err != nil {
+			log.Warn().Err(err).Msg("Failed to set intra-op threads")
+		}
 	}
 
 	// Create dynamic session for int64 input and float32 output data
 	inputNames := []string{"input_ids", "attention_mask"}
 	outputNames := []string{"sentence_embedding"}
 	
-	session, err := ort.NewDynamicSession[int64, float32](s.modelPath, inputNames, outputNames)
+	session, err := ort.NewDynamicAdvancedSession(s.modelPath, inputNames, outputNames, sessionOptions)
 	if err != nil {
+		releaseEnvironment()
 		return fmt.Errorf("failed to create ONNX session: %w", err)
 	}
 
@@ -175,18 +288,70 @@ func (s *RealONNXEmbeddingService) loadTokenizer() error {
 
 // EmbedQuery generates embeddings for a search query
 func (s *RealONNXEmbeddingService) EmbedQuery(text string) ([]float32, error) {
-	prefixedText := config.ModelConfig.QueryPrefix + text
+	prefixedText := s.spec.QueryPrefix + text
 	return s.embed(prefixedText)
 }
 
 // EmbedDocument generates embeddings for a document
 func (s *RealONNXEmbeddingService) EmbedDocument(text string) ([]float32, error) {
-	prefixedText := config.ModelConfig.DocumentPrefix + text
+	prefixedText := s.spec.DocumentPrefix + text
 	return s.embed(prefixedText)
 }
 
+// EmbedQueries generates embeddings for several search queries in batched inference
+func (s *RealONNXEmbeddingService) EmbedQueries(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.embedPrefixed(ctx, texts, s.spec.QueryPrefix)
+}
+
+// EmbedDocuments generates embeddings for several documents in batched inference
+func (s *RealONNXEmbeddingService) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.embedPrefixed(ctx, texts, s.spec.DocumentPrefix)
+}
+
+// embedPrefixed prefixes texts and embeds them maxBatchSize at a time. An
+// inference can't be interrupted, so ctx is checked before each batch.
+func (s *RealONNXEmbeddingService) embedPrefixed(ctx context.Context, texts []string, prefix string) ([][]float32, error) {
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = prefix + text
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for start := 0; start < len(prefixed); start += maxBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		end := min(start+maxBatchSize, len(prefixed))
+		batch, err := s.embedBatch(prefixed[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+
+	return results, nil
+}
+
+const (
+	// maxSequenceLength is the padded token length fed to the model
+	maxSequenceLength = 512
+	// maxBatchSize bounds the number of texts per ONNX inference call
+	maxBatchSize = 32
+	// modelDimensions is the width of EmbeddingGemma's sentence embedding
+	modelDimensions = 768
+)
+
 // embed generates embeddings using the ONNX model
 func (s *RealONNXEmbeddingService) embed(text string) ([]float32, error) {
+	embeddings, err := s.embedBatch([]string{text})
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// embedBatch runs a single ONNX inference over a batch of prefixed texts
+func (s *RealONNXEmbeddingService) embedBatch(texts []string) ([][]float32, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -194,29 +359,26 @@ func (s *RealONNXEmbeddingService) embed(text string) ([]float32, error) {
 		return nil, fmt.Errorf("model not initialized")
 	}
 
-	// Tokenize the text
-	tokens := s.tokenizer.Encode(text)
-	
 	// Prepare input with padding/truncation
-	maxLength := 512
-	inputIds := make([]int64, maxLength)
-	attentionMask := make([]int64, maxLength)
-	
-	// Copy tokens (truncate if too long)
-	copyLen := len(tokens)
-	if copyLen > maxLength {
-		copyLen = maxLength
-	}
-	
-	for i := 0; i < copyLen; i++ {
-		inputIds[i] = int64(tokens[i].ID)
-		attentionMask[i] = 1
+	batchSize := int64(len(texts))
+	inputIds := make([]int64, len(texts)*maxSequenceLength)
+	attentionMask := make([]int64, len(texts)*maxSequenceLength)
+
+	for b, text := range texts {
+		// Tokenize the text
+		tokens := s.tokenizer.Encode(text)
+
+		// Copy tokens (truncate if too long)
+		copyLen := min(len(tokens), maxSequenceLength)
+		offset := b * maxSequenceLength
+		for i := 0; i < copyLen; i++ {
+			inputIds[offset+i] = int64(tokens[i].ID)
+			attentionMask[offset+i] = 1
+		}
 	}
 
 	// Create input shapes
-	batchSize := int64(1)
-	seqLength := int64(maxLength)
-	inputShape := []int64{batchSize, seqLength}
+	inputShape := []int64{batchSize, maxSequenceLength}
 	
 	// Create input tensors with int64 data type (as expected by the model)
 	inputIdsTensor, err := ort.NewTensor(inputShape, inputIds)
@@ -232,8 +394,8 @@ func (s *RealONNXEmbeddingService) embed(text string) ([]float32, error) {
 	defer attentionTensor.Destroy()
 
 	// Create output tensor (empty, will be populated by inference)
-	// EmbeddingGemma outputs 768-dimensional embeddings
-	outputShape := []int64{batchSize, 768}
+	outputDims, dims := s.spec.OutputDims, s.spec.Dimensions
+	outputShape := []int64{batchSize, int64(outputDims)}
 	outputTensor, err := ort.NewEmptyTensor[float32](outputShape)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create output tensor: %w", err)
@@ -241,24 +403,60 @@ func (s *RealONNXEmbeddingService) embed(text string) ([]float32, error) {
 	defer outputTensor.Destroy()
 
 	// Run the ONNX model
-	err = s.session.Run([]*ort.Tensor[int64]{inputIdsTensor, attentionTensor}, []*ort.Tensor[float32]{outputTensor})
+	err = s.session.Run([]ort.ArbitraryTensor{inputIdsTensor, attentionTensor}, []ort.ArbitraryTensor{outputTensor})
 	if err != nil {
 		return nil, fmt.Errorf("failed to run ONNX model: %w", err)
 	}
 
-	// Get the embedding data
+	// Get the embedding data, laid out as [batch_size, hidden_size]
 	embeddingSlice := outputTensor.GetData()
+	if dims > outputDims || len(embeddingSlice) < len(texts)*outputDims {
+		return nil, fmt.Errorf("output embedding size mismatch: got %d, expected %d", len(embeddingSlice), len(texts)*outputDims)
+	}
+
+	// Truncate each row to the desired dimensions (Matryoshka truncation, 128D for EmbeddingGemma)
+	results := make([][]float32, len(texts))
+	for b := range texts {
+		row := embeddingSlice[b*outputDims : b*outputDims+dims]
+		results[b] = make([]float32, dims)
+		copy(results[b], row)
+	}
+
+	return results, nil
+}
 
-	// The output should be [batch_size, hidden_size]. We only have batch_size=1
-	if len(embeddingSlice) < config.ModelConfig.Dimensions {
-		return nil, fmt.Errorf("output embedding size mismatch: got %d, expected %d", len(embeddingSlice), config.ModelConfig.Dimensions)
+// Ready reports whether the model is initialized, without waiting for an
+// initialization in progress
+func (s *RealONNXEmbeddingService) Ready() bool {
+	if !s.mu.TryRLock() {
+		return false
 	}
+	defer s.mu.RUnlock()
+	return s.initialized
+}
 
-	// Truncate to the desired dimensions (Matryoshka truncation to 128D)
-	result := make([]float32, config.ModelConfig.Dimensions)
-	copy(result, embeddingSlice[:config.ModelConfig.Dimensions])
+// ModelHash returns the SHA-256 fingerprint of the loaded model files, if computed
+func (s *RealONNXEmbeddingService) ModelHash() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.modelHash
+}
 
-	return result, nil
+// hashFiles computes a single SHA-256 digest over the contents of several files
+func hashFiles(paths ...string) (string, error) {
+	h := sha256.New()
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			return "", err
+		}
+		_, err = io.Copy(h, file)
+		file.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
 }
 
 // Close cleans up resources
@@ -266,6 +464,10 @@ func (s *RealONNXEmbeddingService) Close() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if !s.initialized {
+		return nil
+	}
+
 	if s.session != nil {
 		s.session.Destroy()
 		s.session = nil
@@ -274,7 +476,7 @@ func (s *RealONNXEmbeddingService) Close() error {
 	// SentencePiece processor doesn't need explicit cleanup
 	s.tokenizer = nil
 
-	ort.DestroyEnvironment()
+	releaseEnvironment()
 	s.initialized = false
 
 	return nil
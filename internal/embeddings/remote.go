@@ -0,0 +1,189 @@
+package embeddings
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// RemoteEmbeddingService embeds text through an external HTTP embedding API,
+// OpenAI-compatible or Ollama, so deployments need no ONNX Runtime
+type RemoteEmbeddingService struct {
+	provider   *config.EmbeddingProvider
+	dimensions int
+	client     *http.Client
+	apiKey     string
+	ready      atomic.Bool // The most recent request succeeded
+}
+
+// NewRemoteEmbeddingService creates a client for a provider, serving vectors
+// truncated to dimensions
+func NewRemoteEmbeddingService(provider *config.EmbeddingProvider, dimensions int) *RemoteEmbeddingService {
+	s := &RemoteEmbeddingService{
+		provider:   provider,
+		dimensions: dimensions,
+		client:     &http.Client{Timeout: time.Duration(provider.Timeout)},
+	}
+	if provider.APIKeyEnv != "" {
+		s.apiKey = os.Getenv(provider.APIKeyEnv)
+	}
+	return s
+}
+
+// Probe embeds a short text to check the provider is reachable and serves
+// wide enough vectors
+func (s *RemoteEmbeddingService) Probe(ctx context.Context) error {
+	_, err := s.embedBatch(ctx, []string{*s.provider.QueryPrefix + "ping"})
+	return err
+}
+
+// EmbedQuery embeds a search query
+func (s *RemoteEmbeddingService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := s.embed(ctx, []string{text}, *s.provider.QueryPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// EmbedQueries embeds several search queries, batchSize texts per request
+func (s *RemoteEmbeddingService) EmbedQueries(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.embed(ctx, texts, *s.provider.QueryPrefix)
+}
+
+// EmbedDocuments embeds several documents, batchSize texts per request
+func (s *RemoteEmbeddingService) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.embed(ctx, texts, *s.provider.DocumentPrefix)
+}
+
+// EmbedDocument embeds a document such as a note
+func (s *RemoteEmbeddingService) EmbedDocument(ctx context.Context, text string) ([]float32, error) {
+	embeddings, err := s.embed(ctx, []string{text}, *s.provider.DocumentPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return embeddings[0], nil
+}
+
+// Ready reports whether the most recent request to the provider succeeded
+func (s *RemoteEmbeddingService) Ready() bool {
+	return s.ready.Load()
+}
+
+// Model returns the provider's model name
+func (s *RemoteEmbeddingService) Model() string {
+	return s.provider.Model
+}
+
+// embed prefixes texts and embeds them in batches
+func (s *RemoteEmbeddingService) embed(ctx context.Context, texts []string, prefix string) ([][]float32, error) {
+	prefixed := make([]string, len(texts))
+	for i, text := range texts {
+		prefixed[i] = prefix + text
+	}
+
+	results := make([][]float32, 0, len(texts))
+	for start := 0; start < len(prefixed); start += s.provider.BatchSize {
+		end := min(start+s.provider.BatchSize, len(prefixed))
+		batch, err := s.embedBatch(ctx, prefixed[start:end])
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, batch...)
+	}
+	return results, nil
+}
+
+// embedBatch sends one request and truncates the returned vectors
+func (s *RemoteEmbeddingService) embedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	embeddings, err := s.request(ctx, texts)
+	if err == nil && len(embeddings) != len(texts) {
+		err = fmt.Errorf("embedding provider returned %d embeddings for %d texts", len(embeddings), len(texts))
+	}
+	for i := 0; err == nil && i < len(embeddings); i++ {
+		if len(embeddings[i]) < s.dimensions {
+			err = fmt.Errorf("embedding provider returned %d dimensions, need at least %d", len(embeddings[i]), s.dimensions)
+			break
+		}
+		// Matryoshka truncation, as for the ONNX model
+		embeddings[i] = embeddings[i][:s.dimensions:s.dimensions]
+	}
+
+	if err != nil {
+		if s.ready.Swap(false) {
+			log.Ctx(ctx).Warn().Err(err).Str("model", s.provider.Model).Msg("Embedding provider failed")
+		}
+		return nil, err
+	}
+	if !s.ready.Swap(true) {
+		log.Ctx(ctx).Info().Str("model", s.provider.Model).Str("url", s.provider.URL).Msg("Embedding provider available")
+	}
+	return embeddings, nil
+}
+
+// request calls the provider's API
+func (s *RemoteEmbeddingService) request(ctx context.Context, texts []string) ([][]float32, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": s.provider.Model,
+		"input": texts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.provider.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+s.apiKey)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding provider request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding provider returned HTTP %d: %s", resp.StatusCode, bytes.TrimSpace(detail))
+	}
+
+	switch s.provider.API {
+	case config.ProviderOllama:
+		var response struct {
+			Embeddings [][]float32 `json:"embeddings"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("invalid embedding provider response: %w", err)
+		}
+		return response.Embeddings, nil
+	default:
+		var response struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float32 `json:"embedding"`
+			} `json:"data"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+			return nil, fmt.Errorf("invalid embedding provider response: %w", err)
+		}
+		embeddings := make([][]float32, len(response.Data))
+		for _, item := range response.Data {
+			if item.Index < 0 || item.Index >= len(embeddings) {
+				return nil, fmt.Errorf("invalid embedding provider response: index %d out of range", item.Index)
+			}
+			embeddings[item.Index] = item.Embedding
+		}
+		return embeddings, nil
+	}
+}
@@ -0,0 +1,103 @@
+package embeddings
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+)
+
+// ErrModelRequired reports a query that only a fallback could have embedded,
+// made with a context from WithRequireModel
+var ErrModelRequired = errors.New("embedding model is not serving and the request requires it")
+
+// QueryReport describes how a query embedding was produced
+type QueryReport struct {
+	Backend string        // BackendONNX, BackendRemote, BackendSimple or BackendPlaceholder
+	Cached  bool          // Served from the query cache rather than inference
+	Took    time.Duration // Including any wait for an inference slot
+}
+
+type reportKey struct{}
+
+type requireModelKey struct{}
+
+// WithQueryReports returns a context whose query embeddings are described to
+// report as they are produced, after any reporter ctx already has
+func WithQueryReports(ctx context.Context, report func(QueryReport)) context.Context {
+	if previous, ok := ctx.Value(reportKey{}).(func(QueryReport)); ok {
+		next := report
+		report = func(r QueryReport) {
+			previous(r)
+			next(r)
+		}
+	}
+	return context.WithValue(ctx, reportKey{}, report)
+}
+
+// WithRequireModel returns a context whose queries fail with
+// ErrModelRequired rather than fall back to simple or placeholder embeddings
+func WithRequireModel(ctx context.Context) context.Context {
+	return context.WithValue(ctx, requireModelKey{}, true)
+}
+
+// modelRequired reports whether ctx forbids fallback embeddings
+func modelRequired(ctx context.Context) bool {
+	required, _ := ctx.Value(requireModelKey{}).(bool)
+	return required
+}
+
+// reportQuery describes a query embedding to the context's reporter, if any
+func reportQuery(ctx context.Context, backend string, cached bool, start time.Time) {
+	if report, ok := ctx.Value(reportKey{}).(func(QueryReport)); ok {
+		report(QueryReport{Backend: backend, Cached: cached, Took: time.Since(start)})
+	}
+}
+
+// queryCounts counts the query embeddings each backend has produced
+type queryCounts struct {
+	onnx, remote, simple, placeholder atomic.Int64
+	cached                            atomic.Int64 // Served from the query cache, also counted under their backend
+	last                              atomic.Value // Backend of the latest query
+}
+
+// add counts one query embedding
+func (q *queryCounts) add(backend string, cached bool) {
+	switch backend {
+	case BackendONNX:
+		q.onnx.Add(1)
+	case BackendRemote:
+		q.remote.Add(1)
+	case BackendSimple:
+		q.simple.Add(1)
+	case BackendPlaceholder:
+		q.placeholder.Add(1)
+	}
+	if cached {
+		q.cached.Add(1)
+	}
+	q.last.Store(backend)
+}
+
+// counts returns the number of queries per backend, with "cached"
+func (q *queryCounts) counts() map[string]int64 {
+	return map[string]int64{
+		BackendONNX:        q.onnx.Load(),
+		BackendRemote:      q.remote.Load(),
+		BackendSimple:      q.simple.Load(),
+		BackendPlaceholder: q.placeholder.Load(),
+		"cached":           q.cached.Load(),
+	}
+}
+
+// latest returns the backend of the latest query, or "" before the first
+func (q *queryCounts) latest() string {
+	backend, _ := q.last.Load().(string)
+	return backend
+}
+
+// report counts a query embedding and describes it to the context's reporter
+func (s *EmbeddingService) report(ctx context.Context, backend string, cached bool, start time.Time) {
+	s.served.add(backend, cached)
+	reportQuery(ctx, backend, cached, start)
+}
@@ -0,0 +1,121 @@
+package embeddings
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// scheduler bounds concurrent ONNX inferences. When every slot is busy,
+// waiting inferences are admitted highest QoS priority first, then in
+// arrival order. A nil scheduler admits everything immediately.
+type scheduler struct {
+	mu      sync.Mutex
+	slots   int
+	running int
+	seq     uint64
+	waiting waitQueue
+}
+
+// newScheduler creates a scheduler with the given number of slots, or nil
+// for unbounded inference
+func newScheduler(slots int) *scheduler {
+	if slots <= 0 {
+		return nil
+	}
+	return &scheduler{slots: slots}
+}
+
+// acquire waits for a slot. Every successful acquire must be released.
+func (s *scheduler) acquire(ctx context.Context, priority int) error {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	if s.running < s.slots && len(s.waiting) == 0 {
+		s.running++
+		s.mu.Unlock()
+		return nil
+	}
+	w := &waiter{priority: priority, seq: s.seq, ready: make(chan struct{})}
+	s.seq++
+	heap.Push(&s.waiting, w)
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if w.index < 0 {
+			// Admitted as the context ended; hand the slot on
+			s.releaseLocked()
+		} else {
+			heap.Remove(&s.waiting, w.index)
+		}
+		return ctx.Err()
+	}
+}
+
+// release frees a slot, admitting the next waiter if any
+func (s *scheduler) release() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.releaseLocked()
+}
+
+func (s *scheduler) releaseLocked() {
+	if len(s.waiting) == 0 {
+		s.running--
+		return
+	}
+	// The slot passes straight to the next waiter, so running is unchanged
+	w := heap.Pop(&s.waiting).(*waiter)
+	close(w.ready)
+}
+
+// waiter is an inference waiting for a slot
+type waiter struct {
+	priority int
+	seq      uint64
+	ready    chan struct{}
+	index    int // Position in the queue, -1 once admitted
+}
+
+// waitQueue is a heap of waiters, highest priority and then oldest first
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *waitQueue) Push(x any) {
+	w := x.(*waiter)
+	w.index = len(*q)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() any {
+	old := *q
+	w := old[len(old)-1]
+	old[len(old)-1] = nil
+	w.index = -1
+	*q = old[:len(old)-1]
+	return w
+}
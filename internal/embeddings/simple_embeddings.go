@@ -76,7 +76,10 @@ func (s *SimpleEmbeddingService) findBestTextMatches(query string, topK int) []s
 
 	// Sort by similarity score
 	sort.Slice(matches, func(i, j int) bool {
-		return matches[i].score > matches[j].score
+		if matches[i].score != matches[j].score {
+			return matches[i].score > matches[j].score
+		}
+		return matches[i].id < matches[j].id
 	})
 
 	// Return top K matches
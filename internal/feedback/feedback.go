@@ -0,0 +1,109 @@
+// Package feedback stores relevance judgments: verses clients have marked
+// relevant or irrelevant for a query. Search uses them to adjust the vector
+// of a repeated query. Queries are stored hashed, so the store never holds
+// query text.
+package feedback
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/store"
+)
+
+// MaxJudged caps the verses judged for one query. Judgments of further
+// verses are ignored, so one query can't grow the store without bound.
+const MaxJudged = 100
+
+// Store holds net votes per query and verse, persisted as one JSON document
+// after every change
+type Store struct {
+	db      store.Store
+	mu      sync.RWMutex
+	queries map[string]map[string]int // hashed query -> reference -> net votes
+}
+
+// NewStore opens the feedback kept in db
+func NewStore(db store.Store) (*Store, error) {
+	s := &Store{
+		db:      db,
+		queries: make(map[string]map[string]int),
+	}
+
+	data, err := db.Get("feedback", "feedback")
+	if errors.Is(err, store.ErrNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read feedback: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.queries); err != nil {
+		return nil, fmt.Errorf("failed to parse feedback: %w", err)
+	}
+	return s, nil
+}
+
+// Judge records a vote that a verse is relevant, or irrelevant, to a query,
+// returning the verse's net votes. ok is false when the query already has
+// MaxJudged verses judged and this isn't one of them.
+func (s *Store) Judge(query, reference string, relevant bool) (votes int, ok bool, err error) {
+	key := queryKey(query)
+	vote := 1
+	if !relevant {
+		vote = -1
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	judged := s.queries[key]
+	if judged == nil {
+		judged = make(map[string]int)
+		s.queries[key] = judged
+	}
+	if _, exists := judged[reference]; !exists && len(judged) >= MaxJudged {
+		return 0, false, nil
+	}
+	judged[reference] += vote
+	return judged[reference], true, s.save()
+}
+
+// Judgments returns the verses voted relevant and irrelevant on balance for
+// a query, in reference order
+func (s *Store) Judgments(query string) (relevant, irrelevant []string) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for reference, votes := range s.queries[queryKey(query)] {
+		switch {
+		case votes > 0:
+			relevant = append(relevant, reference)
+		case votes < 0:
+			irrelevant = append(irrelevant, reference)
+		}
+	}
+	sort.Strings(relevant)
+	sort.Strings(irrelevant)
+	return relevant, irrelevant
+}
+
+// queryKey normalizes a query, so repeats match however they are cased and
+// spaced, and hashes it
+func queryKey(query string) string {
+	return privacy.Hash(strings.Join(strings.Fields(strings.ToLower(query)), " "))
+}
+
+// save persists the judgments. Callers must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.Marshal(s.queries)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Put("feedback", "feedback", data); err != nil {
+		return fmt.Errorf("failed to write feedback: %w", err)
+	}
+	return nil
+}
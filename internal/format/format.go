@@ -0,0 +1,120 @@
+// Package format assembles verse text for display, with the layout and
+// typography options liturgical and print-oriented consumers need.
+package format
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Verse numbering styles
+const (
+	NumbersNone      = "none"
+	NumbersInline    = "inline"
+	NumbersBracketed = "bracketed"
+)
+
+// Layout styles
+const (
+	LayoutParagraph = "paragraph"
+	LayoutVerse     = "verse"
+)
+
+// Divine name styles for the all-caps LORD/GOD of English translations
+const (
+	DivineNameAsIs      = "asis"
+	DivineNameSmallCaps = "smallcaps"
+	DivineNameHTML      = "html"
+	DivineNameTitle     = "title"
+)
+
+// Options controls how verse text is assembled
+type Options struct {
+	VerseNumbers string `json:"verseNumbers,omitempty"` // "none", "inline" or "bracketed"
+	Layout       string `json:"layout,omitempty"`       // "paragraph" or "verse"
+	DivineName   string `json:"divineName,omitempty"`   // "asis", "smallcaps", "html" or "title"
+}
+
+// Verse is the minimal verse shape needed to assemble a passage
+type Verse struct {
+	Chapter  int
+	VerseNum int
+	Text     string
+}
+
+// Validate checks that every option holds a known value
+func (o Options) Validate() error {
+	switch o.VerseNumbers {
+	case "", NumbersNone, NumbersInline, NumbersBracketed:
+	default:
+		return fmt.Errorf("unknown verseNumbers style: %s", o.VerseNumbers)
+	}
+	switch o.Layout {
+	case "", LayoutParagraph, LayoutVerse:
+	default:
+		return fmt.Errorf("unknown layout: %s", o.Layout)
+	}
+	switch o.DivineName {
+	case "", DivineNameAsIs, DivineNameSmallCaps, DivineNameHTML, DivineNameTitle:
+	default:
+		return fmt.Errorf("unknown divineName style: %s", o.DivineName)
+	}
+	return nil
+}
+
+// divineNamePattern matches the all-caps renderings of the Tetragrammaton
+var divineNamePattern = regexp.MustCompile(`\b(LORD|GOD)\b`)
+
+// smallCaps maps the tail letters of LORD/GOD to Unicode small capitals
+var smallCaps = strings.NewReplacer("O", "ᴏ", "R", "ʀ", "D", "ᴅ")
+
+// Text applies typography options to a single piece of text
+func Text(text string, opts Options) string {
+	switch opts.DivineName {
+	case DivineNameSmallCaps:
+		return divineNamePattern.ReplaceAllStringFunc(text, func(name string) string {
+			return name[:1] + smallCaps.Replace(name[1:])
+		})
+	case DivineNameHTML:
+		return divineNamePattern.ReplaceAllStringFunc(text, func(name string) string {
+			return `<span class="small-caps">` + name[:1] + strings.ToLower(name[1:]) + `</span>`
+		})
+	case DivineNameTitle:
+		return divineNamePattern.ReplaceAllStringFunc(text, func(name string) string {
+			return name[:1] + strings.ToLower(name[1:])
+		})
+	default:
+		return text
+	}
+}
+
+// Passage assembles verses into a single string according to opts. Paragraph
+// layout separates chapters with a blank line; verse layout puts each verse
+// on its own line.
+func Passage(verses []Verse, opts Options) string {
+	var b strings.Builder
+	for i, verse := range verses {
+		if i > 0 {
+			switch {
+			case opts.Layout == LayoutVerse:
+				b.WriteString("\n")
+			case verse.Chapter != verses[i-1].Chapter:
+				b.WriteString("\n\n")
+			default:
+				b.WriteString(" ")
+			}
+		}
+
+		switch opts.VerseNumbers {
+		case NumbersInline:
+			fmt.Fprintf(&b, "%d ", verse.VerseNum)
+		case NumbersBracketed:
+			fmt.Fprintf(&b, "[%d] ", verse.VerseNum)
+		}
+
+		b.WriteString(Text(strings.TrimSpace(verse.Text), opts))
+	}
+
+	return b.String()
+}
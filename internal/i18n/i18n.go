@@ -0,0 +1,176 @@
+// Package i18n translates user-facing API messages using bundled catalogs.
+// Catalog keys are the English messages themselves, so untranslated messages
+// fall back to English unchanged.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultLanguage is used when a request asks for no supported language
+const DefaultLanguage = "en"
+
+//go:embed catalogs/*.json
+var catalogFiles embed.FS
+
+// placeholderPattern matches "{name}" placeholders in catalog keys
+var placeholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// template is a catalog key with placeholders, such as "unknown book: {book}"
+type template struct {
+	pattern  *regexp.Regexp
+	names    []string
+	literals int // literal characters, so the most specific template wins
+	key      string
+}
+
+// catalog holds one language's translations
+type catalog struct {
+	exact     map[string]string
+	templates []template
+}
+
+var catalogs = loadCatalogs()
+
+func loadCatalogs() map[string]*catalog {
+	entries, err := catalogFiles.ReadDir("catalogs")
+	if err != nil {
+		panic(err)
+	}
+
+	loaded := make(map[string]*catalog, len(entries))
+	for _, entry := range entries {
+		data, err := catalogFiles.ReadFile(path.Join("catalogs", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		var messages map[string]string
+		if err := json.Unmarshal(data, &messages); err != nil {
+			panic("i18n: invalid catalog " + entry.Name() + ": " + err.Error())
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = newCatalog(messages)
+	}
+	return loaded
+}
+
+func newCatalog(messages map[string]string) *catalog {
+	c := &catalog{exact: messages}
+	for key := range messages {
+		if !placeholderPattern.MatchString(key) {
+			continue
+		}
+
+		t := template{key: key}
+		var expr strings.Builder
+		expr.WriteString("^")
+		last := 0
+		for _, loc := range placeholderPattern.FindAllStringSubmatchIndex(key, -1) {
+			literal := key[last:loc[0]]
+			expr.WriteString(regexp.QuoteMeta(literal))
+			expr.WriteString("(.+?)")
+			t.literals += len(literal)
+			t.names = append(t.names, key[loc[2]:loc[3]])
+			last = loc[1]
+		}
+		expr.WriteString(regexp.QuoteMeta(key[last:]))
+		expr.WriteString("$")
+		t.literals += len(key) - last
+		t.pattern = regexp.MustCompile(expr.String())
+		c.templates = append(c.templates, t)
+	}
+
+	sort.Slice(c.templates, func(i, j int) bool {
+		if c.templates[i].literals != c.templates[j].literals {
+			return c.templates[i].literals > c.templates[j].literals
+		}
+		return c.templates[i].key < c.templates[j].key
+	})
+	return c
+}
+
+// Supported lists the languages with a catalog, plus English
+func Supported() []string {
+	languages := []string{DefaultLanguage}
+	for lang := range catalogs {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages[1:])
+	return languages
+}
+
+// Negotiate picks the response language from an explicit lang parameter,
+// falling back to the best supported Accept-Language entry, then English
+func Negotiate(lang, acceptLanguage string) string {
+	if base := baseLanguage(lang); isSupported(base) {
+		return base
+	}
+
+	best, bestQ := DefaultLanguage, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if base := baseLanguage(tag); isSupported(base) && q > bestQ {
+			best, bestQ = base, q
+		}
+	}
+	return best
+}
+
+// Translate returns a message in lang, or unchanged when the catalog has no
+// entry for it. Placeholder values are kept verbatim, except {message}, which
+// is itself translated so wrapped errors localize too.
+func Translate(lang, message string) string {
+	c, ok := catalogs[lang]
+	if !ok || message == "" {
+		return message
+	}
+	if translated, ok := c.exact[message]; ok {
+		return translated
+	}
+
+	for _, t := range c.templates {
+		match := t.pattern.FindStringSubmatch(message)
+		if match == nil {
+			continue
+		}
+		values := make(map[string]string, len(t.names))
+		for i, name := range t.names {
+			value := match[i+1]
+			if name == "message" {
+				value = Translate(lang, value)
+			}
+			values[name] = value
+		}
+		return placeholderPattern.ReplaceAllStringFunc(c.exact[t.key], func(placeholder string) string {
+			return values[placeholder[1:len(placeholder)-1]]
+		})
+	}
+	return message
+}
+
+func isSupported(lang string) bool {
+	if lang == DefaultLanguage {
+		return true
+	}
+	_, ok := catalogs[lang]
+	return ok
+}
+
+// baseLanguage reduces "pt-BR" to "pt"
+func baseLanguage(tag string) string {
+	base, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	return base
+}
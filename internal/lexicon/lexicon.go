@@ -0,0 +1,293 @@
+// Package lexicon loads Strong's-tagged scripture text and Strong's lexicon
+// entries, answering lookups by Strong's number and by verse for word study.
+package lexicon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// ErrInvalidNumber reports a malformed Strong's number
+	ErrInvalidNumber = errors.New("invalid Strong's number")
+	// ErrNotFound reports a Strong's number with no lexicon entry
+	ErrNotFound = errors.New("no lexicon entry")
+)
+
+// Languages of the two Strong's numbering systems
+const (
+	Hebrew = "hebrew" // H numbers, the Old Testament
+	Greek  = "greek"  // G numbers, the New Testament
+)
+
+// numberPattern matches a Strong's number such as H7225, G25 or H1254a
+var numberPattern = regexp.MustCompile(`^([HGhg])0*(\d{1,5})([a-zA-Z]?)$`)
+
+// Entry is a lexicon entry for one Strong's number
+type Entry struct {
+	Strongs         string `json:"strongs"`
+	Language        string `json:"language"`
+	Lemma           string `json:"lemma"`
+	Transliteration string `json:"transliteration,omitempty"`
+	Pronunciation   string `json:"pronunciation,omitempty"`
+	Derivation      string `json:"derivation,omitempty"`
+	Definition      string `json:"definition,omitempty"`
+	KJVUsage        string `json:"kjvUsage,omitempty"` // How the KJV renders the word
+}
+
+// Word is an English word or phrase of a verse with the Strong's numbers
+// it translates
+type Word struct {
+	Text    string   `json:"text"`
+	Strongs []string `json:"strongs"`
+}
+
+// dictionaryEntry is an entry in the OpenScriptures Strong's dictionaries.
+// The Hebrew dictionary names the transliteration xlit, the Greek translit.
+type dictionaryEntry struct {
+	Lemma      string `json:"lemma"`
+	Translit   string `json:"translit"`
+	Xlit       string `json:"xlit"`
+	Pron       string `json:"pron"`
+	Derivation string `json:"derivation"`
+	StrongsDef string `json:"strongs_def"`
+	KJVDef     string `json:"kjv_def"`
+}
+
+// Service holds lexicon entries and Strong's-tagged verses
+type Service struct {
+	mu          sync.RWMutex
+	entries     map[string]*Entry
+	verses      map[string][]Word // "book:chapter:verse" -> words in order
+	occurrences map[string]int    // Strong's number -> verses using it
+	loaded      bool
+}
+
+// NewService creates an empty lexicon
+func NewService() *Service {
+	return &Service{
+		entries:     make(map[string]*Entry),
+		verses:      make(map[string][]Word),
+		occurrences: make(map[string]int),
+	}
+}
+
+// Load reads Strong's dictionaries (JSON objects keyed by Strong's number)
+// and a Strong's-tagged text, replacing anything loaded before
+func (s *Service) Load(dictionaries []string, taggedText string) error {
+	entries := make(map[string]*Entry)
+	for _, path := range dictionaries {
+		if err := loadDictionary(path, entries); err != nil {
+			return fmt.Errorf("failed to load lexicon %s: %w", path, err)
+		}
+	}
+
+	verses := make(map[string][]Word)
+	if taggedText != "" {
+		file, err := os.Open(taggedText)
+		if err != nil {
+			return fmt.Errorf("failed to open Strong's-tagged text: %w", err)
+		}
+		defer file.Close()
+		if verses, err = parseTagged(file); err != nil {
+			return err
+		}
+	}
+
+	occurrences := make(map[string]int)
+	for _, words := range verses {
+		seen := make(map[string]bool)
+		for _, word := range words {
+			for _, number := range word.Strongs {
+				// A suffixed number also counts toward its base number
+				for _, n := range []string{number, baseNumber(number)} {
+					if !seen[n] {
+						seen[n] = true
+						occurrences[n]++
+					}
+				}
+			}
+		}
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.verses = verses
+	s.occurrences = occurrences
+	s.loaded = true
+	s.mu.Unlock()
+
+	log.Info().Int("entries", len(entries)).Int("verses", len(verses)).Msg("Lexicon loaded successfully")
+	return nil
+}
+
+// Loaded reports whether the lexicon is ready
+func (s *Service) Loaded() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded
+}
+
+// Counts returns the number of lexicon entries and tagged verses
+func (s *Service) Counts() (entries, verses int) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.entries), len(s.verses)
+}
+
+// Lookup returns the entry for a Strong's number and the number of tagged
+// verses using it. A number with a letter suffix falls back to the entry
+// for its base number.
+func (s *Service) Lookup(strongs string) (*Entry, int, error) {
+	number, err := Normalize(strongs)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entry, ok := s.entries[number]
+	if !ok {
+		entry, ok = s.entries[baseNumber(number)]
+	}
+	if !ok {
+		return nil, 0, fmt.Errorf("%w for %s", ErrNotFound, number)
+	}
+	return entry, s.occurrences[number], nil
+}
+
+// ForVerse returns a verse's tagged words, or nil if it isn't tagged
+func (s *Service) ForVerse(book string, chapter, verse int) []Word {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.verses[verseKey(book, chapter, verse)]
+}
+
+// Normalize returns a Strong's number in canonical form: an upper-case
+// language prefix without leading zeros, e.g. "g0025" becomes "G25"
+func Normalize(strongs string) (string, error) {
+	match := numberPattern.FindStringSubmatch(strings.TrimSpace(strongs))
+	if match == nil {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNumber, strongs)
+	}
+	number, _ := strconv.Atoi(match[2])
+	if number == 0 {
+		return "", fmt.Errorf("%w: %q", ErrInvalidNumber, strongs)
+	}
+	return strings.ToUpper(match[1]) + strconv.Itoa(number) + strings.ToLower(match[3]), nil
+}
+
+// baseNumber drops a Strong's number's letter suffix
+func baseNumber(number string) string {
+	return strings.TrimRight(number, "abcdefghijklmnopqrstuvwxyz")
+}
+
+// loadDictionary adds the entries of one dictionary file
+func loadDictionary(path string, entries map[string]*Entry) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var dictionary map[string]dictionaryEntry
+	if err := json.Unmarshal(data, &dictionary); err != nil {
+		return err
+	}
+
+	for key, raw := range dictionary {
+		number, err := Normalize(key)
+		if err != nil {
+			log.Debug().Str("strongs", key).Msg("Skipping lexicon entry with an invalid Strong's number")
+			continue
+		}
+		entry := &Entry{
+			Strongs:         number,
+			Language:        Greek,
+			Lemma:           raw.Lemma,
+			Transliteration: coalesce(raw.Translit, raw.Xlit),
+			Pronunciation:   raw.Pron,
+			Derivation:      strings.TrimSpace(raw.Derivation),
+			Definition:      strings.TrimSpace(raw.StrongsDef),
+			KJVUsage:        strings.TrimSpace(raw.KJVDef),
+		}
+		if number[0] == 'H' {
+			entry.Language = Hebrew
+		}
+		entries[number] = entry
+	}
+	return nil
+}
+
+// parseTagged reads tab-separated "Reference, English, Strong's" lines, one
+// word per line in verse order, such as "Gen.1.1	In the beginning	H7225".
+// A word may translate several space-separated Strong's numbers.
+func parseTagged(r io.Reader) (map[string][]Word, error) {
+	verses := make(map[string][]Word)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 3 {
+			continue
+		}
+
+		ref, err := reference.Parse(fields[0])
+		if err != nil || ref.IsRange() || ref.StartVerse == 0 {
+			log.Debug().Str("ref", fields[0]).Msg("Skipping Strong's-tagged word with an unparseable verse")
+			continue
+		}
+
+		word := Word{Text: strings.TrimSpace(fields[1])}
+		for _, field := range strings.Fields(fields[2]) {
+			if number, err := Normalize(field); err == nil {
+				word.Strongs = append(word.Strongs, number)
+			}
+		}
+		if len(word.Strongs) == 0 {
+			continue
+		}
+
+		key := verseKey(ref.Book, ref.StartChapter, ref.StartVerse)
+		verses[key] = append(verses[key], word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Strong's-tagged text: %w", err)
+	}
+	return verses, nil
+}
+
+// verseKey builds the lookup key for a verse, accepting any book name or
+// abbreviation the canon knows
+func verseKey(book string, chapter, verse int) string {
+	if b, ok := canon.Lookup(book); ok {
+		book = b.Name
+	}
+	return fmt.Sprintf("%s:%d:%d", strings.ToLower(book), chapter, verse)
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
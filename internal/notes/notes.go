@@ -0,0 +1,485 @@
+// Package notes stores rich-text study notes linked to scripture references,
+// per namespace, and exports them as JSON or Markdown.
+package notes
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/wal"
+	"github.com/rs/zerolog/log"
+)
+
+// maxBodyLength bounds a single note's body
+const maxBodyLength = 64 * 1024
+
+// compactEvery is how many logged changes accumulate before they're folded
+// into the JSON snapshot
+const compactEvery = 1000
+
+var (
+	// ErrNotFound is returned for note IDs that don't exist in a namespace
+	ErrNotFound = errors.New("note not found")
+	// ErrInvalid wraps validation failures such as an empty body
+	ErrInvalid = errors.New("invalid note")
+)
+
+// Note is a Markdown note attached to a verse or range
+type Note struct {
+	ID        string              `json:"id"`
+	Namespace string              `json:"namespace"`
+	Reference string              `json:"reference"`
+	Body      string              `json:"body"` // Markdown
+	CreatedAt time.Time           `json:"createdAt"`
+	UpdatedAt time.Time           `json:"updatedAt"`
+	ref       reference.Reference // Parsed Reference, for overlap checks
+	embedding []float32           // Body embedding computed at write time, for note search
+}
+
+// Match is a note found by semantic search
+type Match struct {
+	Note       Note
+	Similarity float32
+}
+
+// Embedder embeds note bodies as documents
+type Embedder interface {
+	EmbedDocument(text string) ([]float32, error)
+}
+
+// record is the on-disk form of a note, including its embedding
+type record struct {
+	Note
+	Embedding []float32 `json:"embedding,omitempty"`
+}
+
+// logEntry is one change in the write-ahead log: a created or updated note,
+// or a deleted note's ID
+type logEntry struct {
+	Op        string  `json:"op"` // "put" or "delete"
+	Namespace string  `json:"namespace"`
+	ID        string  `json:"id"`
+	Note      *record `json:"note,omitempty"`
+}
+
+// Store holds notes by namespace. Each change is appended to a write-ahead
+// log and periodically compacted into a JSON snapshot.
+type Store struct {
+	path     string
+	embedder Embedder
+	changes  *wal.Log
+	mu       sync.RWMutex
+	notes    map[string]map[string]*Note // namespace -> ID -> note
+}
+
+// NewStore opens the note store at path, loading the snapshot if it exists
+// and replaying changes logged since. Notes are embedded with embedder so
+// they can be searched.
+func NewStore(path string, embedder Embedder) (*Store, error) {
+	s := &Store{
+		path:     path,
+		embedder: embedder,
+		notes:    make(map[string]map[string]*Note),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	changes, err := wal.Open(strings.TrimSuffix(path, filepath.Ext(path))+".wal", s.replay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open note log: %w", err)
+	}
+	s.changes = changes
+	return s, nil
+}
+
+// load reads the JSON snapshot, if there is one
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read notes: %w", err)
+	}
+
+	var stored []*record
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse notes: %w", err)
+	}
+	for _, rec := range stored {
+		s.restore(rec)
+	}
+	return nil
+}
+
+// replay applies a logged change on startup
+func (s *Store) replay(raw json.RawMessage) error {
+	var entry logEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return err
+	}
+	switch {
+	case entry.Op == "delete":
+		delete(s.notes[entry.Namespace], entry.ID)
+	case entry.Note != nil:
+		s.restore(entry.Note)
+	}
+	return nil
+}
+
+// restore adds a stored note, embedding it if it predates note search
+func (s *Store) restore(rec *record) {
+	ref, err := reference.Parse(rec.Reference)
+	if err != nil {
+		return
+	}
+	note := rec.Note
+	note.ref = ref
+	note.embedding = rec.Embedding
+	if note.embedding == nil {
+		// Notes written before note search existed are embedded on first load
+		note.embedding = s.embed(note.Body)
+	}
+	s.put(&note)
+}
+
+// Create attaches a new note to a reference and logs the change
+func (s *Store) Create(namespace string, ref reference.Reference, body string) (Note, error) {
+	if err := validateBody(body); err != nil {
+		return Note{}, err
+	}
+	id, err := newID()
+	if err != nil {
+		return Note{}, err
+	}
+
+	now := time.Now().UTC()
+	note := &Note{
+		ID:        id,
+		Namespace: namespace,
+		Reference: ref.String(),
+		Body:      body,
+		CreatedAt: now,
+		UpdatedAt: now,
+		ref:       ref,
+		embedding: s.embed(body),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(note)
+	return *note, s.logChange(logEntry{Op: "put", Namespace: namespace, ID: id, Note: &record{Note: *note, Embedding: note.embedding}})
+}
+
+// Update replaces a note's body and logs the change
+func (s *Store) Update(namespace, id, body string) (Note, error) {
+	if err := validateBody(body); err != nil {
+		return Note{}, err
+	}
+	embedding := s.embed(body)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	note, ok := s.notes[namespace][id]
+	if !ok {
+		return Note{}, ErrNotFound
+	}
+	note.Body = body
+	note.UpdatedAt = time.Now().UTC()
+	note.embedding = embedding
+	return *note, s.logChange(logEntry{Op: "put", Namespace: namespace, ID: id, Note: &record{Note: *note, Embedding: embedding}})
+}
+
+// Delete removes a note and logs the change
+func (s *Store) Delete(namespace, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.notes[namespace][id]; !ok {
+		return ErrNotFound
+	}
+	delete(s.notes[namespace], id)
+	return s.logChange(logEntry{Op: "delete", Namespace: namespace, ID: id})
+}
+
+// Close compacts the log into the snapshot and closes it
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.compact(); err != nil {
+		return err
+	}
+	return s.changes.Close()
+}
+
+// List returns a namespace's notes in canonical reference order
+func (s *Store) List(namespace string) []Note {
+	return s.find(namespace, func(*Note) bool { return true })
+}
+
+// Overlapping returns the notes whose references share a verse with any of refs
+func (s *Store) Overlapping(namespace string, refs ...reference.Reference) []Note {
+	return s.find(namespace, func(note *Note) bool {
+		for _, ref := range refs {
+			if note.ref.Overlaps(ref) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// ForVerse returns the notes covering a verse; a zero verse matches any note
+// within the chapter
+func (s *Store) ForVerse(namespace, book string, chapter, verse int) []Note {
+	if b, ok := canon.Lookup(book); ok {
+		book = b.Name
+	}
+	ref := reference.Reference{Book: book, StartChapter: chapter, StartVerse: verse, EndChapter: chapter, EndVerse: verse}
+	return s.Overlapping(namespace, ref)
+}
+
+// Namespaces returns every namespace with at least one note, sorted
+func (s *Store) Namespaces() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	namespaces := make([]string, 0, len(s.notes))
+	for namespace, notes := range s.notes {
+		if len(notes) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Import stores exported notes in a namespace, keeping their IDs and
+// timestamps, and persists the store. A note whose ID already exists is
+// replaced. Notes are re-embedded, since the exporting instance may have used
+// another model. Invalid notes are skipped and counted.
+func (s *Store) Import(namespace string, exported []Note) (imported, skipped int, err error) {
+	prepared := make([]*Note, 0, len(exported))
+	for _, note := range exported {
+		ref, parseErr := reference.Parse(note.Reference)
+		if parseErr != nil || validateBody(note.Body) != nil || note.ID == "" {
+			skipped++
+			continue
+		}
+
+		note.Namespace = namespace
+		note.Reference = ref.String()
+		note.ref = ref
+		note.embedding = s.embed(note.Body)
+		if note.CreatedAt.IsZero() {
+			note.CreatedAt = time.Now().UTC()
+		}
+		if note.UpdatedAt.IsZero() {
+			note.UpdatedAt = note.CreatedAt
+		}
+		prepared = append(prepared, &note)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, note := range prepared {
+		s.put(note)
+	}
+	return len(prepared), skipped, s.compact()
+}
+
+// DeleteNamespace removes every note in a namespace, persists the store, and
+// returns the number of notes removed
+func (s *Store) DeleteNamespace(namespace string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := len(s.notes[namespace])
+	delete(s.notes, namespace)
+	return removed, s.compact()
+}
+
+// Search returns up to k of a namespace's notes most similar to a query
+// embedding. Notes are scanned in place rather than from index segments.
+func (s *Store) Search(namespace string, query []float32, k int) []Match {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matches := make([]Match, 0)
+	for _, note := range s.notes[namespace] {
+		if note.embedding == nil {
+			continue
+		}
+		matches = append(matches, Match{Note: *note, Similarity: similarity(query, note.embedding)})
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Similarity != matches[j].Similarity {
+			return matches[i].Similarity > matches[j].Similarity
+		}
+		return matches[i].Note.ID < matches[j].Note.ID
+	})
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// embed embeds a note body, returning nil if embedding fails so the note is
+// still stored, just not searchable
+func (s *Store) embed(body string) []float32 {
+	if s.embedder == nil {
+		return nil
+	}
+	embedding, err := s.embedder.EmbedDocument(body)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to embed note")
+		return nil
+	}
+	return embedding
+}
+
+// similarity is the cosine similarity over the shared prefix of two
+// Matryoshka embeddings, so notes embedded at another width still compare
+func similarity(a, b []float32) float32 {
+	n := min(len(a), len(b))
+	var dot, normA, normB float64
+	for i := 0; i < n; i++ {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / (math.Sqrt(normA) * math.Sqrt(normB)))
+}
+
+func (s *Store) find(namespace string, match func(*Note) bool) []Note {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	found := make([]Note, 0)
+	for _, note := range s.notes[namespace] {
+		if match(note) {
+			found = append(found, *note)
+		}
+	}
+	sort.Slice(found, func(i, j int) bool { return less(found[i], found[j]) })
+	return found
+}
+
+func (s *Store) put(note *Note) {
+	if s.notes[note.Namespace] == nil {
+		s.notes[note.Namespace] = make(map[string]*Note)
+	}
+	s.notes[note.Namespace][note.ID] = note
+}
+
+// logChange appends a change to the log, compacting once enough have accumulated.
+// Callers must hold s.mu.
+func (s *Store) logChange(entry logEntry) error {
+	if err := s.changes.Append(entry); err != nil {
+		return err
+	}
+	if s.changes.Len() >= compactEvery {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact writes the snapshot and empties the log. Replaying a change the
+// snapshot already has is harmless, so a crash between the steps loses nothing.
+func (s *Store) compact() error {
+	if err := s.save(); err != nil {
+		return err
+	}
+	return s.changes.Truncate()
+}
+
+// save writes the store atomically so a crash never leaves a truncated file
+func (s *Store) save() error {
+	all := make([]record, 0)
+	for _, notes := range s.notes {
+		for _, note := range notes {
+			all = append(all, record{Note: *note, Embedding: note.embedding})
+		}
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].ID < all[j].ID })
+
+	data, err := json.MarshalIndent(all, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create notes directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write notes: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Markdown renders notes as a Markdown document with one section per note
+func Markdown(namespace string, notes []Note) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Notes: %s\n", namespace)
+	for _, note := range notes {
+		fmt.Fprintf(&b, "\n## %s\n\n", note.Reference)
+		fmt.Fprintf(&b, "_Updated %s_\n\n", note.UpdatedAt.Format(time.RFC3339))
+		b.WriteString(strings.TrimSpace(note.Body))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+func validateBody(body string) error {
+	if strings.TrimSpace(body) == "" {
+		return fmt.Errorf("%w: body is empty", ErrInvalid)
+	}
+	if len(body) > maxBodyLength {
+		return fmt.Errorf("%w: body exceeds %d bytes", ErrInvalid, maxBodyLength)
+	}
+	return nil
+}
+
+// newID returns a random note ID
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// less orders notes by reference, then creation time
+func less(a, b Note) bool {
+	if a.ref.Book != b.ref.Book {
+		return canon.Position(a.ref.Book) < canon.Position(b.ref.Book)
+	}
+	if a.ref.StartChapter != b.ref.StartChapter {
+		return a.ref.StartChapter < b.ref.StartChapter
+	}
+	if a.ref.StartVerse != b.ref.StartVerse {
+		return a.ref.StartVerse < b.ref.StartVerse
+	}
+	return a.CreatedAt.Before(b.CreatedAt)
+}
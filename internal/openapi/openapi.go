@@ -0,0 +1,298 @@
+// Package openapi builds an OpenAPI 3 document from Go request and response
+// types, so the published spec can't drift from the structs handlers bind.
+package openapi
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Document is an OpenAPI 3.0 document
+type Document struct {
+	OpenAPI    string                          `json:"openapi"`
+	Info       Info                            `json:"info"`
+	Paths      map[string]map[string]Operation `json:"paths"`
+	Components Components                      `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Components holds the named schemas referenced by operations
+type Components struct {
+	Schemas map[string]*Schema `json:"schemas"`
+}
+
+// Operation describes a single method on a path
+type Operation struct {
+	Summary     string              `json:"summary,omitempty"`
+	Parameters  []Parameter         `json:"parameters,omitempty"`
+	RequestBody *RequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]Response `json:"responses"`
+	Deprecated  bool                `json:"deprecated,omitempty"`
+}
+
+// Parameter describes a query or path parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Deprecated  bool    `json:"deprecated,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes a JSON request body
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// Response describes a response for one status code
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// MediaType wraps the schema of a body
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Schema is the subset of JSON Schema OpenAPI 3.0 uses
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+// Builder accumulates paths and the component schemas they reference
+type Builder struct {
+	doc Document
+}
+
+// NewBuilder starts a document with the given title and version
+func NewBuilder(title, version, description string) *Builder {
+	return &Builder{doc: Document{
+		OpenAPI:    "3.0.3",
+		Info:       Info{Title: title, Version: version, Description: description},
+		Paths:      make(map[string]map[string]Operation),
+		Components: Components{Schemas: make(map[string]*Schema)},
+	}}
+}
+
+// Route describes one endpoint in terms of Go values whose types become schemas
+type Route struct {
+	Method      string
+	Path        string
+	Summary     string
+	Params      []Parameter
+	Request     interface{} // nil for no body
+	Response    interface{} // nil for an untyped JSON object
+	ContentType string      // response media type; defaults to application/json
+}
+
+// Add registers a route
+func (b *Builder) Add(route Route) {
+	op := Operation{
+		Summary:    route.Summary,
+		Parameters: route.Params,
+		Responses:  make(map[string]Response),
+	}
+
+	if route.Request != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: b.SchemaOf(route.Request)}},
+		}
+	}
+
+	contentType := route.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	body := &Schema{Type: "object"}
+	if route.Response != nil {
+		body = b.SchemaOf(route.Response)
+	}
+	op.Responses["200"] = Response{
+		Description: "Success",
+		Content:     map[string]MediaType{contentType: {Schema: body}},
+	}
+	op.Responses["default"] = Response{
+		Description: "Error",
+		Content:     map[string]MediaType{"application/json": {Schema: errorSchema}},
+	}
+
+	method := strings.ToLower(route.Method)
+	if b.doc.Paths[route.Path] == nil {
+		b.doc.Paths[route.Path] = make(map[string]Operation)
+	}
+	b.doc.Paths[route.Path][method] = op
+}
+
+// Deprecate marks an added operation as deprecated or, given a param, adds
+// that query parameter to it as deprecated. Echo-style path parameters such
+// as :id are accepted.
+func (b *Builder) Deprecate(method, path, param, description string) {
+	path = echoPathParam.ReplaceAllString(path, "{$1}")
+	method = strings.ToLower(method)
+	op, ok := b.doc.Paths[path][method]
+	if !ok {
+		return
+	}
+	if param == "" {
+		op.Deprecated = true
+	} else {
+		deprecated := QueryParam(param, "string", description)
+		deprecated.Deprecated = true
+		op.Parameters = append(append([]Parameter(nil), op.Parameters...), deprecated)
+	}
+	b.doc.Paths[path][method] = op
+}
+
+// echoPathParam matches a path parameter written the Echo way, e.g. :id
+var echoPathParam = regexp.MustCompile(`:([A-Za-z_]+)`)
+
+// Document returns the assembled document
+func (b *Builder) Document() *Document {
+	return &b.doc
+}
+
+// errorSchema matches the {"error": {code, message, ...}, "status"} envelope
+// handlers return on failure
+var errorSchema = &Schema{
+	Type: "object",
+	Properties: map[string]*Schema{
+		"error": {
+			Type: "object",
+			Properties: map[string]*Schema{
+				"code":       {Type: "string"},
+				"message":    {Type: "string"},
+				"details":    {},
+				"suggestion": {Type: "string"},
+				"requestId":  {Type: "string"},
+			},
+			Required: []string{"code", "message"},
+		},
+		"status": {Type: "string"},
+	},
+	Required: []string{"error", "status"},
+}
+
+// QueryParam is a shorthand for an optional query parameter of a primitive type
+func QueryParam(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+// SchemaOf returns the schema for v's type, registering named structs as components
+func (b *Builder) SchemaOf(v interface{}) *Schema {
+	return b.schema(reflect.TypeOf(v))
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+func (b *Builder) schema(t reflect.Type) *Schema {
+	if t == nil {
+		return &Schema{}
+	}
+
+	if t.Kind() == reflect.Pointer {
+		s := b.schema(t.Elem())
+		if s.Ref == "" {
+			s.Nullable = true
+		}
+		return s
+	}
+
+	if t == timeType {
+		return &Schema{Type: "string", Format: "date-time"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schema(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := t.Name()
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// Reserve the name first so recursive types terminate
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	default:
+		// interface{} and anything else accepts any JSON value
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from a struct's exported, JSON-visible fields
+func (b *Builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *Builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" || (!field.IsExported() && !field.Anonymous) {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+
+		// Untagged embedded structs are flattened into the parent, as encoding/json does
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		s.Properties[name] = b.schema(field.Type)
+		if !strings.Contains(opts, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			s.Required = append(s.Required, name)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+// Package privacy holds per-API-key privacy settings and the redaction rules
+// every subsystem that logs or records queries must apply.
+package privacy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/store"
+)
+
+// KeyHeader carries the caller's API key
+const KeyHeader = "X-API-Key"
+
+// MaxKeys caps how many keys may store settings, since any caller can send a new key
+const MaxKeys = 10000
+
+// ErrTooManyKeys reports a new key's settings refused because MaxKeys keys have some
+var ErrTooManyKeys = fmt.Errorf("at most %d keys may store privacy settings", MaxKeys)
+
+// Policy controls what may be kept about a key's queries
+type Policy struct {
+	// NoQueryLogging keeps query text out of logs and recordings entirely
+	NoQueryLogging bool `json:"noQueryLogging"`
+	// HashOnly replaces query text with a stable hash, so analytics can still
+	// count repeated queries without storing them
+	HashOnly bool `json:"hashOnly"`
+}
+
+// Redact returns the form of a query a policy allows to be stored
+func (p Policy) Redact(query string) string {
+	switch {
+	case query == "":
+		return ""
+	case p.NoQueryLogging:
+		return "[redacted]"
+	case p.HashOnly:
+		return Hash(query)
+	default:
+		return query
+	}
+}
+
+// Hash returns a short, stable, non-reversible identifier for a value
+func Hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
+
+// Store holds per-key policies, persisted as one JSON document after every
+// change. Keys are stored hashed so the settings never contain credentials.
+type Store struct {
+	db       store.Store
+	fallback Policy
+	mu       sync.RWMutex
+	policies map[string]Policy // hashed key -> policy
+}
+
+// NewStore opens the policies kept in db. fallback applies to requests
+// without a key and to keys with no explicit settings.
+func NewStore(db store.Store, fallback Policy) (*Store, error) {
+	s := &Store{
+		db:       db,
+		fallback: fallback,
+		policies: make(map[string]Policy),
+	}
+
+	data, err := db.Get("privacy", "privacy")
+	if errors.Is(err, store.ErrNotFound) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read privacy settings: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.policies); err != nil {
+		return nil, fmt.Errorf("failed to parse privacy settings: %w", err)
+	}
+	return s, nil
+}
+
+// For returns the policy for an API key
+func (s *Store) For(key string) Policy {
+	if key == "" {
+		return s.fallback
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if policy, ok := s.policies[Hash(key)]; ok {
+		return policy
+	}
+	return s.fallback
+}
+
+// Set stores a key's policy and persists the store. Storing the policy a
+// key already has writes nothing.
+func (s *Store) Set(key string, policy Policy) error {
+	if key == "" {
+		return errors.New("an API key is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hashed := Hash(key)
+	current, ok := s.policies[hashed]
+	switch {
+	case ok && current == policy:
+		return nil
+	case !ok && len(s.policies) >= MaxKeys:
+		return ErrTooManyKeys
+	}
+	s.policies[hashed] = policy
+	return s.save()
+}
+
+// Reset drops a key's explicit settings so the default applies again
+func (s *Store) Reset(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.policies, Hash(key))
+	return s.save()
+}
+
+// save persists the policies. Callers must hold s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.policies, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := s.db.Put("privacy", "privacy", data); err != nil {
+		return fmt.Errorf("failed to write privacy settings: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+// Package qos assigns API keys to service tiers. A tier sets the caller's
+// rate limit, result cap, access to re-ranking, exemption from load shedding
+// and priority in the embedding queue.
+package qos
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DefaultTier names the tier of callers without a configured key
+const DefaultTier = "default"
+
+// Tier is a level of service
+type Tier struct {
+	Name string `json:"-"`
+
+	// RateLimit is requests/second per key (per IP for the default tier).
+	// Zero uses -rate-limit; negative disables rate limiting for the tier.
+	RateLimit float64 `json:"rateLimit,omitempty"`
+	RateBurst int     `json:"rateBurst,omitempty"` // Defaults to the rate limit
+
+	MaxK         int  `json:"maxK,omitempty"`         // Replaces the granularity's -max-k cap when set
+	Rerank       bool `json:"rerank"`                 // May request two-stage re-ranked search
+	SkipShedding bool `json:"skipShedding,omitempty"` // Always waits for inference instead of being shed
+	Priority     int  `json:"priority,omitempty"`     // Higher is admitted to inference first
+}
+
+// Tiers maps API keys to tiers. Keys authenticate a hosted customer, so the
+// file holding them should be kept private.
+type Tiers struct {
+	Tiers   map[string]Tier   `json:"tiers"`
+	Keys    map[string]string `json:"keys"`              // API key -> tier name
+	Default string            `json:"default,omitempty"` // Tier for unknown keys and anonymous callers
+}
+
+// Unrestricted is the default tier when no tiers are configured: it keeps
+// the behavior of a server without QoS
+var Unrestricted = Tier{Name: DefaultTier, Rerank: true}
+
+// Load reads tiers from a JSON file
+func Load(path string) (*Tiers, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read QoS tiers: %w", err)
+	}
+	var tiers Tiers
+	if err := json.Unmarshal(data, &tiers); err != nil {
+		return nil, fmt.Errorf("failed to parse QoS tiers: %w", err)
+	}
+
+	for name, tier := range tiers.Tiers {
+		if tier.MaxK < 0 || tier.RateBurst < 0 {
+			return nil, fmt.Errorf("QoS tier %s: maxK and rateBurst can't be negative", name)
+		}
+		tier.Name = name
+		tiers.Tiers[name] = tier
+	}
+	for key, name := range tiers.Keys {
+		if _, ok := tiers.Tiers[name]; !ok {
+			return nil, fmt.Errorf("QoS key %s...: unknown tier %s", prefix(key), name)
+		}
+	}
+	if _, ok := tiers.Tiers[DefaultTier]; ok && tiers.Default == "" {
+		tiers.Default = DefaultTier
+	}
+	if _, ok := tiers.Tiers[tiers.Default]; tiers.Default != "" && !ok {
+		return nil, fmt.Errorf("unknown default QoS tier %s", tiers.Default)
+	}
+	return &tiers, nil
+}
+
+// For returns the tier of an API key, which may be empty
+func (t *Tiers) For(key string) Tier {
+	if t == nil {
+		return Unrestricted
+	}
+	if name, ok := t.Keys[key]; ok && key != "" {
+		return t.Tiers[name]
+	}
+	if t.Default != "" {
+		return t.Tiers[t.Default]
+	}
+	return Unrestricted
+}
+
+// Keyed reports whether key is assigned a tier, so it is rate limited on its
+// own rather than by client IP
+func (t *Tiers) Keyed(key string) bool {
+	if t == nil || key == "" {
+		return false
+	}
+	_, ok := t.Keys[key]
+	return ok
+}
+
+// All returns every tier callers may be assigned, including Unrestricted
+// when it is the default
+func (t *Tiers) All() []Tier {
+	if t == nil {
+		return []Tier{Unrestricted}
+	}
+	all := make([]Tier, 0, len(t.Tiers)+1)
+	for _, tier := range t.Tiers {
+		all = append(all, tier)
+	}
+	if t.Default == "" {
+		all = append(all, Unrestricted)
+	}
+	return all
+}
+
+// prefix shortens a key for error messages
+func prefix(key string) string {
+	if len(key) > 4 {
+		return key[:4]
+	}
+	return key
+}
+
+type tierKey struct{}
+
+// WithTier returns a context carrying the caller's tier, read by the search
+// and embedding services
+func WithTier(ctx context.Context, tier Tier) context.Context {
+	return context.WithValue(ctx, tierKey{}, tier)
+}
+
+// FromContext returns the tier in ctx, or Unrestricted
+func FromContext(ctx context.Context) Tier {
+	if tier, ok := ctx.Value(tierKey{}).(Tier); ok {
+		return tier
+	}
+	return Unrestricted
+}
@@ -0,0 +1,243 @@
+// Package questions generates discussion questions for a passage with a
+// configured LLM backend and caches them in the store, so each
+// passage costs one completion.
+package questions
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/store"
+)
+
+// promptVersion changes the cache key when the prompt or parsing changes
+const promptVersion = "1"
+
+// DefaultPrompt asks for open-ended study-group questions, one per line
+const DefaultPrompt = "You write discussion questions for Bible study groups. " +
+	"Given a passage, write %d open-ended questions that help a group observe what the text says, " +
+	"interpret it in context, and apply it. Write one question per line with no numbering or commentary."
+
+// ErrBackend reports a failed or unusable completion
+var ErrBackend = errors.New("question backend failed")
+
+// Set is the cached question set for a passage
+type Set struct {
+	Reference   string    `json:"reference"`
+	Questions   []string  `json:"questions"`
+	Model       string    `json:"model"`
+	GeneratedAt time.Time `json:"generatedAt"`
+}
+
+// Generator calls the backend on a cache miss. Concurrent requests for the
+// same passage share one completion.
+type Generator struct {
+	backend  *config.QuestionsBackend
+	db       store.Store
+	client   *http.Client
+	mu       sync.Mutex
+	inflight map[string]*call
+}
+
+// call is a completion in progress that later requests wait on
+type call struct {
+	done chan struct{}
+	set  *Set
+	err  error
+}
+
+// New creates a generator caching question sets in db
+func New(backend *config.QuestionsBackend, db store.Store) *Generator {
+	return &Generator{
+		backend:  backend,
+		db:       db,
+		client:   &http.Client{Timeout: time.Duration(backend.Timeout)},
+		inflight: make(map[string]*call),
+	}
+}
+
+// Model returns the backend's model name
+func (g *Generator) Model() string {
+	return g.backend.Model
+}
+
+// Questions returns the questions for a passage, generating them on first use.
+// The cache key covers the passage text as well as the reference, so a
+// corpus change regenerates them. cached reports whether the backend was skipped.
+func (g *Generator) Questions(ctx context.Context, reference, text string) (set *Set, cached bool, err error) {
+	key := g.cacheKey(reference, text)
+	if set, err := g.readSet(key); err == nil {
+		return set, true, nil
+	}
+
+	g.mu.Lock()
+	if c, ok := g.inflight[key]; ok {
+		g.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.set, true, c.err
+		case <-ctx.Done():
+			return nil, false, ctx.Err()
+		}
+	}
+	c := &call{done: make(chan struct{})}
+	g.inflight[key] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.inflight, key)
+		g.mu.Unlock()
+		close(c.done)
+	}()
+
+	// The completion isn't tied to this request, since others may be waiting on it
+	c.set, c.err = g.generate(context.WithoutCancel(ctx), reference, text)
+	if c.err != nil {
+		return nil, false, c.err
+	}
+	if err := g.writeSet(key, c.set); err != nil {
+		return nil, false, err
+	}
+	return c.set, false, nil
+}
+
+// cacheKey hashes everything that determines the generated questions
+func (g *Generator) cacheKey(reference, text string) string {
+	h := sha256.New()
+	for _, part := range []string{promptVersion, g.backend.URL, g.backend.Model, g.prompt(), reference, text} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// prompt returns the system prompt with the question count filled in
+func (g *Generator) prompt() string {
+	prompt := g.backend.Prompt
+	if prompt == "" {
+		prompt = DefaultPrompt
+	}
+	if strings.Contains(prompt, "%d") {
+		prompt = fmt.Sprintf(prompt, g.backend.Count)
+	}
+	return prompt
+}
+
+// chatMessage is a message in an OpenAI-compatible chat completion
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// generate asks the backend for questions at temperature 0
+func (g *Generator) generate(ctx context.Context, reference, text string) (*Set, error) {
+	body, err := json.Marshal(map[string]interface{}{
+		"model": g.backend.Model,
+		"messages": []chatMessage{
+			{Role: "system", Content: g.prompt()},
+			{Role: "user", Content: reference + "\n\n" + text},
+		},
+		"temperature": 0,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, g.backend.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackend, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if g.backend.APIKeyEnv != "" {
+		req.Header.Set("Authorization", "Bearer "+os.Getenv(g.backend.APIKeyEnv))
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackend, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrBackend, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: HTTP %d", ErrBackend, resp.StatusCode)
+	}
+
+	var completion struct {
+		Choices []struct {
+			Message chatMessage `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(data, &completion); err != nil || len(completion.Choices) == 0 {
+		return nil, fmt.Errorf("%w: unexpected response", ErrBackend)
+	}
+
+	questions := parseQuestions(completion.Choices[0].Message.Content, g.backend.Count)
+	if len(questions) == 0 {
+		return nil, fmt.Errorf("%w: no questions in response", ErrBackend)
+	}
+	return &Set{
+		Reference:   reference,
+		Questions:   questions,
+		Model:       g.backend.Model,
+		GeneratedAt: time.Now().UTC(),
+	}, nil
+}
+
+// listMarker matches numbering or bullets a model adds despite instructions
+var listMarker = regexp.MustCompile(`^\s*(?:\d+[.)]|[-*•])\s*`)
+
+// parseQuestions takes up to count non-empty lines, without list markers
+func parseQuestions(content string, count int) []string {
+	var questions []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(listMarker.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		questions = append(questions, line)
+		if len(questions) == count {
+			break
+		}
+	}
+	return questions
+}
+
+// readSet loads a cached question set
+func (g *Generator) readSet(key string) (*Set, error) {
+	data, err := g.db.Get("questions", key)
+	if err != nil {
+		return nil, err
+	}
+	var set Set
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	return &set, nil
+}
+
+// writeSet caches a question set
+func (g *Generator) writeSet(key string, set *Set) error {
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return err
+	}
+	return g.db.Put("questions", key, data)
+}
@@ -0,0 +1,168 @@
+// Package quiz builds memorization exercises from verse text: fill-in-the-blank
+// prompts and reference matching distractors, at three difficulty levels.
+package quiz
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+// Difficulty levels
+const (
+	Easy   = "easy"   // A few long content words are blanked; distractors come from other books
+	Medium = "medium" // More content words are blanked; distractors come from the same book
+	Hard   = "hard"   // Most words are blanked, function words included; distractors come from the same chapter
+)
+
+// BlankMarker replaces each blanked word in a fill-in prompt
+const BlankMarker = "_____"
+
+// blankShare is the share of candidate words blanked at each difficulty
+var blankShare = map[string]float64{Easy: 0.2, Medium: 0.4, Hard: 0.7}
+
+// wordPattern finds the words that may be blanked
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// functionWords are only blanked at hard difficulty
+var functionWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "he": true, "her": true, "him": true,
+	"his": true, "i": true, "in": true, "is": true, "it": true, "me": true, "my": true,
+	"not": true, "of": true, "on": true, "or": true, "shall": true, "she": true, "so": true,
+	"that": true, "the": true, "thee": true, "them": true, "they": true, "this": true,
+	"thou": true, "thy": true, "to": true, "unto": true, "was": true, "we": true,
+	"which": true, "with": true, "ye": true, "you": true,
+}
+
+// Blank is a word removed from a fill-in prompt. Start and End are character
+// (code point) offsets of the answer in the original text.
+type Blank struct {
+	Index  int    `json:"index"` // Position among the text's words
+	Answer string `json:"answer"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+}
+
+// ValidateDifficulty checks a difficulty level
+func ValidateDifficulty(difficulty string) error {
+	if _, ok := blankShare[difficulty]; !ok {
+		return fmt.Errorf("unknown difficulty: %s (use easy, medium or hard)", difficulty)
+	}
+	return nil
+}
+
+// FillIn blanks words of text for a fill-in-the-blank exercise. Easy and
+// medium blank content words, easy preferring longer ones; hard blanks any
+// word. At least one word is always blanked. It returns the prompt, with
+// each blank replaced by BlankMarker, and the blanks in text order.
+func FillIn(text, difficulty string, rng *rand.Rand) (string, []Blank) {
+	spans := wordPattern.FindAllStringIndex(text, -1)
+	if len(spans) == 0 {
+		return text, nil
+	}
+
+	var candidates []int
+	for i, span := range spans {
+		word := strings.ToLower(text[span[0]:span[1]])
+		if difficulty == Hard || !functionWords[word] {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = []int{rng.Intn(len(spans))}
+	}
+
+	rng.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	if difficulty == Easy {
+		// Long words are the memorable ones, so easy blanks them first
+		sort.SliceStable(candidates, func(i, j int) bool {
+			a, b := spans[candidates[i]], spans[candidates[j]]
+			return a[1]-a[0] > b[1]-b[0]
+		})
+	}
+	count := max(1, int(float64(len(candidates))*blankShare[difficulty]+0.5))
+	chosen := candidates[:min(count, len(candidates))]
+	sort.Ints(chosen)
+
+	var prompt strings.Builder
+	blanks := make([]Blank, len(chosen))
+	last := 0
+	for i, index := range chosen {
+		span := spans[index]
+		prompt.WriteString(text[last:span[0]])
+		prompt.WriteString(BlankMarker)
+		last = span[1]
+		blanks[i] = Blank{
+			Index:  index,
+			Answer: text[span[0]:span[1]],
+			Start:  len([]rune(text[:span[0]])),
+			End:    len([]rune(text[:span[1]])),
+		}
+	}
+	prompt.WriteString(text[last:])
+	return prompt.String(), blanks
+}
+
+// VerseCounter reports how many verses a chapter has, zero if unknown
+type VerseCounter func(book string, chapter int) int
+
+// Distractors picks count wrong references for a single verse: verses from
+// other books when easy, other chapters of its book when medium, and its
+// own chapter when hard. Harder pools that run out fall back to easier ones.
+func Distractors(answer reference.Reference, difficulty string, count int, verses VerseCounter, rng *rand.Rand) []reference.Reference {
+	seen := map[string]bool{answer.String(): true}
+	var picked []reference.Reference
+	add := func(ref reference.Reference) {
+		if key := ref.String(); !seen[key] && len(picked) < count {
+			seen[key] = true
+			picked = append(picked, ref)
+		}
+	}
+
+	book, ok := canon.Lookup(answer.Book)
+	if !ok {
+		return nil
+	}
+
+	if difficulty == Hard {
+		n := verses(book.Name, answer.StartChapter)
+		for _, v := range rng.Perm(n) {
+			add(verse(book.Name, answer.StartChapter, v+1))
+		}
+	}
+	if difficulty != Easy {
+		for attempt := 0; attempt < count*10 && len(picked) < count && book.Chapters > 1; attempt++ {
+			chapter := rng.Intn(book.Chapters) + 1
+			if chapter == answer.StartChapter {
+				continue
+			}
+			if n := verses(book.Name, chapter); n > 0 {
+				add(verse(book.Name, chapter, rng.Intn(n)+1))
+			}
+		}
+	}
+	for attempt := 0; attempt < count*20 && len(picked) < count; attempt++ {
+		other := canon.Books[rng.Intn(len(canon.Books))]
+		if other.Name == book.Name {
+			continue
+		}
+		chapter := rng.Intn(other.Chapters) + 1
+		if n := verses(other.Name, chapter); n > 0 {
+			add(verse(other.Name, chapter, rng.Intn(n)+1))
+		}
+	}
+	return picked
+}
+
+// verse builds a single-verse reference
+func verse(book string, chapter, v int) reference.Reference {
+	return reference.Reference{Book: book, StartChapter: chapter, StartVerse: v, EndChapter: chapter, EndVerse: v}
+}
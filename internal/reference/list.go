@@ -0,0 +1,79 @@
+package reference
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// List is a sequence of references written together, such as
+// "John 3:16, 18; 4:1; Rom 8:28"
+type List []Reference
+
+// ParseList parses a reference or a list of references. A semicolon starts
+// a new chapter and a comma another verse of the same chapter; either may
+// instead name a new book. "John 3:16,18" is John 3:16 and John 3:18, and
+// "Gen 1; 3" is Genesis 1 and Genesis 3.
+func ParseList(input string) (List, error) {
+	normalized := strings.TrimSpace(dashReplacer.Replace(input))
+	if normalized == "" {
+		return nil, &ParseError{Kind: ErrEmpty, Input: input, Message: "reference is empty"}
+	}
+
+	var list List
+	for _, group := range strings.Split(normalized, ";") {
+		for i, part := range strings.Split(group, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				return nil, &ParseError{Kind: ErrMalformed, Input: input, Message: "reference list has an empty entry"}
+			}
+
+			// A part without a book continues the previous reference
+			if len(list) > 0 && !strings.ContainsFunc(part, unicode.IsLetter) {
+				prev := list[len(list)-1]
+				if i > 0 && prev.StartVerse > 0 && !strings.ContainsAny(part, ":.") {
+					part = strconv.Itoa(prev.EndChapter) + ":" + part
+				}
+				part = prev.Book + " " + part
+			}
+
+			ref, err := Parse(part)
+			if err != nil {
+				if parseErr, ok := err.(*ParseError); ok {
+					parseErr.Input = input
+				}
+				return nil, err
+			}
+			list = append(list, ref)
+		}
+	}
+	return list, nil
+}
+
+// String formats the list in canonical form, naming each book once, e.g.
+// "John 3:16, 18; 4:1; Romans 8:28"
+func (l List) String() string {
+	var b strings.Builder
+	for i, ref := range l {
+		if i == 0 {
+			b.WriteString(ref.String())
+			continue
+		}
+
+		prev := l[i-1]
+		switch {
+		case ref.Book != prev.Book:
+			b.WriteString("; " + ref.String())
+		case prev.StartVerse > 0 && ref.StartVerse > 0 && ref.StartChapter == prev.EndChapter && ref.EndChapter == ref.StartChapter:
+			// Another verse of the same chapter
+			verses := strconv.Itoa(ref.StartVerse)
+			if ref.EndVerse != ref.StartVerse {
+				verses += "-" + strconv.Itoa(ref.EndVerse)
+			}
+			b.WriteString(", " + verses)
+		default:
+			b.WriteString("; " + strings.TrimPrefix(ref.String(), ref.Book+" "))
+		}
+	}
+	return b.String()
+}
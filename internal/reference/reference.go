@@ -0,0 +1,265 @@
+// Package reference parses human-written scripture references such as
+// "John 3:16", "Jn 3.16–18", "1 Jn 1:9" or "Gen 1:1-2:3", and lists of them
+// such as "John 3:16,18; 4:1".
+package reference
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+)
+
+// Reference is a parsed, validated scripture reference. A zero StartVerse
+// means the reference covers whole chapters.
+type Reference struct {
+	Book         string `json:"book"`
+	StartChapter int    `json:"startChapter"`
+	StartVerse   int    `json:"startVerse,omitempty"`
+	EndChapter   int    `json:"endChapter"`
+	EndVerse     int    `json:"endVerse,omitempty"`
+}
+
+// ErrorKind classifies reference parse failures
+type ErrorKind string
+
+const (
+	ErrEmpty        ErrorKind = "empty"
+	ErrUnknownBook  ErrorKind = "unknown_book"
+	ErrMalformed    ErrorKind = "malformed"
+	ErrChapterRange ErrorKind = "chapter_out_of_range"
+	ErrInvalidRange ErrorKind = "invalid_range"
+	ErrNotFound     ErrorKind = "not_found"
+)
+
+// ParseError describes why a reference could not be parsed, with the nearest
+// valid reference when one can be inferred
+type ParseError struct {
+	Kind       ErrorKind `json:"kind"`
+	Input      string    `json:"input"`
+	Message    string    `json:"message"`
+	Suggestion string    `json:"suggestion,omitempty"`
+}
+
+func (e *ParseError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%s: %q (did you mean %q?)", e.Message, e.Input, e.Suggestion)
+	}
+	return fmt.Sprintf("%s: %q", e.Message, e.Input)
+}
+
+// dashReplacer folds the Unicode dashes people paste from documents into "-"
+var dashReplacer = strings.NewReplacer(
+	"‐", "-", // hyphen
+	"‑", "-", // non-breaking hyphen
+	"‒", "-", // figure dash
+	"–", "-", // en dash
+	"—", "-", // em dash
+	"―", "-", // horizontal bar
+	"−", "-", // minus sign
+	"﹘", "-", // small em dash
+	"﹣", "-", // small hyphen-minus
+	"－", "-", // fullwidth hyphen-minus
+	" ", " ", // non-breaking space
+)
+
+var (
+	// referencePattern splits "1 Jn 1:9" into book ("1 Jn") and location ("1:9")
+	referencePattern = regexp.MustCompile(`^((?:[1-3]|i{1,3}|first|second|third|1st|2nd|3rd)?[\s.]*\p{L}[\p{L}\s.']*?)[\s.]*(\d[\d\s:.,-]*)?$`)
+	// locationPattern matches "3", "3:16", "3:16-18", "3:16-4:2" and "3-4"
+	locationPattern = regexp.MustCompile(`^(\d+)(?:[:.](\d+))?(?:-(\d+)(?:[:.](\d+))?)?$`)
+)
+
+// Parse parses a single reference such as "Psalm 119:105" or "Gen 1:1-2:3"
+func Parse(input string) (Reference, error) {
+	normalized := strings.TrimSpace(dashReplacer.Replace(input))
+	if normalized == "" {
+		return Reference{}, &ParseError{Kind: ErrEmpty, Input: input, Message: "reference is empty"}
+	}
+
+	match := referencePattern.FindStringSubmatch(strings.ToLower(normalized))
+	if match == nil {
+		return Reference{}, &ParseError{Kind: ErrMalformed, Input: input, Message: "reference is not of the form \"Book chapter:verse\""}
+	}
+
+	bookName := strings.TrimSpace(match[1])
+	book, ok := canon.Lookup(bookName)
+	if !ok {
+		err := &ParseError{Kind: ErrUnknownBook, Input: input, Message: "unknown book"}
+		if nearest := canon.Nearest(bookName); nearest != nil {
+			err.Suggestion = strings.TrimSpace(nearest.Name + " " + strings.TrimSpace(match[2]))
+		}
+		return Reference{}, err
+	}
+
+	location := strings.Join(strings.Fields(match[2]), "")
+	if location == "" {
+		// A bare book name refers to the whole book
+		return Reference{Book: book.Name, StartChapter: 1, EndChapter: book.Chapters}, nil
+	}
+
+	parts := locationPattern.FindStringSubmatch(location)
+	if parts == nil {
+		return Reference{}, &ParseError{
+			Kind:       ErrMalformed,
+			Input:      input,
+			Message:    "could not read chapter and verse",
+			Suggestion: book.Name + " 1:1",
+		}
+	}
+
+	numbers := make([]int, 4)
+	for i, part := range parts[1:] {
+		if part == "" {
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return Reference{}, &ParseError{Kind: ErrMalformed, Input: input, Message: "chapter and verse numbers must be positive integers"}
+		}
+		numbers[i] = n
+	}
+
+	ref := buildReference(book, numbers, parts[2] != "", parts[4] != "")
+	if err := ref.validate(book, input); err != nil {
+		return Reference{}, err
+	}
+
+	return ref, nil
+}
+
+// buildReference interprets the numeric components of a location. numbers
+// holds start chapter, start verse, range end, and end verse in that order.
+func buildReference(book *canon.Book, numbers []int, hasStartVerse, hasEndVerse bool) Reference {
+	ref := Reference{Book: book.Name}
+
+	// In single-chapter books ("Jude 3", "Phlm 4-6") bare numbers are verses
+	if book.Chapters == 1 && !hasStartVerse && !(numbers[0] == 1 && numbers[2] == 0) {
+		ref.StartChapter, ref.EndChapter = 1, 1
+		ref.StartVerse = numbers[0]
+		ref.EndVerse = numbers[0]
+		if numbers[2] > 0 {
+			ref.EndVerse = numbers[2]
+		}
+		return ref
+	}
+
+	ref.StartChapter = numbers[0]
+	ref.StartVerse = numbers[1]
+
+	switch {
+	case numbers[2] == 0:
+		// "3" or "3:16"
+		ref.EndChapter = ref.StartChapter
+		ref.EndVerse = ref.StartVerse
+	case hasEndVerse:
+		// "3:16-4:2" or "3-4:2"
+		ref.EndChapter = numbers[2]
+		ref.EndVerse = numbers[3]
+		if ref.StartVerse == 0 {
+			ref.StartVerse = 1
+		}
+	case hasStartVerse:
+		// "3:16-18"
+		ref.EndChapter = ref.StartChapter
+		ref.EndVerse = numbers[2]
+	default:
+		// "3-4"
+		ref.EndChapter = numbers[2]
+	}
+
+	return ref
+}
+
+// validate checks the reference against the book's chapter count and ordering
+func (r Reference) validate(book *canon.Book, input string) error {
+	if r.StartChapter > book.Chapters || r.EndChapter > book.Chapters {
+		return &ParseError{
+			Kind:       ErrChapterRange,
+			Input:      input,
+			Message:    fmt.Sprintf("%s has %d chapters", book.Name, book.Chapters),
+			Suggestion: fmt.Sprintf("%s %d", book.Name, book.Chapters),
+		}
+	}
+
+	if r.EndChapter < r.StartChapter || (r.EndChapter == r.StartChapter && r.EndVerse < r.StartVerse) {
+		swapped := Reference{
+			Book:         r.Book,
+			StartChapter: r.EndChapter,
+			StartVerse:   r.EndVerse,
+			EndChapter:   r.StartChapter,
+			EndVerse:     r.StartVerse,
+		}
+		return &ParseError{
+			Kind:       ErrInvalidRange,
+			Input:      input,
+			Message:    "range ends before it starts",
+			Suggestion: swapped.String(),
+		}
+	}
+
+	return nil
+}
+
+// IsRange reports whether the reference spans more than a single verse or chapter
+func (r Reference) IsRange() bool {
+	return r.StartChapter != r.EndChapter || r.StartVerse != r.EndVerse
+}
+
+// Contains reports whether chapter:verse falls within the reference
+func (r Reference) Contains(chapter, verse int) bool {
+	if chapter < r.StartChapter || chapter > r.EndChapter {
+		return false
+	}
+	if chapter == r.StartChapter && r.StartVerse > 0 && verse < r.StartVerse {
+		return false
+	}
+	if chapter == r.EndChapter && r.EndVerse > 0 && verse > r.EndVerse {
+		return false
+	}
+	return true
+}
+
+// Overlaps reports whether two references share at least one verse
+func (r Reference) Overlaps(other Reference) bool {
+	if r.Book != other.Book {
+		return false
+	}
+	return !r.endsBefore(other.StartChapter, other.StartVerse) && !other.endsBefore(r.StartChapter, r.StartVerse)
+}
+
+// endsBefore reports whether the reference ends before chapter:verse, where a
+// zero verse means the start of the chapter
+func (r Reference) endsBefore(chapter, verse int) bool {
+	if r.EndChapter != chapter {
+		return r.EndChapter < chapter
+	}
+	// A zero end verse runs to the end of the chapter
+	return r.EndVerse > 0 && verse > 0 && r.EndVerse < verse
+}
+
+// String formats the reference in canonical form, e.g. "John 3:16-18"
+func (r Reference) String() string {
+	start := strconv.Itoa(r.StartChapter)
+	if r.StartVerse > 0 {
+		start += ":" + strconv.Itoa(r.StartVerse)
+	}
+
+	if !r.IsRange() {
+		return r.Book + " " + start
+	}
+
+	var end string
+	switch {
+	case r.StartChapter == r.EndChapter:
+		end = strconv.Itoa(r.EndVerse)
+	case r.EndVerse > 0:
+		end = fmt.Sprintf("%d:%d", r.EndChapter, r.EndVerse)
+	default:
+		end = strconv.Itoa(r.EndChapter)
+	}
+
+	return r.Book + " " + start + "-" + end
+}
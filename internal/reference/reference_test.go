@@ -0,0 +1,91 @@
+package reference
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Reference
+	}{
+		{"John 3:16", Reference{Book: "John", StartChapter: 3, StartVerse: 16, EndChapter: 3, EndVerse: 16}},
+		{"Jn 3.16–18", Reference{Book: "John", StartChapter: 3, StartVerse: 16, EndChapter: 3, EndVerse: 18}},
+		{"Jn 3.16-18", Reference{Book: "John", StartChapter: 3, StartVerse: 16, EndChapter: 3, EndVerse: 18}},
+		{"John 3:16—18", Reference{Book: "John", StartChapter: 3, StartVerse: 16, EndChapter: 3, EndVerse: 18}},
+		{"1 Jn 1:9", Reference{Book: "1 John", StartChapter: 1, StartVerse: 9, EndChapter: 1, EndVerse: 9}},
+		{"Psalm 119:105", Reference{Book: "Psalms", StartChapter: 119, StartVerse: 105, EndChapter: 119, EndVerse: 105}},
+		{"Gen 1:1-2:3", Reference{Book: "Genesis", StartChapter: 1, StartVerse: 1, EndChapter: 2, EndVerse: 3}},
+		{"Gen 1.1–2.3", Reference{Book: "Genesis", StartChapter: 1, StartVerse: 1, EndChapter: 2, EndVerse: 3}},
+		{"Gen 1-2", Reference{Book: "Genesis", StartChapter: 1, EndChapter: 2}},
+		{"Romans 8", Reference{Book: "Romans", StartChapter: 8, EndChapter: 8}},
+		{"Jude 3", Reference{Book: "Jude", StartChapter: 1, StartVerse: 3, EndChapter: 1, EndVerse: 3}},
+		{"Ruth", Reference{Book: "Ruth", StartChapter: 1, EndChapter: 4}},
+	}
+	for _, tt := range tests {
+		got, err := Parse(tt.input)
+		if err != nil {
+			t.Errorf("Parse(%q) failed: %v", tt.input, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		input string
+		kind  ErrorKind
+	}{
+		{"", ErrEmpty},
+		{"   ", ErrEmpty},
+		{"3:16", ErrMalformed},
+		{"Johnn 3:16", ErrUnknownBook},
+		{"Hezekiah 1:1", ErrUnknownBook},
+		{"John 3:16:1", ErrMalformed},
+		{"John 0:1", ErrMalformed},
+		{"John 22:1", ErrChapterRange},
+		{"John 3:18-16", ErrInvalidRange},
+		{"Gen 2:3-1:1", ErrInvalidRange},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.input)
+		var parseErr *ParseError
+		if !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) error = %v, want a *ParseError", tt.input, err)
+			continue
+		}
+		if parseErr.Kind != tt.kind {
+			t.Errorf("Parse(%q) kind = %s, want %s", tt.input, parseErr.Kind, tt.kind)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	for _, seed := range []string{
+		"John 3:16", "Jn 3.16–18", "1 Jn 1:9", "Psalm 119:105", "Gen 1:1-2:3",
+		"Gen 1-2:3", "Jude 3", "Phlm 4-6", "Ruth", "III John 2", "John 3:18-16", "",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		ref, err := Parse(input)
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse(%q) error = %v, want a *ParseError", input, err)
+			}
+			return
+		}
+		again, err := Parse(ref.String())
+		if err != nil {
+			t.Fatalf("Parse(%q) = %q, which fails to parse: %v", input, ref.String(), err)
+		}
+		if again != ref {
+			t.Fatalf("Parse(%q) = %+v, but Parse(%q) = %+v", input, ref, ref.String(), again)
+		}
+	})
+}
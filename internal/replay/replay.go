@@ -0,0 +1,406 @@
+// Package replay re-issues requests recorded in the server's request log
+// against a running instance, to measure latency and, against a baseline
+// instance, how much results change. It validates index and ranking changes
+// on real traffic before they ship.
+package replay
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// UserAgent identifies replayed requests in the target's logs
+const UserAgent = "goscriptureapi-replay"
+
+// Entry is a recorded request
+type Entry struct {
+	Method  string
+	URI     string
+	Latency time.Duration // As logged by the recording server
+}
+
+// logLine is the subset of a request log line replay reads
+type logLine struct {
+	Message string  `json:"message"`
+	Method  string  `json:"method"`
+	URI     string  `json:"uri"`
+	Latency float64 `json:"latency"` // Milliseconds
+}
+
+// ReadLog reads the GET requests of a JSON request log that took at least
+// minLatency and whose path starts with pathPrefix. Other lines, and requests
+// whose query text a privacy policy redacted or hashed, are skipped and
+// counted, since they can't be reproduced.
+func ReadLog(r io.Reader, minLatency time.Duration, pathPrefix string) (entries []Entry, skipped int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var line logLine
+		if json.Unmarshal(scanner.Bytes(), &line) != nil || line.Message != "request" {
+			continue
+		}
+		latency := time.Duration(line.Latency * float64(time.Millisecond))
+		if line.Method != http.MethodGet || latency < minLatency || !strings.HasPrefix(line.URI, pathPrefix) {
+			continue
+		}
+		if redacted(line.URI) {
+			skipped++
+			continue
+		}
+		entries = append(entries, Entry{Method: line.Method, URI: line.URI, Latency: latency})
+	}
+	return entries, skipped, scanner.Err()
+}
+
+// redacted reports whether a logged URI's query text was redacted or hashed
+func redacted(uri string) bool {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return true
+	}
+	for _, param := range []string{"q", "query"} {
+		value := u.Query().Get(param)
+		if value == "[redacted]" || strings.HasPrefix(value, "sha256:") {
+			return true
+		}
+	}
+	return false
+}
+
+// Options controls a replay
+type Options struct {
+	Target      string  // Base URL of the instance under test
+	Baseline    string  // Optional base URL whose results the target's are compared with
+	Concurrency int     // Requests in flight at once, default 1
+	Rate        float64 // Requests/second across all workers; zero is unlimited
+	Client      *http.Client
+}
+
+// Result is the outcome of replaying one entry
+type Result struct {
+	Entry    Entry
+	Status   int
+	Latency  time.Duration
+	Err      error
+	Baseline *Response // Nil without a baseline
+	Target   *Response
+}
+
+// Response is what one instance returned for an entry
+type Response struct {
+	Status  int
+	Latency time.Duration
+	Refs    []string // Result references, in rank order, for search-like responses
+	Digest  [32]byte // Hash of the body, for everything else
+}
+
+// Run replays entries in order of issue, spread across workers
+func Run(ctx context.Context, entries []Entry, opts Options) []Result {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 60 * time.Second}
+	}
+	var limiter *rate.Limiter
+	if opts.Rate > 0 {
+		limiter = rate.NewLimiter(rate.Limit(opts.Rate), 1)
+	}
+
+	results := make([]Result, len(entries))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = replayOne(ctx, opts, entries[i])
+			}
+		}()
+	}
+	for i := range entries {
+		if limiter != nil && limiter.Wait(ctx) != nil {
+			break
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// replayOne sends an entry to the target and, if set, the baseline
+func replayOne(ctx context.Context, opts Options, entry Entry) Result {
+	result := Result{Entry: entry}
+	target, err := fetch(ctx, opts.Client, opts.Target, entry)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	result.Target = target
+	result.Status = target.Status
+	result.Latency = target.Latency
+
+	if opts.Baseline != "" {
+		baseline, err := fetch(ctx, opts.Client, opts.Baseline, entry)
+		if err != nil {
+			result.Err = fmt.Errorf("baseline: %w", err)
+			return result
+		}
+		result.Baseline = baseline
+	}
+	return result
+}
+
+// fetch sends an entry to one instance and summarizes the response
+func fetch(ctx context.Context, client *http.Client, base string, entry Entry) (*Response, error) {
+	req, err := http.NewRequestWithContext(ctx, entry.Method, strings.TrimSuffix(base, "/")+entry.URI, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", UserAgent)
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &Response{
+		Status:  resp.StatusCode,
+		Latency: time.Since(start),
+		Refs:    references(body),
+		Digest:  sha256.Sum256(body),
+	}, nil
+}
+
+// references extracts the ranked result references of a search response
+func references(body []byte) []string {
+	var response struct {
+		Results []struct {
+			Book       string `json:"book"`
+			Chapter    int    `json:"chapter"`
+			VerseNum   int    `json:"verse"`
+			SearchMeta struct {
+				Reference string `json:"reference"`
+			} `json:"searchMeta"`
+		} `json:"results"`
+	}
+	if json.Unmarshal(body, &response) != nil || response.Results == nil {
+		return nil
+	}
+	refs := make([]string, len(response.Results))
+	for i, result := range response.Results {
+		refs[i] = result.SearchMeta.Reference
+		if refs[i] == "" {
+			refs[i] = fmt.Sprintf("%s %d:%d", result.Book, result.Chapter, result.VerseNum)
+		}
+	}
+	return refs
+}
+
+// Summary aggregates replay results
+type Summary struct {
+	Requests int
+	Errors   int
+	Statuses map[int]int
+
+	// Latency percentiles of the target, and of the recording for comparison
+	Target   Percentiles
+	Recorded Percentiles
+
+	// Against a baseline: requests compared, and how their results agree
+	Compared     int
+	Identical    int     // Same references in the same order, or the same body
+	SameTop      int     // Same first result
+	MeanJaccard  float64 // Mean overlap of the result sets
+	StatusDiffer int     // Target and baseline answered with different statuses
+	Divergent    []Divergence
+}
+
+// Percentiles of a latency distribution
+type Percentiles struct {
+	P50, P90, P99, Max time.Duration
+}
+
+// Divergence is a request whose results differ most from the baseline
+type Divergence struct {
+	URI     string
+	Jaccard float64
+}
+
+// Summarize aggregates results, keeping the worst divergent requests
+func Summarize(results []Result, worst int) Summary {
+	summary := Summary{Statuses: make(map[int]int)}
+	var target, recorded []time.Duration
+	var jaccardSum float64
+	for _, result := range results {
+		if result.Entry.URI == "" {
+			continue // Never issued: the replay was interrupted
+		}
+		summary.Requests++
+		if result.Err != nil {
+			summary.Errors++
+			continue
+		}
+		summary.Statuses[result.Status]++
+		target = append(target, result.Latency)
+		recorded = append(recorded, result.Entry.Latency)
+
+		if result.Baseline == nil {
+			continue
+		}
+		summary.Compared++
+		if result.Baseline.Status != result.Target.Status {
+			summary.StatusDiffer++
+		}
+		a, b := result.Baseline, result.Target
+		if a.Refs == nil || b.Refs == nil {
+			// Error bodies carry request IDs, so matching errors compare by status
+			if a.Digest == b.Digest || a.Status >= 400 && a.Status == b.Status {
+				summary.Identical++
+				summary.SameTop++
+				jaccardSum++
+			} else {
+				summary.Divergent = append(summary.Divergent, Divergence{URI: result.Entry.URI})
+			}
+			continue
+		}
+		jaccard := overlap(a.Refs, b.Refs)
+		jaccardSum += jaccard
+		if equal(a.Refs, b.Refs) {
+			summary.Identical++
+		} else {
+			summary.Divergent = append(summary.Divergent, Divergence{URI: result.Entry.URI, Jaccard: jaccard})
+		}
+		if len(a.Refs) == 0 && len(b.Refs) == 0 || len(a.Refs) > 0 && len(b.Refs) > 0 && a.Refs[0] == b.Refs[0] {
+			summary.SameTop++
+		}
+	}
+	if summary.Compared > 0 {
+		summary.MeanJaccard = jaccardSum / float64(summary.Compared)
+	}
+	sort.SliceStable(summary.Divergent, func(i, j int) bool {
+		return summary.Divergent[i].Jaccard < summary.Divergent[j].Jaccard
+	})
+	if len(summary.Divergent) > worst {
+		summary.Divergent = summary.Divergent[:worst]
+	}
+	summary.Target = percentiles(target)
+	summary.Recorded = percentiles(recorded)
+	return summary
+}
+
+// overlap is the Jaccard index of two reference lists; two empty lists agree
+func overlap(a, b []string) float64 {
+	setA := make(map[string]bool, len(a))
+	for _, ref := range a {
+		setA[ref] = true
+	}
+	setB := make(map[string]bool, len(b))
+	for _, ref := range b {
+		setB[ref] = true
+	}
+	shared := 0
+	for ref := range setB {
+		if setA[ref] {
+			shared++
+		}
+	}
+	union := len(setA) + len(setB) - shared
+	if union == 0 {
+		return 1
+	}
+	return float64(shared) / float64(union)
+}
+
+// equal reports whether two reference lists match in order
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// percentiles computes latency percentiles by nearest rank
+func percentiles(latencies []time.Duration) Percentiles {
+	if len(latencies) == 0 {
+		return Percentiles{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	at := func(p float64) time.Duration {
+		return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+	}
+	return Percentiles{P50: at(0.5), P90: at(0.9), P99: at(0.99), Max: sorted[len(sorted)-1]}
+}
+
+// Write prints the summary for a terminal
+func (s Summary) Write(w io.Writer) {
+	fmt.Fprintf(w, "Requests: %d (%d failed)\n", s.Requests, s.Errors)
+	statuses := make([]int, 0, len(s.Statuses))
+	for status := range s.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Ints(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "  HTTP %d: %d\n", status, s.Statuses[status])
+	}
+
+	fmt.Fprintln(w, "\nLatency      p50        p90        p99        max")
+	for _, row := range []struct {
+		name string
+		p    Percentiles
+	}{{"target", s.Target}, {"recorded", s.Recorded}} {
+		fmt.Fprintf(w, "  %-9s %-10s %-10s %-10s %s\n", row.name,
+			round(row.p.P50), round(row.p.P90), round(row.p.P99), round(row.p.Max))
+	}
+
+	if s.Compared == 0 {
+		return
+	}
+	share := func(n int) string {
+		return fmt.Sprintf("%d (%.1f%%)", n, 100*float64(n)/float64(s.Compared))
+	}
+	fmt.Fprintf(w, "\nCompared with baseline: %d\n", s.Compared)
+	fmt.Fprintf(w, "  identical results:  %s\n", share(s.Identical))
+	fmt.Fprintf(w, "  same top result:    %s\n", share(s.SameTop))
+	fmt.Fprintf(w, "  different status:   %s\n", share(s.StatusDiffer))
+	fmt.Fprintf(w, "  mean Jaccard:       %.3f\n", s.MeanJaccard)
+	if len(s.Divergent) > 0 {
+		fmt.Fprintln(w, "\nMost divergent:")
+		for _, d := range s.Divergent {
+			fmt.Fprintf(w, "  %.3f  %s\n", d.Jaccard, d.URI)
+		}
+	}
+}
+
+// round shortens a latency for display
+func round(d time.Duration) time.Duration {
+	return d.Round(100 * time.Microsecond)
+}
@@ -0,0 +1,157 @@
+// Package saved persists search result sets under short IDs, so a shared
+// link keeps showing the same ranked passages after the indices change.
+package saved
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/store"
+)
+
+// ErrNotFound is returned for unknown or expired result set IDs
+var ErrNotFound = errors.New("saved results not found or expired")
+
+// sweepInterval spaces out scans of the store for expired result sets
+const sweepInterval = time.Hour
+
+// idPattern matches the IDs Save issues: 8 URL-safe base64 characters
+var idPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{8}$`)
+
+// Entry is a saved result set
+type Entry struct {
+	ID        string          `json:"id"`
+	Response  json.RawMessage `json:"response"` // The search response as it was returned
+	CreatedAt time.Time       `json:"createdAt"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+}
+
+// Store keeps result sets as JSON documents until they expire
+type Store struct {
+	db     store.Store
+	maxTTL time.Duration
+
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+// NewStore opens the result sets kept in db. They are kept for at most
+// maxTTL.
+func NewStore(db store.Store, maxTTL time.Duration) (*Store, error) {
+	s := &Store{db: db, maxTTL: maxTTL}
+	s.sweep()
+	return s, nil
+}
+
+// MaxTTL returns the longest a result set is kept
+func (s *Store) MaxTTL() time.Duration {
+	return s.maxTTL
+}
+
+// Save stores a response under a new ID for ttl, capped at the store's
+// maximum; a zero ttl uses the maximum
+func (s *Store) Save(response interface{}, ttl time.Duration) (*Entry, error) {
+	if ttl <= 0 || ttl > s.maxTTL {
+		ttl = s.maxTTL
+	}
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var id string
+	for {
+		if id, err = newID(); err != nil {
+			return nil, err
+		}
+		// Any other error surfaces when writing
+		if _, err := s.db.Get("results", id); err != nil {
+			break
+		}
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	entry := &Entry{ID: id, Response: data, CreatedAt: now, ExpiresAt: now.Add(ttl)}
+	if err := s.write(entry); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	due := time.Since(s.lastSweep) > sweepInterval
+	s.mu.Unlock()
+	if due {
+		go s.sweep()
+	}
+	return entry, nil
+}
+
+// Get returns a saved result set
+func (s *Store) Get(id string) (*Entry, error) {
+	if !idPattern.MatchString(id) {
+		return nil, ErrNotFound
+	}
+	entry, err := s.read(id)
+	if errors.Is(err, store.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(entry.ExpiresAt) {
+		s.db.Delete("results", id)
+		return nil, ErrNotFound
+	}
+	return entry, nil
+}
+
+func (s *Store) read(id string) (*Entry, error) {
+	data, err := s.db.Get("results", id)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// write stores an entry
+func (s *Store) write(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return s.db.Put("results", entry.ID, data)
+}
+
+// sweep removes expired result sets
+func (s *Store) sweep() {
+	s.mu.Lock()
+	s.lastSweep = time.Now()
+	s.mu.Unlock()
+
+	ids, err := s.db.Keys("results")
+	if err != nil {
+		return
+	}
+	now := time.Now()
+	for _, id := range ids {
+		if entry, err := s.read(id); err == nil && now.After(entry.ExpiresAt) {
+			s.db.Delete("results", id)
+		}
+	}
+}
+
+// newID returns a random 8-character ID, short enough to share
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
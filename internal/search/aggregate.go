@@ -0,0 +1,89 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+)
+
+// Aggregations count search candidates by these facets
+const (
+	AggregationBook      = "book"
+	AggregationTestament = "testament"
+	AggregationGenre     = "genre"
+)
+
+// AggregationCandidates is how many of the top candidates aggregations
+// count, when k asks for fewer results
+const AggregationCandidates = 100
+
+// Bucket counts the candidates with one value of a facet
+type Bucket struct {
+	Key   string `json:"key"`            // Usable as the facet's filter, e.g. "1Cor" for book
+	Name  string `json:"name,omitempty"` // Display name, for books
+	Count int    `json:"count"`
+}
+
+// ValidateAggregations checks aggregation names
+func ValidateAggregations(names []string) error {
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case AggregationBook, AggregationTestament, AggregationGenre:
+		default:
+			return fmt.Errorf("unknown aggregation: %s (use book, testament or genre)", name)
+		}
+	}
+	return nil
+}
+
+// Aggregate counts results by each named facet. Buckets are ordered by count,
+// then canonically. Results outside the canon, such as notes, aren't counted.
+func Aggregate(results []SearchResult, names []string) map[string][]Bucket {
+	if len(names) == 0 {
+		return nil
+	}
+	aggregations := make(map[string][]Bucket, len(names))
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		buckets := make(map[string]*Bucket)
+		first := make(map[string]int) // Canonical position of a bucket's first book
+		for _, result := range results {
+			book, ok := canon.Lookup(result.Chunk.Meta.Book)
+			if !ok {
+				continue
+			}
+			var key, display string
+			switch name {
+			case AggregationBook:
+				key, display = book.ID, book.Name
+			case AggregationTestament:
+				key = book.Testament
+			case AggregationGenre:
+				key = book.Genre
+			}
+			bucket, ok := buckets[key]
+			if !ok {
+				bucket = &Bucket{Key: key, Name: display}
+				buckets[key] = bucket
+				first[key] = canon.Position(book.ID)
+			}
+			bucket.Count++
+			first[key] = min(first[key], canon.Position(book.ID))
+		}
+
+		list := make([]Bucket, 0, len(buckets))
+		for _, bucket := range buckets {
+			list = append(list, *bucket)
+		}
+		sort.Slice(list, func(i, j int) bool {
+			if list[i].Count != list[j].Count {
+				return list[i].Count > list[j].Count
+			}
+			return first[list[i].Key] < first[list[j].Key]
+		})
+		aggregations[name] = list
+	}
+	return aggregations
+}
@@ -0,0 +1,219 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Kinds of scripture use detected in a transcript
+const (
+	AlignQuotation = "quotation" // Shares enough word n-grams with the verse to be a quotation
+	AlignAllusion  = "allusion"  // Semantically close to the verse without quoting it
+)
+
+const (
+	// alignCandidates is how many verses each window is checked against
+	alignCandidates = 3
+	// alignNgram is the word n-gram length used to recognize quotations
+	alignNgram = 3
+	// quoteOverlap is the n-gram overlap a window needs to quote a verse
+	quoteOverlap = 0.5
+)
+
+// AlignOptions tunes transcript alignment. Zero values use the defaults.
+type AlignOptions struct {
+	Window        int     // Words per window, default 20
+	Stride        int     // Words between window starts, default half the window
+	MinSimilarity float32 // Similarity an allusion needs, default 0.7
+}
+
+// Alignment is a verse quoted or alluded to in a transcript. Start and End
+// are character (code point) offsets into the transcript.
+type Alignment struct {
+	ID         string
+	Verse      SearchResult
+	Kind       string
+	Similarity float32
+	Overlap    float64 // Share of the word n-grams of the verse, or of a shorter window, found in both
+	Start      int
+	End        int
+}
+
+// transcriptWord is a normalized word and its character span in the transcript
+type transcriptWord struct {
+	text       string
+	start, end int
+}
+
+// Align detects scripture quotations and allusions in a long text. The text
+// is cut into overlapping word windows, which are embedded together and
+// matched against the verse index. A candidate verse sharing enough word
+// n-grams with its window is a quotation, spanning just the shared words;
+// otherwise one similar enough is an allusion, spanning the window.
+// Consecutive windows matching the same verse are merged.
+func (s *SearchService) Align(ctx context.Context, text string, options AlignOptions) ([]Alignment, error) {
+	if options.Window <= 0 {
+		options.Window = 20
+	}
+	if options.Stride <= 0 {
+		options.Stride = max(options.Window/2, 1)
+	}
+	if options.MinSimilarity <= 0 {
+		options.MinSimilarity = 0.7
+	}
+	verseOptions := withDefaults(SearchOptions{K: alignCandidates, Ranking: RankingPure})
+	if err := s.checkLoaded(verseOptions.Granularity); err != nil {
+		return nil, err
+	}
+
+	words := transcriptWords(text)
+	if len(words) == 0 {
+		return nil, nil
+	}
+	runes := []rune(text)
+
+	var spans [][2]int // Word index ranges of each window
+	var windows []string
+	for i := 0; ; i += options.Stride {
+		j := min(i+options.Window, len(words))
+		spans = append(spans, [2]int{i, j})
+		windows = append(windows, string(runes[words[i].start:words[j-1].end]))
+		if j == len(words) {
+			break
+		}
+	}
+
+	embeddings, err := s.embeddings.EmbedQueries(ctx, windows)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	var alignments []Alignment
+	for w, window := range windows {
+		candidates, err := s.searchEmbedding(ctx, window, embeddings[w], verseOptions)
+		if err != nil {
+			return nil, err
+		}
+		windowWords := words[spans[w][0]:spans[w][1]]
+		alignment, ok := bestAlignment(candidates, windowWords, options.MinSimilarity)
+		if !ok {
+			continue
+		}
+
+		// Merge with the previous detection of the same verse if they touch
+		if n := len(alignments); n > 0 && alignments[n-1].ID == alignment.ID && alignments[n-1].End >= alignment.Start {
+			prev := &alignments[n-1]
+			prev.End = max(prev.End, alignment.End)
+			prev.Similarity = max(prev.Similarity, alignment.Similarity)
+			prev.Overlap = max(prev.Overlap, alignment.Overlap)
+			if alignment.Kind == AlignQuotation {
+				prev.Kind = AlignQuotation
+			}
+			continue
+		}
+		alignments = append(alignments, alignment)
+	}
+	return alignments, nil
+}
+
+// bestAlignment picks the window's best match among candidate verses: the
+// most-quoted verse if any is quoted, otherwise the most similar verse if it
+// clears minSimilarity
+func bestAlignment(candidates []SearchResult, window []transcriptWord, minSimilarity float32) (Alignment, bool) {
+	var best Alignment
+	found := false
+	for _, candidate := range candidates {
+		overlap, first, last := ngramOverlap(window, queryTerms(candidate.Chunk.Text))
+		a := Alignment{
+			ID:         candidate.ID,
+			Verse:      candidate,
+			Similarity: candidate.Similarity,
+			Overlap:    overlap,
+		}
+		switch {
+		case overlap >= quoteOverlap:
+			a.Kind = AlignQuotation
+			a.Start, a.End = window[first].start, window[last].end
+		case candidate.Similarity >= minSimilarity:
+			a.Kind = AlignAllusion
+			a.Start, a.End = window[0].start, window[len(window)-1].end
+		default:
+			continue
+		}
+
+		better := !found ||
+			(a.Kind == AlignQuotation && (best.Kind != AlignQuotation || a.Overlap > best.Overlap)) ||
+			(a.Kind == AlignAllusion && best.Kind == AlignAllusion && a.Similarity > best.Similarity)
+		if better {
+			best, found = a, true
+		}
+	}
+	return best, found
+}
+
+// ngramOverlap returns the share of the verse's word n-grams that appear in
+// the window, and the first and last window words covered by a shared n-gram.
+// A verse longer than the window is measured against the window's n-grams
+// instead, so a window inside a long quotation still counts. Verses shorter
+// than an n-gram are compared word for word.
+func ngramOverlap(window []transcriptWord, verse []string) (float64, int, int) {
+	n := min(alignNgram, len(verse))
+	if n == 0 || len(window) < n {
+		return 0, 0, 0
+	}
+
+	verseGrams := make(map[string]bool)
+	for i := 0; i+n <= len(verse); i++ {
+		verseGrams[strings.Join(verse[i:i+n], " ")] = true
+	}
+
+	shared := make(map[string]bool)
+	first, last := -1, -1
+	gram := make([]string, n)
+	for i := 0; i+n <= len(window); i++ {
+		for j := range gram {
+			gram[j] = window[i+j].text
+		}
+		key := strings.Join(gram, " ")
+		if !verseGrams[key] {
+			continue
+		}
+		shared[key] = true
+		if first < 0 {
+			first = i
+		}
+		last = i + n - 1
+	}
+	if first < 0 {
+		return 0, 0, 0
+	}
+	return float64(len(shared)) / float64(min(len(verseGrams), len(window)-n+1)), first, last
+}
+
+// transcriptWords splits text into lowercase words, as queryTerms does, with
+// their character offsets
+func transcriptWords(text string) []transcriptWord {
+	var words []transcriptWord
+	start := -1
+	pos := 0
+	var word []rune
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsNumber(r) || r == '\'' {
+			if start < 0 {
+				start = pos
+				word = word[:0]
+			}
+			word = append(word, unicode.ToLower(r))
+		} else if start >= 0 {
+			words = append(words, transcriptWord{text: string(word), start: start, end: pos})
+			start = -1
+		}
+		pos++
+	}
+	if start >= 0 {
+		words = append(words, transcriptWord{text: string(word), start: start, end: pos})
+	}
+	return words
+}
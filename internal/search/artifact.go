@@ -0,0 +1,127 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// ArtifactHeader describes how an embeddings artifact was produced
+type ArtifactHeader struct {
+	ModelID    string    `json:"modelId"`
+	Dimensions int       `json:"dimensions"`
+	Normalized bool      `json:"normalized"`
+	Metric     string    `json:"metric"` // "cosine", "dot" or "l2"
+	CorpusHash string    `json:"corpusHash,omitempty"`
+	CreatedAt  time.Time `json:"createdAt,omitempty"`
+
+	// Verified is false for legacy artifacts without a header
+	Verified bool `json:"verified"`
+
+	// License is the license and attribution the artifact declares, if any
+	License *config.CorpusLicense `json:"license,omitempty"`
+}
+
+// parseArtifactHeader converts an embeddings artifact's "header" object,
+// returning nil for legacy artifacts that don't carry one
+func parseArtifactHeader(raw *artifactHeaderJSON) *ArtifactHeader {
+	if raw == nil {
+		return nil
+	}
+
+	header := &ArtifactHeader{
+		ModelID:    raw.ModelID,
+		Dimensions: raw.Dimensions,
+		Normalized: raw.Normalized,
+		Metric:     strings.ToLower(raw.Metric),
+		CorpusHash: raw.CorpusHash,
+		Verified:   true,
+	}
+	if createdAt, err := time.Parse(time.RFC3339, raw.CreatedAt); err == nil {
+		header.CreatedAt = createdAt
+	}
+	if raw.License != nil && !raw.License.IsZero() {
+		header.License = raw.License
+	}
+	return header
+}
+
+// validateArtifact checks an artifact header against the active embedding
+// backend and the vectors actually loaded. Legacy artifacts are accepted with
+// a synthesized, unverified header.
+func validateArtifact(granularity string, header *ArtifactHeader, vectors [][]float32, corpusHash string) (*ArtifactHeader, error) {
+	if header == nil {
+		log.Warn().Str("granularity", granularity).Msg("Embeddings artifact has no provenance header, serving unverified")
+		dims := 0
+		if len(vectors) > 0 {
+			dims = len(vectors[0])
+		}
+		return &ArtifactHeader{
+			ModelID:    config.ModelConfig.ModelID,
+			Dimensions: dims,
+			Metric:     "cosine",
+			CorpusHash: corpusHash,
+		}, nil
+	}
+
+	if header.ModelID != "" && !sameModel(header.ModelID, config.ModelConfig.ModelID) {
+		return nil, fmt.Errorf("artifact was built with model %s but queries use %s", header.ModelID, config.ModelConfig.ModelID)
+	}
+
+	if len(vectors) > 0 && header.Dimensions != 0 && header.Dimensions != len(vectors[0]) {
+		return nil, fmt.Errorf("artifact header declares %d dimensions but vectors have %d", header.Dimensions, len(vectors[0]))
+	}
+
+	// The index ranks by cosine; dot product and L2 only agree with it on unit vectors
+	switch header.Metric {
+	case "", "cosine":
+	case "dot", "l2", "euclidean":
+		if !header.Normalized {
+			return nil, fmt.Errorf("artifact uses %s similarity on unnormalized vectors, which cosine ranking can't reproduce", header.Metric)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported similarity metric: %s", header.Metric)
+	}
+
+	if header.CorpusHash != "" && corpusHash != "" && header.CorpusHash != corpusHash {
+		return nil, fmt.Errorf("artifact corpus hash %s does not match loaded text %s", header.CorpusHash, corpusHash)
+	}
+
+	return header, nil
+}
+
+// sameModel compares model IDs, ignoring case and packaging suffixes such as
+// "-ONNX" so "google/embeddinggemma-300m" matches its ONNX export
+func sameModel(a, b string) bool {
+	normalize := func(id string) string {
+		id = strings.ToLower(id)
+		if i := strings.LastIndex(id, "/"); i >= 0 {
+			id = id[i+1:]
+		}
+		return strings.TrimSuffix(id, "-onnx")
+	}
+	return normalize(a) == normalize(b)
+}
+
+// hashCorpus computes a SHA-256 digest over the texts of the given IDs in sorted ID order
+func hashCorpus(ids []string, textLookup map[string]*TextData) string {
+	sorted := append([]string(nil), ids...)
+	sort.Strings(sorted)
+
+	h := sha256.New()
+	for _, id := range sorted {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+		if text, ok := textLookup[id]; ok {
+			h.Write([]byte(text.Text))
+		}
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
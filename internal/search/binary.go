@@ -0,0 +1,117 @@
+package search
+
+import (
+	"container/heap"
+	"context"
+	"math"
+	"math/bits"
+	"sync"
+)
+
+// binaryCandidateFactor widens the re-rank pool for the binary index, whose
+// Hamming ranking is coarser than the int8 index's
+const binaryCandidateFactor = 4
+
+// BinaryIndex stores each vector as the signs of its components, one bit per
+// dimension, at 1/32 of the float index's memory. Vectors are compared by
+// Hamming distance, which estimates the angle between them.
+type BinaryIndex struct {
+	IDs  []string
+	Bits [][]uint64
+	Dims int
+	mu   sync.RWMutex
+}
+
+// NewBinaryIndex creates a new binary index
+func NewBinaryIndex() *BinaryIndex {
+	return &BinaryIndex{
+		IDs:  make([]string, 0),
+		Bits: make([][]uint64, 0),
+	}
+}
+
+// signBits packs a set bit for every positive component
+func signBits(vec []float32) []uint64 {
+	words := make([]uint64, (len(vec)+63)/64)
+	for i, v := range vec {
+		if v > 0 {
+			words[i/64] |= 1 << (i % 64)
+		}
+	}
+	return words
+}
+
+// hamming counts the differing bits of two packed vectors of equal length
+func hamming(a, b []uint64) int {
+	distance := 0
+	for i := range a {
+		distance += bits.OnesCount64(a[i] ^ b[i])
+	}
+	return distance
+}
+
+// Add adds a vector to the binary index
+func (bi *BinaryIndex) Add(id string, vector []float32) {
+	bi.mu.Lock()
+	defer bi.mu.Unlock()
+
+	if bi.Dims == 0 {
+		bi.Dims = len(vector)
+	}
+	bi.IDs = append(bi.IDs, id)
+	bi.Bits = append(bi.Bits, signBits(vector))
+}
+
+// SearchWithFilter ranks vectors by Hamming distance to the query's signs.
+// Similarity is the cosine of the angle the distance estimates, cos(π·d/dims).
+// The scan stops early once ctx ends.
+func (bi *BinaryIndex) SearchWithFilter(ctx context.Context, query []float32, k int, filter func(id string) bool) []SearchResult {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	if len(bi.IDs) == 0 || len(query) != bi.Dims {
+		return nil
+	}
+	q := signBits(query)
+
+	top := &resultHeap{}
+	for i, words := range bi.Bits {
+		if i%cancelCheckInterval == 0 && ctx.Err() != nil {
+			break
+		}
+		if !filter(bi.IDs[i]) {
+			continue
+		}
+		score := float32(math.Cos(math.Pi * float64(hamming(q, words)) / float64(bi.Dims)))
+		r := SearchResult{ID: bi.IDs[i], Similarity: score, Score: score}
+		if top.Len() < k {
+			heap.Push(top, r)
+		} else if k > 0 && ranksAbove(r, (*top)[0]) {
+			(*top)[0] = r
+			heap.Fix(top, 0)
+		}
+	}
+
+	results := []SearchResult(*top)
+	sortBySimilarity(results)
+	return results
+}
+
+// Size returns the number of vectors in the binary index
+func (bi *BinaryIndex) Size() int {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+	return len(bi.IDs)
+}
+
+// GetMemoryUsage estimates memory usage of the binary index
+func (bi *BinaryIndex) GetMemoryUsage() int64 {
+	bi.mu.RLock()
+	defer bi.mu.RUnlock()
+
+	memory := int64(0)
+	for i, words := range bi.Bits {
+		memory += int64(len(words))*8 + int64(len(bi.IDs[i]))
+	}
+	return memory
+}
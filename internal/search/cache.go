@@ -0,0 +1,165 @@
+package search
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// Cache holds parsed downloads by URL, bounded by their total size and
+// expiring each entry after a TTL. The least recently used entries are
+// evicted first.
+type Cache struct {
+	maxBytes int64
+	ttl      time.Duration
+
+	mu      sync.Mutex
+	order   *list.List // front is most recently used
+	entries map[string]*list.Element
+	bytes   int64
+
+	hits, misses, evictions, expirations uint64
+}
+
+type cacheEntry struct {
+	key     string
+	value   interface{}
+	size    int64
+	expires time.Time
+}
+
+// CacheStats reports a cache's occupancy and effectiveness
+type CacheStats struct {
+	MaxBytes    int64   `json:"maxBytes"`
+	Bytes       int64   `json:"bytes"`
+	Entries     int     `json:"entries"`
+	TTLSeconds  float64 `json:"ttlSeconds"`
+	Hits        uint64  `json:"hits"`
+	Misses      uint64  `json:"misses"`
+	Evictions   uint64  `json:"evictions"`   // Dropped to make room
+	Expirations uint64  `json:"expirations"` // Dropped after the TTL
+	HitRate     float64 `json:"hitRate"`
+}
+
+// NewCache creates a cache holding up to maxBytes, whose entries expire
+// after ttl. A non-positive maxBytes disables caching; a non-positive ttl
+// keeps entries until evicted.
+func NewCache(maxBytes int64, ttl time.Duration) *Cache {
+	return &Cache{
+		maxBytes: maxBytes,
+		ttl:      ttl,
+		order:    list.New(),
+		entries:  make(map[string]*list.Element),
+	}
+}
+
+// Get retrieves a value from cache
+func (c *Cache) Get(key string) (interface{}, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if ok && c.expired(elem.Value.(*cacheEntry)) {
+		c.remove(elem)
+		c.expirations++
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+// Set stores a value of the given size in bytes, evicting the least
+// recently used entries to make room. Values larger than the whole cache
+// aren't stored.
+func (c *Cache) Set(key string, value interface{}, size int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purgeExpired()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	if c.maxBytes <= 0 || size > c.maxBytes {
+		return
+	}
+
+	entry := &cacheEntry{key: key, value: value, size: size}
+	if c.ttl > 0 {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.entries[key] = c.order.PushFront(entry)
+	c.bytes += size
+	for c.bytes > c.maxBytes {
+		c.remove(c.order.Back())
+		c.evictions++
+	}
+}
+
+// Delete removes an entry from the cache
+func (c *Cache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+}
+
+// Clear removes all entries from the cache
+func (c *Cache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.entries = make(map[string]*list.Element)
+	c.bytes = 0
+}
+
+// Stats reports the cache's occupancy, dropping expired entries first
+func (c *Cache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.purgeExpired()
+	stats := CacheStats{
+		MaxBytes:    c.maxBytes,
+		Bytes:       c.bytes,
+		Entries:     c.order.Len(),
+		TTLSeconds:  c.ttl.Seconds(),
+		Hits:        c.hits,
+		Misses:      c.misses,
+		Evictions:   c.evictions,
+		Expirations: c.expirations,
+	}
+	if total := c.hits + c.misses; total > 0 {
+		stats.HitRate = float64(c.hits) / float64(total)
+	}
+	return stats
+}
+
+// purgeExpired drops every expired entry. The caller holds c.mu.
+func (c *Cache) purgeExpired() {
+	for elem := c.order.Back(); elem != nil; {
+		prev := elem.Prev()
+		if c.expired(elem.Value.(*cacheEntry)) {
+			c.remove(elem)
+			c.expirations++
+		}
+		elem = prev
+	}
+}
+
+func (c *Cache) expired(entry *cacheEntry) bool {
+	return !entry.expires.IsZero() && time.Now().After(entry.expires)
+}
+
+// remove drops an entry. The caller holds c.mu.
+func (c *Cache) remove(elem *list.Element) {
+	entry := elem.Value.(*cacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	c.bytes -= entry.size
+}
@@ -0,0 +1,66 @@
+package search
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+)
+
+// IndexChecksum fingerprints the content of a loaded index, so replicas can
+// confirm they serve identical data
+type IndexChecksum struct {
+	Granularity string `json:"granularity"`
+	Model       string `json:"model"`
+	Vectors     int    `json:"vectors"`
+	Dimensions  int    `json:"dimensions"`
+	// VectorChecksum is the SHA-256 of the IDs and vectors in index order
+	VectorChecksum string `json:"vectorChecksum"`
+	// TextChecksum is the SHA-256 of the text and metadata behind each ID
+	TextChecksum string `json:"textChecksum"`
+}
+
+// IndexChecksums returns the checksums of every loaded index, by granularity.
+// They are computed at load time, so this is cheap.
+func (s *SearchService) IndexChecksums() []IndexChecksum {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	checksums := make([]IndexChecksum, 0, len(s.indices))
+	for granularity, index := range s.indices {
+		if !s.loadedGranularities[granularity] {
+			continue
+		}
+		checksums = append(checksums, IndexChecksum{
+			Granularity:    granularity,
+			Model:          config.ModelConfig.ModelID,
+			Vectors:        index.Size(),
+			Dimensions:     s.dimensions[granularity],
+			VectorChecksum: s.checksums[granularity],
+			TextChecksum:   s.textChecksums[granularity],
+		})
+	}
+	sort.Slice(checksums, func(i, j int) bool {
+		return checksums[i].Granularity < checksums[j].Granularity
+	})
+	return checksums
+}
+
+// textChecksum hashes the text data of each indexed ID, in index order
+func textChecksum(index *VectorIndex, textLookup map[string]*TextData) string {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	h := sha256.New()
+	encoder := json.NewEncoder(h)
+	for _, id := range index.IDs {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+		if text, ok := textLookup[id]; ok {
+			encoder.Encode(text)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
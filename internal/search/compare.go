@@ -0,0 +1,89 @@
+package search
+
+import (
+	"context"
+	"fmt"
+)
+
+// Comparison reports how two queries relate: how close their embeddings are
+// and how their top-k result sets overlap
+type Comparison struct {
+	Similarity float32        `json:"similarity"`
+	A          []SearchResult `json:"-"`
+	B          []SearchResult `json:"-"`
+	Shared     []SharedResult `json:"shared"`
+	OnlyA      []string       `json:"onlyA"`
+	OnlyB      []string       `json:"onlyB"`
+	Jaccard    float64        `json:"jaccard"` // |shared| / |A ∪ B|
+}
+
+// SharedResult is a result both queries retrieved, with its 1-based rank in each
+type SharedResult struct {
+	Reference string `json:"reference"`
+	RankA     int    `json:"rankA"`
+	RankB     int    `json:"rankB"`
+}
+
+// Compare embeds two queries together and searches with each. optionsA and
+// optionsB may differ only by the queries' inline filters.
+func (s *SearchService) Compare(ctx context.Context, a, b string, optionsA, optionsB SearchOptions) (*Comparison, error) {
+	optionsA, optionsB = withDefaults(optionsA), withDefaults(optionsB)
+	for _, options := range []SearchOptions{optionsA, optionsB} {
+		if err := s.checkLoaded(options.Granularity); err != nil {
+			return nil, err
+		}
+	}
+
+	embeddings, err := s.embeddings.EmbedQueries(ctx, []string{a, b})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	comparison := &Comparison{Similarity: CosineSimilarity(embeddings[0], embeddings[1])}
+	if comparison.A, err = s.rank(ctx, a, embeddings[0], optionsA); err != nil {
+		return nil, err
+	}
+	if comparison.B, err = s.rank(ctx, b, embeddings[1], optionsB); err != nil {
+		return nil, err
+	}
+
+	comparison.Shared, comparison.OnlyA, comparison.OnlyB, comparison.Jaccard = overlap(comparison.A, comparison.B)
+	return comparison, nil
+}
+
+// overlap matches two ranked result lists by reference
+func overlap(a, b []SearchResult) (shared []SharedResult, onlyA, onlyB []string, jaccard float64) {
+	ranksB := make(map[string]int, len(b))
+	for i, r := range b {
+		ranksB[resultReference(r)] = i + 1
+	}
+	inA := make(map[string]bool, len(a))
+	shared, onlyA, onlyB = []SharedResult{}, []string{}, []string{}
+	for i, r := range a {
+		ref := resultReference(r)
+		inA[ref] = true
+		if rankB, ok := ranksB[ref]; ok {
+			shared = append(shared, SharedResult{Reference: ref, RankA: i + 1, RankB: rankB})
+		} else {
+			onlyA = append(onlyA, ref)
+		}
+	}
+	for _, r := range b {
+		if ref := resultReference(r); !inA[ref] {
+			onlyB = append(onlyB, ref)
+		}
+	}
+
+	if union := len(shared) + len(onlyA) + len(onlyB); union > 0 {
+		jaccard = float64(len(shared)) / float64(union)
+	}
+	return shared, onlyA, onlyB, jaccard
+}
+
+// resultReference identifies a result by its reference, or its ID if it has none
+func resultReference(r SearchResult) string {
+	if r.Chunk.Meta.Reference != "" {
+		return r.Chunk.Meta.Reference
+	}
+	return r.ID
+}
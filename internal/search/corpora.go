@@ -0,0 +1,390 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+)
+
+// Corpus load states
+const (
+	CorpusNotLoaded = "not_loaded"
+	CorpusLoading   = "loading"
+	CorpusLoaded    = "loaded"
+	CorpusFailed    = "failed"
+)
+
+// CorpusSource describes where a granularity's artifacts come from
+type CorpusSource struct {
+	Granularity   string `json:"granularity"`
+	EmbeddingsURL string `json:"embeddingsUrl"`
+	FallbackURL   string `json:"fallbackUrl,omitempty"`
+	TextURL       string `json:"textUrl"`
+	Ingested      bool   `json:"ingested,omitempty"` // Built by POST /admin/corpus rather than downloaded
+}
+
+// CorpusInfo is the operational view of one configured corpus
+type CorpusInfo struct {
+	CorpusSource
+	State         string                `json:"state"`
+	Version       string                `json:"version,omitempty"`
+	Vectors       int                   `json:"vectors"`
+	Dimensions    int                   `json:"dimensions,omitempty"`
+	MemoryBytes   int64                 `json:"memoryBytes"`
+	LastRefreshed *time.Time            `json:"lastRefreshed,omitempty"`
+	Artifact      *ArtifactHeader       `json:"artifact,omitempty"`
+	License       *config.CorpusLicense `json:"license,omitempty"`
+	Error         string                `json:"error,omitempty"`
+}
+
+// GranularityOriginal is the Hebrew (OT) and Greek (NT) verse granularity,
+// served when its artifacts are configured
+const GranularityOriginal = "original"
+
+// builtinSources lists the verse and chapter granularities
+func builtinSources() []CorpusSource {
+	return []CorpusSource{
+		{
+			Granularity:   "verse",
+			EmbeddingsURL: config.ArweaveURLs.Verses,
+			FallbackURL:   config.ArweaveURLs.VersesUncompressed,
+			TextURL:       config.ArweaveURLs.VerseText,
+		},
+		{
+			Granularity:   "chapter",
+			EmbeddingsURL: config.ArweaveURLs.Chapters,
+			FallbackURL:   config.ArweaveURLs.ChaptersUncompressed,
+			TextURL:       config.ArweaveURLs.ChapterText,
+		},
+	}
+}
+
+// corpusSources lists every downloaded granularity in display order: the
+// built-in ones, the original-language one if configured, the configured
+// indices, then those registered by POST /admin/granularity/:name/load.
+// Offline, remote artifacts are read from the data directory instead.
+func (s *SearchService) corpusSources() []CorpusSource {
+	sources := append(ConfiguredSources(s.config), s.loads.registeredSources()...)
+	for i := range sources {
+		sources[i].EmbeddingsURL = s.artifact(sources[i].EmbeddingsURL)
+		sources[i].FallbackURL = s.artifact(sources[i].FallbackURL)
+		sources[i].TextURL = s.artifact(sources[i].TextURL)
+	}
+	return sources
+}
+
+// ConfiguredSources lists the built-in granularities, the original-language
+// one if configured, then the configured indices, as configured
+func ConfiguredSources(cfg *config.Config) []CorpusSource {
+	sources := builtinSources()
+	if original := cfg.Original; original.EmbeddingsURL != "" {
+		sources = append(sources, CorpusSource{
+			Granularity:   GranularityOriginal,
+			EmbeddingsURL: original.EmbeddingsURL,
+			FallbackURL:   original.FallbackURL,
+			TextURL:       original.TextURL,
+		})
+	}
+	for _, index := range cfg.Indices {
+		sources = append(sources, CorpusSource{
+			Granularity:   index.Name,
+			EmbeddingsURL: index.EmbeddingsURL,
+			FallbackURL:   index.FallbackURL,
+			TextURL:       index.TextURL,
+		})
+	}
+	return sources
+}
+
+// artifact returns where to read an artifact: offline, a remote one's copy
+// in the data directory
+func (s *SearchService) artifact(url string) string {
+	if s.config.Offline && config.IsRemote(url) {
+		return config.ArtifactPath(s.config.DataDir, url)
+	}
+	return url
+}
+
+// sourceFor returns the configured source for a granularity
+func (s *SearchService) sourceFor(granularity string) (CorpusSource, error) {
+	for _, source := range s.corpusSources() {
+		if source.Granularity == granularity {
+			return source, nil
+		}
+	}
+	return CorpusSource{}, fmt.Errorf("%w: %s", ErrUnknownIndex, granularity)
+}
+
+// Indices names every index the service can serve, loaded or not: built-in,
+// configured, then ingested
+func (s *SearchService) Indices() []string {
+	sources := append(s.corpusSources(), s.loads.ingestedSources()...)
+	names := make([]string, len(sources))
+	for i, source := range sources {
+		names[i] = source.Granularity
+	}
+	return names
+}
+
+// notLoaded reports why a granularity can't serve yet: it is unknown, or
+// still loading. With lazy loading, an index left out of the preload starts
+// loading.
+func (s *SearchService) notLoaded(granularity string) error {
+	if _, err := s.sourceFor(granularity); err != nil && !s.loads.isIngested(granularity) {
+		return err
+	}
+	if s.loadOnDemand(granularity) {
+		return fmt.Errorf("granularity %s %w: loading on first use", granularity, ErrNotLoaded)
+	}
+	return fmt.Errorf("granularity %s %w", granularity, ErrNotLoaded)
+}
+
+// loadTracker records load progress, and which corpora were ingested, under
+// its own lock so the catalog stays readable while a granularity holds the
+// service lock to load
+type loadTracker struct {
+	mu       sync.Mutex
+	states   map[string]string
+	errors   map[string]string
+	loadedAt map[string]time.Time
+	ingested map[string]CorpusSource
+	added    map[string]CorpusSource // Registered at runtime by LoadIndex
+	staged   map[string]*stagedText
+	reloads  map[string]ReloadStatus
+}
+
+func newLoadTracker() *loadTracker {
+	return &loadTracker{
+		states:   make(map[string]string),
+		errors:   make(map[string]string),
+		loadedAt: make(map[string]time.Time),
+		ingested: make(map[string]CorpusSource),
+		added:    make(map[string]CorpusSource),
+		staged:   make(map[string]*stagedText),
+		reloads:  make(map[string]ReloadStatus),
+	}
+}
+
+// beginReload records a reload as running, unless one already is
+func (t *loadTracker) beginReload(granularity, oldVersion string) (ReloadStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.reloads[granularity].State == ReloadRunning {
+		return ReloadStatus{}, false
+	}
+	status := ReloadStatus{
+		Granularity: granularity,
+		State:       ReloadRunning,
+		OldVersion:  oldVersion,
+		StartedAt:   time.Now().UTC(),
+	}
+	t.reloads[granularity] = status
+	return status, true
+}
+
+func (t *loadTracker) finishReload(granularity, newVersion string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status := t.reloads[granularity]
+	finished := time.Now().UTC()
+	status.FinishedAt = &finished
+	if err != nil {
+		status.State = ReloadFailed
+		status.Error = err.Error()
+	} else {
+		status.State = ReloadSwapped
+		status.NewVersion = newVersion
+	}
+	t.reloads[granularity] = status
+}
+
+// reload returns a granularity's latest reload, if it has had one
+func (t *loadTracker) reload(granularity string) (ReloadStatus, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	status, ok := t.reloads[granularity]
+	return status, ok
+}
+
+func (t *loadTracker) stage(granularity string, staged *stagedText) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.staged[granularity] = staged
+}
+
+// stagedText returns a granularity's staged text, or nil once its index serves
+func (t *loadTracker) stagedText(granularity string) *stagedText {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.staged[granularity]
+}
+
+func (t *loadTracker) addIngested(source CorpusSource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.ingested[source.Granularity] = source
+}
+
+func (t *loadTracker) isIngested(name string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.ingested[name]
+	return ok
+}
+
+// ingestedSources returns the ingested corpora sorted by name
+func (t *loadTracker) ingestedSources() []CorpusSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sources := make([]CorpusSource, 0, len(t.ingested))
+	for _, source := range t.ingested {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Granularity < sources[j].Granularity })
+	return sources
+}
+
+func (t *loadTracker) register(source CorpusSource) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.added[source.Granularity] = source
+}
+
+func (t *loadTracker) registered(name string) (CorpusSource, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	source, ok := t.added[name]
+	return source, ok
+}
+
+// registeredSources returns the indices registered at runtime sorted by name
+func (t *loadTracker) registeredSources() []CorpusSource {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	sources := make([]CorpusSource, 0, len(t.added))
+	for _, source := range t.added {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Granularity < sources[j].Granularity })
+	return sources
+}
+
+// begin records a granularity as loading unless it is already loading or
+// loaded, returning the state it found
+func (t *loadTracker) begin(granularity string) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state := t.states[granularity]
+	if state != CorpusLoading && state != CorpusLoaded {
+		t.states[granularity] = CorpusLoading
+		delete(t.errors, granularity)
+	}
+	return state
+}
+
+// forget drops everything recorded about a granularity
+func (t *loadTracker) forget(granularity string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.states, granularity)
+	delete(t.errors, granularity)
+	delete(t.loadedAt, granularity)
+	delete(t.ingested, granularity)
+	delete(t.added, granularity)
+	delete(t.staged, granularity)
+	delete(t.reloads, granularity)
+}
+
+func (t *loadTracker) start(granularity string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.states[granularity] = CorpusLoading
+	delete(t.errors, granularity)
+}
+
+func (t *loadTracker) finish(granularity string, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err != nil {
+		t.states[granularity] = CorpusFailed
+		t.errors[granularity] = err.Error()
+		return
+	}
+	t.states[granularity] = CorpusLoaded
+	delete(t.staged, granularity)
+	t.loadedAt[granularity] = time.Now().UTC()
+}
+
+func (t *loadTracker) get(granularity string) (state, loadErr string, loadedAt time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	state = t.states[granularity]
+	if state == "" {
+		state = CorpusNotLoaded
+	}
+	return state, t.errors[granularity], t.loadedAt[granularity]
+}
+
+// Corpora lists every built-in and configured corpus, then every ingested corpus, with its
+// source, load state, and index statistics. Indices still loading report
+// their state without counts.
+func (s *SearchService) Corpora() []CorpusInfo {
+	sources := append(s.corpusSources(), s.loads.ingestedSources()...)
+	corpora := make([]CorpusInfo, 0, len(sources))
+
+	// Don't block behind a granularity that is mid-load
+	locked := s.mu.TryRLock()
+	if locked {
+		defer s.mu.RUnlock()
+	}
+
+	for _, source := range sources {
+		info := CorpusInfo{CorpusSource: source}
+		var loadedAt time.Time
+		info.State, info.Error, loadedAt = s.loads.get(source.Granularity)
+		if !loadedAt.IsZero() {
+			info.LastRefreshed = &loadedAt
+		}
+
+		if locked {
+			if index, ok := s.indices[source.Granularity]; ok && index.Size() > 0 {
+				info.Vectors = index.Size()
+				info.MemoryBytes = s.memoryBytes(source.Granularity)
+			}
+			info.Version = shortVersion(s.checksums[source.Granularity])
+			info.Dimensions = s.dimensions[source.Granularity]
+			info.Artifact = s.artifacts[source.Granularity]
+		}
+		info.License = s.licenseFor(source.Granularity, info.Artifact)
+
+		corpora = append(corpora, info)
+	}
+
+	return corpora
+}
+
+// License returns the license and attribution of a granularity's text: the
+// artifact's declaration with any configured override applied, or nil if
+// neither declares one
+func (s *SearchService) License(granularity string) *config.CorpusLicense {
+	s.mu.RLock()
+	artifact := s.artifacts[granularity]
+	s.mu.RUnlock()
+	return s.licenseFor(granularity, artifact)
+}
+
+// licenseFor merges the configured license over an artifact's
+func (s *SearchService) licenseFor(granularity string, artifact *ArtifactHeader) *config.CorpusLicense {
+	var license config.CorpusLicense
+	if artifact != nil && artifact.License != nil {
+		license = *artifact.License
+	}
+	license = license.Merge(s.config.CorpusLicenses[granularity])
+	if license.IsZero() {
+		return nil
+	}
+	return &license
+}
@@ -0,0 +1,70 @@
+package search
+
+import (
+	"fmt"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// maxModelDimensions is the full width of EmbeddingGemma embeddings; every
+// Matryoshka truncation up to this size is a valid prefix of it
+const maxModelDimensions = 768
+
+// reconcileDimensions checks an artifact's vectors against the configured
+// query dimensions. Wider artifacts are truncated in place to the query width;
+// narrower artifacts are served by truncating queries to the artifact width.
+// It returns the dimensionality the index will serve at.
+func reconcileDimensions(granularity string, vectors [][]float32) (int, error) {
+	if len(vectors) == 0 {
+		return 0, fmt.Errorf("artifact contains no embeddings")
+	}
+
+	artifactDims := len(vectors[0])
+	for i, vec := range vectors {
+		if len(vec) != artifactDims {
+			return 0, fmt.Errorf("artifact has inconsistent dimensions: vector 0 has %d, vector %d has %d", artifactDims, i, len(vec))
+		}
+	}
+
+	modelDims := config.ModelConfig.Dimensions
+	switch {
+	case artifactDims == 0:
+		return 0, fmt.Errorf("artifact embeddings are empty")
+	case artifactDims > maxModelDimensions:
+		return 0, fmt.Errorf("artifact has %d dimensions, more than the model's %d", artifactDims, maxModelDimensions)
+	case artifactDims == modelDims:
+		return artifactDims, nil
+	case artifactDims > modelDims:
+		// Matryoshka truncation keeps the artifact consistent with 128D queries
+		log.Warn().
+			Str("granularity", granularity).
+			Int("artifactDims", artifactDims).
+			Int("modelDims", modelDims).
+			Msg("Artifact wider than query embeddings, truncating artifact vectors")
+		for i := range vectors {
+			vectors[i] = vectors[i][:modelDims]
+		}
+		return modelDims, nil
+	default:
+		log.Warn().
+			Str("granularity", granularity).
+			Int("artifactDims", artifactDims).
+			Int("modelDims", modelDims).
+			Msg("Artifact narrower than query embeddings, truncating queries for this index")
+		return artifactDims, nil
+	}
+}
+
+// fitQuery truncates a query embedding to an index's dimensionality, refusing
+// queries too narrow to compare
+func fitQuery(query []float32, dims int) ([]float32, error) {
+	switch {
+	case dims == 0 || len(query) == dims:
+		return query, nil
+	case len(query) > dims:
+		return query[:dims], nil
+	default:
+		return nil, fmt.Errorf("query embedding has %d dimensions but index requires %d", len(query), dims)
+	}
+}
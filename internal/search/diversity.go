@@ -0,0 +1,94 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+const (
+	// mmrPoolFactor sets how many candidates MMR chooses from, per result wanted
+	mmrPoolFactor = 5
+	// mmrMaxPool bounds the pool, since selection compares every pick with
+	// every remaining candidate. Deep cursor pages choose from K candidates.
+	mmrMaxPool = 1000
+)
+
+// ValidateDiversity checks an MMR diversity weight
+func ValidateDiversity(diversity float64) error {
+	if diversity < 0 || diversity > 1 || math.IsNaN(diversity) {
+		return fmt.Errorf("diversity must be between 0 and 1")
+	}
+	return nil
+}
+
+// rankDiverse retrieves a pool of candidates several times larger than K and
+// re-ranks it with Maximal Marginal Relevance: each pick maximizes
+//
+//	(1 - diversity) * relevance - diversity * max similarity to earlier picks
+//
+// so near-duplicates such as synoptic parallels give way to distinct passages.
+// Relevance is the candidate's score scaled to [0, 1] over the pool.
+func (s *SearchService) rankDiverse(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	k := options.K
+	limits := s.limitsFor(options)
+	if limits.MaxK > 0 && k > limits.MaxK && !options.Paged {
+		k = limits.MaxK
+	}
+
+	pool := options
+	pool.Diversity = 0
+	pool.K = max(k, min(max(k*mmrPoolFactor, s.config.RerankCandidates), mmrMaxPool))
+	pool.Paged = true // The pool may exceed max-k; k is capped above
+	candidates, err := s.rankAdjusted(ctx, query, queryEmbedding, pool)
+	if err != nil || len(candidates) <= 1 {
+		return candidates, err
+	}
+
+	s.mu.RLock()
+	index := s.indices[options.Granularity]
+	s.mu.RUnlock()
+
+	vectors := make([][]float32, len(candidates))
+	low, high := float64(candidates[0].Score), float64(candidates[0].Score)
+	for i, c := range candidates {
+		vectors[i], _ = index.Get(c.ID)
+		low = math.Min(low, float64(c.Score))
+		high = math.Max(high, float64(c.Score))
+	}
+	relevance := func(i int) float64 {
+		if high == low {
+			return 1
+		}
+		return (float64(candidates[i].Score) - low) / (high - low)
+	}
+
+	// redundancy[i] is candidate i's highest similarity to any pick so far
+	redundancy := make([]float64, len(candidates))
+	picked := make([]bool, len(candidates))
+	results := make([]SearchResult, 0, min(k, len(candidates)))
+	for len(results) < cap(results) {
+		best, bestScore := -1, math.Inf(-1)
+		for i := range candidates {
+			if picked[i] {
+				continue
+			}
+			score := (1-options.Diversity)*relevance(i) - options.Diversity*redundancy[i]
+			if score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+
+		picked[best] = true
+		results = append(results, candidates[best])
+		if vectors[best] == nil {
+			continue
+		}
+		for i := range candidates {
+			if !picked[i] && vectors[i] != nil {
+				redundancy[i] = math.Max(redundancy[i], float64(CosineSimilarity(vectors[i], vectors[best])))
+			}
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,289 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// rrfK dampens reciprocal rank fusion so the top few ranks of one model
+// don't swamp agreement between both
+const rrfK = 60
+
+var (
+	// ErrNoEnsemble reports ensemble ranking on a server without a second model
+	ErrNoEnsemble = errors.New("ensemble ranking needs a second model; start the server with -ensemble-model")
+	// ErrEnsembleGranularity reports ensemble ranking of chapters, which only the primary model indexes
+	ErrEnsembleGranularity = errors.New("ensemble ranking only supports verse granularity")
+)
+
+// QueryEmbedder embeds queries with a model other than the primary one
+type QueryEmbedder interface {
+	ID() string
+	EmbedQuery(ctx context.Context, text string) ([]float32, error)
+}
+
+// ModelContribution is one model's part in an ensemble result's score
+type ModelContribution struct {
+	Model      string  `json:"model"`
+	Rank       int     `json:"rank,omitempty"` // 1-based; omitted if the model didn't retrieve the result
+	Similarity float32 `json:"similarity"`
+	Weight     float64 `json:"weight"`
+	Score      float64 `json:"score"` // weight / (60 + rank), or 0 when unranked
+}
+
+// ensemble is the second model and its verse index
+type ensemble struct {
+	model    *config.EnsembleModel
+	embedder QueryEmbedder
+	index    *VectorIndex
+	dims     int
+	loadErr  string
+}
+
+// SetEnsemble configures the second model used by ranking=ensemble. Its
+// verse index is built by LoadEnsemble.
+func (s *SearchService) SetEnsemble(model *config.EnsembleModel, embedder QueryEmbedder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ensemble = &ensemble{model: model, embedder: embedder}
+}
+
+// LoadEnsemble downloads and indexes the ensemble model's verse embeddings
+func (s *SearchService) LoadEnsemble(ctx context.Context) error {
+	s.mu.RLock()
+	e := s.ensemble
+	s.mu.RUnlock()
+	if e == nil {
+		return nil
+	}
+
+	index, dims, err := s.fetchEnsembleIndex(ctx, e.model)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		e.loadErr = err.Error()
+		return fmt.Errorf("failed to load ensemble model %s: %w", e.model.ID, err)
+	}
+	e.index, e.dims, e.loadErr = index, dims, ""
+	log.Info().Str("model", e.model.ID).Int("vectors", index.Size()).Msg("Ensemble verse index loaded")
+	return nil
+}
+
+// fetchEnsembleIndex builds the verse index for an ensemble model
+func (s *SearchService) fetchEnsembleIndex(ctx context.Context, model *config.EnsembleModel) (*VectorIndex, int, error) {
+	payload, err := s.loadEmbeddings(ctx, s.artifact(model.VersesURL), true)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids, vectors, header := payload.ids, payload.vectors, payload.header
+	if len(vectors) == 0 {
+		return nil, 0, fmt.Errorf("artifact contains no embeddings")
+	}
+	if header != nil && header.ModelID != "" && !sameModel(header.ModelID, model.ID) {
+		return nil, 0, fmt.Errorf("artifact was built with model %s", header.ModelID)
+	}
+
+	dims := len(vectors[0])
+	for i, vec := range vectors {
+		if len(vec) != dims {
+			return nil, 0, fmt.Errorf("artifact has inconsistent dimensions: vector 0 has %d, vector %d has %d", dims, i, len(vec))
+		}
+	}
+	if dims > model.Dimensions {
+		// Matryoshka truncation to the query width, as for the primary model
+		for i := range vectors {
+			vectors[i] = vectors[i][:model.Dimensions]
+		}
+		dims = model.Dimensions
+	}
+
+	index := NewVectorIndex()
+	index.SetShards(s.config.Shards)
+	for i, id := range ids {
+		index.Add(id, vectors[i])
+	}
+	return index, dims, nil
+}
+
+// searchEnsemble ranks verses with both models and fuses the rankings with
+// weighted reciprocal rank fusion. Each result lists every model's rank,
+// similarity and share of the score.
+func (s *SearchService) searchEnsemble(ctx context.Context, query string, primary []float32, options SearchOptions) ([]SearchResult, error) {
+	if options.Granularity != "verse" {
+		return nil, ErrEnsembleGranularity
+	}
+
+	s.mu.RLock()
+	e := s.ensemble
+	var secondaryIndex *VectorIndex
+	var secondaryDims int
+	var loadErr string
+	if e != nil {
+		secondaryIndex, secondaryDims, loadErr = e.index, e.dims, e.loadErr
+	}
+	primaryIndex := s.indices[options.Granularity]
+	primaryDims := s.dimensions[options.Granularity]
+	textLookup := s.textLookup[options.Granularity]
+	tags := s.tags
+	s.mu.RUnlock()
+
+	switch {
+	case e == nil:
+		return nil, ErrNoEnsemble
+	case loadErr != "":
+		return nil, fmt.Errorf("ensemble model %s %w: %s", e.model.ID, ErrUnavailable, loadErr)
+	case secondaryIndex == nil:
+		return nil, fmt.Errorf("ensemble model %s %w", e.model.ID, ErrNotLoaded)
+	}
+
+	secondary, err := e.embedder.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	if secondary, err = fitQuery(secondary, secondaryDims); err != nil {
+		return nil, err
+	}
+	if primary, err = fitQuery(primary, primaryDims); err != nil {
+		return nil, err
+	}
+
+	// Each model contributes its own top candidates
+	depth := max(options.K, s.config.RerankCandidates)
+	filter := buildFilter(options, textLookup, tags)
+	rankings := []struct {
+		model  string
+		weight float64
+		index  *VectorIndex
+		query  []float32
+	}{
+		{config.ModelConfig.ModelID, e.model.PrimaryWeight, primaryIndex, primary},
+		{e.model.ID, e.model.Weight, secondaryIndex, secondary},
+	}
+
+	start := time.Now()
+	fused := make(map[string]*SearchResult)
+	var order []string
+	for m, ranking := range rankings {
+		for rank, hit := range ranking.index.SearchWithFilter(ctx, ranking.query, depth, filter) {
+			result, ok := fused[hit.ID]
+			if !ok {
+				result = &SearchResult{ID: hit.ID, Contributions: make([]ModelContribution, len(rankings))}
+				fused[hit.ID] = result
+				order = append(order, hit.ID)
+			}
+			score := ranking.weight / float64(rrfK+rank+1)
+			result.Contributions[m] = ModelContribution{
+				Model:      ranking.model,
+				Rank:       rank + 1,
+				Similarity: hit.Similarity,
+				Weight:     ranking.weight,
+				Score:      score,
+			}
+			result.Score += float32(score)
+		}
+	}
+
+	scanned := time.Now()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Fill in the similarity of models that didn't rank a result, so every
+	// contribution can be compared
+	results := make([]SearchResult, 0, len(order))
+	for _, id := range order {
+		result := fused[id]
+		for m, ranking := range rankings {
+			if result.Contributions[m].Model != "" {
+				continue
+			}
+			contribution := ModelContribution{Model: ranking.model, Weight: ranking.weight}
+			if vec, ok := ranking.index.Get(id); ok {
+				contribution.Similarity = CosineSimilarity(ranking.query, vec)
+			}
+			result.Contributions[m] = contribution
+		}
+		result.Similarity = result.Contributions[0].Similarity
+		results = append(results, *result)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	if len(results) > options.K {
+		results = results[:options.K]
+	}
+
+	// Score floors are calibrated for cosine similarity, not fused scores, so
+	// only a requested minimum applies, to the primary model's similarity
+	results = attachText(results, textLookup, float32(options.MinScore))
+	if options.Highlight {
+		s.highlight(results, query, primary, options)
+	}
+	options.explain.recordScan(ScanEnsemble, scanned.Sub(start), time.Since(scanned))
+	return results, nil
+}
+
+// rank searches with an embedded query, moved away from any negative example
+// and adjusted by any relevance feedback stored for it. On a stateless node
+// the index node does all of this.
+func (s *SearchService) rank(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	if remote := s.Remote(); remote != nil {
+		return remote.Rank(ctx, query, queryEmbedding, options)
+	}
+	options, err := s.withNegative(ctx, options)
+	if err != nil {
+		return nil, err
+	}
+	queryEmbedding = subtractNegative(queryEmbedding, options)
+	return s.rankAdjusted(ctx, query, s.applyFeedback(query, queryEmbedding, options), options)
+}
+
+// rankAdjusted searches with a query embedding feedback has been applied to,
+// fusing in the ensemble model when ranking=ensemble, rolling verses up by
+// chapter when group=chapter, diversifying the results when diversity is set
+// and fusing in reformulations of the query when expand is set
+func (s *SearchService) rankAdjusted(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	if options.Group == GroupChapter {
+		return s.rankByChapter(ctx, query, queryEmbedding, options)
+	}
+	if options.Diversity > 0 {
+		return s.rankDiverse(ctx, query, queryEmbedding, options)
+	}
+	if options.Expand {
+		return s.searchExpanded(ctx, query, queryEmbedding, options)
+	}
+	if options.Ranking == RankingEnsemble {
+		return s.searchEnsemble(ctx, query, queryEmbedding, options)
+	}
+	return s.searchEmbedding(ctx, query, queryEmbedding, options)
+}
+
+// ensembleStatus reports the ensemble model's load state. Callers must hold s.mu.
+func (s *SearchService) ensembleStatus() map[string]interface{} {
+	if s.ensemble == nil {
+		return nil
+	}
+	status := map[string]interface{}{
+		"model":  s.ensemble.model.ID,
+		"loaded": s.ensemble.index != nil,
+	}
+	if s.ensemble.index != nil {
+		status["count"] = s.ensemble.index.Size()
+		status["dimensions"] = s.ensemble.dims
+	}
+	if s.ensemble.loadErr != "" {
+		status["error"] = s.ensemble.loadErr
+	}
+	return status
+}
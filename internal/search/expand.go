@@ -0,0 +1,114 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/dpshade/goscriptureapi/internal/thesaurus"
+)
+
+const (
+	// maxReformulations caps the rewritten queries an expanded search adds
+	maxReformulations = 4
+	// reformulationWeight is a rewritten query's weight in the fusion, below
+	// the original query's 1 so the user's own wording leads ties
+	reformulationWeight = 0.75
+)
+
+// ErrExpandEnsemble reports query expansion combined with ensemble ranking,
+// which already fuses rankings of its own
+var ErrExpandEnsemble = errors.New("expand can't be combined with ranking=ensemble")
+
+// SetThesaurus replaces the thesaurus used for query expansion and lexical
+// scoring, which defaults to the built-in one
+func (s *SearchService) SetThesaurus(th *thesaurus.Thesaurus) {
+	s.thesaurus.Store(th)
+}
+
+// Thesaurus returns the thesaurus in use
+func (s *SearchService) Thesaurus() *thesaurus.Thesaurus {
+	return s.thesaurus.Load()
+}
+
+// ValidateExpand checks that query expansion can be used with a ranking
+func ValidateExpand(expand bool, ranking string) error {
+	if expand && ranking == RankingEnsemble {
+		return ErrExpandEnsemble
+	}
+	return nil
+}
+
+// searchExpanded searches a query and its thesaurus reformulations and fuses
+// the rankings with weighted reciprocal rank fusion. Each result lists the
+// reformulations that found it.
+func (s *SearchService) searchExpanded(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	if options.Ranking == RankingEnsemble {
+		return nil, ErrExpandEnsemble
+	}
+	reformulations := s.Thesaurus().Reformulations(query, maxReformulations)
+	options.explain.recordExpansions(reformulations)
+	if len(reformulations) == 0 {
+		return s.searchEmbedding(ctx, query, queryEmbedding, options)
+	}
+
+	embeddings, err := s.embeddings.EmbedQueries(ctx, reformulations)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	for i := range embeddings {
+		embeddings[i] = subtractNegative(embeddings[i], options)
+	}
+	queries := append([]string{query}, reformulations...)
+	vectors := append([][]float32{queryEmbedding}, embeddings...)
+
+	fused := make(map[string]*SearchResult)
+	var order []string
+	for q, text := range queries {
+		results, err := s.searchEmbedding(ctx, text, vectors[q], options)
+		if err != nil {
+			return nil, err
+		}
+		weight := 1.0
+		if q > 0 {
+			weight = reformulationWeight
+		}
+		for rank, hit := range results {
+			result, ok := fused[hit.ID]
+			if !ok {
+				// The first query to rank a result supplies its text and highlight
+				first := hit
+				result = &first
+				result.Score = 0
+				fused[hit.ID] = result
+				order = append(order, hit.ID)
+			} else if hit.Similarity > result.Similarity {
+				result.Similarity = hit.Similarity
+			}
+			if q > 0 {
+				result.Expansions = append(result.Expansions, text)
+			}
+			result.Score += float32(weight / float64(rrfK+rank+1))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(order))
+	for _, id := range order {
+		results = append(results, *fused[id])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	k := options.K
+	if limits := s.limitsFor(options); limits.MaxK > 0 && k > limits.MaxK && !options.Paged {
+		k = limits.MaxK
+	}
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
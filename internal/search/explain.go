@@ -0,0 +1,105 @@
+package search
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+)
+
+// Ways an index is searched, as reported by explain
+const (
+	ScanFull         = "full"          // Every filtered vector scored exactly
+	ScanRouted       = "routed"        // Only the books and chapters nearest the query
+	ScanRerankInt8   = "rerank-int8"   // int8 retrieval, then exact re-ranking
+	ScanRerankBinary = "rerank-binary" // Hamming retrieval, then exact re-ranking
+	ScanFields       = "fields"        // Every filtered vector, with field boosts
+	ScanEnsemble     = "ensemble"      // Two models' exact scans, fused
+	ScanLexical      = "lexical"       // Query term overlap, without embeddings
+)
+
+// Explanation records how a search was answered, for explain=true. A search
+// that ranks a pool first, as diversity and group do, adds up its scans.
+type Explanation struct {
+	mu sync.Mutex
+
+	Index   string            `json:"index"`
+	Filters map[string]string `json:"filters,omitempty"`
+	Backend string            `json:"backend,omitempty"` // Embedding backend that embedded the query
+	Cached  bool              `json:"cached,omitempty"`  // The query embedding came from the cache
+	Scan    string            `json:"scan,omitempty"`
+	EmbedMs float64           `json:"embedMs"`
+	ScanMs  float64           `json:"scanMs"` // Scoring and top-k selection
+	SortMs  float64           `json:"sortMs"` // Re-ranking, boosts and attaching text
+
+	Expansions []string `json:"expansions,omitempty"` // Reformulations searched alongside the query, for expand
+	Negative   bool     `json:"negative,omitempty"`   // The negative example was subtracted from the query
+
+	FeedbackRelevant   int `json:"feedbackRelevant,omitempty"`   // Verses judged relevant that adjusted the query
+	FeedbackIrrelevant int `json:"feedbackIrrelevant,omitempty"` // Verses judged irrelevant that adjusted the query
+}
+
+// Explaining returns options that record how the search is answered in e
+func (o SearchOptions) Explaining(e *Explanation) SearchOptions {
+	o.explain = e
+	return o
+}
+
+// RecordEmbedding notes how the query was embedded
+func (e *Explanation) RecordEmbedding(report embeddings.QueryReport) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Backend = report.Backend
+	e.Cached = report.Cached
+	e.EmbedMs += milliseconds(report.Took)
+}
+
+// recordScan notes an index scan; e may be nil
+func (e *Explanation) recordScan(mode string, scan, sort time.Duration) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Scan = mode
+	e.ScanMs += milliseconds(scan)
+	e.SortMs += milliseconds(sort)
+}
+
+// recordFeedback notes the relevance judgments that adjusted the query; e
+// may be nil
+func (e *Explanation) recordFeedback(relevant, irrelevant int) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.FeedbackRelevant = relevant
+	e.FeedbackIrrelevant = irrelevant
+}
+
+// recordNegative notes that the query was moved away from the negative
+// example; e may be nil
+func (e *Explanation) recordNegative() {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Negative = true
+}
+
+// recordExpansions notes the reformulations an expanded search adds; e may
+// be nil
+func (e *Explanation) recordExpansions(reformulations []string) {
+	if e == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Expansions = reformulations
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
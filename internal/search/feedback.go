@@ -0,0 +1,127 @@
+package search
+
+import (
+	"math"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+// Rocchio weights of the query, the relevant verses' centroid and the
+// irrelevant verses' centroid, as in the classic formulation
+const (
+	rocchioAlpha = 1.0
+	rocchioBeta  = 0.75
+	rocchioGamma = 0.15
+)
+
+// FeedbackSource supplies the verses judged relevant and irrelevant to a
+// query, as references
+type FeedbackSource interface {
+	Judgments(query string) (relevant, irrelevant []string)
+}
+
+// SetFeedback connects the relevance judgments applied to repeated queries
+func (s *SearchService) SetFeedback(feedback FeedbackSource) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.feedback = feedback
+}
+
+// FeedbackApplies reports whether stored judgments adjust a search, which
+// changes its ranking without the index changing
+func (s *SearchService) FeedbackApplies(query string, options SearchOptions) bool {
+	feedback := s.feedbackFor(options)
+	if feedback == nil || query == "" {
+		return false
+	}
+	relevant, irrelevant := feedback.Judgments(query)
+	return len(relevant) > 0 || len(irrelevant) > 0
+}
+
+// feedbackFor returns the judgments source for a search, or nil when they
+// don't apply: only verse searches are judged, and pure ranking is raw
+// cosine by definition
+func (s *SearchService) feedbackFor(options SearchOptions) FeedbackSource {
+	if options.Granularity != "verse" || options.Ranking == RankingPure {
+		return nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.feedback
+}
+
+// applyFeedback moves a query vector towards the centroid of the verses
+// judged relevant to it and away from those judged irrelevant (Rocchio).
+// The vector is returned unchanged when there are no judgments.
+func (s *SearchService) applyFeedback(query string, queryEmbedding []float32, options SearchOptions) []float32 {
+	feedback := s.feedbackFor(options)
+	if feedback == nil || query == "" {
+		return queryEmbedding
+	}
+	relevant, irrelevant := feedback.Judgments(query)
+	relevantCentroid, relevantCount := s.centroid(relevant, len(queryEmbedding))
+	irrelevantCentroid, irrelevantCount := s.centroid(irrelevant, len(queryEmbedding))
+	if relevantCount == 0 && irrelevantCount == 0 {
+		return queryEmbedding
+	}
+
+	adjusted := make([]float32, len(queryEmbedding))
+	var queryNorm, adjustedNorm float64
+	for i, value := range queryEmbedding {
+		v := rocchioAlpha * float64(value)
+		if relevantCount > 0 {
+			v += rocchioBeta * float64(relevantCentroid[i])
+		}
+		if irrelevantCount > 0 {
+			v -= rocchioGamma * float64(irrelevantCentroid[i])
+		}
+		adjusted[i] = float32(v)
+		queryNorm += float64(value) * float64(value)
+		adjustedNorm += v * v
+	}
+	// Keep the query's length, which the quantized scan's scale assumes
+	if adjustedNorm > 0 {
+		scale := float32(math.Sqrt(queryNorm / adjustedNorm))
+		for i := range adjusted {
+			adjusted[i] *= scale
+		}
+	}
+	options.explain.recordFeedback(relevantCount, irrelevantCount)
+	return adjusted
+}
+
+// centroid returns the mean embedding of the verses in references, with the
+// number of verses averaged. Unparseable references and verses without a
+// vector of the given dimensions are skipped.
+func (s *SearchService) centroid(references []string, dimensions int) ([]float32, int) {
+	sum := make([]float64, dimensions)
+	count := 0
+	for _, value := range references {
+		ref, err := reference.Parse(value)
+		if err != nil {
+			continue
+		}
+		verses, err := s.Passage(ref)
+		if err != nil {
+			continue
+		}
+		for _, verse := range verses {
+			vector, ok := s.VerseEmbedding(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)
+			if !ok || len(vector) != dimensions {
+				continue
+			}
+			for i, v := range vector {
+				sum[i] += float64(v)
+			}
+			count++
+		}
+	}
+	if count == 0 {
+		return nil, 0
+	}
+	centroid := make([]float32, dimensions)
+	for i := range sum {
+		centroid[i] = float32(sum[i] / float64(count))
+	}
+	return centroid, count
+}
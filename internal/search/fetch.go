@@ -0,0 +1,99 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+)
+
+// FetchedArtifact is one artifact the fetch command saved, or found already saved
+type FetchedArtifact struct {
+	URL     string
+	Path    string
+	Bytes   int64
+	Skipped bool // Already in the data directory
+}
+
+// FetchArtifacts saves every configured index's embeddings and text, and the
+// ensemble model's verse embeddings if given, under cfg.DataDir for Offline
+// use. An index's fallback embeddings are only fetched when its primary ones
+// fail. Artifacts already saved, and local paths, are left alone.
+func FetchArtifacts(ctx context.Context, cfg *config.Config, ensemble *config.EnsembleModel, progress func(FetchedArtifact)) error {
+	seen := make(map[string]bool)
+	fetch := func(url string) error {
+		if !config.IsRemote(url) || seen[url] {
+			return nil
+		}
+		seen[url] = true
+		artifact, err := fetchArtifact(ctx, url, config.ArtifactPath(cfg.DataDir, url))
+		if err == nil {
+			progress(artifact)
+		}
+		return err
+	}
+
+	for _, source := range ConfiguredSources(cfg) {
+		if err := fetch(source.TextURL); err != nil {
+			return fmt.Errorf("%s text: %w", source.Granularity, err)
+		}
+		err := fetch(source.EmbeddingsURL)
+		if err != nil && source.FallbackURL != "" {
+			err = fetch(source.FallbackURL)
+		}
+		if err != nil {
+			return fmt.Errorf("%s embeddings: %w", source.Granularity, err)
+		}
+	}
+	if ensemble != nil {
+		if err := fetch(ensemble.VersesURL); err != nil {
+			return fmt.Errorf("ensemble embeddings: %w", err)
+		}
+	}
+	return nil
+}
+
+// fetchArtifact downloads url to path as is, through a temporary file so an
+// interrupted download is never mistaken for a complete one
+func fetchArtifact(ctx context.Context, url, path string) (FetchedArtifact, error) {
+	artifact := FetchedArtifact{URL: url, Path: path}
+	if info, err := os.Stat(path); err == nil {
+		artifact.Bytes, artifact.Skipped = info.Size(), true
+		return artifact, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return artifact, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return artifact, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return artifact, fmt.Errorf("failed to fetch: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return artifact, fmt.Errorf("HTTP %d", resp.StatusCode)
+	}
+
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return artifact, err
+	}
+	artifact.Bytes, err = io.Copy(file, resp.Body)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return artifact, err
+	}
+	return artifact, os.Rename(tmp, path)
+}
@@ -0,0 +1,165 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/dpshade/goscriptureapi/internal/thesaurus"
+)
+
+// Searchable fields
+const (
+	FieldText      = "text"
+	FieldHeading   = "heading"
+	FieldFootnotes = "footnotes"
+)
+
+// FieldBoost weights a searchable field's contribution to the score
+type FieldBoost struct {
+	Field string
+	Boost float32
+}
+
+// ParseFields parses field specs such as "text", "heading^2" or "footnotes^0.5".
+// An empty list searches verse text only.
+func ParseFields(fields []string) ([]FieldBoost, error) {
+	if len(fields) == 0 {
+		return []FieldBoost{{Field: FieldText, Boost: 1}}, nil
+	}
+
+	boosts := make([]FieldBoost, 0, len(fields))
+	for _, spec := range fields {
+		name, weight, hasWeight := strings.Cut(strings.TrimSpace(spec), "^")
+		name = strings.ToLower(name)
+
+		switch name {
+		case FieldText, FieldHeading, FieldFootnotes:
+		default:
+			return nil, fmt.Errorf("unknown search field: %s", name)
+		}
+
+		boost := float32(1)
+		if hasWeight {
+			w, err := strconv.ParseFloat(weight, 32)
+			if err != nil || w < 0 {
+				return nil, fmt.Errorf("invalid boost for field %s: %s", name, weight)
+			}
+			boost = float32(w)
+		}
+
+		boosts = append(boosts, FieldBoost{Field: name, Boost: boost})
+	}
+
+	return boosts, nil
+}
+
+// textOnly reports whether the boosts reduce to plain semantic search over verse text
+func textOnly(boosts []FieldBoost) bool {
+	return len(boosts) == 1 && boosts[0].Field == FieldText && boosts[0].Boost == 1
+}
+
+// applyFieldBoosts rescores semantic results with lexical matches in the
+// requested fields and returns the top k. Similarity keeps the raw cosine.
+func applyFieldBoosts(results []SearchResult, boosts []FieldBoost, query string, terms [][]string, textLookup map[string]*TextData, k int) []SearchResult {
+
+	for i := range results {
+		text, ok := textLookup[results[i].ID]
+		score := float32(0)
+		var matched []string
+
+		for _, fb := range boosts {
+			if fb.Field == FieldText {
+				score += fb.Boost * results[i].Similarity
+				continue
+			}
+			if !ok {
+				continue
+			}
+
+			var values []string
+			switch fb.Field {
+			case FieldHeading:
+				values = []string{text.Meta.Heading}
+			case FieldFootnotes:
+				values = text.Meta.Footnotes
+			}
+
+			best := float32(0)
+			for _, value := range values {
+				best = max(best, lexicalMatch(query, terms, value))
+			}
+			if best > 0 {
+				score += fb.Boost * best
+				matched = append(matched, fb.Field)
+			}
+		}
+
+		results[i].Score = score
+		results[i].MatchedFields = matched
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+
+	if k > len(results) {
+		k = len(results)
+	}
+	return results[:k]
+}
+
+// queryTerms lowercases a query and splits it into words without punctuation
+func queryTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+	})
+}
+
+// lexicalTerms returns each query term with its thesaurus synonyms, so
+// lexical scoring counts "charity" as a match for "love"
+func lexicalTerms(query string, th *thesaurus.Thesaurus) [][]string {
+	words := queryTerms(query)
+	terms := make([][]string, len(words))
+	for i, word := range words {
+		terms[i] = append([]string{word}, th.Synonyms(word)...)
+	}
+	return terms
+}
+
+// lexicalMatch scores a field value in [0, 1]: 1 for an exact phrase match,
+// otherwise the fraction of query terms the value contains, a term counting
+// when any of its synonyms appears
+func lexicalMatch(query string, terms [][]string, value string) float32 {
+	if value == "" || len(terms) == 0 {
+		return 0
+	}
+
+	lower := strings.ToLower(value)
+	if strings.Contains(lower, strings.ToLower(strings.TrimSpace(query))) {
+		return 1
+	}
+
+	words := make(map[string]bool)
+	for _, word := range queryTerms(lower) {
+		words[word] = true
+	}
+
+	hits := 0
+	for _, alternatives := range terms {
+		for _, term := range alternatives {
+			// Multi-word synonyms such as "with child" match as phrases
+			if words[term] || (strings.Contains(term, " ") && strings.Contains(lower, term)) {
+				hits++
+				break
+			}
+		}
+	}
+
+	return float32(hits) / float32(len(terms))
+}
@@ -0,0 +1,109 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ParseVerseRange parses a verse filter: a verse number such as "16" or an
+// inclusive range such as "1-10". An empty filter matches every verse.
+func ParseVerseRange(spec string) (first, last int, err error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 1, math.MaxInt, nil
+	}
+
+	start, end, isRange := strings.Cut(spec, "-")
+	if first, err = strconv.Atoi(strings.TrimSpace(start)); err != nil || first < 1 {
+		return 0, 0, fmt.Errorf("invalid verse filter: %q (use a verse number or a range such as 1-10)", spec)
+	}
+	last = first
+	if isRange {
+		if last, err = strconv.Atoi(strings.TrimSpace(end)); err != nil || last < first {
+			return 0, 0, fmt.Errorf("invalid verse filter: %q (use a verse number or a range such as 1-10)", spec)
+		}
+	}
+	return first, last, nil
+}
+
+// ParseChapter parses a chapter filter such as "3" or "03". An empty filter
+// matches every chapter and parses as zero.
+func ParseChapter(spec string) (int, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, nil
+	}
+	chapter, err := strconv.Atoi(spec)
+	if err != nil || chapter < 1 {
+		return 0, fmt.Errorf("invalid chapter filter: %q (use a chapter number)", spec)
+	}
+	return chapter, nil
+}
+
+// ValidateMinScore checks a minimum cosine similarity
+func ValidateMinScore(minScore float64) error {
+	if minScore < -1 || minScore > 1 || math.IsNaN(minScore) {
+		return fmt.Errorf("minScore must be between -1 and 1")
+	}
+	return nil
+}
+
+// filterNames lists the filters EmptyFilters checks, in report order
+var filterNames = []string{"book", "books", "testament", "genre", "chapter", "verse", "tag", "phrase", "exclude"}
+
+// EmptyFilters returns the filters that match none of the granularity's
+// entries on their own, explaining an empty result set. It returns nil when
+// the granularity isn't loaded.
+func (s *SearchService) EmptyFilters(options SearchOptions) []string {
+	s.mu.RLock()
+	textLookup := s.textLookup[options.Granularity]
+	tags := s.tags
+	s.mu.RUnlock()
+	if len(textLookup) == 0 {
+		return nil
+	}
+
+	var empty []string
+	for _, name := range filterNames {
+		single := SearchOptions{Namespace: options.Namespace}
+		var active bool
+		switch name {
+		case "book":
+			single.Book, active = options.Book, options.Book != ""
+		case "books":
+			single.Books, active = options.Books, len(options.Books) > 0
+		case "testament":
+			single.Testament, active = options.Testament, options.Testament != ""
+		case "genre":
+			single.Genre, active = options.Genre, options.Genre != ""
+		case "chapter":
+			single.Chapter, active = options.Chapter, options.Chapter != ""
+		case "verse":
+			single.Verse, active = options.Verse, options.Verse != ""
+		case "tag":
+			single.Tag, active = options.Tag, options.Tag != ""
+		case "phrase":
+			single.Phrases, active = options.Phrases, len(options.Phrases) > 0
+		case "exclude":
+			single.Exclude, active = options.Exclude, len(options.Exclude) > 0
+		}
+		if !active {
+			continue
+		}
+
+		filter := buildFilter(single, textLookup, tags)
+		matched := false
+		for id := range textLookup {
+			if filter(id) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			empty = append(empty, name)
+		}
+	}
+	return empty
+}
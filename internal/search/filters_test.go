@@ -0,0 +1,110 @@
+package search
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseVerseRange(t *testing.T) {
+	tests := []struct {
+		spec        string
+		first, last int
+		wantErr     bool
+	}{
+		{spec: "", first: 1, last: math.MaxInt},
+		{spec: "16", first: 16, last: 16},
+		{spec: " 16 ", first: 16, last: 16},
+		{spec: "1-10", first: 1, last: 10},
+		{spec: "1 - 10", first: 1, last: 10},
+		{spec: "5-5", first: 5, last: 5},
+		{spec: "10-1", wantErr: true},
+		{spec: "0", wantErr: true},
+		{spec: "-3", wantErr: true},
+		{spec: "3-", wantErr: true},
+		{spec: "a-b", wantErr: true},
+		{spec: "1-2-3", wantErr: true},
+	}
+	for _, tt := range tests {
+		first, last, err := ParseVerseRange(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseVerseRange(%q) = %d, %d, want an error", tt.spec, first, last)
+			}
+			continue
+		}
+		if err != nil || first != tt.first || last != tt.last {
+			t.Errorf("ParseVerseRange(%q) = %d, %d, %v, want %d, %d", tt.spec, first, last, err, tt.first, tt.last)
+		}
+	}
+}
+
+func TestParseChapter(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    int
+		wantErr bool
+	}{
+		{spec: "", want: 0},
+		{spec: "3", want: 3},
+		{spec: "03", want: 3},
+		{spec: " 12 ", want: 12},
+		{spec: "0", wantErr: true},
+		{spec: "-1", wantErr: true},
+		{spec: "three", wantErr: true},
+		{spec: "3:16", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseChapter(tt.spec)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseChapter(%q) = %d, want an error", tt.spec, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseChapter(%q) = %d, %v, want %d", tt.spec, got, err, tt.want)
+		}
+	}
+}
+
+func TestBuildFilter(t *testing.T) {
+	textLookup := map[string]*TextData{
+		"jhn-3-15": {Meta: Metadata{Book: "John", Chapter: 3, VerseNum: 15}},
+		"jhn-3-16": {Meta: Metadata{Book: "John", Chapter: 3, VerseNum: 16}},
+		"jhn-3-17": {Meta: Metadata{Book: "John", Chapter: 3, VerseNum: 17}},
+		"jhn-4-16": {Meta: Metadata{Book: "John", Chapter: 4, VerseNum: 16}},
+		"rom-3-16": {Meta: Metadata{Book: "Romans", Chapter: 3, VerseNum: 16}},
+		"jhn-3":    {Meta: Metadata{Book: "John", Chapter: 3}}, // A chapter entry
+	}
+
+	tests := []struct {
+		name    string
+		options SearchOptions
+		want    []string
+	}{
+		{"no filter", SearchOptions{}, []string{"jhn-3-15", "jhn-3-16", "jhn-3-17", "jhn-4-16", "rom-3-16", "jhn-3"}},
+		{"single verse", SearchOptions{Book: "John", Chapter: "3", Verse: "16"}, []string{"jhn-3-16", "jhn-3"}},
+		{"verse range", SearchOptions{Book: "John", Chapter: "3", Verse: "16-17"}, []string{"jhn-3-16", "jhn-3-17", "jhn-3"}},
+		{"verse across books", SearchOptions{Verse: "16"}, []string{"jhn-3-16", "jhn-4-16", "rom-3-16", "jhn-3"}},
+		{"chapter only", SearchOptions{Chapter: "3"}, []string{"jhn-3-15", "jhn-3-16", "jhn-3-17", "rom-3-16", "jhn-3"}},
+		{"chapter with leading zero", SearchOptions{Book: "Jn", Chapter: "03"}, []string{"jhn-3-15", "jhn-3-16", "jhn-3-17", "jhn-3"}},
+		{"book abbreviation", SearchOptions{Book: "Rom"}, []string{"rom-3-16"}},
+		{"reversed verse range", SearchOptions{Verse: "17-15"}, nil},
+		{"invalid verse", SearchOptions{Verse: "x"}, nil},
+		{"invalid chapter", SearchOptions{Chapter: "three"}, nil},
+		{"unknown book", SearchOptions{Book: "Hezekiah"}, nil},
+		{"excluded", SearchOptions{Chapter: "4", exclude: map[string]bool{"jhn-4-16": true}}, nil},
+	}
+	for _, tt := range tests {
+		filter := buildFilter(tt.options, textLookup, nil)
+		want := make(map[string]bool)
+		for _, id := range tt.want {
+			want[id] = true
+		}
+		for id := range textLookup {
+			if got := filter(id); got != want[id] {
+				t.Errorf("%s: filter(%q) = %v, want %v", tt.name, id, got, want[id])
+			}
+		}
+	}
+}
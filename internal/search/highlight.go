@@ -0,0 +1,137 @@
+package search
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Default highlight markers
+const (
+	DefaultHighlightPre  = "<mark>"
+	DefaultHighlightPost = "</mark>"
+)
+
+// Highlight is the marked-up text of a result
+type Highlight struct {
+	Text         string `json:"text"`
+	NearestVerse string `json:"nearestVerse,omitempty"` // Chapter granularity: the verse closest to the query
+}
+
+// wordPattern finds the words highlight terms are matched against
+var wordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+
+// stopwords are too common to be worth highlighting
+var stopwords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true,
+	"but": true, "by": true, "for": true, "from": true, "he": true, "his": true, "i": true,
+	"in": true, "is": true, "it": true, "me": true, "my": true, "not": true, "of": true,
+	"on": true, "or": true, "that": true, "the": true, "them": true, "they": true,
+	"this": true, "to": true, "was": true, "we": true, "with": true, "you": true,
+}
+
+// highlightTerms returns the query terms worth marking in result text
+func highlightTerms(query string) []string {
+	var terms []string
+	for _, term := range queryTerms(query) {
+		if !stopwords[term] {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// matchesTerm reports whether a word matches a query term. Longer terms also
+// match inflected forms ("love" marks "loved" and "loveth").
+func matchesTerm(word string, terms []string) bool {
+	word = strings.ToLower(word)
+	for _, term := range terms {
+		if word == term || (len(term) >= 4 && strings.HasPrefix(word, term)) {
+			return true
+		}
+	}
+	return false
+}
+
+// markTerms wraps every word in text that matches a term
+func markTerms(text string, terms []string, pre, post string) string {
+	if len(terms) == 0 {
+		return text
+	}
+	return wordPattern.ReplaceAllStringFunc(text, func(word string) string {
+		if matchesTerm(word, terms) {
+			return pre + word + post
+		}
+		return word
+	})
+}
+
+// markSentence wraps sentence within text and marks terms outside it, so
+// markers never nest. Terms are marked throughout if sentence isn't found.
+func markSentence(text, sentence string, terms []string, pre, post string) string {
+	i := strings.Index(text, sentence)
+	if sentence == "" || i < 0 {
+		return markTerms(text, terms, pre, post)
+	}
+	return markTerms(text[:i], terms, pre, post) +
+		pre + sentence + post +
+		markTerms(text[i+len(sentence):], terms, pre, post)
+}
+
+// highlight attaches marked-up text to results. Chapter results also mark
+// the verse whose precomputed embedding is nearest the query.
+func (s *SearchService) highlight(results []SearchResult, query string, queryEmbedding []float32, options SearchOptions) {
+	pre, post := options.HighlightPre, options.HighlightPost
+	if pre == "" && post == "" {
+		pre, post = DefaultHighlightPre, DefaultHighlightPost
+	}
+	terms := highlightTerms(query)
+
+	for i := range results {
+		text := results[i].Chunk.Text
+		h := &Highlight{Text: markTerms(text, terms, pre, post)}
+
+		if options.Granularity == "chapter" && queryEmbedding != nil {
+			meta := results[i].Chunk.Meta
+			if verse := s.nearestVerse(meta.Book, meta.Chapter, queryEmbedding); verse != nil {
+				h.Text = markSentence(text, strings.TrimSpace(verse.Text), terms, pre, post)
+				h.NearestVerse = verse.Meta.Reference
+			}
+		}
+
+		results[i].Highlight = h
+	}
+}
+
+// nearestVerse returns the verse of a chapter whose embedding is closest to the query
+func (s *SearchService) nearestVerse(book string, chapter int, queryEmbedding []float32) *TextData {
+	s.mu.RLock()
+	verses := s.chapters[chapterKey(book, chapter)]
+	index := s.indices["verse"]
+	dims := s.dimensions["verse"]
+	ids := make([]string, len(verses))
+	for i, verse := range verses {
+		ids[i] = s.verseIDs[verseKey(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)]
+	}
+	s.mu.RUnlock()
+
+	if index == nil || len(verses) == 0 {
+		return nil
+	}
+	query, err := fitQuery(queryEmbedding, dims)
+	if err != nil {
+		return nil
+	}
+
+	var best *TextData
+	var bestScore float32
+	for i, verse := range verses {
+		vec, ok := index.Get(ids[i])
+		if !ok {
+			continue
+		}
+		if score := CosineSimilarity(query, vec); best == nil || score > bestScore {
+			best, bestScore = verse, score
+		}
+	}
+	return best
+}
@@ -1,16 +1,30 @@
 package search
 
 import (
+	"container/heap"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"math"
 	"sort"
 	"sync"
 )
 
+// minShardSize is the fewest vectors worth scanning in a shard of their own
+const minShardSize = 4096
+
+// cancelCheckInterval is how many vectors a scan scores between checks of
+// its context, so a cancelled search stops within a fraction of a millisecond
+const cancelCheckInterval = 1024
+
 // VectorIndex represents an in-memory vector index
 type VectorIndex struct {
 	Vectors [][]float32
 	IDs     []string
+	positions map[string]int
+	shards  int // Searches fan out across up to this many shards; zero or one scans serially
 	mu      sync.RWMutex
 }
 
@@ -19,6 +33,7 @@ func NewVectorIndex() *VectorIndex {
 	return &VectorIndex{
 		Vectors: make([][]float32, 0),
 		IDs:     make([]string, 0),
+		positions: make(map[string]int),
 	}
 }
 
@@ -27,72 +42,224 @@ func (vi *VectorIndex) Add(id string, vector []float32) {
 	vi.mu.Lock()
 	defer vi.mu.Unlock()
 	
+	vi.positions[id] = len(vi.IDs)
 	vi.IDs = append(vi.IDs, id)
 	vi.Vectors = append(vi.Vectors, vector)
 }
 
+// Get returns the stored vector for an ID
+func (vi *VectorIndex) Get(id string) ([]float32, bool) {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+
+	pos, ok := vi.positions[id]
+	if !ok {
+		return nil, false
+	}
+	return vi.Vectors[pos], true
+}
+
 // Search performs a k-nearest neighbor search
 func (vi *VectorIndex) Search(query []float32, k int) []SearchResult {
+	return vi.SearchWithFilter(context.Background(), query, k, func(string) bool { return true })
+}
+
+// SearchWithFilter performs a filtered k-nearest neighbor search. Large
+// indices are scanned as contiguous shards in parallel, each keeping its own
+// top k, and the shard results are merged. A scan stops early once ctx ends,
+// returning what it found so far; callers check ctx.Err().
+func (vi *VectorIndex) SearchWithFilter(ctx context.Context, query []float32, k int, filter func(id string) bool) []SearchResult {
 	vi.mu.RLock()
 	defer vi.mu.RUnlock()
-	
-	if len(vi.Vectors) == 0 {
+
+	total := len(vi.Vectors)
+	if total == 0 || k <= 0 {
 		return nil
 	}
-	
-	// Calculate similarities for all vectors
-	results := make([]SearchResult, 0, len(vi.Vectors))
-	for i, vec := range vi.Vectors {
-		similarity := cosineSimilarity(query, vec)
-		results = append(results, SearchResult{
-			ID:         vi.IDs[i],
-			Similarity: similarity,
-			Score:      similarity,
-		})
+
+	shards := vi.shardCount()
+	if shards == 1 {
+		return vi.searchRange(ctx, query, k, filter, 0, total)
 	}
-	
-	// Sort by similarity (highest first)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Similarity > results[j].Similarity
-	})
-	
-	// Return top k results
-	if k > len(results) {
-		k = len(results)
+
+	size := (total + shards - 1) / shards
+	tops := make([][]SearchResult, shards)
+	var wg sync.WaitGroup
+	for shard := 0; shard < shards; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			tops[shard] = vi.searchRange(ctx, query, k, filter, shard*size, min((shard+1)*size, total))
+		}(shard)
 	}
-	return results[:k]
+	wg.Wait()
+
+	var results []SearchResult
+	for _, top := range tops {
+		results = append(results, top...)
+	}
+	sortBySimilarity(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
 }
 
-// SearchWithFilter performs a filtered k-nearest neighbor search
-func (vi *VectorIndex) SearchWithFilter(query []float32, k int, filter func(id string) bool) []SearchResult {
+// searchRange returns the top k filtered vectors among positions [start, end),
+// best first, stopping early if ctx ends. The caller holds the read lock.
+func (vi *VectorIndex) searchRange(ctx context.Context, query []float32, k int, filter func(id string) bool, start, end int) []SearchResult {
+	top := &resultHeap{}
+	for i := start; i < end; i++ {
+		if (i-start)%cancelCheckInterval == 0 && ctx.Err() != nil {
+			break
+		}
+		vi.scoreInto(top, query, k, filter, i)
+	}
+
+	results := []SearchResult(*top)
+	sortBySimilarity(results)
+	return results
+}
+
+// scoreInto scores the vector at position i and keeps it if it ranks in the
+// top k. The caller holds the read lock.
+func (vi *VectorIndex) scoreInto(top *resultHeap, query []float32, k int, filter func(id string) bool, i int) {
+	if !filter(vi.IDs[i]) {
+		return
+	}
+	similarity := CosineSimilarity(query, vi.Vectors[i])
+	r := SearchResult{ID: vi.IDs[i], Similarity: similarity, Score: similarity}
+	if top.Len() < k {
+		heap.Push(top, r)
+	} else if ranksAbove(r, (*top)[0]) {
+		(*top)[0] = r
+		heap.Fix(top, 0)
+	}
+}
+
+// SearchPositions is SearchWithFilter over only the vectors at the given
+// positions, as chosen by centroid routing
+func (vi *VectorIndex) SearchPositions(ctx context.Context, query []float32, k int, filter func(id string) bool, positions []int) []SearchResult {
 	vi.mu.RLock()
 	defer vi.mu.RUnlock()
-	
-	if len(vi.Vectors) == 0 {
+
+	if len(positions) == 0 || k <= 0 {
 		return nil
 	}
-	
-	// Calculate similarities for filtered vectors
-	results := make([]SearchResult, 0)
-	for i, vec := range vi.Vectors {
-		if !filter(vi.IDs[i]) {
+
+	shards := max(1, min(vi.shards, len(positions)/minShardSize))
+	size := (len(positions) + shards - 1) / shards
+	tops := make([][]SearchResult, shards)
+	var wg sync.WaitGroup
+	for shard := 0; shard < shards; shard++ {
+		wg.Add(1)
+		go func(shard int) {
+			defer wg.Done()
+			top := &resultHeap{}
+			for n, i := range positions[shard*size : min((shard+1)*size, len(positions))] {
+				if n%cancelCheckInterval == 0 && ctx.Err() != nil {
+					break
+				}
+				vi.scoreInto(top, query, k, filter, i)
+			}
+			tops[shard] = *top
+		}(shard)
+	}
+	wg.Wait()
+
+	var results []SearchResult
+	for _, top := range tops {
+		results = append(results, top...)
+	}
+	sortBySimilarity(results)
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results
+}
+
+// SetShards sets how many shards searches of the index fan out across
+func (vi *VectorIndex) SetShards(shards int) {
+	vi.mu.Lock()
+	defer vi.mu.Unlock()
+	vi.shards = shards
+}
+
+// ShardCount returns the number of shards a search of the index scans in parallel
+func (vi *VectorIndex) ShardCount() int {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+	return vi.shardCount()
+}
+
+// shardCount caps the configured shards so each holds at least minShardSize
+// vectors; below that, fanning out costs more than it saves
+func (vi *VectorIndex) shardCount() int {
+	return max(1, min(vi.shards, len(vi.Vectors)/minShardSize))
+}
+
+// SearchChunks scans the index in chunks of chunkSize vectors, calling emit
+// with the running top k after each chunk. Scanning stops early if emit
+// returns false.
+func (vi *VectorIndex) SearchChunks(query []float32, k, chunkSize int, filter func(id string) bool, emit func(top []SearchResult, scanned, total int) bool) {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+
+	total := len(vi.Vectors)
+	if total == 0 {
+		emit(nil, 0, 0)
+		return
+	}
+
+	top := make([]SearchResult, 0, k+chunkSize)
+	for start := 0; start < total; start += chunkSize {
+		end := min(start+chunkSize, total)
+		for i := start; i < end; i++ {
+			if !filter(vi.IDs[i]) {
+				continue
+			}
+			similarity := CosineSimilarity(query, vi.Vectors[i])
+			top = append(top, SearchResult{
+				ID:         vi.IDs[i],
+				Similarity: similarity,
+				Score:      similarity,
+			})
+		}
+
+		sortBySimilarity(top)
+		if len(top) > k {
+			top = top[:k]
+		}
+
+		if !emit(append([]SearchResult(nil), top...), end, total) {
+			return
+		}
+	}
+}
+
+// Rerank re-scores candidates against the full-precision vectors and returns the top k
+func (vi *VectorIndex) Rerank(query []float32, candidates []SearchResult, k int) []SearchResult {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+
+	results := make([]SearchResult, 0, len(candidates))
+	for _, c := range candidates {
+		pos, ok := vi.positions[c.ID]
+		if !ok {
 			continue
 		}
-		
-		similarity := cosineSimilarity(query, vec)
+
+		similarity := CosineSimilarity(query, vi.Vectors[pos])
 		results = append(results, SearchResult{
-			ID:         vi.IDs[i],
+			ID:         c.ID,
 			Similarity: similarity,
 			Score:      similarity,
 		})
 	}
-	
-	// Sort by similarity (highest first)
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Similarity > results[j].Similarity
-	})
-	
-	// Return top k results
+
+	// Sort by exact similarity (highest first)
+	sortBySimilarity(results)
+
 	if k > len(results) {
 		k = len(results)
 	}
@@ -106,16 +273,47 @@ func (vi *VectorIndex) Size() int {
 	return len(vi.Vectors)
 }
 
+// Checksum returns a SHA-256 digest over the index IDs and vector contents in
+// insertion order, identifying exactly which data a ranking was computed from
+func (vi *VectorIndex) Checksum() string {
+	vi.mu.RLock()
+	defer vi.mu.RUnlock()
+
+	h := sha256.New()
+	buf := make([]byte, 4)
+	for i, id := range vi.IDs {
+		h.Write([]byte(id))
+		h.Write([]byte{0})
+		for _, v := range vi.Vectors[i] {
+			binary.LittleEndian.PutUint32(buf, math.Float32bits(v))
+			h.Write(buf)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
 // Clear removes all vectors from the index
 func (vi *VectorIndex) Clear() {
 	vi.mu.Lock()
 	defer vi.mu.Unlock()
 	vi.Vectors = make([][]float32, 0)
 	vi.IDs = make([]string, 0)
+	vi.positions = make(map[string]int)
+}
+
+// sortBySimilarity orders results by similarity (highest first), breaking
+// ties by ID so rankings are reproducible
+func sortBySimilarity(results []SearchResult) {
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Similarity != results[j].Similarity {
+			return results[i].Similarity > results[j].Similarity
+		}
+		return results[i].ID < results[j].ID
+	})
 }
 
-// cosineSimilarity calculates the cosine similarity between two vectors
-func cosineSimilarity(a, b []float32) float32 {
+// CosineSimilarity calculates the cosine similarity between two vectors
+func CosineSimilarity(a, b []float32) float32 {
 	if len(a) != len(b) {
 		return 0
 	}
@@ -135,119 +333,202 @@ func cosineSimilarity(a, b []float32) float32 {
 	return dotProduct / magnitude
 }
 
-// QuantizedVector represents a quantized vector for memory efficiency
+// QuantizedVector is a vector stored with symmetric int8 quantization:
+// component i is approximately Quantized[i] * Scale
 type QuantizedVector struct {
-	ID         string
-	Quantized  []int8   // Quantized to int8 for memory efficiency
-	Scale      float32  // Scale factor for dequantization
+	ID        string
+	Quantized []int8
+	Scale     float32 // maxAbs / 127
+	Norm      float32 // Euclidean norm of Quantized, so cosines need no dequantization
 }
 
-// QuantizedIndex represents a memory-efficient vector index using quantization
+// QuantizedIndex is a vector index at a quarter of the float index's memory.
+// With QuantizeQuery, the query is quantized too and vectors are scored with
+// an int32 dot product; otherwise the float query is scored against the
+// stored int8 components directly.
 type QuantizedIndex struct {
-	Vectors []QuantizedVector
-	mu      sync.RWMutex
+	Vectors       []QuantizedVector
+	QuantizeQuery bool
+	mu            sync.RWMutex
 }
 
 // NewQuantizedIndex creates a new quantized index
-func NewQuantizedIndex() *QuantizedIndex {
+func NewQuantizedIndex(quantizeQuery bool) *QuantizedIndex {
 	return &QuantizedIndex{
-		Vectors: make([]QuantizedVector, 0),
+		Vectors:       make([]QuantizedVector, 0),
+		QuantizeQuery: quantizeQuery,
 	}
 }
 
-// quantizeVector quantizes a float32 vector to int8 for memory efficiency
-func quantizeVector(vec []float32) ([]int8, float32) {
-	// Find min and max for scaling
-	var minVal, maxVal float32 = vec[0], vec[0]
+// quantizeVector quantizes a vector symmetrically around zero, scaling its
+// largest component to ±127, and returns the scale and the quantized norm
+func quantizeVector(vec []float32) ([]int8, float32, float32) {
+	var maxAbs float32
 	for _, v := range vec {
-		if v < minVal {
-			minVal = v
+		if v < 0 {
+			v = -v
 		}
-		if v > maxVal {
-			maxVal = v
+		if v > maxAbs {
+			maxAbs = v
 		}
 	}
-	
-	// Calculate scale
-	scale := (maxVal - minVal) / 255.0
+
+	scale := maxAbs / 127
 	if scale == 0 {
 		scale = 1
 	}
-	
-	// Quantize
+
 	quantized := make([]int8, len(vec))
+	var sumSquares int64
 	for i, v := range vec {
-		normalized := (v - minVal) / scale
-		// Clamp to int8 range
-		val := int(normalized) - 128
-		if val < -128 {
-			val = -128
-		} else if val > 127 {
-			val = 127
+		q := int32(math.Round(float64(v / scale)))
+		if q < -127 {
+			q = -127
+		} else if q > 127 {
+			q = 127
 		}
-		quantized[i] = int8(val)
+		quantized[i] = int8(q)
+		sumSquares += int64(q * q)
 	}
-	
-	return quantized, scale
+
+	return quantized, scale, float32(math.Sqrt(float64(sumSquares)))
 }
 
-// dequantizeVector converts a quantized vector back to float32
-func dequantizeVector(quantized []int8, scale float32) []float32 {
-	vec := make([]float32, len(quantized))
-	for i, q := range quantized {
-		// Convert back from centered int8 to original range
-		vec[i] = (float32(q) + 128) * scale
+// dotInt8 is the int32 dot product of two int8 vectors of equal length
+func dotInt8(a, b []int8) int32 {
+	var s0, s1, s2, s3 int32
+	i := 0
+	for ; i+4 <= len(a); i += 4 {
+		s0 += int32(a[i]) * int32(b[i])
+		s1 += int32(a[i+1]) * int32(b[i+1])
+		s2 += int32(a[i+2]) * int32(b[i+2])
+		s3 += int32(a[i+3]) * int32(b[i+3])
+	}
+	for ; i < len(a); i++ {
+		s0 += int32(a[i]) * int32(b[i])
 	}
-	return vec
+	return s0 + s1 + s2 + s3
+}
+
+// dotFloatInt8 is the dot product of a float vector and an int8 vector
+func dotFloatInt8(a []float32, b []int8) float32 {
+	var sum float32
+	for i, q := range b {
+		sum += a[i] * float32(q)
+	}
+	return sum
 }
 
 // Add adds a vector to the quantized index
 func (qi *QuantizedIndex) Add(id string, vector []float32) {
 	qi.mu.Lock()
 	defer qi.mu.Unlock()
-	
-	quantized, scale := quantizeVector(vector)
+
+	quantized, scale, norm := quantizeVector(vector)
 	qi.Vectors = append(qi.Vectors, QuantizedVector{
 		ID:        id,
 		Quantized: quantized,
 		Scale:     scale,
+		Norm:      norm,
 	})
 }
 
 // Search performs approximate nearest neighbor search on quantized vectors
 func (qi *QuantizedIndex) Search(query []float32, k int) []SearchResult {
+	return qi.SearchWithFilter(context.Background(), query, k, func(string) bool { return true })
+}
+
+// SearchWithFilter performs a filtered approximate search on quantized
+// vectors, stopping early once ctx ends
+func (qi *QuantizedIndex) SearchWithFilter(ctx context.Context, query []float32, k int, filter func(id string) bool) []SearchResult {
 	qi.mu.RLock()
 	defer qi.mu.RUnlock()
-	
+
 	if len(qi.Vectors) == 0 {
 		return nil
 	}
-	
-	// For better accuracy, we could keep the query in float32
-	// and only quantize the stored vectors
-	results := make([]SearchResult, 0, len(qi.Vectors))
-	
-	for _, qvec := range qi.Vectors {
-		// Dequantize for similarity calculation
-		vec := dequantizeVector(qvec.Quantized, qvec.Scale)
-		similarity := cosineSimilarity(query, vec)
-		results = append(results, SearchResult{
-			ID:         qvec.ID,
-			Similarity: similarity,
-			Score:      similarity,
-		})
+
+	// Cosine similarity is scale-invariant, so only the norms are needed
+	scores := make([]float32, len(qi.Vectors))
+	if qi.QuantizeQuery {
+		q, _, qNorm := quantizeVector(query)
+		for i := range qi.Vectors {
+			if i%cancelCheckInterval == 0 && ctx.Err() != nil {
+				return nil
+			}
+			v := &qi.Vectors[i]
+			if qNorm != 0 && v.Norm != 0 && len(v.Quantized) == len(q) && filter(v.ID) {
+				scores[i] = float32(dotInt8(q, v.Quantized)) / (qNorm * v.Norm)
+			} else {
+				scores[i] = float32(math.Inf(-1))
+			}
+		}
+	} else {
+		var sumSquares float64
+		for _, x := range query {
+			sumSquares += float64(x * x)
+		}
+		qNorm := float32(math.Sqrt(sumSquares))
+		for i := range qi.Vectors {
+			if i%cancelCheckInterval == 0 && ctx.Err() != nil {
+				return nil
+			}
+			v := &qi.Vectors[i]
+			if qNorm != 0 && v.Norm != 0 && len(v.Quantized) == len(query) && filter(v.ID) {
+				scores[i] = dotFloatInt8(query, v.Quantized) / (qNorm * v.Norm)
+			} else {
+				scores[i] = float32(math.Inf(-1))
+			}
+		}
 	}
-	
-	// Sort by similarity
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Similarity > results[j].Similarity
-	})
-	
-	// Return top k
-	if k > len(results) {
-		k = len(results)
+
+	// Keep the running top k in a min-heap rather than sorting every score
+	top := &resultHeap{}
+	for i, score := range scores {
+		if math.IsInf(float64(score), -1) {
+			continue
+		}
+		r := SearchResult{ID: qi.Vectors[i].ID, Similarity: score, Score: score}
+		if top.Len() < k {
+			heap.Push(top, r)
+		} else if k > 0 && ranksAbove(r, (*top)[0]) {
+			(*top)[0] = r
+			heap.Fix(top, 0)
+		}
 	}
-	return results[:k]
+
+	results := []SearchResult(*top)
+	sortBySimilarity(results)
+	return results
+}
+
+// ranksAbove reports whether a sorts before b in sortBySimilarity's order
+func ranksAbove(a, b SearchResult) bool {
+	if a.Similarity != b.Similarity {
+		return a.Similarity > b.Similarity
+	}
+	return a.ID < b.ID
+}
+
+// resultHeap is a min-heap of results, lowest ranked on top
+type resultHeap []SearchResult
+
+func (h resultHeap) Len() int            { return len(h) }
+func (h resultHeap) Less(i, j int) bool  { return ranksAbove(h[j], h[i]) }
+func (h resultHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *resultHeap) Push(x interface{}) { *h = append(*h, x.(SearchResult)) }
+func (h *resultHeap) Pop() interface{} {
+	old := *h
+	r := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return r
+}
+
+// Size returns the number of vectors in the quantized index
+func (qi *QuantizedIndex) Size() int {
+	qi.mu.RLock()
+	defer qi.mu.RUnlock()
+	return len(qi.Vectors)
 }
 
 // IndexMetadata stores metadata about the index
@@ -296,8 +577,8 @@ func (qi *QuantizedIndex) GetMemoryUsage() int64 {
 	
 	memory := int64(0)
 	for _, vec := range qi.Vectors {
-		// Each int8 is 1 byte, plus 4 bytes for scale, plus string length
-		memory += int64(len(vec.Quantized)) + 4 + int64(len(vec.ID))
+		// Each int8 is 1 byte, plus 4 bytes each for scale and norm, plus string length
+		memory += int64(len(vec.Quantized)) + 8 + int64(len(vec.ID))
 	}
 	
 	return memory
@@ -0,0 +1,304 @@
+package search
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/rs/zerolog/log"
+)
+
+// MaxIngestDocuments bounds one ingested corpus, which is embedded in a
+// single pass and held in memory
+const MaxIngestDocuments = 50000
+
+var (
+	// ErrInvalidCorpus reports a corpus name or document set that can't be ingested
+	ErrInvalidCorpus = errors.New("invalid corpus")
+	// ErrCorpusNotFound reports an ingested corpus that doesn't exist
+	ErrCorpusNotFound = errors.New("corpus not found")
+)
+
+// corpusNamePattern keeps corpus names usable as granularities and directory names
+var corpusNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// Document is one text of an ingested corpus, such as a commentary entry,
+// sermon or catechism answer
+type Document struct {
+	ID        string `json:"id"`
+	Text      string `json:"text"`
+	Title     string `json:"title,omitempty"`
+	Reference string `json:"reference,omitempty"` // Shown with results, e.g. "Heidelberg Q1" or "John 3:16"
+	Book      string `json:"book,omitempty"`      // Scripture the text discusses, for book filters
+	Chapter   int    `json:"chapter,omitempty"`
+	Verse     int    `json:"verse,omitempty"`
+}
+
+// ValidateCorpusName checks a name for an ingested corpus
+func ValidateCorpusName(name string) error {
+	if !corpusNamePattern.MatchString(name) {
+		return fmt.Errorf("%w: name must be 1-64 lowercase letters, digits, '-' or '_'", ErrInvalidCorpus)
+	}
+	for _, source := range builtinSources() {
+		if source.Granularity == name {
+			return fmt.Errorf("%w: %s is a built-in granularity", ErrInvalidCorpus, name)
+		}
+	}
+	if name == GranularityOriginal {
+		return fmt.Errorf("%w: %s is a built-in granularity", ErrInvalidCorpus, name)
+	}
+	return nil
+}
+
+// ParseDocuments reads documents from a JSON array or from JSON Lines
+func ParseDocuments(r io.Reader) ([]Document, error) {
+	reader := bufio.NewReader(r)
+	first, err := peekNonSpace(reader)
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: no documents", ErrInvalidCorpus)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var docs []Document
+	if first == '[' {
+		if err := json.NewDecoder(reader).Decode(&docs); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCorpus, err)
+		}
+		return docs, nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := bytes.TrimSpace(scanner.Bytes())
+		if len(text) == 0 {
+			continue
+		}
+		var doc Document
+		if err := json.Unmarshal(text, &doc); err != nil {
+			return nil, fmt.Errorf("%w: line %d: %v", ErrInvalidCorpus, line, err)
+		}
+		docs = append(docs, doc)
+		if len(docs) > MaxIngestDocuments {
+			break
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCorpus, err)
+	}
+	return docs, nil
+}
+
+// peekNonSpace returns the first non-whitespace byte without consuming it
+func peekNonSpace(reader *bufio.Reader) (byte, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if b != ' ' && b != '\t' && b != '\r' && b != '\n' {
+			return b, reader.UnreadByte()
+		}
+	}
+}
+
+// validateDocuments checks documents and fills in missing IDs
+func validateDocuments(docs []Document) error {
+	switch {
+	case len(docs) == 0:
+		return fmt.Errorf("%w: no documents", ErrInvalidCorpus)
+	case len(docs) > MaxIngestDocuments:
+		return fmt.Errorf("%w: more than %d documents", ErrInvalidCorpus, MaxIngestDocuments)
+	}
+	seen := make(map[string]bool, len(docs))
+	for i := range docs {
+		if strings.TrimSpace(docs[i].Text) == "" {
+			return fmt.Errorf("%w: document %d has no text", ErrInvalidCorpus, i)
+		}
+		if docs[i].ID == "" {
+			docs[i].ID = fmt.Sprintf("%d", i)
+		}
+		if seen[docs[i].ID] {
+			return fmt.Errorf("%w: duplicate document id %q", ErrInvalidCorpus, docs[i].ID)
+		}
+		seen[docs[i].ID] = true
+	}
+	return nil
+}
+
+// ingestedPath returns where an ingested corpus is persisted
+func (s *SearchService) ingestedPath(name string) string {
+	return filepath.Join(s.config.DataDir, "corpora", name, "snapshot.gob")
+}
+
+// IngestCorpus embeds documents with the document prompt, builds a named
+// index searchable as index=name, and persists it to the data
+// directory with its license. Re-ingesting a name replaces its index.
+// Ingestions run one at a time, each batch yielding to waiting queries.
+func (s *SearchService) IngestCorpus(ctx context.Context, name string, docs []Document, license config.CorpusLicense) (*CorpusInfo, error) {
+	if err := ValidateCorpusName(name); err != nil {
+		return nil, err
+	}
+	if _, err := s.sourceFor(name); err == nil {
+		return nil, fmt.Errorf("%w: %s is a configured index", ErrInvalidCorpus, name)
+	}
+	if err := validateDocuments(docs); err != nil {
+		return nil, err
+	}
+
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+
+	started := time.Now()
+	texts := make([]string, len(docs))
+	for i, doc := range docs {
+		texts[i] = doc.Text
+		if doc.Title != "" {
+			texts[i] = doc.Title + "\n" + doc.Text
+		}
+	}
+	vectors, err := s.embeddings.EmbedDocuments(ctx, texts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", ErrEmbedding, err)
+	}
+
+	corpus := &corpusData{
+		ids:        make([]string, len(docs)),
+		vectors:    vectors,
+		textLookup: make(map[string]*TextData, len(docs)),
+	}
+	for i, doc := range docs {
+		corpus.ids[i] = doc.ID
+		corpus.textLookup[doc.ID] = &TextData{
+			Text: doc.Text,
+			Meta: Metadata{
+				Reference: doc.Reference,
+				Book:      doc.Book,
+				Chapter:   doc.Chapter,
+				VerseNum:  doc.Verse,
+				Heading:   doc.Title,
+			},
+		}
+	}
+	corpus.header = &ArtifactHeader{
+		ModelID:    config.ModelConfig.ModelID,
+		Dimensions: len(vectors[0]),
+		Metric:     "cosine",
+		CorpusHash: hashCorpus(corpus.ids, corpus.textLookup),
+		CreatedAt:  time.Now().UTC().Truncate(time.Second),
+		Verified:   true,
+	}
+	if !license.IsZero() {
+		corpus.header.License = &license
+	}
+
+	source := CorpusSource{Granularity: name, Ingested: true}
+	if err := writeSnapshot(s.ingestedPath(name), source, corpus); err != nil {
+		return nil, err
+	}
+	if err := s.installIngested(source, corpus); err != nil {
+		return nil, err
+	}
+
+	log.Ctx(ctx).Info().
+		Str("corpus", name).
+		Int("documents", len(docs)).
+		Dur("took", time.Since(started)).
+		Msg("Ingested corpus")
+
+	for _, info := range s.Corpora() {
+		if info.Granularity == name {
+			return &info, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrCorpusNotFound, name)
+}
+
+// installIngested serves a parsed ingested corpus under its name
+func (s *SearchService) installIngested(source CorpusSource, corpus *corpusData) (err error) {
+	name := source.Granularity
+	s.loads.start(name)
+	defer func() { s.loads.finish(name, err) }()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.install(name, corpus); err != nil {
+		return err
+	}
+	s.loadedGranularities[name] = true
+	s.loads.addIngested(source)
+	return nil
+}
+
+// LoadIngestedCorpora serves every corpus ingested by a previous run
+func (s *SearchService) LoadIngestedCorpora() error {
+	entries, err := os.ReadDir(filepath.Join(s.config.DataDir, "corpora"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	// Register every corpus first, so searches report it as loading rather
+	// than unknown while the service lock is held by another load
+	var sources []CorpusSource
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || ValidateCorpusName(name) != nil {
+			continue
+		}
+		source := CorpusSource{Granularity: name, Ingested: true}
+		s.loads.addIngested(source)
+		sources = append(sources, source)
+	}
+
+	var loadErrs []error
+	for _, source := range sources {
+		name := source.Granularity
+		corpus, err := readSnapshot(s.ingestedPath(name), source)
+		if err == nil {
+			err = s.installIngested(source, corpus)
+		} else {
+			s.loads.finish(name, err)
+		}
+		if err != nil {
+			loadErrs = append(loadErrs, fmt.Errorf("corpus %s: %w", name, err))
+			continue
+		}
+		log.Info().Str("corpus", name).Int("documents", len(corpus.ids)).Msg("Loaded ingested corpus")
+	}
+	return errors.Join(loadErrs...)
+}
+
+// DeleteCorpus stops serving an ingested corpus and removes it from disk
+func (s *SearchService) DeleteCorpus(name string) error {
+	s.ingestMu.Lock()
+	defer s.ingestMu.Unlock()
+
+	if !s.loads.isIngested(name) {
+		return fmt.Errorf("%w: %s", ErrCorpusNotFound, name)
+	}
+
+	s.mu.Lock()
+	s.drop(name)
+	s.mu.Unlock()
+	s.loads.forget(name)
+
+	return os.RemoveAll(filepath.Dir(s.ingestedPath(name)))
+}
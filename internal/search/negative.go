@@ -0,0 +1,67 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// negativeWeight scales the negative example subtracted from a query vector,
+// enough to push its neighbours down without drowning the query
+const negativeWeight = 0.5
+
+// ErrNegativeEnsemble reports a negative example combined with ensemble
+// ranking, whose second model embeds the query separately
+var ErrNegativeEnsemble = errors.New("negative can't be combined with ranking=ensemble")
+
+// ValidateNegative checks that a negative example can be used with a ranking
+func ValidateNegative(negative, ranking string) error {
+	if negative != "" && ranking == RankingEnsemble {
+		return ErrNegativeEnsemble
+	}
+	return nil
+}
+
+// withNegative embeds a search's negative example, if it has one, so every
+// query vector the search ranks with can be moved away from it
+func (s *SearchService) withNegative(ctx context.Context, options SearchOptions) (SearchOptions, error) {
+	if options.Negative == "" || options.negative != nil {
+		return options, nil
+	}
+	if options.Ranking == RankingEnsemble {
+		return options, ErrNegativeEnsemble
+	}
+	embedding, err := s.embeddings.EmbedQuery(ctx, options.Negative)
+	if err != nil {
+		return options, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	options.negative = embedding
+	return options, nil
+}
+
+// subtractNegative moves a query vector away from the search's negative
+// example, keeping its length. The vector is returned unchanged when there
+// is no example.
+func subtractNegative(queryEmbedding []float32, options SearchOptions) []float32 {
+	if len(options.negative) != len(queryEmbedding) {
+		return queryEmbedding
+	}
+	adjusted := make([]float32, len(queryEmbedding))
+	var queryNorm, adjustedNorm float64
+	for i, value := range queryEmbedding {
+		v := float64(value) - negativeWeight*float64(options.negative[i])
+		adjusted[i] = float32(v)
+		queryNorm += float64(value) * float64(value)
+		adjustedNorm += v * v
+	}
+	// Keep the query's length, which the quantized scan's scale assumes
+	if adjustedNorm > 0 {
+		scale := float32(math.Sqrt(queryNorm / adjustedNorm))
+		for i := range adjusted {
+			adjusted[i] *= scale
+		}
+	}
+	options.explain.recordNegative()
+	return adjusted
+}
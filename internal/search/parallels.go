@@ -0,0 +1,107 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+const (
+	// DefaultParallelThreshold is the cosine similarity a verse in another
+	// book needs to count as a parallel: near-duplicates, not shared themes
+	DefaultParallelThreshold = 0.85
+	// MaxParallelVerses caps the verses of a reference searched for parallels
+	MaxParallelVerses = 50
+)
+
+// ErrParallelRange reports a reference too long to search for parallels
+var ErrParallelRange = errors.New("reference is too long to search for parallels")
+
+// Kinds of parallel passage
+const (
+	ParallelSynoptic  = "synoptic"  // The same account in two Gospels
+	ParallelQuotation = "quotation" // An Old Testament verse quoted or echoed in the New
+	ParallelDuplicate = "duplicate" // Any other near-duplicate, e.g. Kings and Chronicles
+)
+
+// Parallel is a verse of a reference and its near-duplicates in other books
+type Parallel struct {
+	Verse   *TextData
+	Matches []SearchResult
+}
+
+// Parallels finds near-duplicates of each verse of a reference in other
+// books, by thresholding the similarity of their precomputed embeddings.
+// options.MinScore is the threshold and options.K caps the matches per
+// verse. Verses without matches are left out.
+func (s *SearchService) Parallels(ctx context.Context, ref reference.Reference, options SearchOptions) ([]Parallel, error) {
+	verses, err := s.Passage(ref)
+	if err != nil {
+		return nil, err
+	}
+	if len(verses) > MaxParallelVerses {
+		return nil, fmt.Errorf("%w: %s has %d verses, at most %d are searched", ErrParallelRange, ref.String(), len(verses), MaxParallelVerses)
+	}
+
+	book, ok := canon.Lookup(ref.Book)
+	if !ok {
+		return nil, fmt.Errorf("unknown book: %s", ref.Book)
+	}
+	// Parallels are across books, so every other book is searched
+	others := make([]string, 0, len(canon.Books)-1)
+	for i := range canon.Books {
+		if canon.Books[i].ID != book.ID {
+			others = append(others, canon.Books[i].ID)
+		}
+	}
+
+	options = withDefaults(options)
+	options.Granularity = "verse"
+	// A threshold is only meaningful on raw cosine over every vector
+	options.Ranking = RankingPure
+	options.Fields = nil
+	options.Rerank = false
+	options.Exhaustive = true
+	options.Book = ""
+	options.Books = others
+
+	var parallels []Parallel
+	embedded := false
+	for _, verse := range verses {
+		vector, ok := s.VerseEmbedding(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)
+		if !ok {
+			continue
+		}
+		embedded = true
+		matches, err := s.searchEmbedding(ctx, "", vector, options)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) > 0 {
+			parallels = append(parallels, Parallel{Verse: verse, Matches: matches})
+		}
+	}
+	if !embedded {
+		return nil, fmt.Errorf("%w for %s", ErrNoEmbedding, ref.String())
+	}
+	return parallels, nil
+}
+
+// ParallelKind classifies a parallel between verses of two books
+func ParallelKind(a, b string) string {
+	first, ok := canon.Lookup(a)
+	second, ok2 := canon.Lookup(b)
+	switch {
+	case !ok || !ok2:
+		return ParallelDuplicate
+	case first.Genre == canon.GenreGospels && second.Genre == canon.GenreGospels:
+		return ParallelSynoptic
+	case first.Testament != second.Testament:
+		return ParallelQuotation
+	default:
+		return ParallelDuplicate
+	}
+}
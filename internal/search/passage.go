@@ -0,0 +1,135 @@
+package search
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+// buildChapterLookup groups verse texts by canonical book and chapter, ordered by verse number
+func buildChapterLookup(textLookup map[string]*TextData) map[string][]*TextData {
+	chapters := make(map[string][]*TextData)
+	seen := make(map[*TextData]bool)
+
+	// textLookup stores each verse under several IDs, so dedupe by pointer
+	for _, text := range textLookup {
+		if seen[text] || text.Meta.Book == "" {
+			continue
+		}
+		seen[text] = true
+
+		key := chapterKey(text.Meta.Book, text.Meta.Chapter)
+		chapters[key] = append(chapters[key], text)
+	}
+
+	for _, verses := range chapters {
+		sort.Slice(verses, func(i, j int) bool {
+			return verses[i].Meta.VerseNum < verses[j].Meta.VerseNum
+		})
+	}
+
+	return chapters
+}
+
+// buildVerseIDs maps canonical verse keys to their IDs in the vector index
+func buildVerseIDs(index *VectorIndex, textLookup map[string]*TextData) map[string]string {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	ids := make(map[string]string, len(index.IDs))
+	for _, id := range index.IDs {
+		if text, ok := textLookup[id]; ok && text.Meta.Book != "" {
+			ids[verseKey(text.Meta.Book, text.Meta.Chapter, text.Meta.VerseNum)] = id
+		}
+	}
+	return ids
+}
+
+// orderVerses lists the verses of every canonical book in canonical order
+func orderVerses(chapters map[string][]*TextData) []*TextData {
+	var verses []*TextData
+	for _, book := range canon.Books {
+		for chapter := 1; chapter <= book.Chapters; chapter++ {
+			verses = append(verses, chapters[chapterKey(book.Name, chapter)]...)
+		}
+	}
+	return verses
+}
+
+// verseKey builds the verse lookup key, resolving book aliases to canonical names
+func verseKey(book string, chapter, verse int) string {
+	return fmt.Sprintf("%s:%d", chapterKey(book, chapter), verse)
+}
+
+// chapterKey builds the chapter lookup key, resolving book aliases to canonical names
+func chapterKey(book string, chapter int) string {
+	if b, ok := canon.Lookup(book); ok {
+		book = b.Name
+	}
+	return fmt.Sprintf("%s:%d", strings.ToLower(book), chapter)
+}
+
+// Passage returns the verses covered by a reference in canonical order
+func (s *SearchService) Passage(ref reference.Reference) ([]*TextData, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if !s.loadedGranularities["verse"] {
+		return nil, fmt.Errorf("granularity verse %w", ErrNotLoaded)
+	}
+
+	var verses []*TextData
+	for chapter := ref.StartChapter; chapter <= ref.EndChapter; chapter++ {
+		for _, verse := range s.chapters[chapterKey(ref.Book, chapter)] {
+			if ref.Contains(chapter, verse.Meta.VerseNum) {
+				verses = append(verses, verse)
+			}
+		}
+	}
+
+	return verses, nil
+}
+
+// Verses returns every verse in canonical order, or nil if the verse index
+// isn't loaded. The slice is shared and must not be modified.
+func (s *SearchService) Verses() []*TextData {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.verseOrder
+}
+
+// VerseEmbedding returns the precomputed embedding for a single verse
+func (s *SearchService) VerseEmbedding(book string, chapter, verse int) ([]float32, bool) {
+	s.mu.RLock()
+	id, ok := s.verseIDs[verseKey(book, chapter, verse)]
+	index := s.indices["verse"]
+	s.mu.RUnlock()
+
+	if !ok || index == nil {
+		return nil, false
+	}
+	return index.Get(id)
+}
+
+// Embedding returns the vector an index holds for a result ID
+func (s *SearchService) Embedding(granularity, id string) ([]float32, bool) {
+	s.mu.RLock()
+	index := s.indices[granularity]
+	s.mu.RUnlock()
+
+	if index == nil {
+		return nil, false
+	}
+	return index.Get(id)
+}
+
+// VerseCount returns the number of verses loaded for a chapter, or zero if
+// the verse index isn't loaded
+func (s *SearchService) VerseCount(book string, chapter int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.chapters[chapterKey(book, chapter)])
+}
@@ -0,0 +1,219 @@
+package search
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+)
+
+// embeddingsPayload is a parsed embeddings artifact, in artifact order
+type embeddingsPayload struct {
+	ids     []string
+	vectors [][]float32
+	header  *ArtifactHeader
+}
+
+// size estimates the payload's memory for the download cache
+func (p *embeddingsPayload) size() int64 {
+	var size int64
+	for i, vec := range p.vectors {
+		size += int64(len(vec))*4 + int64(len(p.ids[i]))
+	}
+	return size
+}
+
+// embeddingEntry is one element of an artifact's "embeddings" array
+type embeddingEntry struct {
+	ID        string    `json:"id"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// artifactHeaderJSON is an artifact's "header" object as written
+type artifactHeaderJSON struct {
+	ModelID    string                `json:"modelId"`
+	Dimensions int                   `json:"dimensions"`
+	Normalized bool                  `json:"normalized"`
+	Metric     string                `json:"metric"`
+	CorpusHash string                `json:"corpusHash"`
+	CreatedAt  string                `json:"createdAt"`
+	License    *config.CorpusLicense `json:"license"`
+}
+
+// loadEmbeddings streams an embeddings artifact from a URL, decoding one
+// entry at a time so peak memory stays near the size of the vectors
+// themselves rather than several times the file
+func (s *SearchService) loadEmbeddings(ctx context.Context, url string, compressed bool) (*embeddingsPayload, error) {
+	if cached, ok := s.cache.Get(url); ok {
+		if payload, ok := cached.(*embeddingsPayload); ok {
+			return payload, nil
+		}
+	}
+
+	body, err := openURL(ctx, url, compressed)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+
+	payload, err := decodeEmbeddings(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JSON: %w", err)
+	}
+
+	s.cache.Set(url, payload, payload.size())
+	return payload, nil
+}
+
+// decodeEmbeddings reads the IDs, vectors and provenance header from an
+// embeddings artifact. Entries without a vector are skipped, and keys other
+// than "header" and "embeddings" are ignored.
+func decodeEmbeddings(r io.Reader) (*embeddingsPayload, error) {
+	decoder := json.NewDecoder(r)
+	if err := expectDelim(decoder, '{'); err != nil {
+		return nil, err
+	}
+
+	payload := &embeddingsPayload{}
+	for decoder.More() {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch key, _ := token.(string); key {
+		case "header":
+			var raw *artifactHeaderJSON
+			if err := decoder.Decode(&raw); err != nil {
+				return nil, fmt.Errorf("header: %w", err)
+			}
+			payload.header = parseArtifactHeader(raw)
+		case "embeddings":
+			if err := decodeEntries(decoder, payload); err != nil {
+				return nil, fmt.Errorf("embeddings: %w", err)
+			}
+		default:
+			var skip json.RawMessage
+			if err := decoder.Decode(&skip); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return payload, expectDelim(decoder, '}')
+}
+
+// decodeEntries decodes an "embeddings" array one entry at a time
+func decodeEntries(decoder *json.Decoder, payload *embeddingsPayload) error {
+	if err := expectDelim(decoder, '['); err != nil {
+		return err
+	}
+	for decoder.More() {
+		var entry embeddingEntry
+		if err := decoder.Decode(&entry); err != nil {
+			return fmt.Errorf("entry %d: %w", len(payload.ids), err)
+		}
+		if entry.Embedding == nil {
+			continue
+		}
+		payload.ids = append(payload.ids, entry.ID)
+		payload.vectors = append(payload.vectors, entry.Embedding)
+	}
+	return expectDelim(decoder, ']')
+}
+
+// expectDelim consumes the next token, which must be the given delimiter
+func expectDelim(decoder *json.Decoder, delim json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return err
+	}
+	if token != delim {
+		return fmt.Errorf("expected %q, found %v", delim, token)
+	}
+	return nil
+}
+
+// openURL fetches a URL, or opens a local path or file:// URL, transparently
+// decompressing gzipped bodies when compressed is set. Reads fail once ctx
+// ends.
+func openURL(ctx context.Context, url string, compressed bool) (io.ReadCloser, error) {
+	var body io.ReadCloser
+	if config.IsRemote(url) {
+		client := &http.Client{
+			Timeout: 30 * time.Second,
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(config.LocalPath(url))
+		if err != nil {
+			return nil, err
+		}
+		body = readCloser{contextReader{ctx, file}, file}
+	}
+	if !compressed {
+		return body, nil
+	}
+
+	// Check the gzip magic number without reading the body
+	buffered := bufio.NewReader(body)
+	if magic, err := buffered.Peek(2); err != nil || magic[0] != 0x1f || magic[1] != 0x8b {
+		return readCloser{buffered, body}, nil
+	}
+	gzReader, err := gzip.NewReader(buffered)
+	if err != nil {
+		body.Close()
+		return nil, err
+	}
+	return readCloser{gzReader, closers{gzReader, body}}, nil
+}
+
+// readCloser reads from one source and closes another
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// contextReader fails reads once its context ends, as an HTTP body does
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// closers closes each of its closers in order
+type closers []io.Closer
+
+func (c closers) Close() error {
+	var first error
+	for _, closer := range c {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
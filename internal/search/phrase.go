@@ -0,0 +1,45 @@
+package search
+
+import "strings"
+
+// phraseText reduces text to its lowercase words, space separated and
+// padded, so a phrase matches word for word whatever the punctuation:
+// "be still and know" matches "Be still, and know"
+func phraseText(text string) string {
+	return " " + strings.Join(queryTerms(text), " ") + " "
+}
+
+// phraseMatcher returns a predicate reporting whether a text contains every
+// phrase and none of the excluded words or phrases, or nil when there are
+// neither
+func phraseMatcher(phrases, excluded []string) func(text string) bool {
+	required, forbidden := phraseTexts(phrases), phraseTexts(excluded)
+	if len(required) == 0 && len(forbidden) == 0 {
+		return nil
+	}
+	return func(text string) bool {
+		words := phraseText(text)
+		for _, phrase := range required {
+			if !strings.Contains(words, phrase) {
+				return false
+			}
+		}
+		for _, phrase := range forbidden {
+			if strings.Contains(words, phrase) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// phraseTexts normalizes phrases with phraseText, dropping any without words
+func phraseTexts(phrases []string) []string {
+	var normalized []string
+	for _, phrase := range phrases {
+		if p := phraseText(phrase); strings.TrimSpace(p) != "" {
+			normalized = append(normalized, p)
+		}
+	}
+	return normalized
+}
@@ -0,0 +1,113 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+)
+
+// ErrUnknownPipeline reports a pipeline name that isn't in Pipelines
+var ErrUnknownPipeline = errors.New("unknown pipeline")
+
+// Pipeline is a named search configuration: the retrieval and ranking
+// settings a feature flag would switch, applied over a request's filters
+type Pipeline struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Ranking     string  `json:"ranking"`
+	Rerank      bool    `json:"rerank"`
+	Diversity   float64 `json:"diversity,omitempty"`
+	Exhaustive  bool    `json:"exhaustive,omitempty"`
+}
+
+// Pipelines lists the configurations /admin/diff-search can compare
+var Pipelines = []Pipeline{
+	{Name: "exact", Description: "Exact cosine scan of the whole float index", Ranking: RankingDefault, Exhaustive: true},
+	{Name: "routed", Description: "Cosine scan of the books and chapters nearest the query by centroid (with -route-books)", Ranking: RankingDefault},
+	{Name: "rerank", Description: "Quantized retrieval (binary with -binary-index) of -rerank-candidates, re-ranked at full precision", Ranking: RankingDefault, Rerank: true},
+	{Name: "pure", Description: "Raw cosine without field boosts", Ranking: RankingPure},
+	{Name: "ensemble", Description: "Reciprocal rank fusion with the -ensemble-model", Ranking: RankingEnsemble},
+	{Name: "diverse", Description: "Maximal Marginal Relevance with diversity 0.3", Ranking: RankingDefault, Diversity: 0.3},
+}
+
+// pipelineNamed looks up a pipeline
+func pipelineNamed(name string) (Pipeline, error) {
+	for _, pipeline := range Pipelines {
+		if pipeline.Name == name {
+			return pipeline, nil
+		}
+	}
+	return Pipeline{}, fmt.Errorf("%w: %q", ErrUnknownPipeline, name)
+}
+
+// apply replaces the options' retrieval and ranking settings with the pipeline's
+func (p Pipeline) apply(options SearchOptions) SearchOptions {
+	options.Ranking = p.Ranking
+	options.Rerank = p.Rerank
+	options.Diversity = p.Diversity
+	options.Exhaustive = p.Exhaustive
+	return withDefaults(options)
+}
+
+// PipelineRun is one pipeline's ranking of a query
+type PipelineRun struct {
+	Pipeline Pipeline       `json:"pipeline"`
+	Results  []SearchResult `json:"-"`
+	Took     time.Duration  `json:"-"`
+}
+
+// PipelineDiff compares two pipelines' rankings of the same query
+type PipelineDiff struct {
+	A, B          PipelineRun    `json:"-"`
+	Shared        []SharedResult `json:"shared"`
+	OnlyA         []string       `json:"onlyA"`
+	OnlyB         []string       `json:"onlyB"`
+	Jaccard       float64        `json:"jaccard"`       // |shared| / |A ∪ B|
+	SameTop       bool           `json:"sameTop"`       // Both rank the same result first
+	MeanRankShift float64        `json:"meanRankShift"` // Mean |rankA - rankB| over shared results
+}
+
+// DiffPipelines embeds a query once and ranks it with two named pipelines
+func (s *SearchService) DiffPipelines(ctx context.Context, query string, options SearchOptions, a, b string) (*PipelineDiff, error) {
+	var pipelines [2]Pipeline
+	for i, name := range []string{a, b} {
+		pipeline, err := pipelineNamed(name)
+		if err != nil {
+			return nil, err
+		}
+		pipelines[i] = pipeline
+	}
+
+	options = withDefaults(options)
+	if err := s.checkLoaded(options.Granularity); err != nil {
+		return nil, err
+	}
+	queryEmbedding, err := s.embeddings.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	var runs [2]PipelineRun
+	for i, pipeline := range pipelines {
+		started := time.Now()
+		results, err := s.rank(ctx, query, queryEmbedding, pipeline.apply(options))
+		if err != nil {
+			return nil, fmt.Errorf("pipeline %s: %w", pipeline.Name, err)
+		}
+		runs[i] = PipelineRun{Pipeline: pipeline, Results: results, Took: time.Since(started)}
+	}
+
+	diff := &PipelineDiff{A: runs[0], B: runs[1]}
+	diff.Shared, diff.OnlyA, diff.OnlyB, diff.Jaccard = overlap(runs[0].Results, runs[1].Results)
+	diff.SameTop = len(runs[0].Results) > 0 && len(runs[1].Results) > 0 &&
+		resultReference(runs[0].Results[0]) == resultReference(runs[1].Results[0])
+	for _, shared := range diff.Shared {
+		diff.MeanRankShift += math.Abs(float64(shared.RankA - shared.RankB))
+	}
+	if len(diff.Shared) > 0 {
+		diff.MeanRankShift /= float64(len(diff.Shared))
+	}
+	return diff, nil
+}
@@ -0,0 +1,115 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrReloading reports a reload requested while the granularity is loading
+var ErrReloading = errors.New("reload already in progress")
+
+// Reload states
+const (
+	ReloadRunning = "reloading"
+	ReloadSwapped = "swapped"
+	ReloadFailed  = "failed"
+)
+
+// ReloadStatus is the progress of a granularity's hot reload
+type ReloadStatus struct {
+	Granularity string     `json:"granularity"`
+	State       string     `json:"state"`
+	OldVersion  string     `json:"oldVersion,omitempty"` // Index serving when the reload started
+	NewVersion  string     `json:"newVersion,omitempty"` // Index serving after the swap
+	StartedAt   time.Time  `json:"startedAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// Reload re-downloads a granularity's embeddings and text and builds the new
+// index in the background while the current one keeps serving, then swaps it
+// in under the service lock. A granularity that never loaded, or failed to,
+// is loaded afresh. It returns the reload's initial status.
+func (s *SearchService) Reload(granularity string) (ReloadStatus, error) {
+	source, err := s.sourceFor(granularity)
+	if err != nil {
+		if s.loads.isIngested(granularity) {
+			return ReloadStatus{}, fmt.Errorf("%w: %s is ingested; POST /admin/corpus replaces it", ErrInvalidCorpus, granularity)
+		}
+		return ReloadStatus{}, err
+	}
+	if state, _, _ := s.loads.get(granularity); state == CorpusLoading {
+		return ReloadStatus{}, fmt.Errorf("granularity %s: %w", granularity, ErrReloading)
+	}
+
+	status, ok := s.loads.beginReload(granularity, s.IndexVersion(granularity))
+	if !ok {
+		return ReloadStatus{}, fmt.Errorf("granularity %s: %w", granularity, ErrReloading)
+	}
+	go s.reload(source, status)
+	return status, nil
+}
+
+// reload builds and swaps in a fresh index for a source
+func (s *SearchService) reload(source CorpusSource, status ReloadStatus) {
+	granularity := source.Granularity
+	logger := log.With().Str("granularity", granularity).Logger()
+	logger.Info().Str("oldVersion", status.OldVersion).Msg("Reloading index")
+
+	// The reload outlives the admin request that started it
+	err := s.rebuild(context.Background(), source)
+	newVersion := s.IndexVersion(granularity)
+	s.loads.finishReload(granularity, newVersion, err)
+	if err != nil {
+		logger.Error().Err(err).Msg("Index reload failed, keeping the current index")
+		return
+	}
+	logger.Info().
+		Str("oldVersion", status.OldVersion).
+		Str("newVersion", newVersion).
+		Dur("took", time.Since(status.StartedAt)).
+		Msg("Index reloaded")
+}
+
+// rebuild downloads and builds a source's index, then swaps it in
+func (s *SearchService) rebuild(ctx context.Context, source CorpusSource) error {
+	granularity := source.Granularity
+	s.mu.RLock()
+	loaded := s.loadedGranularities[granularity]
+	s.mu.RUnlock()
+	if !loaded {
+		return s.PreloadGranularity(ctx, granularity)
+	}
+
+	// Downloads kept for retrying a failed load may be stale; a reload wants
+	// the artifacts as published now
+	s.releaseDownloads(source)
+	corpus, err := s.fetchText(ctx, source, granularity)
+	if err != nil {
+		return err
+	}
+	if err := s.fetchEmbeddings(ctx, source, corpus); err != nil {
+		return err
+	}
+	built, err := s.build(granularity, corpus)
+	if err != nil {
+		return fmt.Errorf("incompatible %s embeddings: %w", granularity, err)
+	}
+
+	s.mu.Lock()
+	s.swap(granularity, built)
+	s.mu.Unlock()
+	s.loads.finish(granularity, nil)
+	s.releaseDownloads(source)
+
+	if s.config.Snapshots {
+		if err := writeSnapshot(s.SnapshotPath(granularity), source, corpus); err != nil {
+			log.Warn().Err(err).Str("granularity", granularity).Msg("Failed to write index snapshot")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,207 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/startup"
+)
+
+// RankPath is where an index node ranks query embeddings for API nodes
+const RankPath = "/index/rank"
+
+var (
+	// ErrIndexNode reports an index node that couldn't be reached, or failed
+	ErrIndexNode = errors.New("index node failed")
+	// ErrIndexRejected reports a search the index node refused as invalid,
+	// such as ranking=ensemble on a node without the ensemble model
+	ErrIndexRejected = errors.New("rejected by the index node")
+)
+
+// RankRequest asks an index node to rank an embedded query
+type RankRequest struct {
+	Query     string        `json:"query"` // For highlighting, phrases and expansion
+	Embedding []float32     `json:"embedding"`
+	Options   SearchOptions `json:"options"`
+	MaxK      int           `json:"maxK,omitempty"`  // The caller's QoS tier cap, for SearchOptions.MaxK
+	Paged     bool          `json:"paged,omitempty"` // For SearchOptions.Paged
+}
+
+// RankResponse is an index node's ranking, with the version of the index
+// that produced it
+type RankResponse struct {
+	Results []SearchResult `json:"results"`
+	Version string         `json:"version"`
+}
+
+// RemoteIndex ranks queries on an index node: a server holding the indices
+// for stateless API nodes, which embed queries themselves. It speaks JSON over
+// HTTP; there is no gRPC transport.
+type RemoteIndex struct {
+	url    string
+	token  string
+	client *http.Client
+
+	mu       sync.Mutex
+	versions map[string]string // Latest version seen, by granularity
+}
+
+// NewRemoteIndex creates a client for the index node at url, authenticating
+// with the index-node token the two nodes share. Each request to the index
+// node, including a readiness poll, is abandoned after timeout.
+func NewRemoteIndex(url, token string, timeout time.Duration) *RemoteIndex {
+	return &RemoteIndex{
+		url:      strings.TrimSuffix(url, "/"),
+		token:    token,
+		client:   &http.Client{Timeout: timeout},
+		versions: make(map[string]string),
+	}
+}
+
+// URL returns the index node's base URL
+func (r *RemoteIndex) URL() string {
+	return r.url
+}
+
+// Rank ranks an embedded query on the index node. The node's errors come
+// back as the package's own, so API nodes report them as the index node did.
+func (r *RemoteIndex) Rank(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	body, err := json.Marshal(RankRequest{
+		Query:     query,
+		Embedding: queryEmbedding,
+		Options:   options,
+		MaxK:      options.MaxK,
+		Paged:     options.Paged,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url+RankPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	resp, err := r.client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("%w: %w", ErrIndexNode, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, remoteError(resp)
+	}
+	var ranked RankResponse
+	if err := json.NewDecoder(resp.Body).Decode(&ranked); err != nil {
+		return nil, fmt.Errorf("%w: invalid rank response: %w", ErrIndexNode, err)
+	}
+
+	r.mu.Lock()
+	r.versions[options.Granularity] = ranked.Version
+	r.mu.Unlock()
+	return ranked.Results, nil
+}
+
+// remoteError maps an index node's error response to the error it reported
+func remoteError(resp *http.Response) error {
+	var body struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err := json.Unmarshal(raw, &body); err != nil || body.Error.Code == "" {
+		return fmt.Errorf("%w: HTTP %d: %s", ErrIndexNode, resp.StatusCode, strings.TrimSpace(string(raw)))
+	}
+
+	message := body.Error.Message
+	switch body.Error.Code {
+	case "granularity_not_loaded":
+		return fmt.Errorf("%w: %s", ErrNotLoaded, message)
+	case "granularity_unavailable":
+		return fmt.Errorf("%w: %s", ErrUnavailable, message)
+	case "unknown_index":
+		return fmt.Errorf("%w: %s", ErrUnknownIndex, message)
+	case "model_not_ready":
+		return fmt.Errorf("%w: %s", ErrEmbedding, message)
+	case "search_timeout":
+		return fmt.Errorf("index node: %w", context.DeadlineExceeded)
+	case "invalid_request", "limit_exceeded":
+		return fmt.Errorf("%w: %s", ErrIndexRejected, message)
+	}
+	return fmt.Errorf("%w: %s: %s", ErrIndexNode, body.Error.Code, message)
+}
+
+// Version returns the version of a granularity's index the index node last
+// ranked with, or "" before its first search
+func (r *RemoteIndex) Version(granularity string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.versions[granularity]
+}
+
+// WaitReady polls the index node's /status every interval until its verse
+// index serves semantic searches, or ctx ends
+func (r *RemoteIndex) WaitReady(ctx context.Context, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if r.ready(ctx) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ready reports whether the index node's startup has loaded verse embeddings
+func (r *RemoteIndex) ready(ctx context.Context) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url+"/status", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return false
+	}
+	defer resp.Body.Close()
+
+	var status struct {
+		Startup startup.Status `json:"startup"`
+	}
+	if resp.StatusCode != http.StatusOK || json.NewDecoder(resp.Body).Decode(&status) != nil {
+		return false
+	}
+	return status.Startup.State == startup.EmbeddingsLoaded || status.Startup.State == startup.ModelReady
+}
+
+// SetRemote makes the service stateless: it loads no indices and ranks
+// every query on the index node, embedding queries itself
+func (s *SearchService) SetRemote(remote *RemoteIndex) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remote = remote
+}
+
+// Remote returns the index node the service ranks on, or nil if it holds its own indices
+func (s *SearchService) Remote() *RemoteIndex {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.remote
+}
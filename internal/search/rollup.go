@@ -0,0 +1,108 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Result groupings
+const (
+	GroupNone    = ""
+	GroupChapter = "chapter" // One result per chapter: its best verse, with the chapter's score
+)
+
+const (
+	// rollupPoolFactor sets how many verses are scored per chapter wanted
+	rollupPoolFactor = 20
+	// rollupMaxPool bounds the verses scored for a roll-up
+	rollupMaxPool = 2000
+	// rollupEvidence is how many of a chapter's verses are cited as evidence
+	rollupEvidence = 3
+)
+
+// ErrGroupGranularity reports a chapter roll-up of a non-verse index
+var ErrGroupGranularity = errors.New("group=chapter scores verses, so it needs verse granularity")
+
+// ChapterRollup summarizes a chapter's matching verses
+type ChapterRollup struct {
+	Reference string   `json:"reference"` // e.g. "John 3"
+	Score     float32  `json:"score"`     // The best verse's score
+	Hits      int      `json:"hits"`      // Verses of the chapter among the scored candidates
+	Evidence  []string `json:"evidence"`  // The chapter's best verses, best first
+}
+
+// ValidateGroup checks a result grouping
+func ValidateGroup(group string) error {
+	switch group {
+	case GroupNone, GroupChapter:
+		return nil
+	default:
+		return fmt.Errorf("unknown grouping: %s (use chapter)", group)
+	}
+}
+
+// rankByChapter scores a deep pool of verses and rolls them up by chapter.
+// Each result is a chapter's best verse, carrying the chapter's score, how
+// many of its verses matched and which verses matched best. Chapters rank by
+// score, then by hits.
+func (s *SearchService) rankByChapter(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	if options.Granularity != "verse" {
+		return nil, ErrGroupGranularity
+	}
+
+	k := options.K
+	limits := s.limitsFor(options)
+	if limits.MaxK > 0 && k > limits.MaxK && !options.Paged {
+		k = limits.MaxK
+	}
+
+	pool := options
+	pool.Group = GroupNone
+	pool.Diversity = 0
+	pool.K = max(k, min(max(k*rollupPoolFactor, s.config.RerankCandidates), rollupMaxPool))
+	pool.Paged = true // The pool may exceed max-k; k is capped above
+	verses, err := s.rankAdjusted(ctx, query, queryEmbedding, pool)
+	if err != nil {
+		return nil, err
+	}
+
+	// Verses arrive best first, so each chapter's first verse is its best
+	byChapter := make(map[string]*SearchResult)
+	var order []string
+	for _, verse := range verses {
+		meta := verse.Chunk.Meta
+		key := chapterKey(meta.Book, meta.Chapter)
+		result, ok := byChapter[key]
+		if !ok {
+			best := verse
+			best.Rollup = &ChapterRollup{
+				Reference: fmt.Sprintf("%s %d", meta.Book, meta.Chapter),
+				Score:     verse.Score,
+			}
+			result = &best
+			byChapter[key] = result
+			order = append(order, key)
+		}
+		result.Rollup.Hits++
+		if len(result.Rollup.Evidence) < rollupEvidence {
+			result.Rollup.Evidence = append(result.Rollup.Evidence, resultReference(verse))
+		}
+	}
+
+	results := make([]SearchResult, 0, len(order))
+	for _, key := range order {
+		results = append(results, *byChapter[key])
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		if results[i].Rollup.Score != results[j].Rollup.Score {
+			return results[i].Rollup.Score > results[j].Rollup.Score
+		}
+		return results[i].Rollup.Hits > results[j].Rollup.Hits
+	})
+	if len(results) > k {
+		results = results[:k]
+	}
+	return results, nil
+}
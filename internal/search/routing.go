@@ -0,0 +1,157 @@
+package search
+
+import (
+	"math"
+	"sort"
+)
+
+// routeGroup is the index positions of one chapter, or of a whole book,
+// with the centroid of their vectors
+type routeGroup struct {
+	book      string
+	chapter   int
+	centroid  []float32
+	positions []int
+}
+
+// bookRoute is a book's centroid and its chapters
+type bookRoute struct {
+	routeGroup
+	chapters []*routeGroup
+}
+
+// centroidRouter narrows unfiltered scans to the books whose centroids are
+// nearest a query, plus a sample of the nearest chapters of the other books
+type centroidRouter struct {
+	books    []*bookRoute
+	chapters int
+}
+
+// RoutingStatus describes an index's centroid routing for /status
+type RoutingStatus struct {
+	Books    int     `json:"books"`
+	Chapters int     `json:"chapters"`
+	TopBooks int     `json:"topBooks"` // Books scanned in full
+	Sample   float64 `json:"sample"`   // Fraction of the other chapters scanned
+}
+
+// buildRouter computes per-book and per-chapter centroids over an index.
+// It returns nil when the index has too few books for routing to skip any.
+func buildRouter(index *VectorIndex, textLookup map[string]*TextData, topBooks int) *centroidRouter {
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+
+	books := make(map[string]*bookRoute)
+	var order []*bookRoute
+	chapters := make(map[string]*routeGroup)
+	router := &centroidRouter{}
+	for i, id := range index.IDs {
+		var book string
+		var chapter int
+		if text, ok := textLookup[id]; ok {
+			book, chapter = text.Meta.Book, text.Meta.Chapter
+		}
+		b, ok := books[book]
+		if !ok {
+			b = &bookRoute{routeGroup: routeGroup{book: book}}
+			books[book] = b
+			order = append(order, b)
+		}
+		key := chapterKey(book, chapter)
+		c, ok := chapters[key]
+		if !ok {
+			c = &routeGroup{book: book, chapter: chapter}
+			chapters[key] = c
+			b.chapters = append(b.chapters, c)
+			router.chapters++
+		}
+		b.positions = append(b.positions, i)
+		c.positions = append(c.positions, i)
+	}
+	if len(order) <= topBooks {
+		return nil
+	}
+
+	for _, b := range order {
+		b.centroid = centroid(index.Vectors, b.positions)
+		for _, c := range b.chapters {
+			c.centroid = centroid(index.Vectors, c.positions)
+		}
+	}
+	router.books = order
+	return router
+}
+
+// centroid returns the mean of the vectors at positions
+func centroid(vectors [][]float32, positions []int) []float32 {
+	sum := make([]float64, len(vectors[positions[0]]))
+	for _, i := range positions {
+		for d, v := range vectors[i] {
+			sum[d] += float64(v)
+		}
+	}
+	mean := make([]float32, len(sum))
+	for d, v := range sum {
+		mean[d] = float32(v / float64(len(positions)))
+	}
+	return mean
+}
+
+// route returns the index positions to scan for a query in ascending order:
+// every position of the topBooks nearest books, and of the nearest sample
+// fraction of the other books' chapters
+func (r *centroidRouter) route(query []float32, topBooks int, sample float64) []int {
+	scored := make([]scoredRoute, len(r.books))
+	for i, b := range r.books {
+		scored[i] = scoredRoute{group: &b.routeGroup, chapters: b.chapters, score: CosineSimilarity(query, b.centroid)}
+	}
+	sortRoutes(scored)
+
+	var positions []int
+	var rest []scoredRoute
+	for i, s := range scored {
+		if i < topBooks {
+			positions = append(positions, s.group.positions...)
+			continue
+		}
+		for _, c := range s.chapters {
+			rest = append(rest, scoredRoute{group: c, score: CosineSimilarity(query, c.centroid)})
+		}
+	}
+
+	sortRoutes(rest)
+	sampled := int(math.Ceil(sample * float64(len(rest))))
+	for _, s := range rest[:min(sampled, len(rest))] {
+		positions = append(positions, s.group.positions...)
+	}
+	sort.Ints(positions)
+	return positions
+}
+
+// scoredRoute is a group with its centroid's similarity to a query
+type scoredRoute struct {
+	group    *routeGroup
+	chapters []*routeGroup // A book's chapters
+	score    float32
+}
+
+// sortRoutes orders groups nearest first, breaking ties by book and chapter
+// so routing is reproducible
+func sortRoutes(routes []scoredRoute) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].score != routes[j].score {
+			return routes[i].score > routes[j].score
+		}
+		if routes[i].group.book != routes[j].group.book {
+			return routes[i].group.book < routes[j].group.book
+		}
+		return routes[i].group.chapter < routes[j].group.chapter
+	})
+}
+
+// routable reports whether a search may be narrowed by centroid routing:
+// unfiltered, unpaged and not asked to be exhaustive
+func routable(options SearchOptions) bool {
+	return !options.Exhaustive && !options.Paged &&
+		allowedBooks(options) == nil && options.Chapter == "" && options.Verse == "" && options.Tag == "" && len(options.Phrases) == 0 && len(options.Exclude) == 0
+}
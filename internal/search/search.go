@@ -1,31 +1,70 @@
 package search
 
 import (
-	"compress/gzip"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
-	"path/filepath"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/dpshade/goscriptureapi/internal/canon"
 	"github.com/dpshade/goscriptureapi/internal/config"
 	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/thesaurus"
 	"github.com/rs/zerolog/log"
 )
 
-// SearchService handles semantic search operations
+var (
+	// ErrNotLoaded reports a granularity whose index hasn't finished loading
+	ErrNotLoaded = errors.New("not loaded")
+	// ErrUnavailable reports a granularity whose artifact was refused at load time
+	ErrUnavailable = errors.New("unavailable")
+	// ErrEmbedding reports a query the embedding backend couldn't embed
+	ErrEmbedding = errors.New("failed to generate query embedding")
+	// ErrNoEmbedding reports a reference with no precomputed verse embedding
+	ErrNoEmbedding = errors.New("no embedding found")
+	// ErrUnknownIndex reports a granularity that is neither built in,
+	// configured, nor ingested
+	ErrUnknownIndex = errors.New("unknown index")
+)
+
+// SearchService handles semantic search operations. Indices and text are
+// held in process, unless the service is stateless and ranks on an index
+// node (see SetRemote). Then only searches go to the index node; streaming
+// ranks in one pass, shedding falls back to the queue rather than lexical
+// search, and features reading text directly are the index node's to serve.
 type SearchService struct {
 	embeddings      *embeddings.EmbeddingService
 	config          *config.Config
 	indices         map[string]*VectorIndex
+	quantized       map[string]*QuantizedIndex
+	binary          map[string]*BinaryIndex
+	routers         map[string]*centroidRouter
 	textLookup      map[string]map[string]*TextData
+	chapters        map[string][]*TextData
+	verseIDs        map[string]string
+	verseOrder      []*TextData // Every verse in canonical order
 	loadedGranularities map[string]bool
+	checksums       map[string]string
+	textChecksums   map[string]string
+	dimensions      map[string]int
+	loadErrors      map[string]string
+	artifacts       map[string]*ArtifactHeader
+	loads           *loadTracker
+	tags            TagMatcher
+	feedback        FeedbackSource
+	thesaurus       atomic.Pointer[thesaurus.Thesaurus] // Read without mu, which ingestion holds
+	ensemble        *ensemble
 	mu              sync.RWMutex
+	ingestMu        sync.Mutex // Serializes corpus ingestion
+	progress        func(granularity, stage string)
 	cache           *Cache
+	remote          *RemoteIndex // The index node ranking queries, on a stateless node
 }
 
 // TextData represents the text and metadata for a verse or chapter
@@ -40,16 +79,31 @@ type Metadata struct {
 	Book      string   `json:"book"`
 	Chapter   int      `json:"chapter"`
 	VerseNum  int      `json:"verseNum,omitempty"`
+	Heading   string   `json:"heading,omitempty"`
+	Footnotes []string `json:"footnotes,omitempty"`
 	Events    []string `json:"events,omitempty"`
 	Entities  []string `json:"entities,omitempty"`
+
+	// The original granularity's Hebrew or Greek text, beside the English
+	Original        string `json:"original,omitempty"`
+	Transliteration string `json:"transliteration,omitempty"`
+	Language        string `json:"language,omitempty"` // "hebrew", "aramaic" or "greek"
 }
 
 // SearchResult represents a search result
 type SearchResult struct {
-	ID         string    `json:"id"`
-	Similarity float32   `json:"similarity"`
-	Score      float32   `json:"score"`
-	Chunk      ChunkData `json:"chunk"`
+	ID            string    `json:"id"`
+	Similarity    float32   `json:"similarity"`
+	Score         float32   `json:"score"`
+	Chunk         ChunkData `json:"chunk"`
+	MatchedFields []string   `json:"matchedFields,omitempty"`
+	Highlight     *Highlight `json:"highlight,omitempty"`
+	Source        string     `json:"source,omitempty"` // "scripture" or "notes" when searching several sources
+	Contributions []ModelContribution `json:"contributions,omitempty"` // Per-model scores for ensemble ranking
+	Probability   *float64            `json:"probability,omitempty"`   // Softmax of Score over the result set, when requested
+	Rollup        *ChapterRollup      `json:"rollup,omitempty"`        // The result's chapter, for group=chapter
+	Corpus        string              `json:"corpus,omitempty"`        // The ingested corpus the document belongs to
+	Expansions    []string            `json:"expansions,omitempty"`    // Reformulations of the query that found the result, for expand
 }
 
 // ChunkData represents the data for a search result chunk
@@ -61,39 +115,141 @@ type ChunkData struct {
 
 // SearchOptions contains options for search
 type SearchOptions struct {
-	Book        string `json:"book,omitempty"`
-	Chapter     string `json:"chapter,omitempty"`
-	Verse       string `json:"verse,omitempty"`
-	Granularity string `json:"granularity,omitempty"` // "verse" or "chapter"
-	K           int    `json:"k,omitempty"`           // Number of results
+	Book        string   `json:"book,omitempty"`
+	Chapter     string   `json:"chapter,omitempty"`
+	Verse       string   `json:"verse,omitempty"`
+	Granularity string   `json:"granularity,omitempty"` // "verse", "chapter" or "original"
+	K           int      `json:"k,omitempty"`           // Number of results
+	Rerank      bool     `json:"rerank,omitempty"`      // Quantized retrieval followed by exact re-ranking
+	Fields      []string `json:"fields,omitempty"`      // Searchable fields with optional boosts, e.g. "heading^2"
+	Ranking     string   `json:"ranking,omitempty"`     // "default" or "pure" (raw cosine, no boosts)
+	Paged       bool     `json:"-"`                     // Ranks a deep result set for a cursor, bounded by the cursor limit instead of max-k
+	MaxK        int      `json:"-"`                     // Replaces the granularity's max-k, e.g. for the caller's QoS tier
+
+	Highlight     bool   `json:"highlight,omitempty"`     // Wrap query terms (and the nearest verse of a chapter) in markers
+	HighlightPre  string `json:"highlightPre,omitempty"`  // Opening marker, default "<mark>"
+	HighlightPost string `json:"highlightPost,omitempty"` // Closing marker, default "</mark>"
+
+	Tag       string `json:"tag,omitempty"`       // Only verses carrying this tag
+	Namespace string `json:"namespace,omitempty"` // Tag and note namespace, default "default"
+	Notes     bool   `json:"notes,omitempty"`     // Attach the namespace's notes to each result
+
+	Include []string `json:"include,omitempty"` // Extra data to attach to each result: "strongs"
+
+	Testament string   `json:"testament,omitempty"` // "ot" or "nt"
+	Genre     string   `json:"genre,omitempty"`     // e.g. "gospels", "wisdom", or a group: "prophets", "epistles"
+	Books     []string `json:"books,omitempty"`     // Only these books
+
+	Sources []string `json:"sources,omitempty"` // "scripture" (default) and/or "notes" from Namespace
+
+	Transform   string  `json:"transform,omitempty"`   // "softmax" adds probabilities over the result set
+	Temperature float64 `json:"temperature,omitempty"` // Softmax temperature, default 0.05
+
+	Diversity float64 `json:"diversity,omitempty"` // MMR weight in [0, 1]: 0 ranks by relevance alone
+	MinScore  float64 `json:"minScore,omitempty"`  // Drop results whose cosine similarity is below it, on top of -score-floor
+	Group     string  `json:"group,omitempty"`     // "chapter" rolls verse results up by chapter
+
+	Exhaustive bool `json:"exhaustive,omitempty"` // Scan every vector, even with -route-books
+	Expand     bool `json:"expand,omitempty"`     // Also search thesaurus reformulations of the query and fuse the rankings
+
+	Phrases  []string `json:"phrases,omitempty"`  // Only texts containing each phrase word for word, from quoted query text
+	Exclude  []string `json:"exclude,omitempty"`  // Drop texts containing any of these words or phrases
+	Negative string   `json:"negative,omitempty"` // An example of what not to find, subtracted from the query vector
+
+	exclude  map[string]bool // Index IDs never returned, e.g. the source verse of /similar
+	explain  *Explanation    // Records how the search is answered, for explain=true
+	negative []float32       // Negative's embedding, once embedded
 }
 
-// Cache provides simple in-memory caching
-type Cache struct {
-	data map[string]interface{}
-	mu   sync.RWMutex
+// limitsFor returns the granularity limits for a search, with its max-k
+// override and any stricter minimum score it asks for
+func (s *SearchService) limitsFor(options SearchOptions) config.GranularityLimits {
+	limits := s.config.LimitsFor(options.Granularity)
+	if options.MaxK > 0 {
+		limits.MaxK = options.MaxK
+	}
+	if minScore := float32(options.MinScore); minScore > limits.MinScore {
+		limits.MinScore = minScore
+	}
+	return limits
 }
 
-// NewCache creates a new cache
-func NewCache() *Cache {
-	return &Cache{
-		data: make(map[string]interface{}),
+// Search sources
+const (
+	SourceScripture = "scripture"
+	SourceNotes     = "notes"
+)
+
+// Extra data attached to results by Include
+const (
+	IncludeStrongs   = "strongs"   // Each verse's words with their Strong's numbers
+	IncludeMetadata  = "metadata"  // Everything known about the passage, such as its events and entities
+	IncludeEmbedding = "embedding" // The passage's vector from the index
+)
+
+// Ranking modes
+const (
+	RankingDefault  = "default"
+	RankingPure     = "pure"
+	RankingEnsemble = "ensemble" // Fuses the primary and ensemble models' rankings
+)
+
+// SearchesSource reports whether a source is searched. Scripture alone is
+// searched when no sources are named.
+func (o SearchOptions) SearchesSource(source string) bool {
+	if len(o.Sources) == 0 {
+		return source == SourceScripture
 	}
+	for _, s := range o.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSources checks that every source is known
+func ValidateSources(sources []string) error {
+	for _, source := range sources {
+		switch source {
+		case SourceScripture, SourceNotes:
+		default:
+			return fmt.Errorf("unknown source: %s (use scripture or notes)", source)
+		}
+	}
+	return nil
+}
+
+// Includes reports whether extra data is to be attached to results
+func (o SearchOptions) Includes(name string) bool {
+	for _, include := range o.Include {
+		if include == name {
+			return true
+		}
+	}
+	return false
 }
 
-// Get retrieves a value from cache
-func (c *Cache) Get(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	val, ok := c.data[key]
-	return val, ok
+// ValidateInclude checks that every requested extra is known
+func ValidateInclude(include []string) error {
+	for _, name := range include {
+		switch name {
+		case IncludeStrongs, IncludeMetadata, IncludeEmbedding:
+		default:
+			return fmt.Errorf("unknown include: %s (use strongs, metadata or embedding)", name)
+		}
+	}
+	return nil
 }
 
-// Set stores a value in cache
-func (c *Cache) Set(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.data[key] = value
+// ValidateRanking checks that a ranking mode is known
+func ValidateRanking(ranking string) error {
+	switch ranking {
+	case "", RankingDefault, RankingPure, RankingEnsemble:
+		return nil
+	default:
+		return fmt.Errorf("unknown ranking mode: %s", ranking)
+	}
 }
 
 // NewSearchService creates a new search service
@@ -102,168 +258,294 @@ func NewSearchService(embeddingService *embeddings.EmbeddingService, cfg *config
 		embeddings:          embeddingService,
 		config:             cfg,
 		indices:            make(map[string]*VectorIndex),
+		quantized:          make(map[string]*QuantizedIndex),
+		binary:             make(map[string]*BinaryIndex),
+		routers:            make(map[string]*centroidRouter),
 		textLookup:         make(map[string]map[string]*TextData),
 		loadedGranularities: make(map[string]bool),
-		cache:              NewCache(),
+		checksums:          make(map[string]string),
+		textChecksums:      make(map[string]string),
+		dimensions:         make(map[string]int),
+		loadErrors:         make(map[string]string),
+		artifacts:          make(map[string]*ArtifactHeader),
+		loads:              newLoadTracker(),
+		cache:              NewCache(cfg.DownloadCacheBytes, cfg.DownloadCacheTTL),
+	}
+
+	service.thesaurus.Store(thesaurus.Default())
+
+	for _, index := range cfg.Indices {
+		if err := ValidateCorpusName(index.Name); err != nil {
+			return nil, fmt.Errorf("index %s: %w", index.Name, err)
+		}
 	}
 
 	return service, nil
 }
 
-// PreloadGranularity loads embeddings and text data for a granularity
-func (s *SearchService) PreloadGranularity(granularity string) error {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+// PreloadGranularity loads embeddings and text data for a granularity.
+// Downloads stop, and the load fails, if ctx ends first. The index is
+// downloaded and built without the service lock, so other indices keep
+// serving, and swapped in under it.
+func (s *SearchService) PreloadGranularity(ctx context.Context, granularity string) (err error) {
+	s.mu.RLock()
+	loaded := s.loadedGranularities[granularity]
+	s.mu.RUnlock()
+	if loaded {
+		log.Info().Str("granularity", granularity).Msg("Granularity already loaded")
+		return nil
+	}
+
+	source, err := s.sourceFor(granularity)
+	if err != nil {
+		return err
+	}
 
+	s.loads.start(granularity)
+	defer func() { s.loads.finish(granularity, err) }()
+
+	// A warm snapshot from a previous run skips downloading and parsing JSON
+	var corpus *corpusData
+	fromSnapshot := false
+	if s.config.Snapshots {
+		corpus, err = readSnapshot(s.SnapshotPath(granularity), source)
+		switch {
+		case err == nil:
+			fromSnapshot = true
+			log.Info().Str("granularity", granularity).Msg("Loaded index snapshot")
+		case !errors.Is(err, os.ErrNotExist):
+			log.Warn().Err(err).Str("granularity", granularity).Msg("Ignoring index snapshot")
+		}
+	}
+	if fromSnapshot {
+		s.reportProgress(granularity, StageText)
+		s.reportProgress(granularity, StageLexical)
+	} else if corpus, err = s.fetchCorpus(ctx, source, granularity); err != nil {
+		return err
+	}
+
+	built, buildErr := s.build(granularity, corpus)
+	s.mu.Lock()
 	if s.loadedGranularities[granularity] {
-		log.Info().Str("granularity", granularity).Msg("Granularity already loaded")
+		// A concurrent load of the same granularity finished first
+		s.mu.Unlock()
 		return nil
 	}
+	if buildErr != nil {
+		s.loadErrors[granularity] = buildErr.Error()
+		s.mu.Unlock()
+		return fmt.Errorf("incompatible %s embeddings: %w", granularity, buildErr)
+	}
+	s.swap(granularity, built)
+	s.loadedGranularities[granularity] = true
+	vectors := s.indices[granularity].Size()
+	s.mu.Unlock()
 
-	var embeddingURL, fallbackURL, textURL string
-	
-	switch granularity {
-	case "verse":
-		embeddingURL = config.ArweaveURLs.Verses
-		fallbackURL = config.ArweaveURLs.VersesUncompressed
-		textURL = config.ArweaveURLs.VerseText
-	case "chapter":
-		embeddingURL = config.ArweaveURLs.Chapters
-		fallbackURL = config.ArweaveURLs.ChaptersUncompressed
-		textURL = config.ArweaveURLs.ChapterText
-	default:
-		return fmt.Errorf("unknown granularity: %s", granularity)
+	// Snapshot only artifacts that passed validation
+	if !fromSnapshot && s.config.Snapshots {
+		if err := writeSnapshot(s.SnapshotPath(granularity), source, corpus); err != nil {
+			log.Warn().Err(err).Str("granularity", granularity).Msg("Failed to write index snapshot")
+		}
+	}
+
+	s.releaseDownloads(source)
+	s.reportProgress(granularity, StageEmbeddings)
+
+	log.Info().
+		Str("granularity", granularity).
+		Int("vectors", vectors).
+		Bool("snapshot", fromSnapshot).
+		Msg("Granularity loaded successfully")
+
+	return nil
+}
+
+// fetchCorpus downloads and parses a granularity's text, staging it for
+// lexical search, then its embeddings
+func (s *SearchService) fetchCorpus(ctx context.Context, source CorpusSource, granularity string) (*corpusData, error) {
+	corpus, err := s.fetchText(ctx, source, granularity)
+	if err != nil {
+		return nil, err
+	}
+	s.reportProgress(granularity, StageText)
+	s.stageText(granularity, corpus.textLookup)
+	s.reportProgress(granularity, StageLexical)
+
+	if err := s.fetchEmbeddings(ctx, source, corpus); err != nil {
+		return nil, err
 	}
+	return corpus, nil
+}
 
-	// Try to load from cache first
-	cacheDir := filepath.Join(s.config.DataDir, "cache", granularity)
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		log.Warn().Err(err).Msg("Failed to create cache directory")
+// fetchText downloads and parses a granularity's text
+func (s *SearchService) fetchText(ctx context.Context, source CorpusSource, granularity string) (*corpusData, error) {
+	textData, err := s.loadFromURL(ctx, source.TextURL, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load text data: %w", err)
 	}
+	return &corpusData{textLookup: s.processTextData(textData, granularity)}, nil
+}
 
-	// Load embeddings
-	embeddingData, err := s.loadWithFallback(embeddingURL, fallbackURL)
+// fetchEmbeddings downloads and parses a granularity's embeddings, keeping
+// artifact order
+func (s *SearchService) fetchEmbeddings(ctx context.Context, source CorpusSource, corpus *corpusData) error {
+	payload, err := s.loadWithFallback(ctx, source.EmbeddingsURL, source.FallbackURL)
 	if err != nil {
 		return fmt.Errorf("failed to load embeddings: %w", err)
 	}
+	corpus.ids, corpus.vectors, corpus.header = payload.ids, payload.vectors, payload.header
+	return nil
+}
+
+// builtIndex is a granularity's validated indices, ready to be swapped in
+type builtIndex struct {
+	header     *ArtifactHeader
+	dims       int
+	index      *VectorIndex
+	quantized  *QuantizedIndex
+	binary     *BinaryIndex
+	textLookup map[string]*TextData
+	embeddings map[string][]float32
+	router     *centroidRouter // Nil unless centroid routing is enabled and applies
+}
 
-	// Load text data
-	textData, err := s.loadFromURL(textURL, false)
+// install validates parsed corpus data and builds a granularity's indices.
+// Callers must hold s.mu.
+func (s *SearchService) install(granularity string, corpus *corpusData) error {
+	built, err := s.build(granularity, corpus)
 	if err != nil {
-		return fmt.Errorf("failed to load text data: %w", err)
+		s.loadErrors[granularity] = err.Error()
+		return fmt.Errorf("incompatible %s embeddings: %w", granularity, err)
 	}
+	s.swap(granularity, built)
+	return nil
+}
 
-	// Parse and store embeddings
-	index := NewVectorIndex()
-	embeddings := make(map[string][]float32)
-	texts := make(map[string]string)
-	
-	if embeddingData, ok := embeddingData.(map[string]interface{}); ok {
-		if embeddingsList, ok := embeddingData["embeddings"].([]interface{}); ok {
-			for _, item := range embeddingsList {
-				if embedding, ok := item.(map[string]interface{}); ok {
-					id := embedding["id"].(string)
-					if vecData, ok := embedding["embedding"].([]interface{}); ok {
-						vec := make([]float32, len(vecData))
-						for i, v := range vecData {
-							if f, ok := v.(float64); ok {
-								vec[i] = float32(f)
-							}
-						}
-						index.Add(id, vec)
-						embeddings[id] = vec
-					}
-				}
-			}
+// build validates parsed corpus data and builds a granularity's indices
+// without touching the service, so a reload can build beside the index
+// still serving queries
+func (s *SearchService) build(granularity string, corpus *corpusData) (*builtIndex, error) {
+	ids, vectors, textLookup := corpus.ids, corpus.vectors, corpus.textLookup
+
+	// Validate provenance against the active backend before serving anything
+	header, err := validateArtifact(granularity, corpus.header, vectors, hashCorpus(ids, textLookup))
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse to serve an artifact whose dimensions can't be reconciled with queries
+	dims, err := reconcileDimensions(granularity, vectors)
+	if err != nil {
+		return nil, err
+	}
+
+	// Store embeddings
+	built := &builtIndex{
+		header:     header,
+		dims:       dims,
+		index:      NewVectorIndex(),
+		textLookup: textLookup,
+		embeddings: make(map[string][]float32),
+	}
+	built.index.SetShards(s.config.Shards)
+	// The binary index stands in for the int8 index as the re-rank first stage
+	if s.config.BinaryIndex {
+		built.binary = NewBinaryIndex()
+	} else {
+		built.quantized = NewQuantizedIndex(s.config.Int8Query)
+	}
+	for i, id := range ids {
+		built.index.Add(id, vectors[i])
+		if built.binary != nil {
+			built.binary.Add(id, vectors[i])
+		} else {
+			built.quantized.Add(id, vectors[i])
 		}
+		built.embeddings[id] = vectors[i]
+	}
+	if s.config.RouteBooks > 0 {
+		built.router = buildRouter(built.index, textLookup, s.config.RouteBooks)
 	}
+	return built, nil
+}
+
+// swap serves a built index in place of a granularity's current one.
+// Callers must hold s.mu.
+func (s *SearchService) swap(granularity string, built *builtIndex) {
+	delete(s.loadErrors, granularity)
+	s.dimensions[granularity] = built.dims
+	s.artifacts[granularity] = built.header
 
+	index, textLookup := built.index, built.textLookup
 	s.indices[granularity] = index
+	if built.router != nil {
+		s.routers[granularity] = built.router
+	} else {
+		delete(s.routers, granularity)
+	}
+	if built.binary != nil {
+		s.binary[granularity] = built.binary
+		delete(s.quantized, granularity)
+	} else {
+		s.quantized[granularity] = built.quantized
+		delete(s.binary, granularity)
+	}
+	s.checksums[granularity] = index.Checksum()
+	s.textChecksums[granularity] = textChecksum(index, textLookup)
 
-	// Process text data
-	textLookup := s.processTextData(textData, granularity)
 	s.textLookup[granularity] = textLookup
-	
-	// Extract text strings for the embedding service
-	for id, textData := range textLookup {
-		texts[id] = textData.Text
-	}
-	
+
 	// Initialize the embedding service with this data
 	if granularity == "verse" {
-		s.embeddings.InitializeWithPrecomputedData(embeddings, texts)
+		// Extract text strings for the embedding service
+		texts := make(map[string]string)
+		for id, textData := range textLookup {
+			texts[id] = textData.Text
+		}
+		s.embeddings.InitializeWithPrecomputedData(built.embeddings, texts)
+		s.chapters = buildChapterLookup(textLookup)
+		s.verseIDs = buildVerseIDs(index, textLookup)
+		s.verseOrder = orderVerses(s.chapters)
 	}
+}
 
-	s.loadedGranularities[granularity] = true
-	
-	log.Info().
-		Str("granularity", granularity).
-		Int("vectors", index.Size()).
-		Msg("Granularity loaded successfully")
-
-	return nil
+// releaseDownloads drops a source's parsed downloads from the cache once its
+// index is built from them
+func (s *SearchService) releaseDownloads(source CorpusSource) {
+	for _, url := range []string{source.TextURL, source.EmbeddingsURL, source.FallbackURL} {
+		s.cache.Delete(url)
+	}
 }
 
 // loadWithFallback tries to load from primary URL, falls back to secondary if needed
-func (s *SearchService) loadWithFallback(primaryURL, fallbackURL string) (interface{}, error) {
+func (s *SearchService) loadWithFallback(ctx context.Context, primaryURL, fallbackURL string) (*embeddingsPayload, error) {
 	// Try compressed version first
-	data, err := s.loadFromURL(primaryURL, true)
-	if err == nil {
-		return data, nil
+	data, err := s.loadEmbeddings(ctx, primaryURL, true)
+	if err == nil || fallbackURL == "" || ctx.Err() != nil {
+		return data, err
 	}
 
 	log.Warn().Err(err).Msg("Primary URL failed, trying fallback")
 	
 	// Try uncompressed fallback
-	return s.loadFromURL(fallbackURL, false)
+	return s.loadEmbeddings(ctx, fallbackURL, false)
 }
 
 // loadFromURL loads data from a URL
-func (s *SearchService) loadFromURL(url string, compressed bool) (interface{}, error) {
+func (s *SearchService) loadFromURL(ctx context.Context, url string, compressed bool) (interface{}, error) {
 	// Check cache first
 	if cached, ok := s.cache.Get(url); ok {
 		return cached, nil
 	}
 
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Get(url)
+	body, err := openURL(ctx, url, compressed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTP %d", resp.StatusCode)
-	}
-
-	var reader io.Reader = resp.Body
-	
-	// Handle gzip compression
-	if compressed {
-		// Check if content is gzipped
-		body, err := io.ReadAll(resp.Body)
-		if err != nil {
-			return nil, err
-		}
-		
-		// Check gzip magic number
-		if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
-			gzReader, err := gzip.NewReader(strings.NewReader(string(body)))
-			if err != nil {
-				return nil, err
-			}
-			defer gzReader.Close()
-			reader = gzReader
-		} else {
-			reader = strings.NewReader(string(body))
-		}
+		return nil, err
 	}
+	defer body.Close()
 
 	// Read and parse JSON
-	data, err := io.ReadAll(reader)
+	data, err := io.ReadAll(body)
 	if err != nil {
 		return nil, err
 	}
@@ -273,8 +555,8 @@ func (s *SearchService) loadFromURL(url string, compressed bool) (interface{}, e
 		return nil, fmt.Errorf("failed to parse JSON: %w", err)
 	}
 
-	// Cache the result
-	s.cache.Set(url, result)
+	// Cache the result, sized by its JSON
+	s.cache.Set(url, result, int64(len(data)))
 
 	return result, nil
 }
@@ -297,6 +579,23 @@ func (s *SearchService) processTextData(data interface{}, granularity string) ma
 					},
 				}
 
+				// Add section headings and footnotes if the source includes them
+				textData.Meta.Heading = getStringField(verse, "heading")
+				if footnotes, ok := verse["footnotes"].([]interface{}); ok {
+					textData.Meta.Footnotes = interfaceSliceToStringSlice(footnotes)
+				} else if footnote := getStringField(verse, "footnotes"); footnote != "" {
+					textData.Meta.Footnotes = []string{footnote}
+				}
+
+				// Add the original-language text, whose language follows the
+				// testament unless given (e.g. for the Aramaic of Daniel)
+				textData.Meta.Original = getStringField(verse, "original")
+				textData.Meta.Transliteration = getStringField(verse, "transliteration")
+				textData.Meta.Language = getStringField(verse, "language")
+				if textData.Meta.Original != "" && textData.Meta.Language == "" {
+					textData.Meta.Language = originalLanguage(textData.Meta.Book)
+				}
+
 				// Add events and entities if present
 				if events, ok := verse["events"].([]interface{}); ok {
 					textData.Meta.Events = interfaceSliceToStringSlice(events)
@@ -334,62 +633,420 @@ func (s *SearchService) processTextData(data interface{}, granularity string) ma
 	return lookup
 }
 
-// Search performs semantic search
-func (s *SearchService) Search(query string, options SearchOptions) ([]SearchResult, error) {
+// originalLanguage returns the language a book was written in: Hebrew for
+// the Old Testament, Greek for the New
+func originalLanguage(book string) string {
+	if b, ok := canon.Lookup(book); ok && b.Testament == canon.NewTestament {
+		return "greek"
+	}
+	return "hebrew"
+}
+
+// Search performs semantic search. Log lines carry the request logger from ctx.
+func (s *SearchService) Search(ctx context.Context, query string, options SearchOptions) ([]SearchResult, error) {
 	if query == "" {
 		return nil, nil
 	}
 
-	// Default options
+	// Fail fast before paying for inference if the index isn't ready
+	options = withDefaults(options)
+	if err := s.checkLoaded(options.Granularity); err != nil {
+		return nil, err
+	}
+
+	// Generate query embedding using the real model
+	queryEmbedding, err := s.embeddings.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	start := time.Now()
+	results, err := s.rank(ctx, query, queryEmbedding, options)
+	if err == nil {
+		log.Ctx(ctx).Debug().
+			Str("granularity", options.Granularity).
+			Int("results", len(results)).
+			Dur("took", time.Since(start)).
+			Msg("Index scanned")
+	}
+	return results, err
+}
+
+// EmbedQuery embeds a search query with the active model
+func (s *SearchService) EmbedQuery(ctx context.Context, query string) ([]float32, error) {
+	embedding, err := s.embeddings.EmbedQuery(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	return embedding, nil
+}
+
+// ModelReady reports whether queries are embedded by the model rather than a fallback
+func (s *SearchService) ModelReady() bool {
+	return s.embeddings.ModelReady()
+}
+
+// EmbedQueries embeds several search queries in one batch with the active model
+func (s *SearchService) EmbedQueries(ctx context.Context, queries []string) ([][]float32, error) {
+	embeddings, err := s.embeddings.EmbedQueries(ctx, queries)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	return embeddings, nil
+}
+
+// EmbedDocuments embeds passages with the model's document prompt. Unlike
+// queries, documents never fall back to the simple or placeholder embeddings.
+func (s *SearchService) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors, err := s.embeddings.EmbedDocuments(ctx, texts)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+	return vectors, nil
+}
+
+// EmbeddingBackend names the backend the model serves from: "onnx" or "remote"
+func (s *SearchService) EmbeddingBackend() string {
+	return s.embeddings.Backend().Backend
+}
+
+// SearchEmbedding searches with an already computed query embedding, so
+// callers searching other sources with the same query embed it once
+func (s *SearchService) SearchEmbedding(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	options = withDefaults(options)
+	if err := s.checkLoaded(options.Granularity); err != nil {
+		return nil, err
+	}
+	return s.rank(ctx, query, queryEmbedding, options)
+}
+
+// SearchBatch performs semantic search for several queries, embedding them
+// together so the model runs batched inference. options[i] applies to queries[i].
+func (s *SearchService) SearchBatch(ctx context.Context, queries []string, options []SearchOptions) ([][]SearchResult, error) {
+	if len(queries) != len(options) {
+		return nil, fmt.Errorf("got %d queries but %d option sets", len(queries), len(options))
+	}
+
+	for i := range options {
+		options[i] = withDefaults(options[i])
+		if err := s.checkLoaded(options[i].Granularity); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only embed non-empty queries; empty ones yield empty result sets
+	texts := make([]string, 0, len(queries))
+	positions := make([]int, 0, len(queries))
+	for i, query := range queries {
+		if query != "" {
+			texts = append(texts, query)
+			positions = append(positions, i)
+		}
+	}
+
+	results := make([][]SearchResult, len(queries))
+	if len(texts) == 0 {
+		return results, nil
+	}
+
+	queryEmbeddings, err := s.embeddings.EmbedQueries(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	for i, embedding := range queryEmbeddings {
+		pos := positions[i]
+		if results[pos], err = s.rank(ctx, queries[pos], embedding, options[pos]); err != nil {
+			return nil, err
+		}
+	}
+
+	return results, nil
+}
+
+// withDefaults fills in default granularity and result count
+func withDefaults(options SearchOptions) SearchOptions {
 	if options.Granularity == "" {
 		options.Granularity = "verse"
 	}
 	if options.K == 0 {
 		options.K = 10
 	}
+	if options.Ranking == "" {
+		options.Ranking = RankingDefault
+	}
+	if (options.Tag != "" || options.Notes || options.SearchesSource(SourceNotes)) && options.Namespace == "" {
+		options.Namespace = "default"
+	}
+	if options.Ranking == RankingPure || options.Ranking == RankingEnsemble {
+		// Pure ranking is an exact cosine scan, and ensemble ranking fuses
+		// exact scans: no field boosts, no approximate stages
+		options.Fields = nil
+		options.Rerank = false
+	}
+	return options
+}
+
+// checkLoaded reports why a granularity's index can't serve queries, if it can't
+func (s *SearchService) checkLoaded(granularity string) error {
+	// The index node reports its own granularities' state when asked to rank
+	if s.Remote() != nil {
+		return nil
+	}
+
+	// A granularity still loading can't serve, so answer without the lock
+	if state, _, _ := s.loads.get(granularity); state == CorpusLoading {
+		return fmt.Errorf("granularity %s %w: loading", granularity, ErrNotLoaded)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if loadErr, ok := s.loadErrors[granularity]; ok {
+		return fmt.Errorf("granularity %s %w: %s", granularity, ErrUnavailable, loadErr)
+	}
+	if !s.loadedGranularities[granularity] {
+		return s.notLoaded(granularity)
+	}
+	return nil
+}
+
+// searchEmbedding scans the index for a query embedding and attaches text to
+// the results. A scan cut short by ctx returns ctx's error.
+func (s *SearchService) searchEmbedding(ctx context.Context, query string, queryEmbedding []float32, options SearchOptions) ([]SearchResult, error) {
+	if err := ValidateRanking(options.Ranking); err != nil {
+		return nil, err
+	}
+
+	boosts, err := ParseFields(options.Fields)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if granularity is loaded
 	s.mu.RLock()
 	if !s.loadedGranularities[options.Granularity] {
 		s.mu.RUnlock()
-		return nil, fmt.Errorf("granularity %s not loaded", options.Granularity)
+		return nil, s.notLoaded(options.Granularity)
 	}
 	index := s.indices[options.Granularity]
+	quantized := s.quantized[options.Granularity]
+	binary := s.binary[options.Granularity]
+	router := s.routers[options.Granularity]
 	textLookup := s.textLookup[options.Granularity]
+	dims := s.dimensions[options.Granularity]
+	tags := s.tags
 	s.mu.RUnlock()
 
-	// Generate query embedding using the real model
-	queryEmbedding, err := s.embeddings.EmbedQuery(query)
+	queryEmbedding, err = fitQuery(queryEmbedding, dims)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate query embedding: %w", err)
+		return nil, err
 	}
 
-	// Create filter function if filters are specified
-	var filterFunc func(id string) bool
-	if options.Book != "" || options.Chapter != "" {
-		filterFunc = func(id string) bool {
-			if text, ok := textLookup[id]; ok {
-				if options.Book != "" && !strings.EqualFold(text.Meta.Book, options.Book) {
-					return false
+	// Small indices cap K so they don't pad results with unrelated entries
+	limits := s.limitsFor(options)
+	if limits.MaxK > 0 && options.K > limits.MaxK && !options.Paged {
+		options.K = limits.MaxK
+	}
+
+	filterFunc := buildFilter(options, textLookup, tags)
+
+	// Search the index
+	start := time.Now()
+	var searchResults []SearchResult
+	var mode string
+	var scanned time.Time
+	if !textOnly(boosts) {
+		// Field boosts can promote any candidate, so score the whole filtered index
+		mode = ScanFields
+		all := index.SearchWithFilter(ctx, queryEmbedding, index.Size(), filterFunc)
+		scanned = time.Now()
+		searchResults = applyFieldBoosts(all, boosts, query, lexicalTerms(query, s.Thesaurus()), textLookup, options.K)
+	} else if options.Rerank && binary != nil {
+		// Two-stage search: Hamming retrieval of a wider pool, then exact re-ranking
+		mode = ScanRerankBinary
+		candidates := max(s.config.RerankCandidates*binaryCandidateFactor, options.K)
+		pool := binary.SearchWithFilter(ctx, queryEmbedding, candidates, filterFunc)
+		scanned = time.Now()
+		searchResults = index.Rerank(queryEmbedding, pool, options.K)
+	} else if options.Rerank && quantized != nil {
+		// Two-stage search: cheap quantized retrieval, then exact cosine re-ranking
+		mode = ScanRerankInt8
+		candidates := s.config.RerankCandidates
+		if candidates < options.K {
+			candidates = options.K
+		}
+		pool := quantized.SearchWithFilter(ctx, queryEmbedding, candidates, filterFunc)
+		scanned = time.Now()
+		searchResults = index.Rerank(queryEmbedding, pool, options.K)
+	} else if router != nil && routable(options) {
+		// Scan only the books and chapters whose centroids are nearest the query
+		mode = ScanRouted
+		positions := router.route(queryEmbedding, s.config.RouteBooks, s.config.RouteSample)
+		searchResults = index.SearchPositions(ctx, queryEmbedding, options.K, filterFunc, positions)
+		scanned = time.Now()
+	} else {
+		mode = ScanFull
+		searchResults = index.SearchWithFilter(ctx, queryEmbedding, options.K, filterFunc)
+		scanned = time.Now()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	results := attachText(searchResults, textLookup, limits.MinScore)
+	if s.loads.isIngested(options.Granularity) {
+		for i := range results {
+			results[i].Corpus = options.Granularity
+		}
+	}
+	if options.Highlight {
+		s.highlight(results, query, queryEmbedding, options)
+	}
+	options.explain.recordScan(mode, scanned.Sub(start), time.Since(scanned))
+	return results, nil
+}
+
+// TagMatcher answers whether a verse carries a tag. A zero verse asks about
+// any verse of the chapter.
+type TagMatcher interface {
+	HasTag(namespace, tag, book string, chapter, verse int) bool
+}
+
+// SetTags connects the tag store used by tag-filtered searches
+func (s *SearchService) SetTags(tags TagMatcher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tags = tags
+}
+
+// buildFilter returns a predicate accepting index IDs that match the
+// options' book, chapter, verse and tag filters and aren't explicitly excluded
+func buildFilter(options SearchOptions, textLookup map[string]*TextData, tags TagMatcher) func(id string) bool {
+	if options.Tag != "" && tags == nil {
+		// Tag filtering without a tag store matches nothing rather than everything
+		return func(id string) bool { return false }
+	}
+
+	books := allowedBooks(options)
+	phrases := phraseMatcher(options.Phrases, options.Exclude)
+	if books == nil && options.Chapter == "" && options.Verse == "" && options.Tag == "" && phrases == nil {
+		// No filter - accept everything not explicitly excluded
+		return func(id string) bool { return !options.exclude[id] }
+	}
+
+	chapter, err := ParseChapter(options.Chapter)
+	if err != nil {
+		return func(id string) bool { return false }
+	}
+	firstVerse, lastVerse, err := ParseVerseRange(options.Verse)
+	if err != nil {
+		return func(id string) bool { return false }
+	}
+
+	// Compare canonical book IDs so "1 Cor", "I Corinthians" and "1co" all
+	// match. Sharded searches call the filter concurrently.
+	var bookIDs sync.Map
+
+	return func(id string) bool {
+		if options.exclude[id] {
+			return false
+		}
+		if text, ok := textLookup[id]; ok {
+			if books != nil {
+				bookID, seen := bookIDs.Load(text.Meta.Book)
+				if !seen {
+					bookID = canonicalBookID(text.Meta.Book)
+					bookIDs.Store(text.Meta.Book, bookID)
 				}
-				if options.Chapter != "" && fmt.Sprintf("%d", text.Meta.Chapter) != options.Chapter {
+				if !books[bookID.(string)] {
 					return false
 				}
-				return true
 			}
-			return false
+			if chapter > 0 && text.Meta.Chapter != chapter {
+				return false
+			}
+			// Chapter entries have no verse number and span every verse
+			if options.Verse != "" && text.Meta.VerseNum > 0 && (text.Meta.VerseNum < firstVerse || text.Meta.VerseNum > lastVerse) {
+				return false
+			}
+			if options.Tag != "" && !tags.HasTag(options.Namespace, options.Tag, text.Meta.Book, text.Meta.Chapter, text.Meta.VerseNum) {
+				return false
+			}
+			if phrases != nil && !phrases(text.Text) {
+				return false
+			}
+			return true
 		}
-	} else {
-		// No filter - accept all
-		filterFunc = func(id string) bool { return true }
+		return false
 	}
+}
 
-	// Search the index
-	searchResults := index.SearchWithFilter(queryEmbedding, options.K, filterFunc)
+// allowedBooks resolves the book, books, testament and genre filters to the
+// set of canonical book IDs satisfying all of them, or nil when none are set
+func allowedBooks(options SearchOptions) map[string]bool {
+	if options.Book == "" && len(options.Books) == 0 && options.Testament == "" && options.Genre == "" {
+		return nil
+	}
+
+	var listed map[string]bool
+	if options.Book != "" || len(options.Books) > 0 {
+		listed = make(map[string]bool)
+		for _, name := range append([]string{options.Book}, options.Books...) {
+			if name != "" {
+				listed[canonicalBookID(name)] = true
+			}
+		}
+		if options.Book != "" && len(options.Books) > 0 {
+			// A single book filter narrows the list to that book
+			listed = map[string]bool{canonicalBookID(options.Book): listed[canonicalBookID(options.Book)]}
+		}
+	}
+
+	allowed := make(map[string]bool)
+	for i := range canon.Books {
+		book := &canon.Books[i]
+		if listed != nil && !listed[book.ID] {
+			continue
+		}
+		if options.Testament != "" && book.Testament != options.Testament {
+			continue
+		}
+		if options.Genre != "" && !book.InGenre(options.Genre) {
+			continue
+		}
+		allowed[book.ID] = true
+	}
+
+	// Keep names outside the canon, which only match themselves
+	for name := range listed {
+		if _, ok := canon.Lookup(name); !ok && options.Testament == "" && options.Genre == "" {
+			allowed[name] = true
+		}
+	}
+	return allowed
+}
 
-	// Convert to final results with text
+// canonicalBookID resolves a book name to its canonical ID, falling back to
+// the lowercased name for books outside the canon
+func canonicalBookID(name string) string {
+	if book, ok := canon.Lookup(name); ok {
+		return book.ID
+	}
+	return strings.ToLower(name)
+}
+
+// attachText converts index hits to results with text, dropping any below minScore
+func attachText(searchResults []SearchResult, textLookup map[string]*TextData, minScore float32) []SearchResult {
 	results := make([]SearchResult, 0, len(searchResults))
 	for _, sr := range searchResults {
+		if sr.Similarity < minScore {
+			continue
+		}
+
 		textData, ok := textLookup[sr.ID]
 		if !ok {
 			// Create placeholder if text not found
@@ -408,10 +1065,41 @@ func (s *SearchService) Search(query string, options SearchOptions) ([]SearchRes
 				Text: textData.Text,
 				Meta: textData.Meta,
 			},
+			MatchedFields: sr.MatchedFields,
+			Contributions: sr.Contributions,
 		})
 	}
+	return results
+}
 
-	return results, nil
+// IndexVersion returns a short content-derived version for a loaded granularity
+func (s *SearchService) IndexVersion(granularity string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.remote != nil {
+		return s.remote.Version(granularity)
+	}
+	return shortVersion(s.checksums[granularity])
+}
+
+// shortVersion abbreviates an index checksum for display
+func shortVersion(checksum string) string {
+	if len(checksum) > 12 {
+		return checksum[:12]
+	}
+	return checksum
+}
+
+// ModelHash returns the fingerprint of the embedding model answering queries
+func (s *SearchService) ModelHash() string {
+	return s.embeddings.ModelHash()
+}
+
+// Close releases cached payloads and the embedding backend
+func (s *SearchService) Close() error {
+	s.cache.Clear()
+	return s.embeddings.Close()
 }
 
 // GetStatus returns the current status of the search service
@@ -425,11 +1113,81 @@ func (s *SearchService) GetStatus() map[string]interface{} {
 	}
 
 	for granularity, index := range s.indices {
-		status["indices"].(map[string]interface{})[granularity] = map[string]interface{}{
+		indexStatus := map[string]interface{}{
 			"loaded": s.loadedGranularities[granularity],
 			"count":  index.Size(),
 			"memoryBytes": index.GetMemoryUsage(),
+			"version": shortVersion(s.checksums[granularity]),
+			"dimensions": s.dimensions[granularity],
+			"artifact": s.artifacts[granularity],
+			"storage": "memory", // Every index is fully resident; there are no mmap or disk-spill modes
+			"shards": index.ShardCount(),
+		}
+		if quantized, ok := s.quantized[granularity]; ok {
+			indexStatus["quantizedMemoryBytes"] = quantized.GetMemoryUsage()
+			indexStatus["quantizedQuery"] = "float"
+			if quantized.QuantizeQuery {
+				indexStatus["quantizedQuery"] = "int8"
+			}
+		}
+		if binary, ok := s.binary[granularity]; ok {
+			indexStatus["binaryMemoryBytes"] = binary.GetMemoryUsage()
+		}
+		if router, ok := s.routers[granularity]; ok {
+			indexStatus["routing"] = RoutingStatus{
+				Books:    len(router.books),
+				Chapters: router.chapters,
+				TopBooks: s.config.RouteBooks,
+				Sample:   s.config.RouteSample,
+			}
+		}
+		status["indices"].(map[string]interface{})[granularity] = indexStatus
+	}
+
+	// Artifacts refused at load time are reported instead of silently mis-scoring
+	for granularity, loadErr := range s.loadErrors {
+		status["indices"].(map[string]interface{})[granularity] = map[string]interface{}{
+			"loaded": false,
+			"error":  loadErr,
+		}
+	}
+
+	// Every servable index is listed with where it comes from, loaded or not.
+	// A stateless node serves the index node's indices, listed in its status.
+	for _, source := range append(s.corpusSources(), s.loads.ingestedSources()...) {
+		if s.remote != nil {
+			break
+		}
+		indexStatus, ok := status["indices"].(map[string]interface{})[source.Granularity].(map[string]interface{})
+		if !ok {
+			state, loadErr, _ := s.loads.get(source.Granularity)
+			indexStatus = map[string]interface{}{"loaded": false, "state": state}
+			if loadErr != "" {
+				indexStatus["error"] = loadErr
+			}
+			status["indices"].(map[string]interface{})[source.Granularity] = indexStatus
+		}
+		indexStatus["source"] = source
+		if reload, ok := s.loads.reload(source.Granularity); ok {
+			indexStatus["reload"] = reload
 		}
+		if license := s.licenseFor(source.Granularity, s.artifacts[source.Granularity]); license != nil {
+			indexStatus["license"] = license
+		}
+	}
+
+	if s.remote != nil {
+		status["indexNode"] = s.remote.URL()
+	}
+	status["embedding"] = s.embeddings.Backend()
+	status["queryCache"] = s.embeddings.QueryCacheStats()
+	status["downloadCache"] = s.cache.Stats()
+	if ensemble := s.ensembleStatus(); ensemble != nil {
+		status["ensemble"] = ensemble
+	}
+	status["embeddingQueue"] = map[string]interface{}{
+		"depth":     s.embeddings.QueueDepth(),
+		"shedDepth": s.config.ShedQueueDepth,
 	}
 
 	return status
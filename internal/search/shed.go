@@ -0,0 +1,125 @@
+package search
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/qos"
+	"github.com/rs/zerolog/log"
+)
+
+// Ways a shed query is answered instead of waiting for inference
+const (
+	ShedCache   = "cache"   // Semantic search with the query's cached embedding
+	ShedLexical = "lexical" // Ranked by query term overlap with the text
+)
+
+// Overloaded reports whether the embedding queue is deep enough that new
+// queries should be shed rather than queued
+func (s *SearchService) Overloaded() bool {
+	return s.config.ShedQueueDepth > 0 && s.embeddings.QueueDepth() >= s.config.ShedQueueDepth
+}
+
+// SearchOrShed performs a semantic search, unless the embedding queue is
+// overloaded. Then the query is answered from its cached embedding or, failing
+// that, lexically, keeping latency bounded under bursts. It reports which of
+// ShedCache or ShedLexical answered, or "" for a normal search. Callers whose
+// QoS tier skips shedding always get a normal search. A granularity whose
+// text is loaded but whose embeddings aren't is always answered lexically.
+// A stateless node holds no text to search lexically, so it queues queries
+// whose embedding isn't cached.
+func (s *SearchService) SearchOrShed(ctx context.Context, query string, options SearchOptions) ([]SearchResult, string, error) {
+	// Until its embeddings load, a granularity's staged text is all there is to search
+	if staged := s.loads.stagedText(withDefaults(options).Granularity); staged != nil && query != "" {
+		log.Ctx(ctx).Info().Str("mode", ShedLexical).Msg("Answering lexically while embeddings load")
+		results, err := s.searchStaged(query, withDefaults(options), staged)
+		return results, ShedLexical, err
+	}
+
+	if query == "" || !s.Overloaded() || qos.FromContext(ctx).SkipShedding {
+		results, err := s.Search(ctx, query, options)
+		return results, "", err
+	}
+
+	options = withDefaults(options)
+	if err := s.checkLoaded(options.Granularity); err != nil {
+		return nil, "", err
+	}
+
+	logger := log.Ctx(ctx)
+	if embedding, ok := s.embeddings.CachedQuery(ctx, query); ok {
+		logger.Info().Str("mode", ShedCache).Int("queueDepth", s.embeddings.QueueDepth()).Msg("Shedding search")
+		results, err := s.searchEmbedding(ctx, query, embedding, options)
+		return results, ShedCache, err
+	}
+	if s.Remote() != nil {
+		results, err := s.Search(ctx, query, options)
+		return results, "", err
+	}
+	logger.Info().Str("mode", ShedLexical).Int("queueDepth", s.embeddings.QueueDepth()).Msg("Shedding search")
+	results, err := s.searchLexical(query, options)
+	return results, ShedLexical, err
+}
+
+// searchLexical ranks the filtered index by how many query terms each text
+// contains, with exact phrase matches first. Similarity and Score hold the
+// lexical score in [0, 1].
+func (s *SearchService) searchLexical(query string, options SearchOptions) ([]SearchResult, error) {
+	if err := ValidateRanking(options.Ranking); err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	index := s.indices[options.Granularity]
+	textLookup := s.textLookup[options.Granularity]
+	tags := s.tags
+	s.mu.RUnlock()
+
+	index.mu.RLock()
+	defer index.mu.RUnlock()
+	return s.rankLexical(query, options, index.IDs, textLookup, tags), nil
+}
+
+// rankLexical scores texts by query term overlap, in the order of ids. It
+// is the only lexical engine; an FTS5 engine would stand in for it here.
+func (s *SearchService) rankLexical(query string, options SearchOptions, ids []string, textLookup map[string]*TextData, tags TagMatcher) []SearchResult {
+	limits := s.limitsFor(options)
+	if limits.MaxK > 0 && options.K > limits.MaxK && !options.Paged {
+		options.K = limits.MaxK
+	}
+
+	filter := buildFilter(options, textLookup, tags)
+	terms := lexicalTerms(query, s.Thesaurus())
+
+	start := time.Now()
+	var hits []SearchResult
+	for _, id := range ids {
+		text, ok := textLookup[id]
+		if !ok || !filter(id) {
+			continue
+		}
+		if score := lexicalMatch(query, terms, text.Text); score > 0 {
+			hits = append(hits, SearchResult{ID: id, Similarity: score, Score: score})
+		}
+	}
+
+	scanned := time.Now()
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	if len(hits) > options.K {
+		hits = hits[:options.K]
+	}
+
+	// Score floors are calibrated for cosine similarity, so they don't apply
+	results := attachText(hits, textLookup, 0)
+	if options.Highlight {
+		s.highlight(results, query, nil, options)
+	}
+	options.explain.recordScan(ScanLexical, scanned.Sub(start), time.Since(scanned))
+	return results
+}
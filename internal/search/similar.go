@@ -0,0 +1,54 @@
+package search
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+// Similar returns the verses nearest to the precomputed embedding of a
+// reference, excluding the referenced verses themselves. Ranges use the mean
+// of their verse embeddings.
+func (s *SearchService) Similar(ctx context.Context, ref reference.Reference, options SearchOptions) ([]SearchResult, error) {
+	verses, err := s.Passage(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.RLock()
+	index := s.indices["verse"]
+	exclude := make(map[string]bool, len(verses))
+	for _, verse := range verses {
+		if id, ok := s.verseIDs[verseKey(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)]; ok {
+			exclude[id] = true
+		}
+	}
+	s.mu.RUnlock()
+
+	var mean []float32
+	for id := range exclude {
+		vec, ok := index.Get(id)
+		if !ok {
+			continue
+		}
+		if mean == nil {
+			mean = make([]float32, len(vec))
+		}
+		for i := range mean {
+			if i < len(vec) {
+				mean[i] += vec[i]
+			}
+		}
+	}
+	if mean == nil {
+		return nil, fmt.Errorf("%w for %s", ErrNoEmbedding, ref.String())
+	}
+
+	// Cosine similarity is scale-invariant, so the sum ranks the same as the mean
+	options = withDefaults(options)
+	options.Granularity = "verse"
+	options.exclude = exclude
+
+	return s.searchEmbedding(ctx, "", mean, options)
+}
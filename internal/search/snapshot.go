@@ -0,0 +1,155 @@
+package search
+
+import (
+	"bufio"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+)
+
+// snapshotVersion is bumped whenever the snapshot layout changes, so older
+// snapshots are ignored rather than misread
+const snapshotVersion = 1
+
+// corpusData is a parsed granularity: vectors in artifact order, the text
+// lookup, and the artifact's provenance header (nil for legacy artifacts)
+type corpusData struct {
+	ids        []string
+	vectors    [][]float32
+	textLookup map[string]*TextData
+	header     *ArtifactHeader
+}
+
+// snapshot is the on-disk form of corpusData. Vectors are stored flat and
+// texts once each, since the lookup maps several ID formats to the same text.
+type snapshot struct {
+	Version int
+	Source  CorpusSource
+	ModelID string
+
+	IDs        []string
+	Dimensions int
+	Vectors    []float32
+
+	Texts    []TextData
+	TextKeys map[string]int
+
+	Header *ArtifactHeader
+}
+
+// SnapshotPath returns where a granularity's warm snapshot is kept
+func (s *SearchService) SnapshotPath(granularity string) string {
+	return filepath.Join(s.config.DataDir, "cache", granularity, "snapshot.gob")
+}
+
+// RemoveSnapshot deletes a granularity's snapshot, so its next load is
+// built from its artifacts
+func (s *SearchService) RemoveSnapshot(granularity string) error {
+	if err := os.Remove(s.SnapshotPath(granularity)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// writeSnapshot saves parsed corpus data so the next start can skip
+// downloading and parsing JSON
+func writeSnapshot(path string, source CorpusSource, data *corpusData) error {
+	snap := snapshot{
+		Version:  snapshotVersion,
+		Source:   source,
+		ModelID:  config.ModelConfig.ModelID,
+		IDs:      data.ids,
+		TextKeys: make(map[string]int, len(data.textLookup)),
+		Header:   data.header,
+	}
+	if len(data.vectors) > 0 {
+		snap.Dimensions = len(data.vectors[0])
+		snap.Vectors = make([]float32, 0, len(data.vectors)*snap.Dimensions)
+		for _, vec := range data.vectors {
+			snap.Vectors = append(snap.Vectors, vec...)
+		}
+	}
+
+	positions := make(map[*TextData]int)
+	for key, text := range data.textLookup {
+		pos, ok := positions[text]
+		if !ok {
+			pos = len(snap.Texts)
+			positions[text] = pos
+			snap.Texts = append(snap.Texts, *text)
+		}
+		snap.TextKeys[key] = pos
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	tmp := path + ".tmp"
+	file, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	buffered := bufio.NewWriter(file)
+	if err := gob.NewEncoder(buffered).Encode(&snap); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	if err := buffered.Flush(); err != nil {
+		file.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// readSnapshot loads a snapshot written for the same source and model. A
+// missing snapshot returns an error wrapping os.ErrNotExist.
+func readSnapshot(path string, source CorpusSource) (*corpusData, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var snap snapshot
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&snap); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	switch {
+	case snap.Version != snapshotVersion:
+		return nil, fmt.Errorf("snapshot version %d is not %d", snap.Version, snapshotVersion)
+	case snap.Source != source:
+		return nil, fmt.Errorf("snapshot was built from different artifact URLs")
+	case snap.ModelID != config.ModelConfig.ModelID:
+		return nil, fmt.Errorf("snapshot was built for model %s", snap.ModelID)
+	case snap.Dimensions <= 0 || len(snap.Vectors) != len(snap.IDs)*snap.Dimensions:
+		return nil, fmt.Errorf("snapshot vectors don't match its IDs")
+	}
+
+	data := &corpusData{
+		ids:        snap.IDs,
+		vectors:    make([][]float32, len(snap.IDs)),
+		textLookup: make(map[string]*TextData, len(snap.TextKeys)),
+		header:     snap.Header,
+	}
+	for i := range snap.IDs {
+		data.vectors[i] = snap.Vectors[i*snap.Dimensions : (i+1)*snap.Dimensions : (i+1)*snap.Dimensions]
+	}
+	for key, pos := range snap.TextKeys {
+		if pos < 0 || pos >= len(snap.Texts) {
+			return nil, fmt.Errorf("snapshot text index %d out of range", pos)
+		}
+		data.textLookup[key] = &snap.Texts[pos]
+	}
+	return data, nil
+}
@@ -0,0 +1,58 @@
+package search
+
+import "fmt"
+
+// Stages of a granularity load, reported to the progress callback in order
+const (
+	StageText       = "text"       // Text is downloaded and parsed
+	StageLexical    = "lexical"    // Searches can be answered lexically
+	StageEmbeddings = "embeddings" // The index serves semantic searches
+)
+
+// stagedText is a granularity's text, searchable lexically while its
+// embeddings load or after they fail to
+type stagedText struct {
+	ids        []string
+	textLookup map[string]*TextData
+	tags       TagMatcher
+}
+
+// SetProgress registers a callback run as each granularity load reaches a
+// stage. Set it before loading anything.
+func (s *SearchService) SetProgress(progress func(granularity, stage string)) {
+	s.progress = progress
+}
+
+// reportProgress runs the progress callback, if any
+func (s *SearchService) reportProgress(granularity, stage string) {
+	if s.progress != nil {
+		s.progress(granularity, stage)
+	}
+}
+
+// stageText makes parsed text searchable lexically before its embeddings
+// load
+func (s *SearchService) stageText(granularity string, textLookup map[string]*TextData) {
+	// processTextData keys every entry by its position, as granularity_i
+	var ids []string
+	for i := 0; ; i++ {
+		id := fmt.Sprintf("%s_%d", granularity, i)
+		if _, ok := textLookup[id]; !ok {
+			break
+		}
+		ids = append(ids, id)
+	}
+	s.mu.RLock()
+	tags := s.tags
+	s.mu.RUnlock()
+	s.loads.stage(granularity, &stagedText{ids: ids, textLookup: textLookup, tags: tags})
+}
+
+// searchStaged answers a query lexically from staged text, without the
+// service lock
+func (s *SearchService) searchStaged(query string, options SearchOptions, staged *stagedText) ([]SearchResult, error) {
+	if err := ValidateRanking(options.Ranking); err != nil {
+		return nil, err
+	}
+	return s.rankLexical(query, options, staged.ids, staged.textLookup, staged.tags), nil
+}
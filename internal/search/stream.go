@@ -0,0 +1,86 @@
+package search
+
+import (
+	"context"
+	"fmt"
+)
+
+// streamChunkSize is how many vectors are scored between streamed updates
+const streamChunkSize = 4096
+
+// StreamUpdate is a snapshot of the best results found so far
+type StreamUpdate struct {
+	Results []SearchResult
+	Scanned int
+	Total   int
+	Final   bool
+}
+
+// SearchStream performs a semantic search, calling emit with the running top
+// K as the index is scanned so callers can show partial results early.
+// Field-boosted, re-ranked, ensemble, diversified and grouped searches can
+// only be ranked once the scan is complete, so they emit a single final update,
+// as does every search on a stateless node, which can't see the index node's scan.
+// Returning false from emit, or ctx ending, stops the scan.
+func (s *SearchService) SearchStream(ctx context.Context, query string, options SearchOptions, emit func(StreamUpdate) bool) error {
+	options = withDefaults(options)
+	if err := s.checkLoaded(options.Granularity); err != nil {
+		return err
+	}
+	if err := ValidateRanking(options.Ranking); err != nil {
+		return err
+	}
+	boosts, err := ParseFields(options.Fields)
+	if err != nil {
+		return err
+	}
+
+	queryEmbedding, err := s.embeddings.EmbedQuery(ctx, query)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrEmbedding, err)
+	}
+
+	if !textOnly(boosts) || options.Rerank || options.Ranking == RankingEnsemble || options.Diversity > 0 || options.Group != GroupNone || options.Expand || options.Negative != "" || s.Remote() != nil {
+		results, err := s.rank(ctx, query, queryEmbedding, options)
+		if err != nil {
+			return err
+		}
+		emit(StreamUpdate{Results: results, Scanned: len(results), Total: len(results), Final: true})
+		return nil
+	}
+
+	s.mu.RLock()
+	index := s.indices[options.Granularity]
+	textLookup := s.textLookup[options.Granularity]
+	dims := s.dimensions[options.Granularity]
+	tags := s.tags
+	s.mu.RUnlock()
+
+	queryEmbedding, err = fitQuery(queryEmbedding, dims)
+	if err != nil {
+		return err
+	}
+
+	limits := s.limitsFor(options)
+	if limits.MaxK > 0 && options.K > limits.MaxK {
+		options.K = limits.MaxK
+	}
+
+	index.SearchChunks(queryEmbedding, options.K, streamChunkSize, buildFilter(options, textLookup, tags), func(top []SearchResult, scanned, total int) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+		results := attachText(top, textLookup, limits.MinScore)
+		if options.Highlight {
+			s.highlight(results, query, queryEmbedding, options)
+		}
+		return emit(StreamUpdate{
+			Results: results,
+			Scanned: scanned,
+			Total:   total,
+			Final:   scanned == total,
+		})
+	})
+
+	return ctx.Err()
+}
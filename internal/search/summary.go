@@ -0,0 +1,118 @@
+package search
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+)
+
+const (
+	// MaxSummaryVerses caps the verses a chapter summary picks
+	MaxSummaryVerses = 10
+	// summaryRedundancy weighs a candidate's similarity to verses already
+	// picked against its centrality, so a summary doesn't repeat itself
+	summaryRedundancy = 0.3
+)
+
+// SummaryVerse is a verse picked to represent its chapter
+type SummaryVerse struct {
+	Verse      *TextData
+	Centrality float32 // Mean cosine similarity to the chapter's other verses
+}
+
+// ChapterSummary picks up to n verses that best represent a chapter: the
+// medoid, the verse most similar on average to the rest, then the most
+// central verses unlike those already picked. They are returned in verse
+// order.
+func (s *SearchService) ChapterSummary(book string, chapter, n int) ([]SummaryVerse, error) {
+	ref := reference.Reference{Book: book, StartChapter: chapter, EndChapter: chapter}
+	verses, err := s.Passage(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []*TextData
+	var vectors [][]float32
+	for _, verse := range verses {
+		vector, ok := s.VerseEmbedding(verse.Meta.Book, verse.Meta.Chapter, verse.Meta.VerseNum)
+		if !ok || (len(vectors) > 0 && len(vector) != len(vectors[0])) {
+			continue
+		}
+		candidates = append(candidates, verse)
+		vectors = append(vectors, normalized(vector))
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoEmbedding, ref.String())
+	}
+
+	// similarity[i][j] is the cosine of verses i and j
+	similarity := make([][]float32, len(vectors))
+	centrality := make([]float32, len(vectors))
+	for i := range vectors {
+		similarity[i] = make([]float32, len(vectors))
+	}
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			var dot float32
+			for d := range vectors[i] {
+				dot += vectors[i][d] * vectors[j][d]
+			}
+			similarity[i][j], similarity[j][i] = dot, dot
+			centrality[i] += dot
+			centrality[j] += dot
+		}
+	}
+	if len(vectors) > 1 {
+		for i := range centrality {
+			centrality[i] /= float32(len(vectors) - 1)
+		}
+	}
+
+	// Greedy maximal marginal relevance over centrality
+	n = min(n, len(candidates))
+	picked := make([]int, 0, n)
+	taken := make([]bool, len(candidates))
+	for len(picked) < n {
+		best, bestScore := -1, float32(math.Inf(-1))
+		for i := range candidates {
+			if taken[i] {
+				continue
+			}
+			var redundancy float32
+			for _, j := range picked {
+				redundancy = max(redundancy, similarity[i][j])
+			}
+			if score := centrality[i] - summaryRedundancy*redundancy; score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		picked = append(picked, best)
+		taken[best] = true
+	}
+
+	sort.Ints(picked)
+	summary := make([]SummaryVerse, len(picked))
+	for i, p := range picked {
+		summary[i] = SummaryVerse{Verse: candidates[p], Centrality: centrality[p]}
+	}
+	return summary, nil
+}
+
+// normalized returns a unit-length copy of a vector
+func normalized(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	out := make([]float32, len(vector))
+	if norm == 0 {
+		return out
+	}
+	scale := float32(1 / math.Sqrt(norm))
+	for i, v := range vector {
+		out[i] = v * scale
+	}
+	return out
+}
@@ -0,0 +1,65 @@
+package search
+
+import (
+	"fmt"
+	"math"
+)
+
+// Score transformations
+const (
+	TransformNone    = ""
+	TransformSoftmax = "softmax" // Probabilities over the candidate set, summing to 1
+)
+
+// DefaultTemperature suits cosine scores, which rarely spread by more than a
+// few tenths across a result set
+const DefaultTemperature = 0.05
+
+// ValidateTransform checks a score transformation and its temperature
+func ValidateTransform(transform string, temperature float64) error {
+	switch transform {
+	case TransformNone, TransformSoftmax:
+	default:
+		return fmt.Errorf("unknown score transform: %s (use softmax)", transform)
+	}
+	if temperature < 0 || math.IsNaN(temperature) || math.IsInf(temperature, 0) {
+		return fmt.Errorf("temperature must be a positive number")
+	}
+	return nil
+}
+
+// ApplyTransform attaches the options' score transformation to results
+func ApplyTransform(results []SearchResult, options SearchOptions) {
+	if options.Transform == TransformSoftmax {
+		Softmax(results, options.Temperature)
+	}
+}
+
+// Softmax sets each result's Probability to exp(score/temperature),
+// normalized over the results so they sum to 1. Lower temperatures
+// concentrate probability on the top results. A zero temperature uses
+// DefaultTemperature.
+func Softmax(results []SearchResult, temperature float64) {
+	if len(results) == 0 {
+		return
+	}
+	if temperature <= 0 {
+		temperature = DefaultTemperature
+	}
+
+	// Subtracting the top score keeps exp from overflowing
+	top := math.Inf(-1)
+	for _, r := range results {
+		top = math.Max(top, float64(r.Score))
+	}
+	weights := make([]float64, len(results))
+	sum := 0.0
+	for i, r := range results {
+		weights[i] = math.Exp((float64(r.Score) - top) / temperature)
+		sum += weights[i]
+	}
+	for i := range results {
+		p := weights[i] / sum
+		results[i].Probability = &p
+	}
+}
@@ -0,0 +1,179 @@
+package search
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ErrPinned reports an index that can't be unloaded
+var ErrPinned = errors.New("index can't be unloaded")
+
+// LoadIndex starts loading an index in the background, returning its catalog
+// entry and whether a load started; an index already loaded is left as it
+// is. A source registers a new downloaded index under name first. Registered
+// indices are served until they are unloaded or the server restarts.
+func (s *SearchService) LoadIndex(name string, source *CorpusSource) (CorpusInfo, bool, error) {
+	if source != nil {
+		if err := s.register(name, *source); err != nil {
+			return CorpusInfo{}, false, err
+		}
+	} else if _, err := s.sourceFor(name); err != nil {
+		if !s.loads.isIngested(name) {
+			return CorpusInfo{}, false, err
+		}
+		// Ingested corpora are always loaded
+		return s.corpusInfo(name), false, nil
+	}
+	if status, ok := s.loads.reload(name); ok && status.State == ReloadRunning {
+		return CorpusInfo{}, false, fmt.Errorf("granularity %s: %w", name, ErrReloading)
+	}
+
+	switch state := s.loads.begin(name); state {
+	case CorpusLoading:
+		return CorpusInfo{}, false, fmt.Errorf("granularity %s: %w", name, ErrReloading)
+	case CorpusLoaded:
+		return s.corpusInfo(name), false, nil
+	}
+	go func() {
+		// The load outlives the admin request that started it
+		if err := s.PreloadGranularity(context.Background(), name); err != nil {
+			log.Error().Err(err).Str("granularity", name).Msg("Failed to load index on demand")
+			return
+		}
+		log.Info().Str("granularity", name).Msg("Index loaded on demand")
+	}()
+	return s.corpusInfo(name), true, nil
+}
+
+// loadOnDemand starts loading an index that wasn't preloaded, with lazy
+// loading on, and reports whether it is loading. An index whose load failed
+// isn't retried; POST /admin/granularity/:name/load retries it.
+func (s *SearchService) loadOnDemand(name string) bool {
+	if !s.config.LazyLoad || s.config.Preloads(name) {
+		return false
+	}
+	switch state, _, _ := s.loads.get(name); state {
+	case CorpusLoading:
+		return true
+	case CorpusNotLoaded:
+	default:
+		return false
+	}
+	if s.loads.begin(name) == CorpusLoading {
+		return true
+	}
+	go func() {
+		// The load outlives the search that started it
+		if err := s.PreloadGranularity(context.Background(), name); err != nil {
+			log.Error().Err(err).Str("granularity", name).Msg("Failed to load index on first use")
+			return
+		}
+		log.Info().Str("granularity", name).Msg("Index loaded on first use")
+	}()
+	return true
+}
+
+// register adds a downloaded index to those the service can load
+func (s *SearchService) register(name string, source CorpusSource) error {
+	if err := ValidateCorpusName(name); err != nil {
+		return err
+	}
+	if source.EmbeddingsURL == "" || source.TextURL == "" {
+		return fmt.Errorf("%w: an index needs embeddingsUrl and textUrl", ErrInvalidCorpus)
+	}
+	if s.loads.isIngested(name) {
+		return fmt.Errorf("%w: %s is an ingested corpus", ErrInvalidCorpus, name)
+	}
+	if _, ok := s.loads.registered(name); !ok {
+		if _, err := s.sourceFor(name); err == nil {
+			return fmt.Errorf("%w: %s is already configured", ErrInvalidCorpus, name)
+		}
+	}
+	if state, _, _ := s.loads.get(name); state == CorpusLoading || state == CorpusLoaded {
+		return fmt.Errorf("%w: %s is %s; unload it first", ErrInvalidCorpus, name, state)
+	}
+
+	source.Granularity, source.Ingested = name, false
+	s.loads.register(source)
+	return nil
+}
+
+// UnloadIndex stops serving an index and frees its memory, returning the
+// bytes its vectors held. Its snapshot is kept, so loading it again is fast.
+// A registered index is forgotten. The verse index can't be unloaded, since
+// passages and every other index resolve references through it.
+func (s *SearchService) UnloadIndex(name string) (int64, error) {
+	switch {
+	case name == "verse":
+		return 0, fmt.Errorf("%w: verse backs passages and references", ErrPinned)
+	case s.loads.isIngested(name):
+		return 0, fmt.Errorf("%w: %s is ingested; DELETE /admin/corpus/%s removes it", ErrInvalidCorpus, name, name)
+	}
+	source, err := s.sourceFor(name)
+	if err != nil {
+		return 0, err
+	}
+	if state, _, _ := s.loads.get(name); state == CorpusLoading {
+		return 0, fmt.Errorf("granularity %s: %w", name, ErrReloading)
+	}
+	if status, ok := s.loads.reload(name); ok && status.State == ReloadRunning {
+		return 0, fmt.Errorf("granularity %s: %w", name, ErrReloading)
+	}
+
+	s.mu.Lock()
+	freed := s.memoryBytes(name)
+	s.drop(name)
+	s.mu.Unlock()
+	s.loads.forget(name)
+	s.releaseDownloads(source)
+
+	// Hand the vectors back to the OS now rather than at the next GC cycle
+	debug.FreeOSMemory()
+	log.Info().Str("granularity", name).Int64("freedBytes", freed).Msg("Index unloaded")
+	return freed, nil
+}
+
+// drop removes everything served for a granularity. Callers must hold s.mu.
+func (s *SearchService) drop(name string) {
+	delete(s.loadedGranularities, name)
+	delete(s.indices, name)
+	delete(s.quantized, name)
+	delete(s.binary, name)
+	delete(s.routers, name)
+	delete(s.textLookup, name)
+	delete(s.checksums, name)
+	delete(s.textChecksums, name)
+	delete(s.dimensions, name)
+	delete(s.loadErrors, name)
+	delete(s.artifacts, name)
+}
+
+// memoryBytes estimates the memory of a granularity's vector indices.
+// Callers must hold s.mu.
+func (s *SearchService) memoryBytes(name string) int64 {
+	var memory int64
+	if index, ok := s.indices[name]; ok {
+		memory += index.GetMemoryUsage()
+	}
+	if quantized, ok := s.quantized[name]; ok {
+		memory += quantized.GetMemoryUsage()
+	}
+	if binary, ok := s.binary[name]; ok {
+		memory += binary.GetMemoryUsage()
+	}
+	return memory
+}
+
+// corpusInfo returns a granularity's catalog entry
+func (s *SearchService) corpusInfo(name string) CorpusInfo {
+	for _, info := range s.Corpora() {
+		if info.Granularity == name {
+			return info
+		}
+	}
+	return CorpusInfo{CorpusSource: CorpusSource{Granularity: name}, State: CorpusNotLoaded}
+}
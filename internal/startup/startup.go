@@ -0,0 +1,156 @@
+// Package startup tracks the server's initialization as an ordered sequence
+// of states, so a load that fails leaves a recorded reason instead of a
+// half-initialized service.
+package startup
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// State is a stage of startup. Each state implies every state before it.
+type State string
+
+// Startup states, in order
+const (
+	Starting         State = "starting"
+	ConfigLoaded     State = "config-loaded"
+	TextLoaded       State = "text-loaded"       // Verse text is parsed
+	LexicalReady     State = "lexical-ready"     // Searches can be answered lexically
+	EmbeddingsLoaded State = "embeddings-loaded" // The verse index serves semantic searches
+	ModelReady       State = "model-ready"       // Queries are embedded by the model
+)
+
+// States lists the startup states in order
+var States = []State{Starting, ConfigLoaded, TextLoaded, LexicalReady, EmbeddingsLoaded, ModelReady}
+
+// order returns a state's position in States, or -1
+func order(state State) int {
+	for i, s := range States {
+		if s == state {
+			return i
+		}
+	}
+	return -1
+}
+
+// Transition is a state reached during startup
+type Transition struct {
+	State   State     `json:"state"`
+	At      time.Time `json:"at"`
+	SinceMs int64     `json:"sinceStartMs"`
+}
+
+// Failure is why startup stopped short of the next state
+type Failure struct {
+	Stage State     `json:"stage"` // The state that couldn't be reached
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+// Status is a snapshot of startup for /status
+type Status struct {
+	State       State        `json:"state"`
+	Ready       bool         `json:"ready"` // Reached ModelReady
+	Failure     *Failure     `json:"failure,omitempty"`
+	Transitions []Transition `json:"transitions"`
+}
+
+// Machine moves through the startup states in order
+type Machine struct {
+	mu          sync.Mutex
+	started     time.Time
+	transitions []Transition
+	failure     *Failure
+}
+
+// New creates a machine in the Starting state
+func New() *Machine {
+	now := time.Now()
+	return &Machine{
+		started:     now,
+		transitions: []Transition{{State: Starting, At: now}},
+	}
+}
+
+// State returns the latest state reached
+func (m *Machine) State() State {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.transitions[len(m.transitions)-1].State
+}
+
+// Reached reports whether startup has reached a state
+func (m *Machine) Reached(state State) bool {
+	return order(m.State()) >= order(state)
+}
+
+// Advance moves to the next state. A retried load repeats states already
+// reached, which are ignored, and reaching the state a failure stopped at
+// clears the failure. Skipping a state or advancing past a failure is an error.
+func (m *Machine) Advance(to State) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	current := m.transitions[len(m.transitions)-1].State
+	if order(to) >= 0 && order(to) <= order(current) {
+		return nil
+	}
+	if m.failure != nil {
+		if m.failure.Stage != to {
+			return fmt.Errorf("startup failed at %s: %s", m.failure.Stage, m.failure.Error)
+		}
+		m.failure = nil
+	}
+	if order(to) != order(current)+1 {
+		return fmt.Errorf("invalid startup transition %s -> %s", current, to)
+	}
+
+	now := time.Now()
+	transition := Transition{State: to, At: now, SinceMs: now.Sub(m.started).Milliseconds()}
+	m.transitions = append(m.transitions, transition)
+	log.Info().
+		Str("state", string(to)).
+		Str("from", string(current)).
+		Int64("sinceStartMs", transition.SinceMs).
+		Msg("Startup state reached")
+	return nil
+}
+
+// Fail records why the next state couldn't be reached. Startup stays in
+// its current state; only the first failure is kept.
+func (m *Machine) Fail(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.failure != nil {
+		return
+	}
+	current := order(m.transitions[len(m.transitions)-1].State)
+	stage := States[current]
+	if current+1 < len(States) {
+		stage = States[current+1]
+	}
+	m.failure = &Failure{Stage: stage, Error: err.Error(), At: time.Now()}
+	log.Error().Err(err).Str("stage", string(stage)).Msg("Startup failed")
+}
+
+// Status returns a snapshot of the machine
+func (m *Machine) Status() Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	status := Status{
+		State:       m.transitions[len(m.transitions)-1].State,
+		Transitions: append([]Transition(nil), m.transitions...),
+	}
+	status.Ready = status.State == ModelReady
+	if m.failure != nil {
+		failure := *m.failure
+		status.Failure = &failure
+	}
+	return status
+}
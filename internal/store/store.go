@@ -0,0 +1,208 @@
+// Package store is the persistence layer for small server state: privacy
+// settings, relevance feedback, saved result sets and cached question sets.
+// Services read and write JSON documents by bucket and key through the Store
+// interface, so they don't handle files themselves and a backend can be
+// swapped without touching them. Only the files and memory backends exist:
+// an embedded database backend (SQLite or Badger) is still pending, as is
+// moving index snapshots, tags, notes and QoS keys onto the store.
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Backends
+const (
+	BackendFiles  = "files"
+	BackendMemory = "memory"
+)
+
+// ErrNotFound is returned for keys that aren't stored
+var ErrNotFound = errors.New("not found")
+
+// Store holds JSON documents by bucket and key. Implementations must be safe
+// for concurrent use, and Put must never leave a partly written value.
+type Store interface {
+	Get(bucket, key string) ([]byte, error)
+	Put(bucket, key string, value []byte) error
+	Delete(bucket, key string) error
+	Keys(bucket string) ([]string, error) // Sorted
+}
+
+// Open opens a store with the named backend. Files are kept under dir.
+func Open(backend, dir string) (Store, error) {
+	switch backend {
+	case "", BackendFiles:
+		return Files(dir), nil
+	case BackendMemory:
+		return Memory(), nil
+	default:
+		return nil, fmt.Errorf("unknown store backend: %s (use files or memory)", backend)
+	}
+}
+
+// validKey rejects buckets and keys that can't safely name a file. Every
+// backend applies it, so switching backends never changes which keys work.
+func validKey(bucket, key string) error {
+	for _, name := range []string{bucket, key} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) || strings.ContainsRune(name, 0) {
+			return fmt.Errorf("invalid store key: %q/%q", bucket, key)
+		}
+	}
+	return nil
+}
+
+// fileStore keeps each document in <dir>/<bucket>/<key>.json
+type fileStore struct {
+	dir string
+}
+
+// Files returns a store keeping each document in its own file,
+// <dir>/<bucket>/<key>.json, replaced atomically on every write
+func Files(dir string) Store {
+	return &fileStore{dir: dir}
+}
+
+func (s *fileStore) path(bucket, key string) string {
+	return filepath.Join(s.dir, bucket, key+".json")
+}
+
+func (s *fileStore) Get(bucket, key string) ([]byte, error) {
+	if err := validKey(bucket, key); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(s.path(bucket, key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+// Put writes a temporary file and renames it, so a crash never leaves a
+// truncated document
+func (s *fileStore) Put(bucket, key string, value []byte) error {
+	if err := validKey(bucket, key); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(s.dir, bucket), 0755); err != nil {
+		return fmt.Errorf("failed to create %s directory: %w", bucket, err)
+	}
+	path := s.path(bucket, key)
+	tmp, err := os.CreateTemp(filepath.Dir(path), key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(value); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Delete(bucket, key string) error {
+	if err := validKey(bucket, key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(bucket, key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (s *fileStore) Keys(bucket string) ([]string, error) {
+	if err := validKey(bucket, "_"); err != nil {
+		return nil, err
+	}
+	files, err := os.ReadDir(filepath.Join(s.dir, bucket))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, file := range files {
+		if name, ok := strings.CutSuffix(file.Name(), ".json"); ok && !file.IsDir() {
+			keys = append(keys, name)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+// memoryStore keeps documents in maps, for state that needn't outlive the
+// process
+type memoryStore struct {
+	mu      sync.RWMutex
+	buckets map[string]map[string][]byte
+}
+
+// Memory returns a store that keeps documents in memory only
+func Memory() Store {
+	return &memoryStore{buckets: make(map[string]map[string][]byte)}
+}
+
+func (s *memoryStore) Get(bucket, key string) ([]byte, error) {
+	if err := validKey(bucket, key); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.buckets[bucket][key]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+func (s *memoryStore) Put(bucket, key string, value []byte) error {
+	if err := validKey(bucket, key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buckets[bucket] == nil {
+		s.buckets[bucket] = make(map[string][]byte)
+	}
+	s.buckets[bucket][key] = append([]byte(nil), value...)
+	return nil
+}
+
+func (s *memoryStore) Delete(bucket, key string) error {
+	if err := validKey(bucket, key); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.buckets[bucket], key)
+	return nil
+}
+
+func (s *memoryStore) Keys(bucket string) ([]string, error) {
+	if err := validKey(bucket, "_"); err != nil {
+		return nil, err
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	keys := make([]string, 0, len(s.buckets[bucket]))
+	for key := range s.buckets[bucket] {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
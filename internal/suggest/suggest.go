@@ -0,0 +1,193 @@
+// Package suggest completes partially typed search box input: book names,
+// chapter and verse references, and queries other callers search for often.
+package suggest
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+)
+
+// Kinds of suggestion
+const (
+	KindReference = "reference"
+	KindQuery     = "query"
+	KindTopic     = "topic"
+)
+
+const (
+	// MinPopularity is how many times a query must be searched before it is
+	// suggested to anyone, so one caller's unusual query never leaks to others
+	MinPopularity = 3
+	// maxQueries bounds the popular query trie; once full, only queries
+	// already present are counted
+	maxQueries = 10000
+)
+
+// Suggestion is one completion of the input
+type Suggestion struct {
+	Text  string  `json:"text"`
+	Kind  string  `json:"kind"`
+	Count int     `json:"count,omitempty"` // Times searched, for query suggestions
+	Score float32 `json:"score,omitempty"` // Similarity to the input, for semantic topic suggestions
+}
+
+// VerseCounter reports how many verses a chapter has, or 0 if unknown
+type VerseCounter func(book string, chapter int) int
+
+// Suggester completes references from the canon and queries from the
+// searches it has recorded
+type Suggester struct {
+	books  *trie
+	verses VerseCounter
+
+	mu      sync.RWMutex
+	queries *trie
+}
+
+// referencePattern splits "John 3:1" into a book, a chapter and an optional
+// verse prefix. The book must end in a letter so "1 John" isn't read as
+// chapter 1 of an empty book.
+var referencePattern = regexp.MustCompile(`^(.*[^\d\s:.])\.?\s*(\d+)(?:\s*([:.])\s*(\d*))?$`)
+
+// New creates a suggester. verses supplies verse counts for verse completions.
+func New(verses VerseCounter) *Suggester {
+	books := newTrie()
+	for i, book := range canon.Books {
+		// Weighted so completions come out in canonical order
+		weight := len(canon.Books) - i
+		books.add(bookKey(book.Name), book.Name, weight)
+		books.add(bookKey(book.ID), book.Name, weight)
+		for _, abbr := range book.Abbreviations {
+			books.add(abbr, book.Name, weight)
+		}
+	}
+	return &Suggester{books: books, verses: verses, queries: newTrie()}
+}
+
+// Suggest returns up to limit completions of input, references first
+func (s *Suggester) Suggest(input string, limit int) []Suggestion {
+	input = strings.TrimSpace(input)
+	if input == "" || limit <= 0 {
+		return []Suggestion{}
+	}
+
+	suggestions := append([]Suggestion{}, s.references(input, limit)...)
+	if len(suggestions) < limit {
+		suggestions = append(suggestions, s.popular(input, limit-len(suggestions))...)
+	}
+	return suggestions
+}
+
+// Record counts a searched query towards popular query suggestions
+func (s *Suggester) Record(query string) {
+	key := normalizeQuery(query)
+	if key == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queries.size >= maxQueries && s.queries.get(key) == nil {
+		return
+	}
+	s.queries.add(key, key, 1)
+}
+
+// references completes a book name, or a chapter or verse of a named book
+func (s *Suggester) references(input string, limit int) []Suggestion {
+	if m := referencePattern.FindStringSubmatch(input); m != nil {
+		book, ok := canon.Lookup(m[1])
+		if !ok {
+			return nil
+		}
+		if m[3] != "" {
+			chapter, _ := strconv.Atoi(m[2])
+			return s.verseCompletions(book, chapter, m[4], limit)
+		}
+		return s.chapterCompletions(book, m[2], limit)
+	}
+
+	var suggestions []Suggestion
+	seen := make(map[string]bool)
+	for _, e := range s.books.complete(bookKey(input)) {
+		if seen[e.value] {
+			continue
+		}
+		seen[e.value] = true
+		suggestions = append(suggestions, Suggestion{Text: e.value, Kind: KindReference})
+	}
+	if len(suggestions) == 1 {
+		// The input names a single book, so its chapters come next
+		book, _ := canon.Lookup(suggestions[0].Text)
+		suggestions = append(suggestions, s.chapterCompletions(book, "", limit-1)...)
+	}
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions
+}
+
+// chapterCompletions lists a book's chapters whose number starts with prefix.
+// When prefix names a chapter outright, its verses follow.
+func (s *Suggester) chapterCompletions(book *canon.Book, prefix string, limit int) []Suggestion {
+	var suggestions []Suggestion
+	for chapter := 1; chapter <= book.Chapters && len(suggestions) < limit; chapter++ {
+		if strings.HasPrefix(strconv.Itoa(chapter), prefix) {
+			suggestions = append(suggestions, Suggestion{Text: book.Name + " " + strconv.Itoa(chapter), Kind: KindReference})
+		}
+	}
+	if prefix != "" && len(suggestions) == 1 {
+		chapter, _ := strconv.Atoi(prefix)
+		suggestions = append(suggestions, s.verseCompletions(book, chapter, "", limit-1)...)
+	}
+	return suggestions
+}
+
+// verseCompletions lists a chapter's verses whose number starts with prefix.
+// Verse counts come from the loaded corpus, so there are none until it loads.
+func (s *Suggester) verseCompletions(book *canon.Book, chapter int, prefix string, limit int) []Suggestion {
+	if chapter < 1 || chapter > book.Chapters {
+		return nil
+	}
+	var suggestions []Suggestion
+	count := s.verses(book.Name, chapter)
+	for verse := 1; verse <= count && len(suggestions) < limit; verse++ {
+		if strings.HasPrefix(strconv.Itoa(verse), prefix) {
+			text := book.Name + " " + strconv.Itoa(chapter) + ":" + strconv.Itoa(verse)
+			suggestions = append(suggestions, Suggestion{Text: text, Kind: KindReference})
+		}
+	}
+	return suggestions
+}
+
+// popular completes input from recorded queries searched at least
+// MinPopularity times, most searched first
+func (s *Suggester) popular(input string, limit int) []Suggestion {
+	s.mu.RLock()
+	entries := s.queries.complete(normalizeQuery(input))
+	s.mu.RUnlock()
+
+	var suggestions []Suggestion
+	for _, e := range entries {
+		if e.weight < MinPopularity || len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, Suggestion{Text: e.value, Kind: KindQuery, Count: e.weight})
+	}
+	return suggestions
+}
+
+// bookKey normalizes a book name as canon does: lowercase with spaces and periods removed
+func bookKey(name string) string {
+	name = strings.ToLower(strings.ReplaceAll(name, ".", ""))
+	return strings.Join(strings.Fields(name), "")
+}
+
+// normalizeQuery lowercases a query and collapses its whitespace
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
@@ -0,0 +1,126 @@
+package suggest
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// minTopicSimilarity is the cosine similarity a topic label needs to the
+// input to be suggested semantically
+const minTopicSimilarity = 0.5
+
+// DefaultTopics are the topic labels suggested for search box input
+var DefaultTopics = []string{
+	"Abraham's faith", "adoption", "angels", "anger", "anxiety and worry", "baptism",
+	"blessing", "compassion", "contentment", "courage", "covenant", "creation",
+	"death and grief", "discipleship", "doubt", "enemies", "eternal life", "faith",
+	"faithfulness of God", "family", "fasting", "fear", "forgiveness", "freedom",
+	"generosity", "glory of God", "grace", "gratitude", "greed", "healing", "heaven",
+	"holiness", "Holy Spirit", "hope", "hospitality", "humility", "idolatry",
+	"joy", "judgment", "justice", "kingdom of God", "law", "leadership", "love",
+	"marriage", "mercy", "money", "obedience", "parenting", "patience", "peace",
+	"persecution", "perseverance", "poverty", "prayer", "pride", "prophecy",
+	"providence", "redemption", "repentance", "rest", "resurrection", "righteousness",
+	"sabbath", "sacrifice", "salvation", "sin", "sorrow", "strength", "suffering",
+	"temptation", "thanksgiving", "the church", "the cross", "the poor",
+	"trust", "truth", "unity", "wisdom", "work", "worship",
+}
+
+// BatchEmbedder embeds several texts as search queries
+type BatchEmbedder func(ctx context.Context, texts []string) ([][]float32, error)
+
+// Topics suggests topic labels by prefix and by meaning. Labels are embedded
+// on first semantic use, as queries, so they compare directly with input.
+type Topics struct {
+	labels []string
+	embed  BatchEmbedder
+
+	mu         sync.Mutex
+	embeddings [][]float32
+}
+
+// NewTopics creates a topic suggester over labels
+func NewTopics(labels []string, embed BatchEmbedder) *Topics {
+	return &Topics{labels: labels, embed: embed}
+}
+
+// Prefix returns up to limit labels with a word starting with input
+func (t *Topics) Prefix(input string, limit int) []Suggestion {
+	input = normalizeQuery(input)
+	var suggestions []Suggestion
+	if input == "" {
+		return suggestions
+	}
+	for _, label := range t.labels {
+		if len(suggestions) == limit {
+			break
+		}
+		lower := strings.ToLower(label)
+		if strings.HasPrefix(lower, input) || strings.Contains(lower, " "+input) {
+			suggestions = append(suggestions, Suggestion{Text: label, Kind: KindTopic})
+		}
+	}
+	return suggestions
+}
+
+// Similar returns up to limit labels closest in meaning to a query
+// embedding, most similar first, skipping labels in exclude
+func (t *Topics) Similar(ctx context.Context, query []float32, limit int, exclude map[string]bool) ([]Suggestion, error) {
+	embeddings, err := t.labelEmbeddings(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var suggestions []Suggestion
+	for i, label := range t.labels {
+		if exclude[label] {
+			continue
+		}
+		if score := cosine(query, embeddings[i]); score >= minTopicSimilarity {
+			suggestions = append(suggestions, Suggestion{Text: label, Kind: KindTopic, Score: score})
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return suggestions[i].Score > suggestions[j].Score
+	})
+	if len(suggestions) > limit {
+		suggestions = suggestions[:limit]
+	}
+	return suggestions, nil
+}
+
+// labelEmbeddings embeds the labels once. A failure is retried on the next
+// call, since the model may still be loading.
+func (t *Topics) labelEmbeddings(ctx context.Context) ([][]float32, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.embeddings != nil {
+		return t.embeddings, nil
+	}
+	embeddings, err := t.embed(ctx, t.labels)
+	if err != nil {
+		return nil, err
+	}
+	t.embeddings = embeddings
+	return embeddings, nil
+}
+
+// cosine is the cosine similarity of two vectors, zero if their lengths differ
+func cosine(a, b []float32) float32 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return float32(dot / math.Sqrt(normA*normB))
+}
@@ -0,0 +1,97 @@
+package suggest
+
+import "sort"
+
+// trie maps string keys to weighted values for prefix completion
+type trie struct {
+	root *node
+	size int
+}
+
+type node struct {
+	children map[rune]*node
+	entry    *entry
+}
+
+// entry is a completion stored under a key
+type entry struct {
+	value  string
+	weight int
+}
+
+func newTrie() *trie {
+	return &trie{root: &node{}}
+}
+
+// add stores value under key, adding weight to any existing entry's weight
+func (t *trie) add(key, value string, weight int) *entry {
+	n := t.root
+	for _, r := range key {
+		if n.children == nil {
+			n.children = make(map[rune]*node)
+		}
+		child, ok := n.children[r]
+		if !ok {
+			child = &node{}
+			n.children[r] = child
+		}
+		n = child
+	}
+	if n.entry == nil {
+		n.entry = &entry{value: value}
+		t.size++
+	}
+	n.entry.weight += weight
+	return n.entry
+}
+
+// get returns the entry stored under exactly key
+func (t *trie) get(key string) *entry {
+	n := t.find(key)
+	if n == nil {
+		return nil
+	}
+	return n.entry
+}
+
+// complete returns every entry whose key starts with prefix, heaviest first
+// and then by value
+func (t *trie) complete(prefix string) []entry {
+	n := t.find(prefix)
+	if n == nil {
+		return nil
+	}
+
+	var entries []entry
+	var walk func(*node)
+	walk = func(n *node) {
+		if n.entry != nil {
+			entries = append(entries, *n.entry)
+		}
+		for _, child := range n.children {
+			walk(child)
+		}
+	}
+	walk(n)
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].weight != entries[j].weight {
+			return entries[i].weight > entries[j].weight
+		}
+		return entries[i].value < entries[j].value
+	})
+	return entries
+}
+
+// find returns the node reached by key, or nil
+func (t *trie) find(key string) *node {
+	n := t.root
+	for _, r := range key {
+		child, ok := n.children[r]
+		if !ok {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
@@ -0,0 +1,408 @@
+// Package tags stores free-form, per-namespace verse tags for community
+// annotations and answers "which verses carry this tag" lookups for search.
+package tags
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/dpshade/goscriptureapi/internal/canon"
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/wal"
+)
+
+// DefaultNamespace is used when a request doesn't name one
+const DefaultNamespace = "default"
+
+// compactEvery is how many logged changes accumulate before they're folded
+// into the JSON snapshot
+const compactEvery = 1000
+
+// namePattern restricts tags and namespaces to URL- and query-friendly names
+var namePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,63}$`)
+
+// Store holds tags keyed by namespace and canonical verse reference. Each
+// change is appended to a write-ahead log and periodically compacted into a
+// JSON snapshot.
+type Store struct {
+	path    string
+	changes *wal.Log
+	mu      sync.RWMutex
+
+	verses   map[string]map[string]map[string]bool // namespace -> "John 3:16" -> tags
+	tagged   map[string]map[string]map[string]bool // namespace -> tag -> "John 3:16"
+	chapters map[string]map[string]map[string]int  // namespace -> tag -> "John 3" -> tagged verse count
+}
+
+// fileFormat is the on-disk layout: namespace -> verse -> tags
+type fileFormat struct {
+	Namespaces map[string]map[string][]string `json:"namespaces"`
+}
+
+// logEntry is one change in the write-ahead log
+type logEntry struct {
+	Op        string   `json:"op"` // "add" or "remove"
+	Namespace string   `json:"namespace"`
+	Verses    []string `json:"verses"`
+	Tags      []string `json:"tags"`
+}
+
+// NewStore opens the tag store at path, loading the snapshot if it exists and
+// replaying changes logged since
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:     path,
+		verses:   make(map[string]map[string]map[string]bool),
+		tagged:   make(map[string]map[string]map[string]bool),
+		chapters: make(map[string]map[string]map[string]int),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	changes, err := wal.Open(strings.TrimSuffix(path, filepath.Ext(path))+".wal", s.replay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tag log: %w", err)
+	}
+	s.changes = changes
+	return s, nil
+}
+
+// load reads the JSON snapshot, if there is one
+func (s *Store) load() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tags: %w", err)
+	}
+
+	var stored fileFormat
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return fmt.Errorf("failed to parse tags: %w", err)
+	}
+	for namespace, verses := range stored.Namespaces {
+		for verse, tags := range verses {
+			ref, err := reference.Parse(verse)
+			if err != nil {
+				continue
+			}
+			for _, tag := range tags {
+				s.add(namespace, ref, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// replay applies a logged change on startup
+func (s *Store) replay(raw json.RawMessage) error {
+	var entry logEntry
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		return err
+	}
+	for _, verse := range entry.Verses {
+		ref, err := reference.Parse(verse)
+		if err != nil {
+			continue
+		}
+		for _, tag := range entry.Tags {
+			if entry.Op == "remove" {
+				s.remove(entry.Namespace, ref, tag)
+			} else {
+				s.add(entry.Namespace, ref, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// Normalize lowercases a tag or namespace and checks that it is a valid name
+func Normalize(name string) (string, error) {
+	normalized := strings.ToLower(strings.TrimSpace(name))
+	if !namePattern.MatchString(normalized) {
+		return "", fmt.Errorf("invalid name %q: use up to 64 letters, digits, '.', '_' or '-'", name)
+	}
+	return normalized, nil
+}
+
+// Add attaches tags to each verse and logs the change
+func (s *Store) Add(namespace string, verses []reference.Reference, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, verse := range verses {
+		for _, tag := range tags {
+			s.add(namespace, verse, tag)
+		}
+	}
+	return s.logChange("add", namespace, verses, tags)
+}
+
+// Remove detaches tags from each verse and logs the change
+func (s *Store) Remove(namespace string, verses []reference.Reference, tags []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, verse := range verses {
+		for _, tag := range tags {
+			s.remove(namespace, verse, tag)
+		}
+	}
+	return s.logChange("remove", namespace, verses, tags)
+}
+
+// Close compacts the log into the snapshot and closes it
+func (s *Store) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.compact(); err != nil {
+		return err
+	}
+	return s.changes.Close()
+}
+
+// Tags returns the sorted tags on a verse
+func (s *Store) Tags(namespace string, verse reference.Reference) []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tags := make([]string, 0)
+	for tag := range s.verses[namespace][verse.String()] {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Verses returns the verses carrying a tag in canonical order
+func (s *Store) Verses(namespace, tag string) []reference.Reference {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	verses := make([]reference.Reference, 0, len(s.tagged[namespace][tag]))
+	for key := range s.tagged[namespace][tag] {
+		if ref, err := reference.Parse(key); err == nil {
+			verses = append(verses, ref)
+		}
+	}
+	sort.Slice(verses, func(i, j int) bool { return less(verses[i], verses[j]) })
+	return verses
+}
+
+// Namespaces returns every namespace with at least one tag, sorted
+func (s *Store) Namespaces() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	namespaces := make([]string, 0, len(s.verses))
+	for namespace, verses := range s.verses {
+		if len(verses) > 0 {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// Export returns a namespace's tags keyed by verse reference
+func (s *Store) Export(namespace string) map[string][]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	exported := make(map[string][]string, len(s.verses[namespace]))
+	for verse, tagSet := range s.verses[namespace] {
+		tags := make([]string, 0, len(tagSet))
+		for tag := range tagSet {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		exported[verse] = tags
+	}
+	return exported
+}
+
+// Import adds exported tags to a namespace and persists the store. Verses
+// that don't parse and invalid tag names are skipped and counted.
+func (s *Store) Import(namespace string, exported map[string][]string) (imported, skipped int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for verse, tags := range exported {
+		ref, parseErr := reference.Parse(verse)
+		if parseErr != nil || ref.IsRange() || ref.StartVerse == 0 {
+			skipped += len(tags)
+			continue
+		}
+		for _, tag := range tags {
+			name, nameErr := Normalize(tag)
+			if nameErr != nil {
+				skipped++
+				continue
+			}
+			s.add(namespace, ref, name)
+			imported++
+		}
+	}
+	return imported, skipped, s.compact()
+}
+
+// DeleteNamespace removes every tag in a namespace, persists the store, and
+// returns the number of verse tags removed
+func (s *Store) DeleteNamespace(namespace string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := 0
+	for _, tagSet := range s.verses[namespace] {
+		removed += len(tagSet)
+	}
+	delete(s.verses, namespace)
+	delete(s.tagged, namespace)
+	delete(s.chapters, namespace)
+	return removed, s.compact()
+}
+
+// HasTag reports whether a verse carries a tag. A zero verse asks whether any
+// verse of the chapter does, for chapter-granularity search.
+func (s *Store) HasTag(namespace, tag, book string, chapter, verse int) bool {
+	if b, ok := canon.Lookup(book); ok {
+		book = b.Name
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if verse == 0 {
+		return s.chapters[namespace][tag][fmt.Sprintf("%s %d", book, chapter)] > 0
+	}
+	return s.tagged[namespace][tag][fmt.Sprintf("%s %d:%d", book, chapter, verse)]
+}
+
+func (s *Store) add(namespace string, verse reference.Reference, tag string) {
+	key := verse.String()
+	if s.verses[namespace][key][tag] {
+		return
+	}
+
+	if s.verses[namespace] == nil {
+		s.verses[namespace] = make(map[string]map[string]bool)
+		s.tagged[namespace] = make(map[string]map[string]bool)
+		s.chapters[namespace] = make(map[string]map[string]int)
+	}
+	if s.verses[namespace][key] == nil {
+		s.verses[namespace][key] = make(map[string]bool)
+	}
+	if s.tagged[namespace][tag] == nil {
+		s.tagged[namespace][tag] = make(map[string]bool)
+		s.chapters[namespace][tag] = make(map[string]int)
+	}
+
+	s.verses[namespace][key][tag] = true
+	s.tagged[namespace][tag][key] = true
+	s.chapters[namespace][tag][chapterOf(verse)]++
+}
+
+func (s *Store) remove(namespace string, verse reference.Reference, tag string) {
+	key := verse.String()
+	if !s.verses[namespace][key][tag] {
+		return
+	}
+
+	delete(s.verses[namespace][key], tag)
+	if len(s.verses[namespace][key]) == 0 {
+		delete(s.verses[namespace], key)
+	}
+	delete(s.tagged[namespace][tag], key)
+	if len(s.tagged[namespace][tag]) == 0 {
+		delete(s.tagged[namespace], tag)
+	}
+	s.chapters[namespace][tag][chapterOf(verse)]--
+	if s.chapters[namespace][tag][chapterOf(verse)] <= 0 {
+		delete(s.chapters[namespace][tag], chapterOf(verse))
+	}
+}
+
+// logChange appends a change to the log, compacting once enough have accumulated.
+// Callers must hold s.mu.
+func (s *Store) logChange(op, namespace string, verses []reference.Reference, tags []string) error {
+	entry := logEntry{Op: op, Namespace: namespace, Verses: make([]string, len(verses)), Tags: tags}
+	for i, verse := range verses {
+		entry.Verses[i] = verse.String()
+	}
+	if err := s.changes.Append(entry); err != nil {
+		return err
+	}
+	if s.changes.Len() >= compactEvery {
+		return s.compact()
+	}
+	return nil
+}
+
+// compact writes the snapshot and empties the log. Changes are idempotent, so
+// a crash between the two steps only replays changes the snapshot has.
+func (s *Store) compact() error {
+	if err := s.save(); err != nil {
+		return err
+	}
+	return s.changes.Truncate()
+}
+
+// save writes the store atomically so a crash never leaves a truncated file
+func (s *Store) save() error {
+	stored := fileFormat{Namespaces: make(map[string]map[string][]string)}
+	for namespace, verses := range s.verses {
+		if len(verses) == 0 {
+			continue
+		}
+		stored.Namespaces[namespace] = make(map[string][]string, len(verses))
+		for verse, tagSet := range verses {
+			tags := make([]string, 0, len(tagSet))
+			for tag := range tagSet {
+				tags = append(tags, tag)
+			}
+			sort.Strings(tags)
+			stored.Namespaces[namespace][verse] = tags
+		}
+	}
+
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create tags directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write tags: %w", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// chapterOf returns the "Book chapter" key of a verse
+func chapterOf(verse reference.Reference) string {
+	return fmt.Sprintf("%s %d", verse.Book, verse.StartChapter)
+}
+
+// less orders references canonically: book order, then chapter, then verse
+func less(a, b reference.Reference) bool {
+	if a.Book != b.Book {
+		return canon.Position(a.Book) < canon.Position(b.Book)
+	}
+	if a.StartChapter != b.StartChapter {
+		return a.StartChapter < b.StartChapter
+	}
+	return a.StartVerse < b.StartVerse
+}
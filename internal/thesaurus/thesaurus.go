@@ -0,0 +1,240 @@
+// Package thesaurus maps modern words to their King James equivalents and
+// back ("love" and "charity", "donkey" and "ass"), so searches in modern
+// English find archaic wording. Query expansion rewrites queries with it, and
+// lexical scoring counts a synonym as the word it stands for.
+package thesaurus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// builtin maps modern words and phrases to their King James equivalents,
+// most common first
+var builtin = map[string][]string{
+	"love":        {"charity"},
+	"donkey":      {"ass"},
+	"kindness":    {"lovingkindness"},
+	"holy spirit": {"holy ghost"},
+	"you":         {"thee", "ye"},
+	"your":        {"thy", "thine"},
+	"has":         {"hath"},
+	"does":        {"doth"},
+	"says":        {"saith"},
+	"slave":       {"servant", "bondservant"},
+	"immediately": {"straightway"},
+	"perhaps":     {"peradventure"},
+	"lamp":        {"candle"},
+	"lampstand":   {"candlestick"},
+	"food":        {"meat"},
+	"grain":       {"corn"},
+	"teacher":     {"master"},
+	"gossip":      {"talebearer"},
+	"stubborn":    {"stiffnecked"},
+	"pregnant":    {"with child"},
+	"kill":        {"slay"},
+	"killed":      {"slain"},
+	"humble":      {"lowly"},
+	"awesome":     {"terrible"},
+	"corpse":      {"carcase"},
+	"sheol":       {"hell"},
+	"hades":       {"hell"},
+	"worry":       {"take thought"},
+}
+
+// Entry is a term with its synonyms
+type Entry struct {
+	Term     string   `json:"term"`
+	Synonyms []string `json:"synonyms"`
+}
+
+// Thesaurus looks up synonyms in either direction. It is immutable and safe
+// for concurrent use.
+type Thesaurus struct {
+	synonyms map[string][]string
+	patterns map[string]*regexp.Regexp // Matches each term as whole words
+	modern   map[string][]string       // The mapping it was built from
+}
+
+// Default returns the built-in thesaurus
+func Default() *Thesaurus {
+	return New(nil)
+}
+
+// New returns the built-in thesaurus extended with more modern terms and
+// their equivalents. An extra term's equivalents are added to any built-in
+// ones.
+func New(extra map[string][]string) *Thesaurus {
+	modern := make(map[string][]string, len(builtin)+len(extra))
+	for term, equivalents := range builtin {
+		modern[term] = append([]string(nil), equivalents...)
+	}
+	for term, equivalents := range extra {
+		term = normalize(term)
+		for _, equivalent := range equivalents {
+			equivalent = normalize(equivalent)
+			if term == "" || equivalent == "" || equivalent == term || contains(modern[term], equivalent) {
+				continue
+			}
+			modern[term] = append(modern[term], equivalent)
+		}
+	}
+
+	terms := make([]string, 0, len(modern))
+	for term := range modern {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	// Modern terms are read both ways, in a fixed order
+	t := &Thesaurus{
+		synonyms: make(map[string][]string),
+		patterns: make(map[string]*regexp.Regexp),
+		modern:   modern,
+	}
+	for _, term := range terms {
+		for _, equivalent := range modern[term] {
+			t.add(term, equivalent)
+			t.add(equivalent, term)
+		}
+	}
+	for term := range t.synonyms {
+		t.patterns[term] = regexp.MustCompile(`\b` + regexp.QuoteMeta(term) + `\b`)
+	}
+	return t
+}
+
+// Load reads a JSON object of modern terms to their equivalents, such as
+// {"anxious": ["careful"]}, and extends the built-in thesaurus with it
+func Load(path string) (*Thesaurus, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read thesaurus: %w", err)
+	}
+	var extra map[string][]string
+	if err := json.Unmarshal(data, &extra); err != nil {
+		return nil, fmt.Errorf("failed to parse thesaurus: %w", err)
+	}
+	for term, equivalents := range extra {
+		if normalize(term) == "" || len(equivalents) == 0 {
+			return nil, fmt.Errorf("thesaurus term %q has no equivalents", term)
+		}
+	}
+	return New(extra), nil
+}
+
+// add records one direction of a synonym pair
+func (t *Thesaurus) add(term, synonym string) {
+	if !contains(t.synonyms[term], synonym) {
+		t.synonyms[term] = append(t.synonyms[term], synonym)
+	}
+}
+
+// Synonyms returns a term's synonyms, most common first, or nil
+func (t *Thesaurus) Synonyms(term string) []string {
+	return t.synonyms[normalize(term)]
+}
+
+// Entries returns every modern term with its equivalents, in term order
+func (t *Thesaurus) Entries() []Entry {
+	entries := make([]Entry, 0, len(t.modern))
+	for term, equivalents := range t.modern {
+		entries = append(entries, Entry{Term: term, Synonyms: equivalents})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Term < entries[j].Term
+	})
+	return entries
+}
+
+// Len returns the number of modern terms
+func (t *Thesaurus) Len() int {
+	return len(t.modern)
+}
+
+// Reformulations rewrites a query with synonyms: once with every matched
+// term replaced, then once per single substitution, up to limit. A query
+// with no thesaurus terms has none.
+func (t *Thesaurus) Reformulations(query string, limit int) []string {
+	lowered := strings.ToLower(query)
+	type match struct {
+		term       string
+		start, end int
+	}
+	var matches []match
+	for term, pattern := range t.patterns {
+		if loc := pattern.FindStringIndex(lowered); loc != nil {
+			matches = append(matches, match{term, loc[0], loc[1]})
+		}
+	}
+	// Of overlapping matches, keep the earlier, then the longer
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].start != matches[j].start {
+			return matches[i].start < matches[j].start
+		}
+		return matches[i].end > matches[j].end
+	})
+	kept := matches[:0]
+	for _, m := range matches {
+		if len(kept) == 0 || m.start >= kept[len(kept)-1].end {
+			kept = append(kept, m)
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+
+	replace := func(picks map[int]string) string {
+		var b strings.Builder
+		last := 0
+		for i, m := range kept {
+			if synonym, ok := picks[i]; ok {
+				b.WriteString(lowered[last:m.start])
+				b.WriteString(synonym)
+				last = m.end
+			}
+		}
+		b.WriteString(lowered[last:])
+		return b.String()
+	}
+
+	var reformulations []string
+	seen := map[string]bool{lowered: true}
+	add := func(reformulation string) {
+		if !seen[reformulation] && len(reformulations) < limit {
+			seen[reformulation] = true
+			reformulations = append(reformulations, reformulation)
+		}
+	}
+	if len(kept) > 1 {
+		all := make(map[int]string, len(kept))
+		for i, m := range kept {
+			all[i] = t.synonyms[m.term][0]
+		}
+		add(replace(all))
+	}
+	for i, m := range kept {
+		for _, synonym := range t.synonyms[m.term] {
+			add(replace(map[int]string{i: synonym}))
+		}
+	}
+	return reformulations
+}
+
+// normalize lowercases a term and collapses its whitespace
+func normalize(term string) string {
+	return strings.Join(strings.Fields(strings.ToLower(term)), " ")
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+// Package wal is an append-only, fsynced write-ahead log of JSON records. The
+// mutable stores log each change here so it's durable immediately, and
+// periodically compact the log into their snapshot file.
+package wal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Log appends JSON records, one per line, to a file
+type Log struct {
+	mu      sync.Mutex
+	file    *os.File
+	records int
+}
+
+// Open opens the log at path for appending, replaying existing records into
+// apply first. A torn final record from a crash mid-write is discarded.
+func Open(path string, apply func(json.RawMessage) error) (*Log, error) {
+	records, valid, err := replay(path, apply)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open log: %w", err)
+	}
+
+	// Drop a torn tail so new records don't follow garbage, and terminate a
+	// final record whose newline never made it to disk
+	if err := file.Truncate(valid); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to repair log: %w", err)
+	}
+	if valid > 0 {
+		last := make([]byte, 1)
+		if _, err := file.ReadAt(last, valid-1); err == nil && last[0] != '\n' {
+			if _, err := file.Write([]byte{'\n'}); err != nil {
+				file.Close()
+				return nil, fmt.Errorf("failed to repair log: %w", err)
+			}
+		}
+	}
+	return &Log{file: file, records: records}, nil
+}
+
+// Append durably writes a record
+func (l *Log) Append(record interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if _, err := l.file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append to log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+	l.records++
+	return nil
+}
+
+// Len returns the number of records since the last truncation
+func (l *Log) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.records
+}
+
+// Truncate empties the log once its records are saved in a snapshot
+func (l *Log) Truncate() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.file.Truncate(0); err != nil {
+		return fmt.Errorf("failed to truncate log: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return fmt.Errorf("failed to sync log: %w", err)
+	}
+	l.records = 0
+	return nil
+}
+
+// Close closes the log file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// replay feeds each complete record to apply. It returns how many there
+// were and the length of the file up to the end of the last good record.
+func replay(path string, apply func(json.RawMessage) error) (records int, valid int64, err error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to read log: %w", err)
+	}
+
+	for offset := 0; offset < len(data); {
+		end := bytes.IndexByte(data[offset:], '\n')
+		if end < 0 {
+			end = len(data)
+		} else {
+			end += offset + 1
+		}
+
+		line := bytes.TrimSpace(data[offset:end])
+		if len(line) > 0 {
+			if !json.Valid(line) {
+				// Only the last write can be torn by a crash
+				log.Warn().Str("path", path).Int("record", records+1).Msg("Discarding torn write-ahead log record")
+				break
+			}
+			if err := apply(json.RawMessage(line)); err != nil {
+				return records, valid, fmt.Errorf("failed to replay log record %d: %w", records+1, err)
+			}
+			records++
+		}
+		offset = end
+		valid = int64(end)
+	}
+	return records, valid, nil
+}
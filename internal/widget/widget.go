@@ -0,0 +1,77 @@
+// Package widget holds the embeddable search box script and the publishable
+// keys that restrict which websites may embed it.
+package widget
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// Script renders a search box backed by /widget/search. It is served as
+// /widget.js and configured by its script tag's data-key attribute.
+//
+//go:embed widget.js
+var Script []byte
+
+// Keys maps publishable widget keys to the origins allowed to use them. Keys
+// appear in page source, so they identify a site rather than authenticate it.
+type Keys map[string][]string
+
+// Load reads widget keys from a JSON object of key -> allowed origins
+func Load(path string) (Keys, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read widget keys: %w", err)
+	}
+	var keys Keys
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return nil, fmt.Errorf("failed to parse widget keys: %w", err)
+	}
+
+	for key, origins := range keys {
+		if len(origins) == 0 {
+			return nil, fmt.Errorf("widget key %s allows no origins", key)
+		}
+		for i, origin := range origins {
+			normalized, ok := normalizeOrigin(origin)
+			if !ok {
+				return nil, fmt.Errorf("widget key %s: %q is not an origin such as https://example.org", key, origin)
+			}
+			origins[i] = normalized
+		}
+	}
+	return keys, nil
+}
+
+// Known reports whether key is a configured widget key
+func (k Keys) Known(key string) bool {
+	_, ok := k[key]
+	return ok
+}
+
+// Allows reports whether a page on origin may use key
+func (k Keys) Allows(key, origin string) bool {
+	origin, ok := normalizeOrigin(origin)
+	if !ok {
+		return false
+	}
+	for _, allowed := range k[key] {
+		if allowed == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeOrigin lowercases an http(s) origin, rejecting anything with a path
+func normalizeOrigin(origin string) (string, bool) {
+	u, err := url.Parse(strings.TrimSuffix(origin, "/"))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+		return "", false
+	}
+	return strings.ToLower(u.Scheme + "://" + u.Host), true
+}
@@ -0,0 +1,70 @@
+// Scripture search widget. Embed with:
+//   <script src="https://api.example.org/widget.js" data-key="KEY" async></script>
+// Optional attributes: data-target (id of the element to render into),
+// data-k (results, at most 10) and data-placeholder.
+(function () {
+  var script = document.currentScript;
+  if (!script) return;
+
+  var base = new URL(script.src).origin;
+  var key = script.getAttribute("data-key") || "";
+  var k = script.getAttribute("data-k") || "5";
+  var targetId = script.getAttribute("data-target");
+  var target = targetId ? document.getElementById(targetId) : null;
+
+  var root = document.createElement("div");
+  root.className = "scripture-search";
+  var form = document.createElement("form");
+  var input = document.createElement("input");
+  input.type = "search";
+  input.placeholder = script.getAttribute("data-placeholder") || "Search the Bible";
+  input.setAttribute("aria-label", input.placeholder);
+  var button = document.createElement("button");
+  button.type = "submit";
+  button.textContent = "Search";
+  var list = document.createElement("ol");
+  list.className = "scripture-search-results";
+  form.appendChild(input);
+  form.appendChild(button);
+  root.appendChild(form);
+  root.appendChild(list);
+
+  if (target) {
+    target.appendChild(root);
+  } else {
+    script.parentNode.insertBefore(root, script.nextSibling);
+  }
+
+  function show(items) {
+    list.textContent = "";
+    items.forEach(function (item) {
+      var li = document.createElement("li");
+      var ref = document.createElement("strong");
+      ref.textContent = item.reference;
+      li.appendChild(ref);
+      li.appendChild(document.createTextNode(" " + item.text));
+      list.appendChild(li);
+    });
+  }
+
+  form.addEventListener("submit", function (event) {
+    event.preventDefault();
+    var q = input.value.trim();
+    if (!q) return;
+
+    var url = base + "/widget/search?key=" + encodeURIComponent(key) +
+      "&k=" + encodeURIComponent(k) + "&q=" + encodeURIComponent(q);
+    fetch(url)
+      .then(function (res) { return res.json(); })
+      .then(function (body) {
+        if (body.results) {
+          show(body.results);
+        } else {
+          show([{ reference: "", text: (body.error && body.error.message) || "Search failed" }]);
+        }
+      })
+      .catch(function () {
+        show([{ reference: "", text: "Search is unavailable" }]);
+      });
+  });
+})();
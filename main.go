@@ -1,17 +1,41 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/dpshade/goscriptureapi/internal/analytics"
 	"github.com/dpshade/goscriptureapi/internal/api"
+	"github.com/dpshade/goscriptureapi/internal/cluster"
 	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/crossrefs"
+	"github.com/dpshade/goscriptureapi/internal/deprecation"
 	"github.com/dpshade/goscriptureapi/internal/embeddings"
+	"github.com/dpshade/goscriptureapi/internal/feedback"
+	"github.com/dpshade/goscriptureapi/internal/lexicon"
+	"github.com/dpshade/goscriptureapi/internal/notes"
+	"github.com/dpshade/goscriptureapi/internal/privacy"
+	"github.com/dpshade/goscriptureapi/internal/qos"
+	"github.com/dpshade/goscriptureapi/internal/questions"
+	"github.com/dpshade/goscriptureapi/internal/replay"
+	"github.com/dpshade/goscriptureapi/internal/saved"
 	"github.com/dpshade/goscriptureapi/internal/search"
+	"github.com/dpshade/goscriptureapi/internal/startup"
+	"github.com/dpshade/goscriptureapi/internal/store"
+	"github.com/dpshade/goscriptureapi/internal/tags"
+	"github.com/dpshade/goscriptureapi/internal/thesaurus"
+	"github.com/dpshade/goscriptureapi/internal/widget"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/rs/zerolog"
@@ -19,12 +43,84 @@ import (
 )
 
 func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+// serve runs the API server until SIGINT or SIGTERM, exiting 0 once it has
+// shut down. Fatal errors exit through the logger.
+func serve(args []string) int {
+	stages := startup.New()
+
 	// Parse command line flags
-	port := flag.String("port", "8080", "Port to listen on")
-	modelPath := flag.String("model", "", "Path to ONNX model file (optional, will download if not provided)")
-	dataDir := flag.String("data", "./data", "Directory to store cached data")
-	debug := flag.Bool("debug", false, "Enable debug logging")
-	flag.Parse()
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi [serve] [flags]")
+		flags.PrintDefaults()
+	}
+	port := flags.String("port", "8080", "Port to listen on")
+	modelPath := flags.String("model", "", "Path to ONNX model file (optional, will download if not provided)")
+	dataDir := flags.String("data", "./data", "Directory to store cached data")
+	storeBackend := flags.String("store", store.BackendFiles, "Backend for privacy settings, relevance feedback, saved results and cached questions: files (in the data directory) or memory")
+	debug := flags.Bool("debug", false, "Enable debug logging")
+	crossrefsPath := flags.String("crossrefs", "", "Path to a cross-reference dataset (optional, will download if not provided)")
+	questionsBackend := flags.String("questions-backend", "", "Path to a JSON description of an LLM backend for /questions (optional)")
+	lexiconPaths := flags.String("lexicon", "", "Comma-separated Strong's dictionary JSON files (optional, enables /lexicon)")
+	strongsText := flags.String("strongs-text", "", "Path to a Strong's-tagged text for include=strongs (optional)")
+	dailyVerses := flags.String("daily-verses", "", "Path to a JSON array of references for /verse-of-the-day to rotate through (optional)")
+	analyticsLog := flags.Bool("analytics", false, "Log searches and result clicks to the data directory, enabling /feedback and /analytics/top-queries")
+	thesaurusPath := flags.String("thesaurus", "", "Path to a JSON object of modern term -> King James equivalents extending the built-in thesaurus (optional)")
+	relevanceFeedback := flags.Bool("relevance-feedback", false, "Store relevance judgments sent to /feedback and adjust repeated verse queries with them")
+	savedResultsTTL := flags.Duration("saved-results-ttl", 30*24*time.Hour, "Longest a saved result set is kept (0 disables /results)")
+	widgetKeys := flags.String("widget-keys", "", "Path to a JSON object of widget key -> allowed origins (optional, enables /widget/search)")
+	onnxThreads := flags.Int("onnx-threads", 4, "Intra-op thread count for ONNX inference")
+	deterministic := flags.Bool("deterministic", false, "Pin threads and seeds and stamp responses with model hash and index version")
+	seed := flags.Int64("seed", 42, "Seed for any randomized ranking steps")
+	cursorTTL := flags.Duration("cursor-ttl", 5*time.Minute, "How long an unused result cursor stays valid")
+	maxCursors := flags.Int("max-cursors", 1000, "Maximum number of live result cursors")
+	cursorMaxResults := flags.Int("cursor-max-results", 2000, "Maximum results ranked for a paged search")
+	rateLimit := flags.Float64("rate-limit", 0, "Per-client-IP requests/second on /search and /embed (0 disables)")
+	rateBurst := flags.Int("rate-burst", 0, "Per-client-IP burst size (defaults to the rate limit)")
+	trustedProxies := flags.String("trusted-proxies", "", "Comma-separated proxy IPs or CIDR ranges whose X-Forwarded-For identifies the client (default: trust none, using the connection's peer)")
+	shutdownTimeout := flags.Duration("shutdown-timeout", 30*time.Second, "Time to let in-flight requests drain on shutdown")
+	adminTokenEnv := flags.String("admin-token-env", "", "Environment variable holding the bearer token for the /admin endpoints (disabled without it)")
+	maxSearchDuration := flags.Duration("max-search-duration", 10*time.Second, "Longest a request may spend searching before it fails with 504 (0 is unbounded)")
+	waitForModel := flags.Bool("wait-for-model", false, "Start serving only once the embedding model is ready")
+	offline := flags.Bool("offline", false, "Read artifacts, the model and cross-references saved by the fetch command, never downloading")
+	rerankCandidates := flags.Int("rerank-candidates", 200, "Number of quantized candidates to re-rank at full precision")
+	shards := flags.Int("shards", runtime.NumCPU(), "Shards a vector index search fans out across in parallel")
+	routeBooks := flags.Int("route-books", 0, "Scan only the N books nearest an unfiltered query in full, by centroid (0 scans everything)")
+	routeSample := flags.Float64("route-sample", 0.1, "Fraction of the other books' chapters, nearest first, that routed searches also scan")
+	binaryIndex := flags.Bool("binary-index", false, "Retrieve re-rank candidates from a 1-bit sign index instead of the int8 index")
+	int8Query := flags.Bool("int8-query", true, "Quantize queries to int8 for the quantized index scan (false scores float queries against int8 vectors)")
+	scoreFloor := flags.String("score-floor", "", "Per-granularity minimum similarity, e.g. verse=0.2,chapter=0.35")
+	maxK := flags.String("max-k", "", "Per-granularity result cap, e.g. verse=100,chapter=25")
+	searchCacheTTL := flags.Duration("search-cache-ttl", 5*time.Minute, "Edge cache lifetime advertised on GET /search responses (0 disables)")
+	canonicalRedirect := flags.Bool("canonical-redirect", false, "Redirect non-canonical GET /search URLs to their canonical form")
+	compress := flags.Bool("compress", true, "Gzip responses for clients that accept it")
+	snapshots := flags.Bool("snapshots", true, "Persist parsed indices to the data directory for fast restarts")
+	queryCacheSize := flags.Int("query-cache", 1024, "Number of query embeddings to cache (0 disables)")
+	downloadCacheMB := flags.Int64("download-cache-mb", 256, "Megabytes of parsed artifact downloads kept for retrying failed loads (0 disables)")
+	downloadCacheTTL := flags.Duration("download-cache-ttl", 10*time.Minute, "How long a cached artifact download is kept")
+	responseCacheMB := flags.Int64("response-cache-mb", 64, "Megabytes of /search responses kept for repeated queries (0 disables)")
+	responseCacheTTL := flags.Duration("response-cache-ttl", 5*time.Minute, "How long a cached /search response is kept")
+	ensembleModel := flags.String("ensemble-model", "", "Path to a JSON description of a second embedding model for ranking=ensemble (optional)")
+	shedQueueDepth := flags.Int("shed-queue-depth", 16, "Embedding queue depth at which searches are answered from cache or lexically (0 disables)")
+	embeddingProvider := flags.String("embedding-provider", "", "Path to a JSON description of an HTTP embedding API to use instead of ONNX (optional)")
+	inferenceSlots := flags.Int("inference-slots", 0, "Concurrent ONNX inferences; waiting queries are admitted by QoS priority (0 is unbounded)")
+	qosTiers := flags.String("qos-tiers", "", "Path to a JSON description of QoS tiers and the API keys assigned to them (optional)")
+	indices := flags.String("indices", "", "Path to a JSON array of named indices to load alongside verse and chapter (optional)")
+	preload := flags.String("preload", "", "Comma-separated indices to load at startup, e.g. verse,chapter (default: every configured index; verse is always loaded)")
+	lazyLoad := flags.Bool("lazy-load", false, "Load indices left out of -preload on their first search, answering 503 until they are ready")
+	originalEmbeddings := flags.String("original-embeddings", "", "URL of the original-language (Hebrew/Greek) verse embeddings (optional, enables granularity=original)")
+	originalFallback := flags.String("original-fallback", "", "URL of uncompressed original-language embeddings, tried if -original-embeddings fails (optional)")
+	originalText := flags.String("original-text", "", "URL of the original-language verse text for granularity=original")
+	corpusLicenses := flags.String("corpus-licenses", "", "Path to a JSON object of corpus granularity -> license and attribution (optional)")
+	noQueryLog := flags.Bool("no-query-log", false, "Keep query text out of logs by default")
+	hashQueries := flags.Bool("hash-queries", false, "Log a hash of query text instead of the text by default")
+	indexNode := flags.String("index-node", "", "URL of an index node to rank searches on, loading no indices here and forwarding other requests to it (needs -index-token-env)")
+	indexTimeout := flags.Duration("index-timeout", 10*time.Second, "Longest a request to -index-node may take, including its readiness checks")
+	indexTokenEnv := flags.String("index-token-env", "", "Environment variable holding the bearer token stateless API nodes and their index node share (an index node ranks for none without it)")
+	flags.Parse(args)
 
 	// Setup logging
 	zerolog.TimeFieldFormat = time.RFC3339
@@ -34,6 +130,16 @@ func main() {
 	} else {
 		zerolog.SetGlobalLevel(zerolog.InfoLevel)
 	}
+	zerolog.DefaultContextLogger = &log.Logger // For code logging with a context that has no request logger
+
+	limits, err := config.ParseLimits(*scoreFloor, *maxK)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid granularity limits")
+	}
+	proxies, err := config.ParseTrustedProxies(*trustedProxies)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid -trusted-proxies")
+	}
 
 	// Create configuration
 	cfg := &config.Config{
@@ -41,7 +147,110 @@ func main() {
 		ModelPath: *modelPath,
 		DataDir:   *dataDir,
 		Debug:     *debug,
+
+		RerankCandidates:   *rerankCandidates,
+		Int8Query:          *int8Query,
+		BinaryIndex:        *binaryIndex,
+		Shards:             *shards,
+		RouteBooks:         *routeBooks,
+		RouteSample:        *routeSample,
+		ShutdownTimeout:    *shutdownTimeout,
+		MaxSearchDuration:  *maxSearchDuration,
+		WaitForModel:       *waitForModel,
+		Offline:            *offline,
+		CrossRefsPath:      *crossrefsPath,
+		WidgetKeysPath:     *widgetKeys,
+		ONNXThreads:        *onnxThreads,
+		Deterministic:      *deterministic,
+		Seed:               *seed,
+		CursorTTL:          *cursorTTL,
+		MaxCursors:         *maxCursors,
+		CursorMaxResults:   *cursorMaxResults,
+		RateLimit:          *rateLimit,
+		RateBurst:          *rateBurst,
+		TrustedProxies:     proxies,
+		Limits:             limits,
+		SearchCacheTTL:     *searchCacheTTL,
+		CanonicalRedirect:  *canonicalRedirect,
+		Compress:           *compress,
+		Snapshots:          *snapshots,
+		QueryCacheSize:     *queryCacheSize,
+		DownloadCacheBytes: *downloadCacheMB << 20,
+		DownloadCacheTTL:   *downloadCacheTTL,
+		ResponseCacheBytes: *responseCacheMB << 20,
+		ResponseCacheTTL:   *responseCacheTTL,
+		ShedQueueDepth:     *shedQueueDepth,
+		InferenceSlots:     *inferenceSlots,
+		NoQueryLogging:     *noQueryLog,
+		HashQueries:        *hashQueries,
+		IndexNode:          strings.TrimSuffix(*indexNode, "/"),
+		IndexTimeout:       *indexTimeout,
+	}
+
+	if *adminTokenEnv != "" {
+		if cfg.AdminToken = os.Getenv(*adminTokenEnv); cfg.AdminToken == "" {
+			log.Fatal().Str("env", *adminTokenEnv).Msg("The admin token environment variable is not set")
+		}
+	}
+	if *indexTokenEnv != "" {
+		if cfg.IndexToken = os.Getenv(*indexTokenEnv); cfg.IndexToken == "" {
+			log.Fatal().Str("env", *indexTokenEnv).Msg("The index-node token environment variable is not set")
+		}
+	}
+	var indexNodeURL *url.URL
+	if cfg.IndexNode != "" {
+		if cfg.IndexToken == "" {
+			log.Fatal().Msg("-index-node needs -index-token-env, for the token the index node expects")
+		}
+		if indexNodeURL, err = url.Parse(cfg.IndexNode); err != nil || indexNodeURL.Scheme == "" || indexNodeURL.Host == "" {
+			log.Fatal().Str("url", cfg.IndexNode).Msg("Invalid -index-node URL")
+		}
+	}
+	if *embeddingProvider != "" {
+		if cfg.EmbeddingProvider, err = config.LoadEmbeddingProvider(*embeddingProvider); err != nil {
+			log.Fatal().Err(err).Msg("Invalid embedding provider")
+		}
+	}
+	if *indices != "" {
+		if cfg.Indices, err = config.LoadIndices(*indices); err != nil {
+			log.Fatal().Err(err).Msg("Invalid indices")
+		}
+	}
+	if *originalEmbeddings != "" {
+		if *originalText == "" {
+			log.Fatal().Msg("-original-embeddings needs -original-text")
+		}
+		cfg.Original = config.IndexSource{
+			Name:          search.GranularityOriginal,
+			EmbeddingsURL: *originalEmbeddings,
+			FallbackURL:   *originalFallback,
+			TextURL:       *originalText,
+		}
+	}
+	if *preload != "" {
+		known := make(map[string]bool)
+		for _, source := range search.ConfiguredSources(cfg) {
+			known[source.Granularity] = true
+		}
+		cfg.Preload = []string{}
+		for _, name := range strings.Split(*preload, ",") {
+			if name = strings.TrimSpace(name); name == "" {
+				continue
+			}
+			if !known[name] {
+				log.Fatal().Str("index", name).Msg("Unknown index in -preload")
+			}
+			cfg.Preload = append(cfg.Preload, name)
+		}
 	}
+	cfg.LazyLoad = *lazyLoad
+	if *corpusLicenses != "" {
+		if cfg.CorpusLicenses, err = config.LoadCorpusLicenses(*corpusLicenses); err != nil {
+			log.Fatal().Err(err).Msg("Invalid corpus licenses")
+		}
+	}
+
+	advance(stages, startup.ConfigLoaded)
 
 	// Initialize embedding service
 	log.Info().Msg("Initializing EmbeddingGemma service...")
@@ -56,52 +265,313 @@ func main() {
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to initialize search service")
 	}
+	if cfg.IndexNode != "" {
+		searchService.SetRemote(search.NewRemoteIndex(cfg.IndexNode, cfg.IndexToken, cfg.IndexTimeout))
+	}
 
-	// Preload indices in background
-	go func() {
-		log.Info().Msg("Preloading verse embeddings...")
-		if err := searchService.PreloadGranularity("verse"); err != nil {
-			log.Error().Err(err).Msg("Failed to preload verse embeddings")
-		} else {
-			log.Info().Msg("Verse embeddings loaded successfully")
+	// A second model for ensemble ranking loads alongside the primary one
+	var ensembleEmbedder *embeddings.ModelEmbedder
+	if *ensembleModel != "" {
+		model, err := config.LoadEnsembleModel(*ensembleModel)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid ensemble model")
+		}
+		ensembleEmbedder = embeddings.NewModelEmbedder(cfg, ensembleSpec(model))
+		searchService.SetEnsemble(model, ensembleEmbedder)
+	}
+
+	if *thesaurusPath != "" {
+		th, err := thesaurus.Load(*thesaurusPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid thesaurus")
+		}
+		searchService.SetThesaurus(th)
+	}
+
+	// Open the verse tag store before loading so tag filters are ready with the index
+	tagStore, err := tags.NewStore(filepath.Join(cfg.DataDir, "tags", "tags.json"))
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open tag store")
+	}
+	searchService.SetTags(tagStore)
+
+	noteStore, err := notes.NewStore(filepath.Join(cfg.DataDir, "notes", "notes.json"), embeddingService)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open note store")
+	}
+
+	dataStore, err := store.Open(*storeBackend, cfg.DataDir)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid store")
+	}
+
+	privacyStore, err := privacy.NewStore(dataStore, privacy.Policy{
+		NoQueryLogging: cfg.NoQueryLogging,
+		HashOnly:       cfg.HashQueries,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to open privacy settings")
+	}
+
+	// The verse load drives startup through text, lexical search and embeddings
+	searchService.SetProgress(func(granularity, stage string) {
+		if granularity != "verse" {
+			return
 		}
+		switch stage {
+		case search.StageText:
+			advance(stages, startup.TextLoaded)
+		case search.StageLexical:
+			advance(stages, startup.LexicalReady)
+		case search.StageEmbeddings:
+			advance(stages, startup.EmbeddingsLoaded)
+			go func() {
+				if err := embeddingService.WaitReady(context.Background()); err != nil {
+					stages.Fail(err)
+					return
+				}
+				advance(stages, startup.ModelReady)
+			}()
+		}
+	})
+
+	// Preload indices in background, abandoning downloads on shutdown
+	loadCtx, stopLoads := context.WithCancel(context.Background())
+	defer stopLoads()
+	if remote := searchService.Remote(); remote != nil {
+		// A stateless node loads nothing: it is ready once the index node is
+		go func() {
+			log.Info().Str("indexNode", remote.URL()).Msg("Waiting for the index node...")
+			if err := remote.WaitReady(loadCtx, time.Second); err != nil {
+				return
+			}
+			for _, state := range []startup.State{startup.TextLoaded, startup.LexicalReady, startup.EmbeddingsLoaded} {
+				advance(stages, state)
+			}
+			if err := embeddingService.WaitReady(loadCtx); err != nil {
+				stages.Fail(err)
+				return
+			}
+			advance(stages, startup.ModelReady)
+		}()
+	} else {
+		go func() {
+			log.Info().Msg("Preloading verse embeddings...")
+			if err := searchService.PreloadGranularity(loadCtx, "verse"); err != nil {
+				log.Error().Err(err).Msg("Failed to preload verse embeddings")
+				stages.Fail(err)
+			} else {
+				log.Info().Msg("Verse embeddings loaded successfully")
+			}
+
+			if err := searchService.LoadEnsemble(loadCtx); err != nil {
+				log.Error().Err(err).Msg("Failed to load ensemble embeddings")
+			}
+
+			// Verse is loaded above; the rest load as -preload selects
+			for _, source := range search.ConfiguredSources(cfg) {
+				name := source.Granularity
+				if name == "verse" || !cfg.Preloads(name) {
+					continue
+				}
+				log.Info().Str("index", name).Msg("Preloading index...")
+				if err := searchService.PreloadGranularity(loadCtx, name); err != nil {
+					log.Error().Err(err).Str("index", name).Msg("Failed to preload index")
+				} else {
+					log.Info().Str("index", name).Msg("Index loaded successfully")
+				}
+			}
+		}()
 
-		log.Info().Msg("Preloading chapter embeddings...")
-		if err := searchService.PreloadGranularity("chapter"); err != nil {
-			log.Error().Err(err).Msg("Failed to preload chapter embeddings")
-		} else {
-			log.Info().Msg("Chapter embeddings loaded successfully")
+		// Ingested corpora are local snapshots, so they needn't wait for downloads
+		go func() {
+			if err := searchService.LoadIngestedCorpora(); err != nil {
+				log.Error().Err(err).Msg("Failed to load ingested corpora")
+			}
+		}()
+	}
+
+	// Load cross-references in background
+	crossrefService := crossrefs.NewService(cfg)
+	go func() {
+		if err := crossrefService.Load(); err != nil {
+			log.Error().Err(err).Msg("Failed to load cross-references")
 		}
 	}()
 
+	var tiers *qos.Tiers
+	if *qosTiers != "" {
+		if tiers, err = qos.Load(*qosTiers); err != nil {
+			log.Fatal().Err(err).Msg("Invalid QoS tiers")
+		}
+	}
+
+	deprecations := deprecation.NewRegistry(api.DeprecatedSurfaces...)
+
 	// Setup Echo server
 	e := echo.New()
 	e.HideBanner = true
 	e.HidePort = true
+	e.JSONSerializer = api.LocalizedSerializer{}
+	e.HTTPErrorHandler = api.ErrorHandler
+	e.IPExtractor = api.IPExtractor(cfg.TrustedProxies)
 
 	// Middleware
-	e.Use(middleware.Logger())
+	e.Use(api.RequestID())
+	e.Use(api.RequestLogger(privacyStore))
+	e.Use(api.QoS(tiers))
+	e.Use(api.DeprecationHeaders(deprecations))
 	e.Use(middleware.Recover())
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
-		AllowOrigins: []string{"*"},
-		AllowMethods: []string{echo.GET, echo.POST, echo.OPTIONS},
-		AllowHeaders: []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept},
+		AllowOrigins:  []string{"*"},
+		AllowMethods:  []string{echo.GET, echo.POST, echo.PUT, echo.DELETE, echo.OPTIONS},
+		AllowHeaders:  []string{echo.HeaderOrigin, echo.HeaderContentType, echo.HeaderAccept, "If-None-Match", privacy.KeyHeader},
+		ExposeHeaders: []string{echo.HeaderXRequestID, api.TierHeader, "Deprecation", "Sunset", "ETag"},
 	}))
+	if cfg.Compress {
+		e.Use(middleware.GzipWithConfig(middleware.GzipConfig{MinLength: 1024}))
+	}
 
 	// API handler
-	apiHandler := api.NewHandler(searchService)
+	apiHandler := api.NewHandler(cfg, searchService, crossrefService, tagStore, noteStore, privacyStore)
+	rateLimiter := api.RateLimiter(cfg, tiers)
+	searchDeadline := api.SearchDeadline(cfg.MaxSearchDuration)
+	adminAuth := api.AdminAuth(cfg.AdminToken)
+	apiHandler.SetDeprecations(deprecations)
+	if indexNodeURL != nil {
+		apiHandler.SetIndexNode(indexNodeURL)
+		e.Use(apiHandler.Stateless())
+	}
+	apiHandler.SetStartup(stages)
+	if cfg.WidgetKeysPath != "" {
+		keys, err := widget.Load(cfg.WidgetKeysPath)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid widget keys")
+		}
+		apiHandler.SetWidgetKeys(keys)
+	}
+	if *questionsBackend != "" {
+		backend, err := config.LoadQuestionsBackend(*questionsBackend)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid questions backend")
+		}
+		apiHandler.SetQuestions(questions.New(backend, dataStore))
+	}
+	if *lexiconPaths != "" {
+		service := lexicon.NewService()
+		if err := service.Load(strings.Split(*lexiconPaths, ","), *strongsText); err != nil {
+			log.Fatal().Err(err).Msg("Failed to load lexicon")
+		}
+		apiHandler.SetLexicon(service)
+	}
+	if *dailyVerses != "" {
+		refs, err := config.LoadDailyVerses(*dailyVerses)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Invalid daily verses")
+		}
+		apiHandler.SetDailyVerses(refs)
+	}
+	var queryLog *analytics.Log
+	if *analyticsLog {
+		queryLog, err = analytics.Open(filepath.Join(cfg.DataDir, "analytics", "queries.jsonl"))
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open analytics log")
+		}
+		apiHandler.SetAnalytics(queryLog)
+	}
+	if *relevanceFeedback {
+		judgments, err := feedback.NewStore(dataStore)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open relevance feedback")
+		}
+		searchService.SetFeedback(judgments)
+		apiHandler.SetFeedback(judgments)
+	}
+	if *savedResultsTTL > 0 {
+		results, err := saved.NewStore(dataStore, *savedResultsTTL)
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to open saved results")
+		}
+		apiHandler.SetSavedResults(results)
+	}
 
 	// Routes
 	e.GET("/health", apiHandler.Health)
 	e.GET("/status", apiHandler.Status)
-	e.GET("/search", apiHandler.Search)   // Support GET for search
-	e.POST("/search", apiHandler.Search)  // Keep POST support
-	e.POST("/embed", apiHandler.Embed)
+	e.GET("/meta", apiHandler.Meta)
+	e.GET("/search", apiHandler.Search, rateLimiter, searchDeadline)  // Support GET for search
+	e.POST("/search", apiHandler.Search, rateLimiter, searchDeadline) // Keep POST support
+	e.GET("/search/stream", apiHandler.SearchStream, rateLimiter, searchDeadline)
+	e.POST("/search/batch", apiHandler.SearchBatch, rateLimiter, searchDeadline)
+	e.POST("/results/save", apiHandler.SaveResults, rateLimiter, searchDeadline)
+	e.GET("/results/:id", apiHandler.SavedResults)
+	e.POST("/feedback", apiHandler.Feedback, rateLimiter)
+	e.POST("/queries/compare", apiHandler.CompareQueries, rateLimiter, searchDeadline)
+	e.POST("/transcripts/align", apiHandler.AlignTranscript, rateLimiter, searchDeadline)
+	e.POST("/embed", apiHandler.Embed, rateLimiter)
+	e.GET("/passages", apiHandler.Passages)
+	e.POST("/passages", apiHandler.Passages)
+	e.GET("/crossrefs", apiHandler.CrossReferences)
+	e.GET("/similar", apiHandler.Similar, searchDeadline)
+	e.GET("/parallels", apiHandler.Parallels, rateLimiter, searchDeadline)
+	e.GET("/verse-of-the-day", apiHandler.VerseOfTheDay, rateLimiter, searchDeadline)
+	e.GET("/questions", apiHandler.Questions, rateLimiter)
+	e.GET("/lexicon/:strongs", apiHandler.Lexicon)
+	e.GET("/quiz/fill-in", apiHandler.QuizFillIn)
+	e.GET("/quiz/match", apiHandler.QuizMatch)
+	e.GET("/suggest", apiHandler.Suggest, searchDeadline)
+	e.GET("/autocomplete", apiHandler.Autocomplete, rateLimiter, searchDeadline)
+	e.GET("/canon", apiHandler.Canon)
+	e.GET("/books", apiHandler.Books)
+	e.GET("/books/:book/chapters", apiHandler.BookChapters)
+	e.GET("/chapters/:book/:chapter/summary", apiHandler.ChapterSummary)
+	e.GET("/tags", apiHandler.Tags)
+	e.GET("/thesaurus", apiHandler.Thesaurus)
+	e.POST("/tags", apiHandler.AddTags)
+	e.DELETE("/tags", apiHandler.RemoveTags)
+	e.GET("/notes", apiHandler.Notes)
+	e.POST("/notes", apiHandler.CreateNote)
+	e.GET("/notes/export", apiHandler.ExportNotes)
+	e.PUT("/notes/:id", apiHandler.UpdateNote)
+	e.DELETE("/notes/:id", apiHandler.DeleteNote)
+	e.GET("/userdata/export", apiHandler.ExportUserData)
+	e.POST("/userdata/import", apiHandler.ImportUserData)
+	e.GET("/privacy", apiHandler.Privacy)
+	e.PUT("/privacy", apiHandler.SetPrivacy, rateLimiter)
+	e.GET("/analytics/top-queries", apiHandler.TopQueries, adminAuth)
+	e.GET("/widget.js", apiHandler.WidgetScript)
+	e.GET("/widget/search", apiHandler.WidgetSearch, rateLimiter, searchDeadline)
+	e.GET("/openapi.json", apiHandler.OpenAPI)
+	e.POST(search.RankPath, apiHandler.RankIndex, api.IndexAuth(cfg.IndexToken), searchDeadline)
+
+	// Admin routes all require the admin token
+	admin := e.Group("/admin", adminAuth)
+	admin.POST("/purge", apiHandler.Purge)
+	admin.GET("/corpora", apiHandler.Corpora)
+	admin.GET("/index-checksums", apiHandler.IndexChecksums)
+	admin.GET("/usage", apiHandler.Usage)
+	admin.POST("/diff-search", apiHandler.DiffSearch, searchDeadline)
+	admin.POST("/reload", apiHandler.Reload)
+	admin.POST("/granularity/:name/load", apiHandler.LoadIndex)
+	admin.POST("/granularity/:name/unload", apiHandler.UnloadIndex)
+	admin.POST("/corpus", apiHandler.IngestCorpus, rateLimiter)
+	admin.DELETE("/corpus/:name", apiHandler.DeleteCorpus)
+
+	// Nothing is served before the model can answer queries
+	if cfg.WaitForModel {
+		log.Info().Msg("Waiting for the embedding model before serving...")
+		waitCtx, stopWaiting := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		err := embeddingService.WaitReady(waitCtx)
+		stopWaiting()
+		if err != nil {
+			log.Fatal().Err(err).Msg("Embedding model did not become ready")
+		}
+	}
 
 	// Start server in goroutine
 	go func() {
 		log.Info().Str("port", cfg.Port).Msg("Starting HTTP server")
-		if err := e.Start(fmt.Sprintf(":%s", cfg.Port)); err != nil {
+		if err := e.Start(fmt.Sprintf(":%s", cfg.Port)); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Error().Err(err).Msg("Server error")
 		}
 	}()
@@ -110,9 +580,330 @@ func main() {
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
+	stopLoads()
+
+	log.Info().Dur("timeout", cfg.ShutdownTimeout).Msg("Shutting down server, draining in-flight requests...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := e.Shutdown(ctx); err != nil {
+		log.Error().Err(err).Msg("Error draining server, forcing close")
+		if err := e.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing server")
+		}
+	}
+
+	// Fold logged tag and note changes into their snapshots
+	if err := tagStore.Close(); err != nil {
+		log.Error().Err(err).Msg("Error closing tag store")
+	}
+	if err := noteStore.Close(); err != nil {
+		log.Error().Err(err).Msg("Error closing note store")
+	}
+	if queryLog != nil {
+		if err := queryLog.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing analytics log")
+		}
+	}
+
+	// Release the ONNX session and cached payloads once no request can use them
+	if ensembleEmbedder != nil {
+		if err := ensembleEmbedder.Close(); err != nil {
+			log.Error().Err(err).Msg("Error closing ensemble model")
+		}
+	}
+	if err := searchService.Close(); err != nil {
+		log.Error().Err(err).Msg("Error closing search service")
+	}
+	log.Info().Msg("Server stopped")
+	return 0
+}
+
+// verifyCluster compares the index checksums of several nodes, exiting 0 when
+// they match, 1 when they differ and 2 when a node can't be checked
+func verifyCluster(args []string) int {
+	flags := flag.NewFlagSet("verify-cluster", flag.ExitOnError)
+	timeout := flags.Duration("timeout", 10*time.Second, "Time to wait for each node")
+	adminTokenEnv := flags.String("admin-token-env", "", "Environment variable holding the nodes' admin bearer token")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi verify-cluster [-timeout 10s] [-admin-token-env VAR] URL URL...")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() < 2 {
+		flags.Usage()
+		return 2
+	}
+
+	var token string
+	if *adminTokenEnv != "" {
+		if token = os.Getenv(*adminTokenEnv); token == "" {
+			fmt.Fprintf(os.Stderr, "%s is not set\n", *adminTokenEnv)
+			return 2
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report := cluster.Verify(ctx, &http.Client{Timeout: *timeout}, flags.Args(), token)
+	report.Write(os.Stdout)
+
+	for _, node := range report.Nodes {
+		if node.Err != nil {
+			return 2
+		}
+	}
+	if !report.Consistent() {
+		return 1
+	}
+	return 0
+}
+
+// replayLog replays the GET requests of request logs against a target, and
+// optionally a baseline to diff results with, exiting 0 when every request
+// got a response and 1 otherwise
+func replayLog(args []string) int {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	target := flags.String("target", "http://localhost:8080", "Base URL of the instance to replay against")
+	baseline := flags.String("baseline", "", "Base URL of an instance to compare results with (optional)")
+	concurrency := flags.Int("concurrency", 4, "Requests in flight at once")
+	rps := flags.Float64("rate", 0, "Requests/second across all workers (0 is unlimited)")
+	slow := flags.Duration("slow", 0, "Only replay requests that took at least this long when recorded")
+	path := flags.String("path", "/search", "Only replay requests whose path starts with this")
+	limit := flags.Int("limit", 0, "Replay at most this many requests (0 is all)")
+	worst := flags.Int("show", 10, "Number of most divergent requests to list")
+	timeout := flags.Duration("timeout", 60*time.Second, "Time to wait for each response")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi replay [flags] LOG...  (use - for stdin)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	var entries []replay.Entry
+	skipped := 0
+	for _, name := range flags.Args() {
+		file := os.Stdin
+		if name != "-" {
+			f, err := os.Open(name)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 2
+			}
+			defer f.Close()
+			file = f
+		}
+		read, redacted, err := replay.ReadLog(file, *slow, *path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			return 2
+		}
+		entries = append(entries, read...)
+		skipped += redacted
+	}
+	if *limit > 0 && len(entries) > *limit {
+		entries = entries[:*limit]
+	}
+	fmt.Printf("Replaying %d requests (%d skipped with redacted queries)\n\n", len(entries), skipped)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	results := replay.Run(ctx, entries, replay.Options{
+		Target:      *target,
+		Baseline:    *baseline,
+		Concurrency: *concurrency,
+		Rate:        *rps,
+		Client:      &http.Client{Timeout: *timeout},
+	})
+	summary := replay.Summarize(results, *worst)
+	summary.Write(os.Stdout)
+
+	if summary.Errors > 0 || summary.Requests < len(entries) {
+		return 1
+	}
+	return 0
+}
+
+// ingestCorpus embeds a corpus file into a named index in the data
+// directory, which the server loads at startup, exiting 0 on success, 1 when
+// ingestion fails and 2 on bad usage
+func ingestCorpus(args []string) int {
+	flags := flag.NewFlagSet("ingest", flag.ExitOnError)
+	name := flags.String("name", "", "Corpus name, searched as granularity=NAME")
+	dataDir := flags.String("data", "./data", "Data directory of the server that will serve the corpus")
+	modelPath := flags.String("model", "", "Path to ONNX model file (optional, will download if not provided)")
+	embeddingProvider := flags.String("embedding-provider", "", "Path to a JSON description of an HTTP embedding API to use instead of ONNX (optional)")
+	onnxThreads := flags.Int("onnx-threads", 4, "Intra-op thread count for ONNX inference")
+	wait := flags.Duration("wait", 5*time.Minute, "Time to wait for the embedding model to become ready")
+	title := flags.String("title", "", "Corpus title shown in /meta (optional)")
+	license := flags.String("license", "", "License of the corpus text, e.g. CC-BY-4.0 (optional)")
+	licenseURL := flags.String("license-url", "", "URL of the license text (optional)")
+	attribution := flags.String("attribution", "", "Attribution notice to display with the corpus text (optional)")
+	sourceURL := flags.String("source-url", "", "Where the corpus text was obtained (optional)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi ingest -name NAME [flags] FILE  (JSON array or JSON Lines; use - for stdin)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || search.ValidateCorpusName(*name) != nil {
+		flags.Usage()
+		return 2
+	}
+
+	file := os.Stdin
+	if flags.Arg(0) != "-" {
+		f, err := os.Open(flags.Arg(0))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+		defer f.Close()
+		file = f
+	}
+	docs, err := search.ParseDocuments(file)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+
+	cfg := &config.Config{
+		ModelPath:   *modelPath,
+		DataDir:     *dataDir,
+		ONNXThreads: *onnxThreads,
+		Shards:      runtime.NumCPU(),
+		Int8Query:   true,
+	}
+	if *embeddingProvider != "" {
+		if cfg.EmbeddingProvider, err = config.LoadEmbeddingProvider(*embeddingProvider); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+	embeddingService, err := embeddings.NewEmbeddingService(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer embeddingService.Close()
+	searchService, err := search.NewSearchService(embeddingService, cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	waitCtx, cancel := context.WithTimeout(ctx, *wait)
+	defer cancel()
+	if err := embeddingService.WaitReady(waitCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "embedding model not ready: %v\n", err)
+		return 1
+	}
+
+	corpus, err := searchService.IngestCorpus(ctx, *name, docs, config.CorpusLicense{
+		Title:       *title,
+		License:     *license,
+		LicenseURL:  *licenseURL,
+		Attribution: *attribution,
+		SourceURL:   *sourceURL,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Ingested %d documents into %s (%d dimensions, version %s)\n", corpus.Vectors, corpus.Granularity, corpus.Dimensions, corpus.Version)
+	return 0
+}
+
+// fetchData downloads everything the server would into a data directory, so
+// it can be copied to a machine without network access and served with
+// -offline, exiting 0 on success, 1 when a download fails and 2 on bad usage
+func fetchData(args []string) int {
+	flags := flag.NewFlagSet("fetch", flag.ExitOnError)
+	dataDir := flags.String("data", "./data", "Data directory to fetch into")
+	skipModel := flags.Bool("skip-model", false, "Don't fetch the ONNX model, e.g. when serving with -embedding-provider")
+	sources := addIndexFlags(flags)
+	ensembleModel := flags.String("ensemble-model", "", "Path to a JSON description of an ensemble model to fetch too (optional)")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: goscriptureapi fetch [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	cfg := &config.Config{DataDir: *dataDir}
+	err := sources.apply(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	var ensemble *config.EnsembleModel
+	if *ensembleModel != "" {
+		if ensemble, err = config.LoadEnsembleModel(*ensembleModel); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 2
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = search.FetchArtifacts(ctx, cfg, ensemble, func(artifact search.FetchedArtifact) {
+		verb := "Fetched"
+		if artifact.Skipped {
+			verb = "Already have"
+		}
+		fmt.Printf("%s %s (%d bytes)\n", verb, artifact.URL, artifact.Bytes)
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	if !*skipModel {
+		fmt.Println("Fetching the embedding model...")
+		if err := embeddings.FetchModel(cfg, embeddings.DefaultModelSpec()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		if ensemble != nil {
+			if err := embeddings.FetchModel(cfg, ensembleSpec(ensemble)); err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+		}
+	}
+
+	fmt.Println("Fetching cross-references...")
+	if err := crossrefs.NewService(cfg).Load(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	fmt.Printf("Fetched into %s; copy it to the offline machine and serve it with -data and -offline\n", *dataDir)
+	return 0
+}
+
+// ensembleSpec describes an ensemble model's files and output
+func ensembleSpec(model *config.EnsembleModel) embeddings.ModelSpec {
+	return embeddings.ModelSpec{
+		ID:           model.ID,
+		Dir:          filepath.Join("models", "ensemble"),
+		ModelURL:     model.ModelURL,
+		DataURL:      model.DataURL,
+		TokenizerURL: model.TokenizerURL,
+		QueryPrefix:  model.QueryPrefix,
+		OutputDims:   model.OutputDims,
+		Dimensions:   model.Dimensions,
+	}
+}
 
-	log.Info().Msg("Shutting down server...")
-	if err := e.Close(); err != nil {
-		log.Error().Err(err).Msg("Error closing server")
+// advance moves startup to its next state, logging a transition out of order
+func advance(stages *startup.Machine, state startup.State) {
+	if err := stages.Advance(state); err != nil {
+		log.Warn().Err(err).Msg("Startup transition refused")
 	}
-}
\ No newline at end of file
+}
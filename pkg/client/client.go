@@ -0,0 +1,283 @@
+// Package client is a Go client for the scripture search API. Its request
+// and response types mirror the server's JSON, and it is versioned with the
+// server in this module, so a client and server of the same release agree on
+// every field.
+//
+//	c, err := client.New("http://localhost:8080", client.WithAPIKey(key))
+//	results, err := c.Search(ctx, client.SearchRequest{Query: "love your enemies", K: 5})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// KeyHeader carries the API key that selects a caller's service tier and
+// privacy policy
+const KeyHeader = "X-API-Key"
+
+// Defaults for a client built without options
+const (
+	DefaultTimeout = 30 * time.Second
+	DefaultRetries = 2
+	DefaultBackoff = 250 * time.Millisecond
+)
+
+// maxBackoff caps the wait between retries, including a server's Retry-After
+const maxBackoff = 30 * time.Second
+
+// Client calls one API server. It is safe for concurrent use.
+type Client struct {
+	baseURL    *url.URL
+	httpClient *http.Client
+	apiKey     string
+	language   string
+	retries    int
+	backoff    time.Duration
+	userAgent  string
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient sends requests with an http.Client of the caller's, for
+// custom transports or proxies. Its timeout replaces WithTimeout's.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithTimeout bounds each attempt of a request, retries excluded (default:
+// 30s, 0 for none). Bound a whole call, retries included, with its context.
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.httpClient = &http.Client{Transport: c.httpClient.Transport, Timeout: timeout}
+	}
+}
+
+// WithAPIKey sends an API key with every request
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithLanguage asks for error messages in a language, such as "es"
+func WithLanguage(language string) Option {
+	return func(c *Client) {
+		c.language = language
+	}
+}
+
+// WithRetries retries failed requests up to n more times, waiting backoff
+// before the first retry and doubling it for each one after (defaults: 2 and
+// 250ms). Only connection failures and 429, 502, 503 and 504 responses are
+// retried; a 429 or 503 waits as long as its Retry-After asks.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(n, 0)
+		c.backoff = max(backoff, 0)
+	}
+}
+
+// WithUserAgent sets the User-Agent header
+func WithUserAgent(userAgent string) Option {
+	return func(c *Client) {
+		c.userAgent = userAgent
+	}
+}
+
+// New creates a client for the server at baseURL, such as
+// "https://api.example.org". A path in baseURL prefixes every endpoint.
+func New(baseURL string, options ...Option) (*Client, error) {
+	parsed, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("invalid base URL %q: scheme must be http or https", baseURL)
+	}
+
+	c := &Client{
+		baseURL:    parsed,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		retries:    DefaultRetries,
+		backoff:    DefaultBackoff,
+		userAgent:  "goscriptureapi-client",
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c, nil
+}
+
+// Search runs a semantic search
+func (c *Client) Search(ctx context.Context, req SearchRequest) (*SearchResponse, error) {
+	var response SearchResponse
+	if err := c.do(ctx, http.MethodPost, "/search", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Passages looks up references in one request, in request order. A
+// reference that can't be resolved has its Error set rather than failing
+// the call.
+func (c *Client) Passages(ctx context.Context, req PassagesRequest) (*PassagesResponse, error) {
+	var response PassagesResponse
+	if err := c.do(ctx, http.MethodPost, "/passages", req, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Passage looks up a single reference such as "John 3:16-18", returning its
+// parse error if it can't be resolved
+func (c *Client) Passage(ctx context.Context, ref string, format FormatOptions) (*Passage, error) {
+	response, err := c.Passages(ctx, PassagesRequest{References: []string{ref}, Format: format})
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Passages) == 0 {
+		return nil, fmt.Errorf("no passage returned for %q", ref)
+	}
+	passage := response.Passages[0]
+	if passage.Error != nil {
+		return nil, passage.Error
+	}
+	return &passage, nil
+}
+
+// Embed returns the embedding of a text, embedded as a "query" (the
+// default) or a "document"
+func (c *Client) Embed(ctx context.Context, text, kind string) (*EmbedResponse, error) {
+	var response EmbedResponse
+	if err := c.do(ctx, http.MethodPost, "/embed", EmbedRequest{Text: text, Type: kind}, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Status reports the server's indices, embedding backend and startup state
+func (c *Client) Status(ctx context.Context) (*Status, error) {
+	var response Status
+	if err := c.do(ctx, http.MethodGet, "/status", nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// do sends a request, retrying transient failures, and decodes a successful
+// response into out or a failed one into an *Error
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	endpoint := c.baseURL.JoinPath(path)
+
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	wait := c.backoff
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.attempt(ctx, method, endpoint.String(), payload, out)
+		if err == nil || attempt >= c.retries || !retryable(err) {
+			return err
+		}
+		delay := wait + time.Duration(rand.Int63n(int64(wait)/2+1))
+		if retryAfter > delay {
+			delay = retryAfter
+		}
+		timer := time.NewTimer(min(delay, maxBackoff))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+		wait *= 2
+	}
+}
+
+// attempt sends a request once, returning how long the server asked to wait
+// before another attempt
+func (c *Client) attempt(ctx context.Context, method, endpoint string, payload []byte, out interface{}) (time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set(KeyHeader, c.apiKey)
+	}
+	if c.language != "" {
+		req.Header.Set("Accept-Language", c.language)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return parseRetryAfter(resp.Header.Get("Retry-After")), decodeError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("invalid response from %s: %w", endpoint, err)
+	}
+	return 0, nil
+}
+
+// retryable reports whether a failed attempt may succeed if repeated: a
+// connection failure, rate limiting, or a server that is unavailable or
+// still loading
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// A cancelled or expired context isn't worth retrying
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0)
+	}
+	return 0
+}
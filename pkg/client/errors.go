@@ -0,0 +1,88 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Error codes the server may return, for branching on Error.Code
+const (
+	CodeInvalidRequest       = "invalid_request"
+	CodeInvalidReference     = "invalid_reference"
+	CodeUnknownBook          = "unknown_book"
+	CodeLimitExceeded        = "limit_exceeded"
+	CodeNotFound             = "not_found"
+	CodeUnknownIndex         = "unknown_index"
+	CodeRateLimited          = "rate_limited"
+	CodeGranularityNotLoaded = "granularity_not_loaded"
+	CodeGranularityFailed    = "granularity_unavailable"
+	CodeReloadInProgress     = "reload_in_progress"
+	CodeModelNotReady        = "model_not_ready"
+	CodeFeatureDisabled      = "feature_disabled"
+	CodeSearchTimeout        = "search_timeout"
+	CodeInternal             = "internal_error"
+)
+
+// Error is a response the server answered with a non-2xx status
+type Error struct {
+	StatusCode int
+	Code       string          // Such as "unknown_book", empty if the body wasn't an API error
+	Message    string          // In the client's WithLanguage language when the server has it
+	Details    json.RawMessage // Code-specific details, if any
+	Suggestion string
+	RequestID  string
+}
+
+func (e *Error) Error() string {
+	message := fmt.Sprintf("HTTP %d", e.StatusCode)
+	if e.Code != "" {
+		message += " " + e.Code
+	}
+	if e.Message != "" {
+		message += ": " + e.Message
+	}
+	if e.Suggestion != "" {
+		message += " (" + e.Suggestion + ")"
+	}
+	return message
+}
+
+// decodeError reads an error response. A body that isn't the API's error
+// object becomes the message as is.
+func decodeError(resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	apiErr := &Error{StatusCode: resp.StatusCode}
+
+	var envelope struct {
+		Error struct {
+			Code       string          `json:"code"`
+			Message    string          `json:"message"`
+			Details    json.RawMessage `json:"details"`
+			Suggestion string          `json:"suggestion"`
+			RequestID  string          `json:"requestId"`
+		} `json:"error"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		apiErr.Message = strings.TrimSpace(string(body))
+		return apiErr
+	}
+	apiErr.Code = envelope.Error.Code
+	apiErr.Message = coalesce(envelope.Error.Message, envelope.Message)
+	apiErr.Details = envelope.Error.Details
+	apiErr.Suggestion = envelope.Error.Suggestion
+	apiErr.RequestID = coalesce(envelope.Error.RequestID, resp.Header.Get("X-Request-ID"))
+	return apiErr
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,262 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SearchRequest is the body of POST /search. Zero fields take the server's
+// defaults.
+type SearchRequest struct {
+	Query        string        `json:"query"`
+	Raw          bool          `json:"raw,omitempty"`          // Search the query as written, without reading filters
+	Explain      bool          `json:"explain,omitempty"`      // Report how the search was answered
+	RequireModel bool          `json:"requireModel,omitempty"` // Fail rather than embed the query with a fallback
+	Granularity  string        `json:"granularity,omitempty"`  // "verse", "chapter" or "original"
+	Index        string        `json:"index,omitempty"`        // Named index to search, taking precedence over Granularity
+	K            int           `json:"k,omitempty"`
+	Book         string        `json:"book,omitempty"`
+	Chapter      string        `json:"chapter,omitempty"`
+	Verse        string        `json:"verse,omitempty"`
+	Rerank       bool          `json:"rerank,omitempty"`
+	Exhaustive   bool          `json:"exhaustive,omitempty"` // Scan every vector, skipping centroid routing
+	Format       FormatOptions `json:"format,omitempty"`
+	Fields       []string      `json:"fields,omitempty"`
+	Ranking      string        `json:"ranking,omitempty"`
+	PageSize     int           `json:"pageSize,omitempty"` // Enables cursor paging
+	Cursor       string        `json:"cursor,omitempty"`   // Continues a previous page
+
+	Highlight     bool   `json:"highlight,omitempty"`
+	HighlightPre  string `json:"highlightPre,omitempty"`
+	HighlightPost string `json:"highlightPost,omitempty"`
+
+	Tag       string `json:"tag,omitempty"`       // Only verses carrying this tag
+	Namespace string `json:"namespace,omitempty"` // Tag and note namespace
+	Notes     bool   `json:"notes,omitempty"`     // Attach notes to each result
+
+	Include      []string `json:"include,omitempty"`      // Extra data for each result: "strongs", "metadata", "embedding"
+	ResultFields []string `json:"resultFields,omitempty"` // Result fields to return, e.g. "book", "chapter", "searchMeta.similarity"
+
+	Testament string   `json:"testament,omitempty"`
+	Genre     string   `json:"genre,omitempty"`
+	Books     []string `json:"books,omitempty"`
+
+	Sources []string `json:"sources,omitempty"` // "scripture" and/or "notes"
+
+	Transform   string  `json:"transform,omitempty"`   // "softmax"
+	Temperature float64 `json:"temperature,omitempty"` // Softmax temperature
+
+	Diversity float64 `json:"diversity,omitempty"` // MMR weight: 0 for relevance only, up to 1
+	MinScore  float64 `json:"minScore,omitempty"`  // Only results at least this similar to the query
+	Group     string  `json:"group,omitempty"`     // "chapter" returns each chapter's best verse
+	Expand    bool    `json:"expand,omitempty"`    // Also search thesaurus reformulations of the query
+
+	Exclude  []string `json:"exclude,omitempty"`  // Drop results containing any of these words or phrases
+	Negative string   `json:"negative,omitempty"` // An example of what not to find, subtracted from the query vector
+
+	Aggs []string `json:"aggs,omitempty"` // Facets to count over the top candidates: "book", "testament", "genre"
+}
+
+// FormatOptions controls how verse text is rendered
+type FormatOptions struct {
+	VerseNumbers string `json:"verseNumbers,omitempty"` // "none", "inline" or "bracketed"
+	Layout       string `json:"layout,omitempty"`       // "paragraph" or "verse"
+	DivineName   string `json:"divineName,omitempty"`   // "asis", "smallcaps", "html" or "title"
+}
+
+// SearchResponse is the response to a search
+type SearchResponse struct {
+	Query            string              `json:"query"`
+	Results          []Result            `json:"results"`
+	Count            int                 `json:"count"`
+	Status           string              `json:"status"`
+	Ranking          string              `json:"ranking,omitempty"`
+	Reproducibility  *Reproducibility    `json:"reproducibility,omitempty"`
+	Offset           int                 `json:"offset,omitempty"`
+	Total            int                 `json:"total,omitempty"`
+	Cursor           string              `json:"cursor,omitempty"`           // Pass as SearchRequest.Cursor for the next page
+	Sources          map[string]int      `json:"sources,omitempty"`          // Result counts per source when notes are searched
+	Diagnostics      []string            `json:"diagnostics,omitempty"`      // Why nothing matched, when Count is zero
+	Parsed           json.RawMessage     `json:"parsed,omitempty"`           // How the query was read, when it had filters or references
+	EmptyFilters     []string            `json:"emptyFilters,omitempty"`     // Filters matching no candidates on their own, when Count is zero
+	Degraded         string              `json:"degraded,omitempty"`         // "cache" or "lexical" when shed under load, "lexical" while embeddings load
+	Explain          json.RawMessage     `json:"explain,omitempty"`          // How the search was answered, with Explain set
+	EmbeddingBackend string              `json:"embeddingBackend,omitempty"` // What embedded the query: "onnx", "remote", or the "simple" or "placeholder" fallback
+	Aggregations     map[string][]Bucket `json:"aggregations,omitempty"`     // Facet counts over the top candidates, with Aggs
+}
+
+// Bucket counts the candidates with one value of a facet
+type Bucket struct {
+	Key   string `json:"key"`            // Usable as the facet's filter, e.g. "1Cor" for book
+	Name  string `json:"name,omitempty"` // Display name, for books
+	Count int    `json:"count"`
+}
+
+// Reproducibility is everything needed to reproduce a response
+type Reproducibility struct {
+	ModelHash    string `json:"modelHash,omitempty"`
+	IndexVersion string `json:"indexVersion"`
+	Seed         int64  `json:"seed"`
+}
+
+// Result is a verse, chapter, note or document found by a search
+type Result struct {
+	Book       string                 `json:"book"`
+	Chapter    int                    `json:"chapter"`
+	VerseNum   int                    `json:"verseNum"`
+	Text       string                 `json:"text"`
+	SearchMeta map[string]interface{} `json:"_searchMeta,omitempty"`
+}
+
+// Similarity returns the result's similarity to the query
+func (r Result) Similarity() float64 {
+	similarity, _ := r.SearchMeta["similarity"].(float64)
+	return similarity
+}
+
+// PassagesRequest is the body of POST /passages
+type PassagesRequest struct {
+	References  []string      `json:"references"`
+	Format      FormatOptions `json:"format,omitempty"`
+	Notes       bool          `json:"notes,omitempty"`       // Attach notes overlapping each passage
+	Namespace   string        `json:"namespace,omitempty"`   // Note namespace
+	Attribution bool          `json:"attribution,omitempty"` // Include the verse text's license and attribution
+}
+
+// PassagesResponse holds one passage per requested reference, in order
+type PassagesResponse struct {
+	Passages    []Passage `json:"passages"`
+	Count       int       `json:"count"`
+	Attribution *License  `json:"attribution,omitempty"`
+	Status      string    `json:"status"`
+}
+
+// Passage is the resolution of one requested reference
+type Passage struct {
+	Input     string          `json:"input"`
+	Reference string          `json:"reference,omitempty"` // Canonical form of Input
+	Text      string          `json:"text,omitempty"`
+	Verses    []Result        `json:"verses,omitempty"`
+	Error     *ReferenceError `json:"error,omitempty"`
+	Notes     []Note          `json:"notes,omitempty"`
+}
+
+// ReferenceError is why a reference couldn't be resolved, with the nearest
+// valid reference when one can be inferred
+type ReferenceError struct {
+	Kind       string `json:"kind"` // Such as "unknown_book" or "chapter_out_of_range"
+	Input      string `json:"input"`
+	Message    string `json:"message"`
+	Suggestion string `json:"suggestion,omitempty"`
+}
+
+func (e *ReferenceError) Error() string {
+	if e.Suggestion != "" {
+		return fmt.Sprintf("%s: %q (did you mean %q?)", e.Message, e.Input, e.Suggestion)
+	}
+	return fmt.Sprintf("%s: %q", e.Message, e.Input)
+}
+
+// Note is a note attached to a verse range
+type Note struct {
+	ID        string    `json:"id"`
+	Namespace string    `json:"namespace"`
+	Reference string    `json:"reference"`
+	Body      string    `json:"body"` // Markdown
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// License is a corpus's license and attribution
+type License struct {
+	Title       string `json:"title,omitempty"`
+	License     string `json:"license,omitempty"` // SPDX identifier or name, e.g. "CC-BY-4.0"
+	LicenseURL  string `json:"licenseUrl,omitempty"`
+	Attribution string `json:"attribution,omitempty"` // Notice to display alongside quoted text
+	SourceURL   string `json:"sourceUrl,omitempty"`
+}
+
+// EmbedRequest is the body of POST /embed
+type EmbedRequest struct {
+	Text         string `json:"text"`
+	Type         string `json:"type,omitempty"`         // "query" (default) or "document"
+	RequireModel bool   `json:"requireModel,omitempty"` // Fail rather than embed a query with a fallback
+}
+
+// EmbedResponse is a text's embedding
+type EmbedResponse struct {
+	Embedding        []float32 `json:"embedding"`
+	Dimensions       int       `json:"dimensions"`
+	EmbeddingBackend string    `json:"embeddingBackend"` // What embedded the text: "onnx", "remote", or for queries the "simple" or "placeholder" fallback
+}
+
+// Status is the server's state as reported by /status. Sections the client
+// doesn't type are kept in Other.
+type Status struct {
+	Initialized bool                       `json:"initialized"`
+	Indices     map[string]IndexStatus     `json:"indices"` // By granularity or index name
+	Embedding   EmbeddingStatus            `json:"embedding"`
+	Startup     *StartupStatus             `json:"startup,omitempty"`
+	Other       map[string]json.RawMessage `json:"-"`
+}
+
+// UnmarshalJSON decodes the typed sections and keeps the rest in Other
+func (s *Status) UnmarshalJSON(data []byte) error {
+	type typed Status
+	if err := json.Unmarshal(data, (*typed)(s)); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, &s.Other); err != nil {
+		return err
+	}
+	for _, key := range []string{"initialized", "indices", "embedding", "startup"} {
+		delete(s.Other, key)
+	}
+	return nil
+}
+
+// IndexStatus is one index's load state and size
+type IndexStatus struct {
+	Loaded      bool   `json:"loaded"`
+	State       string `json:"state,omitempty"` // "not_loaded", "loading" or "failed" while not loaded
+	Error       string `json:"error,omitempty"`
+	Count       int    `json:"count,omitempty"`
+	MemoryBytes int64  `json:"memoryBytes,omitempty"`
+	Version     string `json:"version,omitempty"`
+	Dimensions  int    `json:"dimensions,omitempty"`
+	Storage     string `json:"storage,omitempty"`
+	Shards      int    `json:"shards,omitempty"`
+}
+
+// EmbeddingStatus describes the backend embedding queries
+type EmbeddingStatus struct {
+	Backend string           `json:"backend"` // "onnx" or "remote"
+	API     string           `json:"api,omitempty"`
+	Model   string           `json:"model"`
+	Ready   bool             `json:"ready"`            // False while queries use a fallback embedding
+	Active  string           `json:"active,omitempty"` // Backend that embedded the latest query, possibly a fallback
+	Queries map[string]int64 `json:"queries"`          // Queries embedded per backend since startup
+}
+
+// StartupStatus is how far the server has come since it started
+type StartupStatus struct {
+	State       string              `json:"state"` // "starting" through "model-ready"
+	Ready       bool                `json:"ready"`
+	Failure     *StartupFailure     `json:"failure,omitempty"`
+	Transitions []StartupTransition `json:"transitions"`
+}
+
+// StartupFailure is why startup stopped short of the next state
+type StartupFailure struct {
+	Stage string    `json:"stage"` // The state that couldn't be reached
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+// StartupTransition is when the server reached a startup state
+type StartupTransition struct {
+	State   string    `json:"state"`
+	At      time.Time `json:"at"`
+	SinceMs int64     `json:"sinceStartMs"`
+}
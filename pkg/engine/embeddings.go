@@ -0,0 +1,80 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/dpshade/goscriptureapi/internal/embeddings"
+)
+
+// EmbeddingService embeds queries and documents with the ONNX model or an
+// HTTP embedding provider. The model loads in the background; until it is
+// ready, queries are embedded by a lower-quality fallback, which
+// WaitReady avoids.
+type EmbeddingService struct {
+	service *embeddings.EmbeddingService
+}
+
+// ErrModelNotReady reports documents that can't be embedded because the
+// model isn't serving
+var ErrModelNotReady = embeddings.ErrModelNotReady
+
+// RequireModel returns a context whose queries fail with an error wrapping
+// ErrModelRequired rather than being embedded by a fallback
+func RequireModel(ctx context.Context) context.Context {
+	return embeddings.WithRequireModel(ctx)
+}
+
+// ErrModelRequired reports a query under RequireModel while the model isn't
+// serving
+var ErrModelRequired = embeddings.ErrModelRequired
+
+// NewEmbeddingService starts loading the embedding model
+func NewEmbeddingService(cfg Config) (*EmbeddingService, error) {
+	internal, err := cfg.internal()
+	if err != nil {
+		return nil, err
+	}
+	service, err := embeddings.NewEmbeddingService(internal)
+	if err != nil {
+		return nil, err
+	}
+	return &EmbeddingService{service: service}, nil
+}
+
+// EmbedQuery embeds a search query
+func (s *EmbeddingService) EmbedQuery(ctx context.Context, text string) ([]float32, error) {
+	return s.service.EmbedQuery(ctx, text)
+}
+
+// EmbedQueries embeds several search queries together
+func (s *EmbeddingService) EmbedQueries(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.service.EmbedQueries(ctx, texts)
+}
+
+// EmbedDocuments embeds passages to be searched, such as the entries of a
+// VectorIndex. Documents are never embedded by a fallback: it returns
+// ErrModelNotReady until the model is serving.
+func (s *EmbeddingService) EmbedDocuments(ctx context.Context, texts []string) ([][]float32, error) {
+	return s.service.EmbedDocuments(ctx, texts)
+}
+
+// Ready reports whether the model is serving, rather than a fallback
+func (s *EmbeddingService) Ready() bool {
+	return s.service.ModelReady()
+}
+
+// WaitReady blocks until the model is serving, its loading fails, or ctx
+// is done
+func (s *EmbeddingService) WaitReady(ctx context.Context) error {
+	return s.service.WaitReady(ctx)
+}
+
+// Backend names what embeds queries: "onnx" or "remote"
+func (s *EmbeddingService) Backend() string {
+	return s.service.Backend().Backend
+}
+
+// Close releases the model
+func (s *EmbeddingService) Close() error {
+	return s.service.Close()
+}
@@ -0,0 +1,101 @@
+// Package engine runs semantic scripture search in process, without the
+// HTTP server. It wraps the server's embedding service, vector index and
+// search service behind an API kept stable across releases, unlike the
+// internal packages it is built on.
+//
+//	embedder, err := engine.NewEmbeddingService(engine.Config{DataDir: "./data"})
+//	defer embedder.Close()
+//	searcher, err := engine.NewSearchService(engine.Config{DataDir: "./data"}, embedder)
+//	defer searcher.Close()
+//	err = searcher.Load(ctx, "verse")
+//	results, err := searcher.Search(ctx, "love your enemies", engine.Options{K: 5})
+//
+// The engine logs through zerolog's global logger; quiet it with
+// zerolog.SetGlobalLevel.
+package engine
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/dpshade/goscriptureapi/internal/config"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// Errors a search may wrap, for errors.Is
+var (
+	// ErrNotLoaded reports an index that hasn't been loaded with Load
+	ErrNotLoaded = search.ErrNotLoaded
+	// ErrUnavailable reports an index whose load failed
+	ErrUnavailable = search.ErrUnavailable
+	// ErrUnknownIndex reports an index name that isn't configured
+	ErrUnknownIndex = search.ErrUnknownIndex
+	// ErrEmbedding reports a query that couldn't be embedded
+	ErrEmbedding = search.ErrEmbedding
+)
+
+// Config configures the engine. Zero fields take the server's defaults.
+type Config struct {
+	DataDir           string // Models, downloaded artifacts and snapshots (default: ./data)
+	ModelPath         string // A local ONNX model instead of the downloaded EmbeddingGemma
+	EmbeddingProvider string // JSON description of an HTTP embedding API to use instead of ONNX
+	Indices           string // JSON array of named indices to load alongside verse and chapter
+	Offline           bool   // Read what the fetch command saved in DataDir, never downloading
+	ONNXThreads       int    // Intra-op threads for ONNX inference (default: 4)
+	QueryCacheSize    int    // Query embeddings to cache (default: 1024, negative disables)
+	Shards            int    // Shards an index scan fans out across (default: the number of CPUs)
+	NoSnapshots       bool   // Don't save or load index snapshots in DataDir
+}
+
+// internal builds the server configuration the engine's services share
+func (c Config) internal() (*config.Config, error) {
+	cfg := &config.Config{
+		DataDir:            coalesce(c.DataDir, "./data"),
+		ModelPath:          c.ModelPath,
+		Offline:            c.Offline,
+		ONNXThreads:        c.ONNXThreads,
+		QueryCacheSize:     c.QueryCacheSize,
+		Shards:             c.Shards,
+		Snapshots:          !c.NoSnapshots,
+		Int8Query:          true,
+		RerankCandidates:   200,
+		RouteSample:        0.1,
+		CursorMaxResults:   2000,
+		DownloadCacheBytes: 256 << 20,
+		DownloadCacheTTL:   10 * time.Minute,
+	}
+	if cfg.ONNXThreads <= 0 {
+		cfg.ONNXThreads = 4
+	}
+	switch {
+	case cfg.QueryCacheSize == 0:
+		cfg.QueryCacheSize = 1024
+	case cfg.QueryCacheSize < 0:
+		cfg.QueryCacheSize = 0
+	}
+	if cfg.Shards <= 0 {
+		cfg.Shards = runtime.NumCPU()
+	}
+
+	var err error
+	if c.EmbeddingProvider != "" {
+		if cfg.EmbeddingProvider, err = config.LoadEmbeddingProvider(c.EmbeddingProvider); err != nil {
+			return nil, err
+		}
+	}
+	if c.Indices != "" {
+		if cfg.Indices, err = config.LoadIndices(c.Indices); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+func coalesce(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,63 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// VectorIndex is an in-memory index of vectors searched by cosine
+// similarity, for callers indexing their own documents. It is safe for
+// concurrent use.
+type VectorIndex struct {
+	index *search.VectorIndex
+}
+
+// Match is a vector found by a VectorIndex search
+type Match struct {
+	ID         string
+	Similarity float32
+}
+
+// NewVectorIndex creates an empty index
+func NewVectorIndex() *VectorIndex {
+	return &VectorIndex{index: search.NewVectorIndex()}
+}
+
+// Add indexes a vector under an ID, which should be unique
+func (vi *VectorIndex) Add(id string, vector []float32) {
+	vi.index.Add(id, vector)
+}
+
+// Get returns the vector indexed under an ID
+func (vi *VectorIndex) Get(id string) ([]float32, bool) {
+	return vi.index.Get(id)
+}
+
+// Search returns the k vectors most similar to a query, most similar first
+func (vi *VectorIndex) Search(query []float32, k int) []Match {
+	return matches(vi.index.Search(query, k))
+}
+
+// SearchWithFilter is Search over only the IDs filter accepts
+func (vi *VectorIndex) SearchWithFilter(query []float32, k int, filter func(id string) bool) []Match {
+	return matches(vi.index.SearchWithFilter(context.Background(), query, k, filter))
+}
+
+// Size returns the number of indexed vectors
+func (vi *VectorIndex) Size() int {
+	return vi.index.Size()
+}
+
+// CosineSimilarity returns the cosine similarity of two vectors
+func CosineSimilarity(a, b []float32) float32 {
+	return search.CosineSimilarity(a, b)
+}
+
+func matches(results []search.SearchResult) []Match {
+	found := make([]Match, len(results))
+	for i, result := range results {
+		found[i] = Match{ID: result.ID, Similarity: result.Similarity}
+	}
+	return found
+}
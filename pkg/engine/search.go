@@ -0,0 +1,153 @@
+package engine
+
+import (
+	"context"
+
+	"github.com/dpshade/goscriptureapi/internal/reference"
+	"github.com/dpshade/goscriptureapi/internal/search"
+)
+
+// SearchService searches the verse and chapter indices, and any named
+// indices in Config.Indices. Indices download (or load from their
+// snapshots) when Load is called.
+type SearchService struct {
+	service *search.SearchService
+}
+
+// Options narrow and shape a search. Zero fields take the server's
+// defaults.
+type Options struct {
+	Index      string   // "verse" (default), "chapter", or a named index
+	K          int      // Number of results (default: 10)
+	Book       string   // Only this book
+	Chapter    string   // Only this chapter, or range of chapters such as "3-5", of Book
+	Verse      string   // Only this verse, or range of verses, of Chapter
+	Books      []string // Only these books
+	Testament  string   // "ot" or "nt"
+	Genre      string   // Such as "gospels" or "wisdom", or a group: "prophets", "epistles"
+	MinScore   float64  // Drop results less similar to the query than this
+	Diversity  float64  // MMR weight in [0, 1]: 0 ranks by relevance alone
+	Rerank     bool     // Quantized retrieval followed by exact re-ranking
+	Exhaustive bool     // Scan every vector, skipping centroid routing
+	Expand     bool     // Also search reformulations with modern and King James synonyms, fusing the rankings
+	Phrases    []string // Only texts containing each phrase word for word, ignoring case and punctuation
+	Exclude    []string // Drop texts containing any of these words or phrases
+	Negative   string   // An example sentence of what not to find, subtracted from the query vector
+	Highlight  bool     // Set Result.Highlight, with query terms in <mark> tags
+}
+
+// Result is a verse or chapter found by a search
+type Result struct {
+	ID         string
+	Reference  string // Such as "John 3:16", when the index records it
+	Book       string
+	Chapter    int
+	Verse      int // Zero for a chapter
+	Text       string
+	Similarity float32 // Cosine similarity to the query
+	Score      float32 // Similarity after ranking boosts; results are ordered by it
+	Highlight  string  // Text with query terms marked, with Options.Highlight
+}
+
+// Verse is a verse of a passage
+type Verse struct {
+	Book    string
+	Chapter int
+	Verse   int
+	Text    string
+}
+
+// NewSearchService creates a search service embedding queries with
+// embedder. It loads no index until Load is called.
+func NewSearchService(cfg Config, embedder *EmbeddingService) (*SearchService, error) {
+	internal, err := cfg.internal()
+	if err != nil {
+		return nil, err
+	}
+	service, err := search.NewSearchService(embedder.service, internal)
+	if err != nil {
+		return nil, err
+	}
+	return &SearchService{service: service}, nil
+}
+
+// Load downloads or reads an index, blocking until it is searchable or ctx
+// ends
+func (s *SearchService) Load(ctx context.Context, index string) error {
+	return s.service.PreloadGranularity(ctx, index)
+}
+
+// Indices lists the names of every index that can be loaded
+func (s *SearchService) Indices() []string {
+	return s.service.Indices()
+}
+
+// Search returns the passages most similar to a query
+func (s *SearchService) Search(ctx context.Context, query string, options Options) ([]Result, error) {
+	results, err := s.service.Search(ctx, query, search.SearchOptions{
+		Granularity: coalesce(options.Index, "verse"),
+		K:           max(options.K, 0),
+		Book:        options.Book,
+		Chapter:     options.Chapter,
+		Verse:       options.Verse,
+		Books:       options.Books,
+		Testament:   options.Testament,
+		Genre:       options.Genre,
+		MinScore:    options.MinScore,
+		Diversity:   options.Diversity,
+		Rerank:      options.Rerank,
+		Exhaustive:  options.Exhaustive,
+		Expand:      options.Expand,
+		Phrases:     options.Phrases,
+		Exclude:     options.Exclude,
+		Negative:    options.Negative,
+		Highlight:   options.Highlight,
+		Ranking:     search.RankingDefault,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := make([]Result, len(results))
+	for i, result := range results {
+		meta := result.Chunk.Meta
+		found[i] = Result{
+			ID:         result.ID,
+			Reference:  meta.Reference,
+			Book:       meta.Book,
+			Chapter:    meta.Chapter,
+			Verse:      meta.VerseNum,
+			Text:       result.Chunk.Text,
+			Similarity: result.Similarity,
+			Score:      result.Score,
+		}
+		if result.Highlight != nil {
+			found[i].Highlight = result.Highlight.Text
+		}
+	}
+	return found, nil
+}
+
+// Passage returns the verses of a reference such as "John 3:16-18" in
+// order. It needs the verse index loaded.
+func (s *SearchService) Passage(ref string) ([]Verse, error) {
+	parsed, err := reference.Parse(ref)
+	if err != nil {
+		return nil, err
+	}
+	verses, err := s.service.Passage(parsed)
+	if err != nil {
+		return nil, err
+	}
+
+	passage := make([]Verse, len(verses))
+	for i, verse := range verses {
+		passage[i] = Verse{Book: verse.Meta.Book, Chapter: verse.Meta.Chapter, Verse: verse.Meta.VerseNum, Text: verse.Text}
+	}
+	return passage, nil
+}
+
+// Close releases the indices
+func (s *SearchService) Close() error {
+	return s.service.Close()
+}